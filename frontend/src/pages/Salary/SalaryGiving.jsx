@@ -158,6 +158,8 @@ const SalaryGiving = () => {
           return <span style={{ color: "orange" }}>未发放</span>;
         } else if (isPay === 2) {
           return <span style={{ color: "green" }}>已发放</span>;
+        } else if (isPay === 3) {
+          return <span style={{ color: "#1890ff" }}>银行处理中</span>;
         }
         return <span>未知</span>;
       },
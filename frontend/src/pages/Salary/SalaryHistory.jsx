@@ -1,6 +1,6 @@
 import React, { useState, useEffect } from "react";
 import { Table, Card, Form, Input, Button, message, Space } from "antd";
-import { SearchOutlined, ReloadOutlined, EyeOutlined } from "@ant-design/icons";
+import { SearchOutlined, ReloadOutlined, EyeOutlined, FilePdfOutlined } from "@ant-design/icons";
 import { useNavigate } from "react-router-dom";
 import { usePermission } from "../../components/Auth/usePermission";
 import { PAGINATION_CONFIG, TABLE_CONFIG } from "../../utils/constants";
@@ -142,6 +142,8 @@ const SalaryHistory = () => {
           return <span style={{ color: "orange" }}>未发放</span>;
         } else if (isPay === 2) {
           return <span style={{ color: "green" }}>已发放</span>;
+        } else if (isPay === 3) {
+          return <span style={{ color: "#1890ff" }}>银行处理中</span>;
         }
         return <span>未知</span>;
       },
@@ -149,7 +151,7 @@ const SalaryHistory = () => {
     {
       title: "操作",
       key: "action",
-      width: 100,
+      width: 180,
       fixed: "right",
       render: (_, record) => (
         <Space size="small">
@@ -163,6 +165,13 @@ const SalaryHistory = () => {
               查看
             </Button>
           )}
+          <Button
+            size="small"
+            icon={<FilePdfOutlined />}
+            onClick={() => window.open(salaryService.getPayslipUrl(record.staff_id, record.salary_date))}
+          >
+            工资条
+          </Button>
         </Space>
       ),
     },
@@ -101,7 +101,7 @@ const InsuranceRateManagement = () => {
     try {
       if (editingRecord) {
         await updateInsuranceRateV2({ ...values, id: editingRecord.id });
-        message.success('更新成功');
+        message.success('已提交变更申请，待其他管理员审批');
       } else {
         await createInsuranceRateV2(values);
         message.success('创建成功');
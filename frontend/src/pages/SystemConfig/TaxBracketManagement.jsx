@@ -109,7 +109,7 @@ const TaxBracketManagement = () => {
     try {
       if (editingRecord) {
         await updateTaxBracketV2({ ...values, id: editingRecord.id });
-        message.success('更新成功');
+        message.success('已提交变更申请，待其他管理员审批');
       } else {
         await createTaxBracketV2(values);
         message.success('创建成功');
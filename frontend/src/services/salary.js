@@ -59,5 +59,10 @@ export const salaryService = {
     paySalary: async (salaryRecordId) => {
         const response = await api.get(`/salary_record/pay_salary_record_by_id/${salaryRecordId}`)
         return response
+    },
+
+    // 工资条PDF下载地址，仅员工本人及人事管理员可下载
+    getPayslipUrl: (staffId, salaryDate) => {
+        return `/api/v2/payslip/download/${staffId}/${salaryDate}`
     }
 }
\ No newline at end of file
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		bonusGroup := v2Group.Group("/annual_bonus")
+		bonusGroup.POST("/create", CreateAnnualBonusV2)
+		bonusGroup.GET("/query", GetAnnualBonusesV2)
+		bonusGroup.DELETE("/delete/:id", DeleteAnnualBonusV2)
+		bonusGroup.POST("/tax_method", UpdateAnnualBonusTaxMethodV2)
+	})
+}
+
+// CreateAnnualBonusV2 登记全年一次性奖金
+// @Summary 登记全年一次性奖金
+// @Tags V2 AnnualBonus
+// @Accept json
+// @Produce json
+// @Param annual_bonus body model.SalaryV2AnnualBonusCreateDTO true "全年一次性奖金信息"
+// @Success 200 {object} Response
+// @Router /api/v2/annual_bonus/create [post]
+func CreateAnnualBonusV2(c *gin.Context) {
+	var dto model.SalaryV2AnnualBonusCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.CreateAnnualBonusV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "登记全年一次性奖金成功")
+}
+
+// GetAnnualBonusesV2 获取全年一次性奖金发放记录
+// @Summary 获取全年一次性奖金发放记录
+// @Tags V2 AnnualBonus
+// @Accept json
+// @Produce json
+// @Param staff_id query string false "员工工号"
+// @Param bonus_year query string false "奖金所属年度"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/annual_bonus/query [get]
+func GetAnnualBonusesV2(c *gin.Context) {
+	staffId := c.Query("staff_id")
+	bonusYear := c.Query("bonus_year")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	bonuses, total, err := service.GetAnnualBonusesV2(c, staffId, bonusYear, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  bonuses,
+		"total": total,
+	}, "查询全年一次性奖金成功")
+}
+
+// DeleteAnnualBonusV2 作废全年一次性奖金
+// @Summary 作废全年一次性奖金
+// @Tags V2 AnnualBonus
+// @Accept json
+// @Produce json
+// @Param id path int true "奖金记录ID"
+// @Success 200 {object} Response
+// @Router /api/v2/annual_bonus/delete/{id} [delete]
+func DeleteAnnualBonusV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.DeleteAnnualBonusV2(c, uint(id), getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "作废全年一次性奖金成功")
+}
+
+// UpdateAnnualBonusTaxMethodV2 设置员工全年一次性奖金计税方式
+// @Summary 设置员工全年一次性奖金计税方式
+// @Tags V2 AnnualBonus
+// @Accept json
+// @Produce json
+// @Param tax_method body model.SalaryV2AnnualBonusTaxMethodDTO true "计税方式 (separate, combined)"
+// @Success 200 {object} Response
+// @Router /api/v2/annual_bonus/tax_method [post]
+func UpdateAnnualBonusTaxMethodV2(c *gin.Context) {
+	var dto model.SalaryV2AnnualBonusTaxMethodDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.UpdateAnnualBonusTaxMethodV2(c, &dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "设置计税方式成功")
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		bankGroup := v2Group.Group("/bank_payment")
+		bankGroup.POST("/layout/save", SaveBankLayoutV2)
+		bankGroup.GET("/layout/query", GetBankLayoutsV2)
+		bankGroup.DELETE("/layout/delete/:layout_id", DeleteBankLayoutV2)
+		bankGroup.POST("/batch/create", CreatePaymentBatchV2)
+		bankGroup.GET("/batch/query", GetPaymentBatchesV2)
+		bankGroup.GET("/batch/lines/:batch_id", GetPaymentLinesV2)
+		bankGroup.GET("/batch/download/:batch_id", DownloadPaymentBatchV2)
+		bankGroup.POST("/batch/return/:batch_id", ImportPaymentReturnV2)
+		bankGroup.GET("/batch/reconcile/:batch_id", ReconcilePaymentBatchV2)
+	})
+}
+
+// SaveBankLayoutV2 保存银行代发文件格式
+// @Summary 保存银行代发文件格式
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Param layout body model.SalaryV2BankLayoutCreateDTO true "代发文件格式"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/layout/save [post]
+func SaveBankLayoutV2(c *gin.Context) {
+	var dto model.SalaryV2BankLayoutCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.SaveBankLayoutV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "保存代发文件格式成功")
+}
+
+// GetBankLayoutsV2 获取银行代发文件格式列表
+// @Summary 获取银行代发文件格式列表
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/layout/query [get]
+func GetBankLayoutsV2(c *gin.Context) {
+	layouts, err := service.GetBankLayoutsV2(c)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  layouts,
+		"total": len(layouts),
+	}, "查询代发文件格式成功")
+}
+
+// DeleteBankLayoutV2 停用银行代发文件格式
+// @Summary 停用银行代发文件格式
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Param layout_id path string true "代发文件格式ID"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/layout/delete/{layout_id} [delete]
+func DeleteBankLayoutV2(c *gin.Context) {
+	if err := service.DeleteBankLayoutV2(c, c.Param("layout_id"), getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "停用代发文件格式成功")
+}
+
+// CreatePaymentBatchV2 批量生成银行代发批次
+// @Summary 批量生成银行代发批次，实发工资不大于0的薪资记录无需代发，直接标记为已发放
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Param batch body model.SalaryV2PaymentBatchCreateDTO true "代发月份及员工"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/batch/create [post]
+func CreatePaymentBatchV2(c *gin.Context) {
+	var dto model.SalaryV2PaymentBatchCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	batch, err := service.CreatePaymentBatchV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		LogOperationFailure(c, getCurrentStaffId(c), getCurrentStaffName(c), "CREATE", "SALARY",
+			"生成银行代发批次: "+dto.SalaryDate, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if batch == nil {
+		LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "UPDATE", "SALARY",
+			"实发工资均不大于0，直接标记为已发放: "+dto.SalaryDate)
+		sendSuccess(c, nil, "所选薪资记录实发工资均不大于0，无需银行代发，已标记为已发放")
+		return
+	}
+
+	LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "CREATE", "SALARY",
+		"生成银行代发批次: "+dto.SalaryDate+" "+batch.BatchId)
+	sendSuccess(c, batch, "生成银行代发批次成功")
+}
+
+// GetPaymentBatchesV2 获取银行代发批次列表
+// @Summary 获取银行代发批次列表
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Param salary_date query string false "薪资月份"
+// @Param status query string false "批次状态"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/batch/query [get]
+func GetPaymentBatchesV2(c *gin.Context) {
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	batches, total, err := service.GetPaymentBatchesV2(c, c.Query("salary_date"), c.Query("status"), start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  batches,
+		"total": total,
+	}, "查询银行代发批次成功")
+}
+
+// GetPaymentLinesV2 获取银行代发批次明细
+// @Summary 获取银行代发批次明细
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Param batch_id path string true "代发批次ID"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/batch/lines/{batch_id} [get]
+func GetPaymentLinesV2(c *gin.Context) {
+	lines, err := service.GetPaymentLinesV2(c, c.Param("batch_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  lines,
+		"total": len(lines),
+	}, "查询银行代发明细成功")
+}
+
+// DownloadPaymentBatchV2 下载银行代发文件
+// @Summary 下载银行代发文件
+// @Tags V2 BankPayment
+// @Produce octet-stream
+// @Param batch_id path string true "代发批次ID"
+// @Router /api/v2/bank_payment/batch/download/{batch_id} [get]
+func DownloadPaymentBatchV2(c *gin.Context) {
+	batch, err := service.GetPaymentBatchFileV2(c, c.Param("batch_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename="+batch.FileName)
+	c.Header("X-Batch-Checksum", batch.Checksum)
+	c.Data(http.StatusOK, "application/octet-stream", []byte(batch.FileContent))
+}
+
+// ImportPaymentReturnV2 导入银行回盘文件
+// @Summary 导入银行回盘文件
+// @Tags V2 BankPayment
+// @Accept multipart/form-data
+// @Produce json
+// @Param batch_id path string true "代发批次ID"
+// @Param return_file formData file true "银行回盘文件"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/batch/return/{batch_id} [post]
+func ImportPaymentReturnV2(c *gin.Context) {
+	file, err := c.FormFile("return_file")
+	if err != nil {
+		sendFail(c, 500, "请上传银行回盘文件")
+		return
+	}
+	fileOpen, err := file.Open()
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	defer fileOpen.Close()
+	content, err := ioutil.ReadAll(fileOpen)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	batch, unmatched, err := service.ImportPaymentReturnV2(c, c.Param("batch_id"), content, getCurrentStaffIdStr(c))
+	if err != nil {
+		LogOperationFailure(c, getCurrentStaffId(c), getCurrentStaffName(c), "IMPORT", "SALARY",
+			"导入银行回盘文件: "+c.Param("batch_id"), err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "IMPORT", "SALARY",
+		"导入银行回盘文件: "+batch.BatchId)
+	sendSuccess(c, gin.H{
+		"batch":     batch,
+		"unmatched": unmatched,
+	}, "导入银行回盘文件成功")
+}
+
+// ReconcilePaymentBatchV2 银行代发批次对账
+// @Summary 银行代发批次对账
+// @Tags V2 BankPayment
+// @Accept json
+// @Produce json
+// @Param batch_id path string true "代发批次ID"
+// @Success 200 {object} Response
+// @Router /api/v2/bank_payment/batch/reconcile/{batch_id} [get]
+func ReconcilePaymentBatchV2(c *gin.Context) {
+	result, err := service.ReconcilePaymentBatchV2(c, c.Param("batch_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, result, "银行代发批次对账成功")
+}
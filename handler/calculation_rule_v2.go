@@ -17,6 +17,8 @@ func init() {
 		calculationGroup.POST("/rule/edit", UpdateCalculationRuleV2)
 		calculationGroup.DELETE("/rule/delete/:id", DeleteCalculationRuleV2)
 		calculationGroup.GET("/rule/value/:rule_type", GetCalculationRuleValueV2)
+		calculationGroup.POST("/rule/evaluate", EvaluateFormulaV2)
+		calculationGroup.GET("/rule/variables", GetFormulaVariablesV2)
 	})
 }
 
@@ -148,4 +150,41 @@ func GetCalculationRuleValueV2(c *gin.Context) {
 		"rule_type": ruleType,
 		"value":     value,
 	}, "获取计算规则值成功")
-}
\ No newline at end of file
+}
+// EvaluateFormulaV2 试算计算规则公式
+// @Summary 试算计算规则公式
+// @Tags V2 Calculation
+// @Accept json
+// @Produce json
+// @Param formula body model.SalaryV2FormulaEvaluateDTO true "公式及变量取值"
+// @Success 200 {object} Response
+// @Router /api/v2/calculation/rule/evaluate [post]
+func EvaluateFormulaV2(c *gin.Context) {
+	var dto model.SalaryV2FormulaEvaluateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	result, err := service.EvaluateFormula(dto.Formula, dto.Variables)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"formula": dto.Formula,
+		"result":  result,
+	}, "公式计算成功")
+}
+
+// GetFormulaVariablesV2 获取计算规则公式可用变量
+// @Summary 获取计算规则公式可用变量
+// @Tags V2 Calculation
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v2/calculation/rule/variables [get]
+func GetFormulaVariablesV2(c *gin.Context) {
+	sendSuccess(c, service.FormulaVariables, "获取公式变量成功")
+}
@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		policyGroup := v2Group.Group("/insurance_policy")
+		policyGroup.POST("/create", CreateInsurancePolicyV2)
+		policyGroup.GET("/query", GetInsurancePoliciesV2)
+		policyGroup.GET("/query/:policy_id", GetInsurancePolicyV2)
+		policyGroup.POST("/edit", UpdateInsurancePolicyV2)
+		policyGroup.DELETE("/delete/:policy_id", DeleteInsurancePolicyV2)
+		policyGroup.POST("/assign", AssignStaffInsurancePolicyV2)
+		policyGroup.GET("/staff/query", GetStaffInsurancePoliciesV2)
+		policyGroup.DELETE("/staff/delete/:assignment_id", DeleteStaffInsurancePolicyV2)
+	})
+}
+
+// CreateInsurancePolicyV2 创建参保方案
+// @Summary 创建参保方案
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param policy body model.SalaryV2InsurancePolicyCreateDTO true "参保方案信息"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/create [post]
+func CreateInsurancePolicyV2(c *gin.Context) {
+	var dto model.SalaryV2InsurancePolicyCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	policy, err := service.CreateInsurancePolicyV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "CREATE", "PARAMETER",
+			"创建参保方案 "+dto.PolicyName, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "CREATE", "PARAMETER",
+		"创建参保方案 "+dto.PolicyName)
+
+	sendSuccess(c, policy, "创建参保方案成功")
+}
+
+// GetInsurancePoliciesV2 获取参保方案列表
+// @Summary 获取参保方案列表
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param city query string false "参保城市"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/query [get]
+func GetInsurancePoliciesV2(c *gin.Context) {
+	city := c.Query("city")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	policies, total, err := service.GetInsurancePoliciesV2(c, city, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  policies,
+		"total": total,
+	}, "查询参保方案成功")
+}
+
+// GetInsurancePolicyV2 获取参保方案详情
+// @Summary 获取参保方案详情及各险种费率
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param policy_id path string true "参保方案ID"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/query/{policy_id} [get]
+func GetInsurancePolicyV2(c *gin.Context) {
+	policy, err := service.GetInsurancePolicyV2(c, c.Param("policy_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, policy, "查询参保方案成功")
+}
+
+// UpdateInsurancePolicyV2 更新参保方案
+// @Summary 更新参保方案名称及城市，方案费率通过社保费率变更申请修改
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param policy body model.SalaryV2InsurancePolicyEditDTO true "参保方案信息"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/edit [post]
+func UpdateInsurancePolicyV2(c *gin.Context) {
+	var dto model.SalaryV2InsurancePolicyEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.UpdateInsurancePolicyV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "更新参保方案成功")
+}
+
+// DeleteInsurancePolicyV2 删除参保方案
+// @Summary 删除参保方案
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param policy_id path string true "参保方案ID"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/delete/{policy_id} [delete]
+func DeleteInsurancePolicyV2(c *gin.Context) {
+	policyId := c.Param("policy_id")
+	staffId := getCurrentStaffId(c)
+	if err := service.DeleteInsurancePolicyV2(c, policyId, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "DELETE", "PARAMETER",
+			"删除参保方案 "+policyId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "DELETE", "PARAMETER",
+		"删除参保方案 "+policyId)
+
+	sendSuccess(c, nil, "删除参保方案成功")
+}
+
+// AssignStaffInsurancePolicyV2 为员工分配参保方案
+// @Summary 为员工分配参保方案
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param assignment body model.SalaryV2StaffInsurancePolicyAssignDTO true "员工参保方案"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/assign [post]
+func AssignStaffInsurancePolicyV2(c *gin.Context) {
+	var dto model.SalaryV2StaffInsurancePolicyAssignDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	if err := service.AssignStaffInsurancePolicyV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+			"分配参保方案 "+dto.PolicyId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+		"分配参保方案 "+dto.PolicyId)
+
+	sendSuccess(c, nil, "分配参保方案成功")
+}
+
+// GetStaffInsurancePoliciesV2 获取员工参保方案
+// @Summary 获取员工参保方案
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param staff_id query string false "员工工号"
+// @Param policy_id query string false "参保方案ID"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/staff/query [get]
+func GetStaffInsurancePoliciesV2(c *gin.Context) {
+	staffId := c.Query("staff_id")
+	policyId := c.Query("policy_id")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	assignments, total, err := service.GetStaffInsurancePoliciesV2(c, staffId, policyId, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  assignments,
+		"total": total,
+	}, "查询员工参保方案成功")
+}
+
+// DeleteStaffInsurancePolicyV2 删除员工参保方案
+// @Summary 删除员工参保方案，删除后相应月份按分公司默认费率缴纳
+// @Tags V2 InsurancePolicy
+// @Accept json
+// @Produce json
+// @Param assignment_id path string true "员工参保方案ID"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance_policy/staff/delete/{assignment_id} [delete]
+func DeleteStaffInsurancePolicyV2(c *gin.Context) {
+	if err := service.DeleteStaffInsurancePolicyV2(c, c.Param("assignment_id")); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除员工参保方案成功")
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"hrms/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		insuranceGroup := v2Group.Group("/insurance")
+		insuranceGroup.GET("/report/query/:month", GetInsuranceContributionReportV2)
+		insuranceGroup.GET("/report/export/:month", ExportInsuranceContributionReportV2)
+	})
+}
+
+// GetInsuranceContributionReportV2 获取月度社保及住房公积金缴费报表
+// @Summary 获取月度社保及住房公积金缴费报表
+// @Tags V2 Insurance
+// @Accept json
+// @Produce json
+// @Param month path string true "薪资月份"
+// @Param insurance_type query string false "险种"
+// @Success 200 {object} Response
+// @Router /api/v2/insurance/report/query/{month} [get]
+func GetInsuranceContributionReportV2(c *gin.Context) {
+	report, err := service.GetInsuranceContributionReportV2(c, c.Param("month"), c.Query("insurance_type"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, report, "查询缴费报表成功")
+}
+
+// ExportInsuranceContributionReportV2 导出月度社保及住房公积金缴费报表
+// @Summary 导出月度社保及住房公积金缴费报表
+// @Tags V2 Insurance
+// @Produce octet-stream
+// @Param month path string true "薪资月份"
+// @Success 200 {file} file
+// @Router /api/v2/insurance/report/export/{month} [get]
+func ExportInsuranceContributionReportV2(c *gin.Context) {
+	month := c.Param("month")
+	content, err := service.ExportInsuranceContributionReportV2(c, month)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=insurance_contribution_"+month+".xlsx")
+	c.Data(http.StatusOK, xlsxContentType, content)
+}
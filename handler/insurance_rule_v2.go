@@ -4,6 +4,7 @@ import (
 	"hrms/model"
 	"hrms/service"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,7 +22,7 @@ func init() {
 }
 
 // CreateInsuranceRateV2 创建保险费率
-// @Summary 创建保险费率
+// @Summary 提交新增保险费率的变更申请，经其他管理员审批后生效
 // @Tags V2 Insurance
 // @Accept json
 // @Produce json
@@ -35,22 +36,15 @@ func CreateInsuranceRateV2(c *gin.Context) {
 		return
 	}
 
-	// 前端传入的是元，转换为分
-	if dto.MinBase > 0 {
-		dto.MinBase = dto.MinBase * 100
-	}
-	if dto.MaxBase > 0 {
-		dto.MaxBase = dto.MaxBase * 100
-	}
-
 	staffId := getCurrentStaffId(c)
 	staffIdStr := strconv.FormatUint(staffId, 10)
-	if err := service.CreateInsuranceRateV2(c, &dto, staffIdStr); err != nil {
+	// 新增及停用社保费率须提交变更申请，经其他管理员审批后生效
+	if _, err := service.CreateInsuranceRateV2(c, &dto, staffIdStr); err != nil {
 		sendFail(c, 500, err.Error())
 		return
 	}
 
-	sendSuccess(c, nil, "创建社保费率成功")
+	sendSuccess(c, nil, "已提交变更申请，待其他管理员审批")
 }
 
 // GetInsuranceRatesV2 获取保险费率列表
@@ -59,16 +53,18 @@ func CreateInsuranceRateV2(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Param insurance_type query string false "保险类型 (pension, medical, unemployment, housing, injury, maternity)"
+// @Param policy_id query string false "参保方案ID，为空时查询分公司默认费率"
 // @Param start query int false "起始位置"
 // @Param limit query int false "限制数量"
 // @Success 200 {object} Response
 // @Router /api/v2/insurance/rate/query [get]
 func GetInsuranceRatesV2(c *gin.Context) {
 	insuranceType := c.Query("insurance_type")
+	policyId := c.Query("policy_id")
 	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	insuranceRates, total, err := service.GetInsuranceRatesV2(c, insuranceType, start, limit)
+	insuranceRates, total, err := service.GetInsuranceRatesV2(c, insuranceType, policyId, start, limit)
 	if err != nil {
 		sendFail(c, 500, err.Error())
 		return
@@ -95,26 +91,23 @@ func UpdateInsuranceRateV2(c *gin.Context) {
 		return
 	}
 
-	// 前端传入的是元，转换为分
-	if dto.MinBase > 0 {
-		dto.MinBase = dto.MinBase * 100
-	}
-	if dto.MaxBase > 0 {
-		dto.MaxBase = dto.MaxBase * 100
-	}
-
 	staffId := getCurrentStaffId(c)
 	staffIdStr := strconv.FormatUint(staffId, 10)
-	if err := service.UpdateInsuranceRateV2(c, &dto, staffIdStr); err != nil {
+	// 税率及社保费率修改须提交变更申请，经其他管理员审批后生效，生效日期在今天之后的到期自动生效
+	change := &model.SalaryV2ParameterChangeCreateDTO{ParameterType: "insurance_rate", InsuranceRate: &dto}
+	if dto.EffectiveDate > time.Now().Format("2006-01-02") {
+		change.EffectiveDate = dto.EffectiveDate
+	}
+	if _, err := service.CreateParameterChangeV2(c, change, staffIdStr); err != nil {
 		sendFail(c, 500, err.Error())
 		return
 	}
 
-	sendSuccess(c, nil, "更新社保费率成功")
+	sendSuccess(c, nil, "已提交变更申请，待其他管理员审批")
 }
 
 // DeleteInsuranceRateV2 删除保险费率
-// @Summary 删除保险费率
+// @Summary 提交停用保险费率的变更申请，经其他管理员审批后生效
 // @Tags V2 Insurance
 // @Accept json
 // @Produce json
@@ -131,12 +124,12 @@ func DeleteInsuranceRateV2(c *gin.Context) {
 
 	staffId := getCurrentStaffId(c)
 	staffIdStr := strconv.FormatUint(staffId, 10)
-	if err := service.DeleteInsuranceRateV2(c, uint(id), staffIdStr); err != nil {
+	if _, err := service.DeleteInsuranceRateV2(c, uint(id), staffIdStr); err != nil {
 		sendFail(c, 500, err.Error())
 		return
 	}
 
-	sendSuccess(c, nil, "删除社保费率成功")
+	sendSuccess(c, nil, "已提交变更申请，待其他管理员审批")
 }
 
 // CalculateInsuranceV2 计算保险费用
@@ -173,7 +166,7 @@ func CalculateInsuranceV2(c *gin.Context) {
 		}
 	}
 
-	result, err := service.CalculateInsuranceV2(c, salary, insuranceTypes)
+	result, err := service.CalculateInsuranceV2(c, model.NewMoney(salary), insuranceTypes)
 	if err != nil {
 		sendFail(c, 500, err.Error())
 		return
@@ -182,3 +182,35 @@ func getCurrentStaffName(c *gin.Context) string {
 	userName := base64Decode(user[3])
 	return userName
 }
+
+// getCurrentStaffIdStr 获取当前登录员工工号（字符串形式，兼容非纯数字工号）
+func getCurrentStaffIdStr(c *gin.Context) string {
+	cookie, err := c.Cookie("user_cookie")
+	if err != nil || cookie == "" {
+		return ""
+	}
+	user := strings.Split(cookie, "_")
+	if len(user) < 4 {
+		return ""
+	}
+	return user[1]
+}
+
+// getCurrentUserType 获取当前登录用户类型：supersys超级管理员、sys系统管理员、normal普通员工
+func getCurrentUserType(c *gin.Context) string {
+	cookie, err := c.Cookie("user_cookie")
+	if err != nil || cookie == "" {
+		return ""
+	}
+	user := strings.Split(cookie, "_")
+	if len(user) < 4 {
+		return ""
+	}
+	return user[0]
+}
+
+// isAdmin 当前登录用户是否为管理员(supersys、sys)
+func isAdmin(c *gin.Context) bool {
+	userType := getCurrentUserType(c)
+	return userType == "supersys" || userType == "sys"
+}
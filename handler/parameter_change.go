@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		changeGroup := v2Group.Group("/parameter_change")
+		changeGroup.POST("/create", CreateParameterChangeV2)
+		changeGroup.POST("/preview", PreviewParameterChangeV2)
+		changeGroup.GET("/query", GetParameterChangesV2)
+		changeGroup.GET("/query/:change_id", GetParameterChangeV2)
+		changeGroup.POST("/approve", ApproveParameterChangeV2)
+		changeGroup.POST("/cancel/:change_id", CancelParameterChangeV2)
+	})
+}
+
+// CreateParameterChangeV2 提交参数变更申请
+// @Summary 提交参数变更申请
+// @Tags V2 ParameterChange
+// @Accept json
+// @Produce json
+// @Param change body model.SalaryV2ParameterChangeCreateDTO true "参数变更申请信息"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_change/create [post]
+func CreateParameterChangeV2(c *gin.Context) {
+	var dto model.SalaryV2ParameterChangeCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	change, err := service.CreateParameterChangeV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, change, "提交参数变更申请成功")
+}
+
+// PreviewParameterChangeV2 预览参数变更差异
+// @Summary 预览参数变更差异
+// @Tags V2 ParameterChange
+// @Accept json
+// @Produce json
+// @Param change body model.SalaryV2ParameterChangeCreateDTO true "参数变更申请信息"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_change/preview [post]
+func PreviewParameterChangeV2(c *gin.Context) {
+	var dto model.SalaryV2ParameterChangeCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	diffs, err := service.PreviewParameterChangeV2(c, &dto)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, diffs, "预览参数变更成功")
+}
+
+// GetParameterChangesV2 获取参数变更申请列表
+// @Summary 获取参数变更申请列表
+// @Tags V2 ParameterChange
+// @Accept json
+// @Produce json
+// @Param parameter_type query string false "参数类型"
+// @Param status query string false "申请状态"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_change/query [get]
+func GetParameterChangesV2(c *gin.Context) {
+	parameterType := c.Query("parameter_type")
+	status := c.Query("status")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	changes, total, err := service.GetParameterChangesV2(c, parameterType, status, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  changes,
+		"total": total,
+	}, "查询参数变更申请成功")
+}
+
+// GetParameterChangeV2 获取参数变更申请详情及差异
+// @Summary 获取参数变更申请详情及差异
+// @Tags V2 ParameterChange
+// @Accept json
+// @Produce json
+// @Param change_id path string true "变更申请ID"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_change/query/{change_id} [get]
+func GetParameterChangeV2(c *gin.Context) {
+	detail, err := service.GetParameterChangeV2(c, c.Param("change_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, detail, "查询参数变更申请成功")
+}
+
+// ApproveParameterChangeV2 审批参数变更申请
+// @Summary 审批参数变更申请
+// @Tags V2 ParameterChange
+// @Accept json
+// @Produce json
+// @Param approve body model.SalaryV2ParameterChangeApproveDTO true "审批信息"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_change/approve [post]
+func ApproveParameterChangeV2(c *gin.Context) {
+	var dto model.SalaryV2ParameterChangeApproveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可审批参数变更申请")
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	if err := service.ApproveParameterChangeV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER_CHANGE",
+			"审批参数变更申请 "+dto.ChangeId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER_CHANGE",
+		"审批参数变更申请 "+dto.ChangeId)
+
+	sendSuccess(c, nil, "审批参数变更申请成功")
+}
+
+// CancelParameterChangeV2 撤销参数变更申请
+// @Summary 撤销参数变更申请
+// @Tags V2 ParameterChange
+// @Accept json
+// @Produce json
+// @Param change_id path string true "变更申请ID"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_change/cancel/{change_id} [post]
+func CancelParameterChangeV2(c *gin.Context) {
+	if err := service.CancelParameterChangeV2(c, c.Param("change_id"), getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "撤销参数变更申请成功")
+}
@@ -0,0 +1,144 @@
+package handler
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/service"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		parameterSetGroup := v2Group.Group("/parameter_set")
+		parameterSetGroup.GET("/export", ExportParameterSetV2)
+		parameterSetGroup.POST("/import", ImportParameterSetV2)
+		parameterSetGroup.POST("/sync", SyncParameterSetV2)
+	})
+}
+
+// ExportParameterSetV2 导出全部V2薪资参数
+// @Summary 导出全部V2薪资参数
+// @Tags V2 ParameterSet
+// @Produce octet-stream
+// @Param format query string false "导出格式 (json, yaml)，默认json"
+// @Router /api/v2/parameter_set/export [get]
+func ExportParameterSetV2(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	content, err := service.ExportParameterSetV2(c, format)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	contentType := "application/json"
+	if format == "yaml" {
+		contentType = "application/x-yaml"
+	}
+	c.Header("Content-Disposition", "attachment; filename=salary_parameters_"+time.Now().Format("20060102")+"."+format)
+	c.Data(http.StatusOK, contentType, content)
+}
+
+// ImportParameterSetV2 导入V2薪资参数
+// @Summary 导入V2薪资参数，试运行时只校验并返回差异，税率区间及社保费率提交变更申请待审批
+// @Tags V2 ParameterSet
+// @Accept multipart/form-data
+// @Produce json
+// @Param parameter_file formData file true "参数文件(.json, .yaml)"
+// @Param format formData string false "文件格式 (json, yaml)，默认按文件扩展名判断"
+// @Param dry_run formData bool false "是否试运行"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_set/import [post]
+func ImportParameterSetV2(c *gin.Context) {
+	file, err := c.FormFile("parameter_file")
+	if err != nil {
+		sendFail(c, 500, "请上传参数文件")
+		return
+	}
+	format := c.PostForm("format")
+	if format == "" {
+		format = "json"
+		if ext := strings.ToLower(filepath.Ext(file.Filename)); ext == ".yaml" || ext == ".yml" {
+			format = "yaml"
+		}
+	}
+	dryRun := c.PostForm("dry_run") == "true"
+	// 导入会修改税率及社保费率，仅限管理员操作
+	if !dryRun && !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可导入参数")
+		return
+	}
+	fileOpen, err := file.Open()
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	defer fileOpen.Close()
+	content, err := ioutil.ReadAll(fileOpen)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	result, err := service.ImportParameterSetV2(c, content, format, dryRun, getCurrentStaffIdStr(c))
+	if err != nil {
+		LogOperationFailure(c, getCurrentStaffId(c), getCurrentStaffName(c), "IMPORT", "PARAMETER",
+			"导入薪资参数: "+file.Filename, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if len(result.Errors) > 0 {
+		sendSuccess(c, result, "参数文件校验不通过")
+		return
+	}
+	if !result.Applied {
+		sendSuccess(c, result, "参数文件校验通过")
+		return
+	}
+
+	LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "IMPORT", "PARAMETER",
+		"导入薪资参数: "+file.Filename)
+	if len(result.PendingChanges) > 0 {
+		sendSuccess(c, result, fmt.Sprintf("导入薪资参数成功，税率区间及社保费率已提交%d项变更申请，待其他管理员审批", len(result.PendingChanges)))
+		return
+	}
+	sendSuccess(c, result, "导入薪资参数成功")
+}
+
+// SyncParameterSetV2 将当前分公司参数同步至其他分公司
+// @Summary 将当前分公司参数同步至其他分公司，税率区间及社保费率在目标分公司提交变更申请待审批
+// @Tags V2 ParameterSet
+// @Accept json
+// @Produce json
+// @Param sync body model.SalaryV2ParameterSyncDTO true "同步信息"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_set/sync [post]
+func SyncParameterSetV2(c *gin.Context) {
+	var dto model.SalaryV2ParameterSyncDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可同步分公司参数")
+		return
+	}
+
+	results, err := service.SyncParameterSetV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if !dto.DryRun {
+		LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "UPDATE", "PARAMETER",
+			"同步薪资参数至分公司: "+strings.Join(dto.BranchIds, ","))
+	}
+
+	sendSuccess(c, results, "同步分公司参数完成")
+}
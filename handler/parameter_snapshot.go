@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"fmt"
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		snapshotGroup := v2Group.Group("/parameter_snapshot")
+		snapshotGroup.POST("/create", CreateParameterSnapshotV2)
+		snapshotGroup.GET("/query", GetParameterSnapshotsV2)
+		snapshotGroup.GET("/compare", CompareParameterSnapshotsV2)
+		snapshotGroup.POST("/restore/:snapshot_id", RestoreParameterSnapshotV2)
+		snapshotGroup.DELETE("/delete/:snapshot_id", DeleteParameterSnapshotV2)
+	})
+}
+
+// CreateParameterSnapshotV2 保存当前参数快照
+// @Summary 保存当前参数快照
+// @Tags V2 ParameterSnapshot
+// @Accept json
+// @Produce json
+// @Param snapshot body model.SalaryV2ParameterSnapshotCreateDTO true "快照信息"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_snapshot/create [post]
+func CreateParameterSnapshotV2(c *gin.Context) {
+	var dto model.SalaryV2ParameterSnapshotCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	snapshot, err := service.CreateParameterSnapshotV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, snapshot, "保存参数快照成功")
+}
+
+// GetParameterSnapshotsV2 获取参数快照列表
+// @Summary 获取参数快照列表
+// @Tags V2 ParameterSnapshot
+// @Accept json
+// @Produce json
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_snapshot/query [get]
+func GetParameterSnapshotsV2(c *gin.Context) {
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	snapshots, total, err := service.GetParameterSnapshotsV2(c, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  snapshots,
+		"total": total,
+	}, "查询参数快照成功")
+}
+
+// CompareParameterSnapshotsV2 比较两个参数快照
+// @Summary 比较两个参数快照
+// @Tags V2 ParameterSnapshot
+// @Accept json
+// @Produce json
+// @Param from query string true "基准快照ID，current表示当前参数"
+// @Param to query string false "比较快照ID，默认为当前参数"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_snapshot/compare [get]
+func CompareParameterSnapshotsV2(c *gin.Context) {
+	from := c.Query("from")
+	to := c.DefaultQuery("to", "current")
+	if from == "" {
+		sendFail(c, 500, "请选择基准快照")
+		return
+	}
+
+	diffs, err := service.CompareParameterSnapshotsV2(c, from, to)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, diffs, "比较参数快照成功")
+}
+
+// RestoreParameterSnapshotV2 将参数整体恢复为快照内容
+// @Summary 将参数整体恢复为快照内容，税率区间及社保费率提交变更申请，经其他管理员审批后生效
+// @Tags V2 ParameterSnapshot
+// @Accept json
+// @Produce json
+// @Param snapshot_id path string true "快照ID"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_snapshot/restore/{snapshot_id} [post]
+func RestoreParameterSnapshotV2(c *gin.Context) {
+	// 快照恢复会同时修改税率及社保费率，仅限管理员操作
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可恢复参数快照")
+		return
+	}
+
+	snapshotId := c.Param("snapshot_id")
+	staffId := getCurrentStaffId(c)
+	changes, err := service.RestoreParameterSnapshotV2(c, snapshotId, getCurrentStaffIdStr(c))
+	if err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+			"恢复参数快照 "+snapshotId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+		"恢复参数快照 "+snapshotId)
+
+	if len(changes) > 0 {
+		sendSuccess(c, changes, fmt.Sprintf("恢复参数快照成功，税率区间及社保费率已提交%d项变更申请，待其他管理员审批", len(changes)))
+		return
+	}
+	sendSuccess(c, nil, "恢复参数快照成功")
+}
+
+// DeleteParameterSnapshotV2 删除参数快照
+// @Summary 删除参数快照
+// @Tags V2 ParameterSnapshot
+// @Accept json
+// @Produce json
+// @Param snapshot_id path string true "快照ID"
+// @Success 200 {object} Response
+// @Router /api/v2/parameter_snapshot/delete/{snapshot_id} [delete]
+func DeleteParameterSnapshotV2(c *gin.Context) {
+	if err := service.DeleteParameterSnapshotV2(c, c.Param("snapshot_id")); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除参数快照成功")
+}
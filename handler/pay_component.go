@@ -0,0 +1,237 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		componentGroup := v2Group.Group("/pay_component")
+		componentGroup.POST("/create", CreatePayComponentV2)
+		componentGroup.GET("/query", GetPayComponentsV2)
+		componentGroup.POST("/edit", UpdatePayComponentV2)
+		componentGroup.DELETE("/delete/:id", DeletePayComponentV2)
+		// 员工薪资项目
+		componentGroup.POST("/staff/create", CreateStaffPayComponentV2)
+		componentGroup.GET("/staff/query", GetStaffPayComponentsV2)
+		componentGroup.POST("/staff/edit", UpdateStaffPayComponentV2)
+		componentGroup.DELETE("/staff/delete/:id", DeleteStaffPayComponentV2)
+		// 薪资记录明细
+		componentGroup.GET("/record_items/:salary_record_id", GetSalaryRecordItemsV2)
+	})
+}
+
+// CreatePayComponentV2 创建薪资项目
+// @Summary 创建薪资项目
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param component body model.SalaryV2PayComponentCreateDTO true "薪资项目信息"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/create [post]
+func CreatePayComponentV2(c *gin.Context) {
+	var dto model.SalaryV2PayComponentCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.CreatePayComponentV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "创建薪资项目成功")
+}
+
+// GetPayComponentsV2 获取薪资项目列表
+// @Summary 获取薪资项目列表
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param component_type query string false "项目类型：earning应发项，deduction扣款项"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/query [get]
+func GetPayComponentsV2(c *gin.Context) {
+	componentType := c.Query("component_type")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	components, total, err := service.GetPayComponentsV2(c, componentType, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  components,
+		"total": total,
+	}, "查询薪资项目成功")
+}
+
+// UpdatePayComponentV2 更新薪资项目
+// @Summary 更新薪资项目
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param component body model.SalaryV2PayComponentEditDTO true "薪资项目信息"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/edit [post]
+func UpdatePayComponentV2(c *gin.Context) {
+	var dto model.SalaryV2PayComponentEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.UpdatePayComponentV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "更新薪资项目成功")
+}
+
+// DeletePayComponentV2 停用薪资项目
+// @Summary 停用薪资项目
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param id path int true "薪资项目ID"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/delete/{id} [delete]
+func DeletePayComponentV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.DeletePayComponentV2(c, uint(id), getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除薪资项目成功")
+}
+
+// CreateStaffPayComponentV2 创建员工薪资项目
+// @Summary 创建员工薪资项目
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param component body model.SalaryV2StaffPayComponentCreateDTO true "员工薪资项目信息"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/staff/create [post]
+func CreateStaffPayComponentV2(c *gin.Context) {
+	var dto model.SalaryV2StaffPayComponentCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.CreateStaffPayComponentV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "创建员工薪资项目成功")
+}
+
+// GetStaffPayComponentsV2 获取员工薪资项目列表
+// @Summary 获取员工薪资项目列表
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param staff_id query string false "员工工号"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/staff/query [get]
+func GetStaffPayComponentsV2(c *gin.Context) {
+	staffId := c.Query("staff_id")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	staffComponents, total, err := service.GetStaffPayComponentsV2(c, staffId, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  staffComponents,
+		"total": total,
+	}, "查询员工薪资项目成功")
+}
+
+// UpdateStaffPayComponentV2 更新员工薪资项目
+// @Summary 更新员工薪资项目
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param component body model.SalaryV2StaffPayComponentEditDTO true "员工薪资项目信息"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/staff/edit [post]
+func UpdateStaffPayComponentV2(c *gin.Context) {
+	var dto model.SalaryV2StaffPayComponentEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.UpdateStaffPayComponentV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "更新员工薪资项目成功")
+}
+
+// DeleteStaffPayComponentV2 删除员工薪资项目
+// @Summary 删除员工薪资项目
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param id path int true "员工薪资项目ID"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/staff/delete/{id} [delete]
+func DeleteStaffPayComponentV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.DeleteStaffPayComponentV2(c, uint(id), getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除员工薪资项目成功")
+}
+
+// GetSalaryRecordItemsV2 获取薪资记录的薪资项目明细
+// @Summary 获取薪资记录的薪资项目明细
+// @Tags V2 PayComponent
+// @Accept json
+// @Produce json
+// @Param salary_record_id path string true "薪资记录ID"
+// @Success 200 {object} Response
+// @Router /api/v2/pay_component/record_items/{salary_record_id} [get]
+func GetSalaryRecordItemsV2(c *gin.Context) {
+	items, err := service.GetSalaryRecordItemsV2(c, c.Param("salary_record_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, items, "查询薪资记录明细成功")
+}
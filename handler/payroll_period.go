@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		periodGroup := v2Group.Group("/payroll_period")
+		periodGroup.GET("/query", GetPayrollPeriodsV2)
+		periodGroup.POST("/lock", LockPayrollPeriodV2)
+		periodGroup.POST("/close", ClosePayrollPeriodV2)
+		periodGroup.POST("/unlock", UnlockPayrollPeriodV2)
+	})
+}
+
+// GetPayrollPeriodsV2 获取薪资期间列表
+// @Summary 获取薪资期间列表
+// @Tags V2 PayrollPeriod
+// @Accept json
+// @Produce json
+// @Param status query string false "期间状态"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/payroll_period/query [get]
+func GetPayrollPeriodsV2(c *gin.Context) {
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	periods, total, err := service.GetPayrollPeriodsV2(c, c.Query("status"), start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  periods,
+		"total": total,
+	}, "查询薪资期间成功")
+}
+
+// LockPayrollPeriodV2 锁定薪资期间
+// @Summary 锁定薪资期间
+// @Tags V2 PayrollPeriod
+// @Accept json
+// @Produce json
+// @Param period body model.SalaryV2PayrollPeriodDTO true "薪资月份"
+// @Success 200 {object} Response
+// @Router /api/v2/payroll_period/lock [post]
+func LockPayrollPeriodV2(c *gin.Context) {
+	var dto model.SalaryV2PayrollPeriodDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.LockPayrollPeriodV2(c, dto.PeriodMonth, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "锁定薪资期间成功")
+}
+
+// ClosePayrollPeriodV2 薪资期间结账
+// @Summary 薪资期间结账
+// @Tags V2 PayrollPeriod
+// @Accept json
+// @Produce json
+// @Param period body model.SalaryV2PayrollPeriodDTO true "薪资月份"
+// @Success 200 {object} Response
+// @Router /api/v2/payroll_period/close [post]
+func ClosePayrollPeriodV2(c *gin.Context) {
+	var dto model.SalaryV2PayrollPeriodDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.ClosePayrollPeriodV2(c, dto.PeriodMonth, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "薪资期间结账成功")
+}
+
+// UnlockPayrollPeriodV2 解锁薪资期间，仅限管理员
+// @Summary 解锁薪资期间
+// @Tags V2 PayrollPeriod
+// @Accept json
+// @Produce json
+// @Param period body model.SalaryV2PayrollPeriodUnlockDTO true "薪资月份及解锁原因"
+// @Success 200 {object} Response
+// @Router /api/v2/payroll_period/unlock [post]
+func UnlockPayrollPeriodV2(c *gin.Context) {
+	var dto model.SalaryV2PayrollPeriodUnlockDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if !isAdmin(c) {
+		LogOperationFailure(c, getCurrentStaffId(c), getCurrentStaffName(c), "UNLOCK", "SALARY",
+			"解锁薪资期间"+dto.PeriodMonth, "无权限")
+		sendFail(c, 403, "仅管理员可解锁薪资期间")
+		return
+	}
+
+	if err := service.UnlockPayrollPeriodV2(c, &dto, getCurrentStaffIdStr(c), getCurrentStaffName(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "解锁薪资期间成功")
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"hrms/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		payslipGroup := v2Group.Group("/payslip")
+		payslipGroup.GET("/query/:staff_id/:month", GetPayslipV2)
+		payslipGroup.GET("/download/:staff_id/:month", DownloadPayslipV2)
+	})
+}
+
+// canViewPayslip 工资条仅员工本人及人事管理员可查看
+func canViewPayslip(c *gin.Context, staffId string) bool {
+	if isAdmin(c) {
+		return true
+	}
+	return staffId != "" && getCurrentStaffIdStr(c) == staffId
+}
+
+// GetPayslipV2 获取员工月度工资条
+// @Summary 获取员工月度工资条
+// @Tags V2 Payslip
+// @Accept json
+// @Produce json
+// @Param staff_id path string true "员工ID"
+// @Param month path string true "薪资月份"
+// @Success 200 {object} Response
+// @Router /api/v2/payslip/query/{staff_id}/{month} [get]
+func GetPayslipV2(c *gin.Context) {
+	staffId := c.Param("staff_id")
+	if !canViewPayslip(c, staffId) {
+		sendFail(c, 403, "无权查看该员工工资条")
+		return
+	}
+
+	payslip, err := service.GetPayslipV2(c, staffId, c.Param("month"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, payslip, "查询工资条成功")
+}
+
+// DownloadPayslipV2 下载员工月度工资条PDF
+// @Summary 下载员工月度工资条PDF
+// @Tags V2 Payslip
+// @Produce application/pdf
+// @Param staff_id path string true "员工ID"
+// @Param month path string true "薪资月份"
+// @Success 200 {file} file
+// @Router /api/v2/payslip/download/{staff_id}/{month} [get]
+func DownloadPayslipV2(c *gin.Context) {
+	staffId := c.Param("staff_id")
+	month := c.Param("month")
+	if !canViewPayslip(c, staffId) {
+		sendFail(c, 403, "无权下载该员工工资条")
+		return
+	}
+
+	content, err := service.RenderPayslipPDFV2(c, staffId, month)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "EXPORT", "SALARY",
+		"下载工资条"+staffId+" "+month)
+	c.Header("Content-Disposition", "attachment; filename=payslip_"+staffId+"_"+month+".pdf")
+	c.Data(http.StatusOK, "application/pdf", content)
+}
@@ -55,8 +55,9 @@ func RankCreate(c *gin.Context) {
 		return
 	}
 	rank := model.Rank{
-		RankId:   service.RandomID("rank"),
-		RankName: rankCreateDto.RankName,
+		RankId:    service.RandomID("rank"),
+		RankName:  rankCreateDto.RankName,
+		RankLevel: rankCreateDto.RankLevel,
 	}
 	resource.HrmsDB(c).Create(&rank)
 
@@ -94,7 +95,7 @@ func RankEdit(c *gin.Context) {
 	resource.HrmsDB(c).Where("rank_id = ?", rankEditDTO.RankId).First(&originalRank)
 	
 	resource.HrmsDB(c).Model(&model.Rank{}).Where("rank_id = ?", rankEditDTO.RankId).
-		Updates(&model.Rank{RankName: rankEditDTO.RankName})
+		Updates(&model.Rank{RankName: rankEditDTO.RankName, RankLevel: rankEditDTO.RankLevel})
 	
 	LogOperationSuccess(c, staffId, staffName, "UPDATE", "RANK", 
 		"编辑职级成功: "+originalRank.RankName)
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		retroGroup := v2Group.Group("/retro")
+		retroGroup.POST("/preview", PreviewRetroAdjustmentsV2)
+		retroGroup.POST("/create", CreateRetroAdjustmentsV2)
+		retroGroup.GET("/query", GetRetroAdjustmentsV2)
+		retroGroup.POST("/cancel/:retro_id", CancelRetroAdjustmentV2)
+	})
+}
+
+// PreviewRetroAdjustmentsV2 试算追溯调整
+// @Summary 试算追溯调整
+// @Tags V2 Retro
+// @Accept json
+// @Produce json
+// @Param retro body model.SalaryV2RetroCalculateDTO true "追溯区间"
+// @Success 200 {object} Response
+// @Router /api/v2/retro/preview [post]
+func PreviewRetroAdjustmentsV2(c *gin.Context) {
+	var dto model.SalaryV2RetroCalculateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	adjustments, err := service.PreviewRetroAdjustmentsV2(c, &dto)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  adjustments,
+		"total": len(adjustments),
+	}, "试算追溯调整成功")
+}
+
+// CreateRetroAdjustmentsV2 生成追溯调整
+// @Summary 生成追溯调整
+// @Tags V2 Retro
+// @Accept json
+// @Produce json
+// @Param retro body model.SalaryV2RetroCalculateDTO true "追溯区间"
+// @Success 200 {object} Response
+// @Router /api/v2/retro/create [post]
+func CreateRetroAdjustmentsV2(c *gin.Context) {
+	var dto model.SalaryV2RetroCalculateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	adjustments, err := service.CreateRetroAdjustmentsV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  adjustments,
+		"total": len(adjustments),
+	}, "生成追溯调整成功")
+}
+
+// GetRetroAdjustmentsV2 获取追溯调整列表
+// @Summary 获取追溯调整列表
+// @Tags V2 Retro
+// @Accept json
+// @Produce json
+// @Param staff_id query string false "员工工号"
+// @Param source_month query string false "被追溯月份"
+// @Param target_month query string false "计入月份"
+// @Param status query string false "状态"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/retro/query [get]
+func GetRetroAdjustmentsV2(c *gin.Context) {
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	adjustments, total, err := service.GetRetroAdjustmentsV2(c, c.Query("staff_id"), c.Query("source_month"),
+		c.Query("target_month"), c.Query("status"), start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  adjustments,
+		"total": total,
+	}, "查询追溯调整成功")
+}
+
+// CancelRetroAdjustmentV2 撤销追溯调整
+// @Summary 撤销追溯调整
+// @Tags V2 Retro
+// @Accept json
+// @Produce json
+// @Param retro_id path string true "追溯调整ID"
+// @Success 200 {object} Response
+// @Router /api/v2/retro/cancel/{retro_id} [post]
+func CancelRetroAdjustmentV2(c *gin.Context) {
+	if err := service.CancelRetroAdjustmentV2(c, c.Param("retro_id"), getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "撤销追溯调整成功")
+}
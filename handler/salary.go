@@ -26,6 +26,7 @@ func init() {
 		salaryRecordGroup.GET("/pay_salary_record_by_id/:id", PaySalaryRecordById)
 		salaryRecordGroup.GET("/query_history/:staff_id", GetHadPaySalaryRecordByStaffId)
 		salaryRecordGroup.GET("/query_history/all", GetHadPaySalaryRecordByStaffId)
+		salaryRecordGroup.GET("/segments/:salary_record_id", GetSalaryRecordSegments)
 	})
 }
 
@@ -323,17 +324,23 @@ func PaySalaryRecordById(c *gin.Context) {
 	var salaryRecord model.SalaryRecord
 	resource.HrmsDB(c).Where("id = ?", id).First(&salaryRecord)
 	
-	err = service.PaySalaryRecordById(c, int64(id))
+	batch, err := service.PaySalaryRecordById(c, int64(id), getCurrentStaffIdStr(c))
 	if err != nil {
 		LogOperationFailure(c, staffId, staffName, "UPDATE", "SALARY", 
 			"发放薪资失败: "+salaryRecord.StaffName, err.Error())
 		sendFail(c, 5002, "发放失败"+err.Error())
 		return
 	}
+	if batch == nil {
+		LogOperationSuccess(c, staffId, staffName, "UPDATE", "SALARY",
+			"实发工资不大于0，直接标记为已发放: "+salaryRecord.StaffName)
+		sendSuccess(c, nil, "实发工资不大于0，无需银行代发，已标记为已发放")
+		return
+	}
 	
 	LogOperationSuccess(c, staffId, staffName, "UPDATE", "SALARY", 
-		"发放薪资成功: "+salaryRecord.StaffName)
-	sendSuccess(c, nil, "发放成功")
+		"生成银行代发批次: "+salaryRecord.StaffName+" "+batch.BatchId)
+	sendSuccess(c, batch, "已生成银行代发批次，待银行回盘确认后发放")
 }
 
 // 根据员工ID查询已发放薪资记录
@@ -369,3 +376,20 @@ func GetHadPaySalaryRecordByStaffId(c *gin.Context) {
 	}
 	sendTotalSuccess(c, list, total, "")
 }
+
+// 查询薪资记录基本工资分段明细
+// @Summary 查询薪资记录基本工资分段明细
+// @Tags SalaryRecord
+// @Accept  json
+// @Produce  json
+// @Param salary_record_id path string true "薪资发放记录ID"
+// @Success 200 {object} Response
+// @Router /api/salary_record/segments/{salary_record_id} [get]
+func GetSalaryRecordSegments(c *gin.Context) {
+	segments, err := service.GetSalaryRecordSegments(c, c.Param("salary_record_id"))
+	if err != nil {
+		sendFail(c, 5000, err.Error())
+		return
+	}
+	sendSuccess(c, segments, "")
+}
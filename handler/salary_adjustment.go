@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		adjustmentGroup := v2Group.Group("/salary_adjustment")
+		adjustmentGroup.POST("/create", CreateSalaryAdjustmentV2)
+		adjustmentGroup.GET("/query", GetSalaryAdjustmentsV2)
+		adjustmentGroup.GET("/leader/pending", GetSalaryAdjustmentsByLeaderV2)
+		adjustmentGroup.POST("/leader/approve", ApproveSalaryAdjustmentByLeaderV2)
+		adjustmentGroup.POST("/hr/approve", ApproveSalaryAdjustmentByHrV2)
+		adjustmentGroup.POST("/cancel/:adjustment_id", CancelSalaryAdjustmentV2)
+		adjustmentGroup.GET("/history/:staff_id", GetCompensationHistoryV2)
+	})
+}
+
+// CreateSalaryAdjustmentV2 提交调薪申请
+// @Summary 提交调薪申请
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Param adjustment body model.SalaryV2AdjustmentCreateDTO true "调薪申请信息"
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/create [post]
+func CreateSalaryAdjustmentV2(c *gin.Context) {
+	var dto model.SalaryV2AdjustmentCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if !isAdmin(c) && dto.StaffId != getCurrentStaffIdStr(c) {
+		sendFail(c, 403, "仅管理员可为其他员工提交调薪申请")
+		return
+	}
+
+	if err := service.CreateSalaryAdjustmentV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "提交调薪申请成功")
+}
+
+// GetSalaryAdjustmentsV2 获取调薪申请列表
+// @Summary 获取调薪申请列表
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Param staff_id query string false "员工工号"
+// @Param status query string false "申请状态"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/query [get]
+func GetSalaryAdjustmentsV2(c *gin.Context) {
+	staffId := c.Query("staff_id")
+	status := c.Query("status")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	adjustments, total, err := service.GetSalaryAdjustmentsV2(c, staffId, status, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  adjustments,
+		"total": total,
+	}, "查询调薪申请成功")
+}
+
+// GetSalaryAdjustmentsByLeaderV2 获取待当前领导审批的调薪申请
+// @Summary 获取待当前领导审批的调薪申请
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/leader/pending [get]
+func GetSalaryAdjustmentsByLeaderV2(c *gin.Context) {
+	adjustments, total, err := service.GetSalaryAdjustmentsByLeaderV2(c, getCurrentStaffIdStr(c))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  adjustments,
+		"total": total,
+	}, "查询待审批调薪申请成功")
+}
+
+// ApproveSalaryAdjustmentByLeaderV2 直属领导审批调薪申请
+// @Summary 直属领导审批调薪申请
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Param approve body model.SalaryV2AdjustmentApproveDTO true "审批信息"
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/leader/approve [post]
+func ApproveSalaryAdjustmentByLeaderV2(c *gin.Context) {
+	var dto model.SalaryV2AdjustmentApproveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.ApproveSalaryAdjustmentByLeaderV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "审批调薪申请成功")
+}
+
+// ApproveSalaryAdjustmentByHrV2 人事审批调薪申请
+// @Summary 人事审批调薪申请，仅限管理员，不能审批本人或本人提交的申请
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Param approve body model.SalaryV2AdjustmentApproveDTO true "审批信息"
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/hr/approve [post]
+func ApproveSalaryAdjustmentByHrV2(c *gin.Context) {
+	var dto model.SalaryV2AdjustmentApproveDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可进行人事审批")
+		return
+	}
+
+	if err := service.ApproveSalaryAdjustmentByHrV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "审批调薪申请成功")
+}
+
+// CancelSalaryAdjustmentV2 撤销调薪申请
+// @Summary 撤销调薪申请，普通员工只能撤销本人的调薪申请
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Param adjustment_id path string true "调薪申请ID"
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/cancel/{adjustment_id} [post]
+func CancelSalaryAdjustmentV2(c *gin.Context) {
+	if err := service.CancelSalaryAdjustmentV2(c, c.Param("adjustment_id"), getCurrentStaffIdStr(c), isAdmin(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "撤销调薪申请成功")
+}
+
+// GetCompensationHistoryV2 获取员工薪酬变动历史
+// @Summary 获取员工薪酬变动历史
+// @Tags V2 SalaryAdjustment
+// @Accept json
+// @Produce json
+// @Param staff_id path string true "员工工号"
+// @Success 200 {object} Response
+// @Router /api/v2/salary_adjustment/history/{staff_id} [get]
+func GetCompensationHistoryV2(c *gin.Context) {
+	histories, err := service.GetCompensationHistoryV2(c, c.Param("staff_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, histories, "查询薪酬变动历史成功")
+}
@@ -170,4 +170,58 @@ func ToggleTemplateStatus(c *gin.Context) {
 	}
 
 	sendSuccess(c, nil, "状态更新成功")
-}
\ No newline at end of file
+}
+// AssignSalaryTemplate 为员工分配薪资模板
+func AssignSalaryTemplate(c *gin.Context) {
+	var assignReq model.TemplateAssignRequest
+	if err := c.ShouldBindJSON(&assignReq); err != nil {
+		log.Printf("[AssignSalaryTemplate] 参数绑定错误: %v", err)
+		sendFail(c, 5001, "参数绑定错误: "+err.Error())
+		return
+	}
+
+	assignment, err := service.AssignSalaryTemplate(c, &assignReq, getCurrentStaffIdStr(c))
+	if err != nil {
+		log.Printf("[AssignSalaryTemplate] 分配模板失败: %v", err)
+		sendFail(c, 5002, "分配模板失败: "+err.Error())
+		return
+	}
+
+	sendSuccess(c, assignment, "分配成功")
+}
+
+// GetStaffSalaryTemplates 获取员工薪资模板分配记录
+func GetStaffSalaryTemplates(c *gin.Context) {
+	staffID := c.Param("staff_id")
+	if staffID == "" {
+		sendFail(c, 5001, "员工ID不能为空")
+		return
+	}
+
+	assignments, err := service.GetStaffSalaryTemplates(c, staffID)
+	if err != nil {
+		log.Printf("[GetStaffSalaryTemplates] 获取模板分配记录失败: %v", err)
+		sendFail(c, 5002, "获取模板分配记录失败: "+err.Error())
+		return
+	}
+
+	sendSuccess(c, assignments, "获取成功")
+}
+
+// ReapplySalaryTemplate 按模板重新生成已分配员工的薪资套账
+func ReapplySalaryTemplate(c *gin.Context) {
+	templateID := c.Param("template_id")
+	if templateID == "" {
+		sendFail(c, 5001, "模板ID不能为空")
+		return
+	}
+
+	count, err := service.ReapplySalaryTemplate(c, templateID)
+	if err != nil {
+		log.Printf("[ReapplySalaryTemplate] 重新应用模板失败: %v", err)
+		sendFail(c, 5002, "重新应用模板失败: "+err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{"count": count}, "重新应用成功")
+}
@@ -17,9 +17,11 @@ func init() {
 		systemGroup.POST("/parameter/edit", UpdateSystemParameterV2)
 		systemGroup.DELETE("/parameter/delete/:id", DeleteSystemParameterV2)
 		systemGroup.GET("/parameter/value/:parameter_key", GetSystemParameterValueV2)
+		systemGroup.GET("/parameter/registry", GetSystemParameterSchemasV2)
 		
 		historyGroup := v2Group.Group("/history")
 		historyGroup.GET("/parameter/query", GetParameterHistoryV2)
+		historyGroup.POST("/parameter/restore", RestoreParameterHistoryV2)
 		
 		// 薪资模板路由
 		templateGroup := v2Group.Group("/template")
@@ -31,6 +33,9 @@ func init() {
 		templateGroup.POST("/apply", ApplySalaryTemplate)
 		templateGroup.GET("/applicable/:staff_id", GetApplicableTemplates)
 		templateGroup.PUT("/toggle/:template_id", ToggleTemplateStatus)
+		templateGroup.POST("/assign", AssignSalaryTemplate)
+		templateGroup.GET("/assignment/:staff_id", GetStaffSalaryTemplates)
+		templateGroup.POST("/reapply/:template_id", ReapplySalaryTemplate)
 	})
 }
 
@@ -164,6 +169,17 @@ func GetSystemParameterValueV2(c *gin.Context) {
 	}, "获取系统参数值成功")
 }
 
+// GetSystemParameterSchemasV2 获取已注册系统参数的类型及取值约束
+// @Summary 获取已注册系统参数的类型及取值约束
+// @Tags V2 System
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v2/system/parameter/registry [get]
+func GetSystemParameterSchemasV2(c *gin.Context) {
+	sendSuccess(c, service.GetSystemParameterSchemasV2(), "获取系统参数约束成功")
+}
+
 // GetParameterHistoryV2 获取参数变更历史
 // @Summary 获取参数变更历史
 // @Tags V2 History
@@ -191,4 +207,38 @@ func GetParameterHistoryV2(c *gin.Context) {
 		"list":  history,
 		"total": total,
 	}, "查询参数历史成功")
+}
+
+// RestoreParameterHistoryV2 将参数恢复至历史版本
+// @Summary 将参数恢复至历史版本
+// @Tags V2 History
+// @Accept json
+// @Produce json
+// @Param restore body model.SalaryV2ParameterRestoreDTO true "恢复信息"
+// @Success 200 {object} Response
+// @Router /api/v2/history/parameter/restore [post]
+func RestoreParameterHistoryV2(c *gin.Context) {
+	var dto model.SalaryV2ParameterRestoreDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	change, err := service.RestoreParameterHistoryV2(c, &dto, strconv.FormatUint(staffId, 10))
+	if err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+			"恢复参数历史版本 "+dto.HistoryId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+		"恢复参数历史版本 "+dto.HistoryId)
+
+	// 税率区间及社保费率须经审批后生效
+	if change != nil {
+		sendSuccess(c, change, "已提交变更申请，待其他管理员审批")
+		return
+	}
+	sendSuccess(c, nil, "恢复参数历史版本成功")
 }
\ No newline at end of file
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		settlementGroup := v2Group.Group("/settlement")
+		settlementGroup.POST("/create", CreateFinalSettlementV2)
+		settlementGroup.GET("/query/:staff_id", GetFinalSettlementV2)
+		settlementGroup.GET("/statement/:staff_id", RenderSettlementStatementV2)
+	})
+}
+
+// CreateFinalSettlementV2 生成离职结算
+// @Summary 生成离职结算
+// @Tags V2 Settlement
+// @Accept json
+// @Produce json
+// @Param settlement body model.SalaryV2SettlementCreateDTO true "离职结算信息"
+// @Success 200 {object} Response
+// @Router /api/v2/settlement/create [post]
+func CreateFinalSettlementV2(c *gin.Context) {
+	var dto model.SalaryV2SettlementCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	settlement, err := service.CreateFinalSettlementV2(c, &dto, getCurrentStaffIdStr(c))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, settlement, "生成离职结算成功")
+}
+
+// GetFinalSettlementV2 查询离职结算
+// @Summary 查询离职结算
+// @Tags V2 Settlement
+// @Accept json
+// @Produce json
+// @Param staff_id path string true "员工工号"
+// @Success 200 {object} Response
+// @Router /api/v2/settlement/query/{staff_id} [get]
+func GetFinalSettlementV2(c *gin.Context) {
+	settlement, record, err := service.GetFinalSettlementV2(c, c.Param("staff_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"settlement":    settlement,
+		"salary_record": record,
+	}, "查询离职结算成功")
+}
+
+// RenderSettlementStatementV2 打印离职结算单
+// @Summary 打印离职结算单
+// @Tags V2 Settlement
+// @Produce html
+// @Param staff_id path string true "员工工号"
+// @Router /api/v2/settlement/statement/{staff_id} [get]
+func RenderSettlementStatementV2(c *gin.Context) {
+	settlement, record, err := service.GetFinalSettlementV2(c, c.Param("staff_id"))
+	if err != nil {
+		log.Printf("[RenderSettlementStatementV2] err = %v", err)
+		sendFail(c, 500, err.Error())
+		return
+	}
+	c.HTML(http.StatusOK, "settlement_statement.html", gin.H{
+		"settlement": settlement,
+		"record":     record,
+		"printDate":  time.Now().Format("2006-01-02"),
+	})
+}
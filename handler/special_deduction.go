@@ -0,0 +1,249 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		deductionGroup := v2Group.Group("/deduction")
+		deductionGroup.POST("/create", CreateSpecialDeductionV2)
+		deductionGroup.GET("/query", GetSpecialDeductionsV2)
+		deductionGroup.POST("/edit", UpdateSpecialDeductionV2)
+		deductionGroup.DELETE("/delete/:id", DeleteSpecialDeductionV2)
+		// 员工自助申报
+		deductionGroup.POST("/my/create", CreateMySpecialDeductionV2)
+		deductionGroup.GET("/my/query", GetMySpecialDeductionsV2)
+		deductionGroup.POST("/my/edit", UpdateMySpecialDeductionV2)
+		deductionGroup.DELETE("/my/delete/:id", DeleteMySpecialDeductionV2)
+	})
+}
+
+// CreateSpecialDeductionV2 创建专项附加扣除申报
+// @Summary 创建专项附加扣除申报
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param deduction body model.SalaryV2SpecialDeductionCreateDTO true "专项附加扣除信息"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/create [post]
+func CreateSpecialDeductionV2(c *gin.Context) {
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可维护其他员工的专项附加扣除，本人申报请使用自助申报")
+		return
+	}
+	var dto model.SalaryV2SpecialDeductionCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.CreateSpecialDeductionV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "创建专项附加扣除申报成功")
+}
+
+// GetSpecialDeductionsV2 获取专项附加扣除申报列表
+// @Summary 获取专项附加扣除申报列表
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param staff_id query string false "员工工号"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/query [get]
+func GetSpecialDeductionsV2(c *gin.Context) {
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可维护其他员工的专项附加扣除，本人申报请使用自助申报")
+		return
+	}
+	staffId := c.Query("staff_id")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	deductions, total, err := service.GetSpecialDeductionsV2(c, staffId, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  deductions,
+		"total": total,
+	}, "查询专项附加扣除申报成功")
+}
+
+// UpdateSpecialDeductionV2 更新专项附加扣除申报
+// @Summary 更新专项附加扣除申报
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param deduction body model.SalaryV2SpecialDeductionEditDTO true "专项附加扣除信息"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/edit [post]
+func UpdateSpecialDeductionV2(c *gin.Context) {
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可维护其他员工的专项附加扣除，本人申报请使用自助申报")
+		return
+	}
+	var dto model.SalaryV2SpecialDeductionEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.UpdateSpecialDeductionV2(c, &dto, "", getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "更新专项附加扣除申报成功")
+}
+
+// DeleteSpecialDeductionV2 删除专项附加扣除申报
+// @Summary 删除专项附加扣除申报
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param id path int true "申报ID"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/delete/{id} [delete]
+func DeleteSpecialDeductionV2(c *gin.Context) {
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可维护其他员工的专项附加扣除，本人申报请使用自助申报")
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.DeleteSpecialDeductionV2(c, uint(id), "", getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除专项附加扣除申报成功")
+}
+
+// CreateMySpecialDeductionV2 员工本人申报专项附加扣除
+// @Summary 员工本人申报专项附加扣除
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param deduction body model.SalaryV2SpecialDeductionCreateDTO true "专项附加扣除信息"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/my/create [post]
+func CreateMySpecialDeductionV2(c *gin.Context) {
+	var dto model.SalaryV2SpecialDeductionCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffIdStr(c)
+	if staffId == "" {
+		sendFail(c, 500, "未登录")
+		return
+	}
+	dto.StaffId = staffId
+	dto.StaffName = getCurrentStaffName(c)
+	if err := service.CreateSpecialDeductionV2(c, &dto, staffId); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "申报专项附加扣除成功")
+}
+
+// GetMySpecialDeductionsV2 查询员工本人的专项附加扣除申报
+// @Summary 查询员工本人的专项附加扣除申报
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/my/query [get]
+func GetMySpecialDeductionsV2(c *gin.Context) {
+	staffId := getCurrentStaffIdStr(c)
+	if staffId == "" {
+		sendFail(c, 500, "未登录")
+		return
+	}
+
+	deductions, total, err := service.GetSpecialDeductionsV2(c, staffId, -1, -1)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  deductions,
+		"total": total,
+	}, "查询专项附加扣除申报成功")
+}
+
+// UpdateMySpecialDeductionV2 修改员工本人的专项附加扣除申报
+// @Summary 修改员工本人的专项附加扣除申报
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param deduction body model.SalaryV2SpecialDeductionEditDTO true "专项附加扣除信息"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/my/edit [post]
+func UpdateMySpecialDeductionV2(c *gin.Context) {
+	var dto model.SalaryV2SpecialDeductionEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffIdStr(c)
+	if staffId == "" {
+		sendFail(c, 500, "未登录")
+		return
+	}
+	if err := service.UpdateSpecialDeductionV2(c, &dto, staffId, staffId); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "修改专项附加扣除申报成功")
+}
+
+// DeleteMySpecialDeductionV2 删除员工本人的专项附加扣除申报
+// @Summary 删除员工本人的专项附加扣除申报
+// @Tags V2 Deduction
+// @Accept json
+// @Produce json
+// @Param id path int true "申报ID"
+// @Success 200 {object} Response
+// @Router /api/v2/deduction/my/delete/{id} [delete]
+func DeleteMySpecialDeductionV2(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffIdStr(c)
+	if staffId == "" {
+		sendFail(c, 500, "未登录")
+		return
+	}
+	if err := service.DeleteSpecialDeductionV2(c, uint(id), staffId, staffId); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除专项附加扣除申报成功")
+}
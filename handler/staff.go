@@ -175,12 +175,31 @@ func StaffEdit(c *gin.Context) {
 	var leader model.Staff
 	resource.HrmsDB(c).Where("staff_id = ?", staffEditDTO.LeaderStaffId).Find(&leader)
 	staff.LeaderName = leader.StaffName
+	// 记录原基本工资、职级、部门，职级或部门变更后按薪资模板刷新薪资套账
+	var originalStaff model.Staff
+	resource.HrmsDB(c).Where("staff_id = ?", staffEditDTO.StaffId).Find(&originalStaff)
+	// 基本工资需通过调薪申请审批后变更
+	if staff.BaseSalary != 0 && staff.BaseSalary != originalStaff.BaseSalary {
+		sendFail(c, 5001, "编辑失败，基本工资变更请提交调薪申请")
+		return
+	}
 
-	result := resource.HrmsDB(c).Model(&model.Staff{}).Where("staff_id = ?", staffEditDTO.StaffId).Updates(&staff)
-	if result.Error != nil {
+	// 职级或部门变更时在同一事务中刷新薪资套账，刷新失败时编辑一并回滚
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Staff{}).Where("staff_id = ?", staffEditDTO.StaffId).Updates(&staff).Error; err != nil {
+			return err
+		}
+		if (staff.RankId != "" && staff.RankId != originalStaff.RankId) ||
+			(staff.DepId != "" && staff.DepId != originalStaff.DepId) {
+			return service.RefreshStaffSalaryByTemplate(tx, staffEditDTO.StaffId)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("[StaffEdit] err = %v", err)
 		LogOperationFailure(c, staffId, staffName, "UPDATE", "STAFF",
-			"编辑员工: "+staffEditDTO.StaffName, result.Error.Error())
-		sendFail(c, 5001, "编辑失败"+result.Error.Error())
+			"编辑员工: "+staffEditDTO.StaffName, err.Error())
+		sendFail(c, 5001, "编辑失败"+err.Error())
 		return
 	}
 
@@ -583,8 +602,8 @@ func ExcelExport(c *gin.Context) {
 				case "最高学历":
 					staff.EduLevel = v.String()
 				case "基本薪资":
-					if s, err := v.Int64(); err != nil {
-						staff.BaseSalary = -1
+					if s, err := model.ParseMoney(v.String()); err != nil {
+						staff.BaseSalary = model.MoneyFromYuan(-1)
 					} else {
 						staff.BaseSalary = s
 					}
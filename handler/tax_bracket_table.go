@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		taxGroup := v2Group.Group("/tax")
+		taxGroup.POST("/bracket/validate", ValidateTaxBracketTableV2)
+		taxGroup.POST("/bracket/replace", ReplaceTaxBracketTableV2)
+		taxGroup.GET("/bracket/preset", GetTaxBracketPresetsV2)
+		taxGroup.POST("/bracket/preset/load", LoadTaxBracketPresetV2)
+	})
+}
+
+// ValidateTaxBracketTableV2 校验税率表
+// @Summary 校验税率表(区间连续不重叠、税率递增、速算扣除数一致)
+// @Tags V2 Tax
+// @Accept json
+// @Produce json
+// @Param table body model.SalaryV2TaxBracketReplaceDTO true "税率表"
+// @Success 200 {object} Response
+// @Router /api/v2/tax/bracket/validate [post]
+func ValidateTaxBracketTableV2(c *gin.Context) {
+	var dto model.SalaryV2TaxBracketReplaceDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	errs := service.ValidateTaxBracketTableV2(&dto)
+	sendSuccess(c, gin.H{
+		"valid":  len(errs) == 0,
+		"errors": errs,
+	}, "校验税率表成功")
+}
+
+// ReplaceTaxBracketTableV2 整体替换税率表
+// @Summary 整体替换税率表，原税率区间全部停用，替换后立即生效，生效日期不能晚于今天
+// @Tags V2 Tax
+// @Accept json
+// @Produce json
+// @Param table body model.SalaryV2TaxBracketReplaceDTO true "税率表"
+// @Success 200 {object} Response
+// @Router /api/v2/tax/bracket/replace [post]
+func ReplaceTaxBracketTableV2(c *gin.Context) {
+	// 整表替换仅限管理员操作
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可替换税率表")
+		return
+	}
+
+	var dto model.SalaryV2TaxBracketReplaceDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	if err := service.ReplaceTaxBracketTableV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+			"替换税率表 "+dto.BracketType, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+		"替换税率表 "+dto.BracketType)
+
+	sendSuccess(c, nil, "替换税率表成功")
+}
+
+// GetTaxBracketPresetsV2 获取内置税率表
+// @Summary 获取内置税率表
+// @Tags V2 Tax
+// @Accept json
+// @Produce json
+// @Success 200 {object} Response
+// @Router /api/v2/tax/bracket/preset [get]
+func GetTaxBracketPresetsV2(c *gin.Context) {
+	sendSuccess(c, service.GetTaxBracketPresetsV2(), "查询内置税率表成功")
+}
+
+// LoadTaxBracketPresetV2 加载内置税率表
+// @Summary 以内置税率表替换当前分公司的税率表，替换后立即生效，生效日期不能晚于今天
+// @Tags V2 Tax
+// @Accept json
+// @Produce json
+// @Param preset body model.SalaryV2TaxBracketPresetLoadDTO true "内置税率表"
+// @Success 200 {object} Response
+// @Router /api/v2/tax/bracket/preset/load [post]
+func LoadTaxBracketPresetV2(c *gin.Context) {
+	if !isAdmin(c) {
+		sendFail(c, 403, "仅管理员可加载内置税率表")
+		return
+	}
+
+	var dto model.SalaryV2TaxBracketPresetLoadDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	if err := service.LoadTaxBracketPresetV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+			"加载内置税率表 "+dto.PresetCode, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "UPDATE", "PARAMETER",
+		"加载内置税率表 "+dto.PresetCode)
+
+	sendSuccess(c, nil, "加载内置税率表成功")
+}
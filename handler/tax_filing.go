@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"hrms/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+const xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		taxFilingGroup := v2Group.Group("/tax_filing")
+		taxFilingGroup.GET("/declaration/query/:month", GetTaxFilingDeclarationV2)
+		taxFilingGroup.GET("/declaration/export/:month", ExportTaxFilingDeclarationV2)
+		taxFilingGroup.GET("/annual/query/:year", GetTaxAnnualSummaryV2)
+		taxFilingGroup.GET("/annual/export/:year", ExportTaxAnnualSummaryV2)
+	})
+}
+
+// GetTaxFilingDeclarationV2 获取月度个税扣缴申报数据
+// @Summary 获取月度个税扣缴申报数据
+// @Tags V2 TaxFiling
+// @Accept json
+// @Produce json
+// @Param month path string true "薪资月份"
+// @Success 200 {object} Response
+// @Router /api/v2/tax_filing/declaration/query/{month} [get]
+func GetTaxFilingDeclarationV2(c *gin.Context) {
+	declaration, err := service.GetTaxFilingDeclarationV2(c, c.Param("month"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, declaration, "查询个税扣缴申报数据成功")
+}
+
+// ExportTaxFilingDeclarationV2 导出月度个税扣缴申报文件
+// @Summary 导出月度个税扣缴申报文件
+// @Tags V2 TaxFiling
+// @Produce octet-stream
+// @Param month path string true "薪资月份"
+// @Success 200 {file} file
+// @Router /api/v2/tax_filing/declaration/export/{month} [get]
+func ExportTaxFilingDeclarationV2(c *gin.Context) {
+	month := c.Param("month")
+	content, err := service.ExportTaxFilingDeclarationV2(c, month)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=iit_declaration_"+month+".xlsx")
+	c.Data(http.StatusOK, xlsxContentType, content)
+}
+
+// GetTaxAnnualSummaryV2 获取员工年度个税汇总
+// @Summary 获取员工年度个税汇总
+// @Tags V2 TaxFiling
+// @Accept json
+// @Produce json
+// @Param year path string true "年度"
+// @Param staff_id query string false "员工ID"
+// @Success 200 {object} Response
+// @Router /api/v2/tax_filing/annual/query/{year} [get]
+func GetTaxAnnualSummaryV2(c *gin.Context) {
+	summaries, err := service.GetTaxAnnualSummaryV2(c, c.Param("year"), c.Query("staff_id"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  summaries,
+		"total": len(summaries),
+	}, "查询年度个税汇总成功")
+}
+
+// ExportTaxAnnualSummaryV2 导出员工年度个税汇总
+// @Summary 导出员工年度个税汇总
+// @Tags V2 TaxFiling
+// @Produce octet-stream
+// @Param year path string true "年度"
+// @Success 200 {file} file
+// @Router /api/v2/tax_filing/annual/export/{year} [get]
+func ExportTaxAnnualSummaryV2(c *gin.Context) {
+	year := c.Param("year")
+	content, err := service.ExportTaxAnnualSummaryV2(c, year)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	c.Header("Content-Disposition", "attachment; filename=iit_annual_"+year+".xlsx")
+	c.Data(http.StatusOK, xlsxContentType, content)
+}
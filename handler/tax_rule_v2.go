@@ -4,6 +4,7 @@ import (
 	"hrms/model"
 	"hrms/service"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,7 +22,7 @@ func init() {
 }
 
 // CreateTaxBracketV2 创建税率区间
-// @Summary 创建税率区间
+// @Summary 提交新增税率区间的变更申请，经其他管理员审批后生效
 // @Tags V2 Tax
 // @Accept json
 // @Produce json
@@ -35,21 +36,15 @@ func CreateTaxBracketV2(c *gin.Context) {
 		return
 	}
 
-	// 前端传入的是元，转换为分
-	dto.MinIncome = dto.MinIncome * 100
-	dto.QuickDeduction = dto.QuickDeduction * 100
-	if dto.MaxIncome > 0 {
-		dto.MaxIncome = dto.MaxIncome * 100
-	}
-
 	staffId := getCurrentStaffId(c)
 	staffIdStr := strconv.FormatUint(staffId, 10)
-	if err := service.CreateTaxBracketV2(c, &dto, staffIdStr); err != nil {
+	// 新增及停用税率区间须提交变更申请，经其他管理员审批后生效
+	if _, err := service.CreateTaxBracketV2(c, &dto, staffIdStr); err != nil {
 		sendFail(c, 500, err.Error())
 		return
 	}
 
-	sendSuccess(c, nil, "创建税率区间成功")
+	sendSuccess(c, nil, "已提交变更申请，待其他管理员审批")
 }
 
 // GetTaxBracketsV2 获取税率区间列表
@@ -57,15 +52,17 @@ func CreateTaxBracketV2(c *gin.Context) {
 // @Tags V2 Tax
 // @Accept json
 // @Produce json
+// @Param bracket_type query string false "税率表类型 (monthly, annual)"
 // @Param start query int false "起始位置"
 // @Param limit query int false "限制数量"
 // @Success 200 {object} Response
 // @Router /api/v2/tax/bracket/query [get]
 func GetTaxBracketsV2(c *gin.Context) {
+	bracketType := c.Query("bracket_type")
 	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
-	taxBrackets, total, err := service.GetTaxBracketsV2(c, start, limit)
+	taxBrackets, total, err := service.GetTaxBracketsV2(c, bracketType, start, limit)
 	if err != nil {
 		sendFail(c, 500, err.Error())
 		return
@@ -92,25 +89,23 @@ func UpdateTaxBracketV2(c *gin.Context) {
 		return
 	}
 
-	// 前端传入的是元，转换为分
-	dto.MinIncome = dto.MinIncome * 100
-	dto.QuickDeduction = dto.QuickDeduction * 100
-	if dto.MaxIncome > 0 {
-		dto.MaxIncome = dto.MaxIncome * 100
-	}
-
 	staffId := getCurrentStaffId(c)
 	staffIdStr := strconv.FormatUint(staffId, 10)
-	if err := service.UpdateTaxBracketV2(c, &dto, staffIdStr); err != nil {
+	// 税率及社保费率修改须提交变更申请，经其他管理员审批后生效，生效日期在今天之后的到期自动生效
+	change := &model.SalaryV2ParameterChangeCreateDTO{ParameterType: "tax_bracket", TaxBracket: &dto}
+	if dto.EffectiveDate > time.Now().Format("2006-01-02") {
+		change.EffectiveDate = dto.EffectiveDate
+	}
+	if _, err := service.CreateParameterChangeV2(c, change, staffIdStr); err != nil {
 		sendFail(c, 500, err.Error())
 		return
 	}
 
-	sendSuccess(c, nil, "更新税率区间成功")
+	sendSuccess(c, nil, "已提交变更申请，待其他管理员审批")
 }
 
 // DeleteTaxBracketV2 删除税率区间
-// @Summary 删除税率区间
+// @Summary 提交停用税率区间的变更申请，经其他管理员审批后生效
 // @Tags V2 Tax
 // @Accept json
 // @Produce json
@@ -127,12 +122,12 @@ func DeleteTaxBracketV2(c *gin.Context) {
 
 	staffId := getCurrentStaffId(c)
 	staffIdStr := strconv.FormatUint(staffId, 10)
-	if err := service.DeleteTaxBracketV2(c, uint(id), staffIdStr); err != nil {
+	if _, err := service.DeleteTaxBracketV2(c, uint(id), staffIdStr); err != nil {
 		sendFail(c, 500, err.Error())
 		return
 	}
 
-	sendSuccess(c, nil, "删除税率区间成功")
+	sendSuccess(c, nil, "已提交变更申请，待其他管理员审批")
 }
 
 // CalculateTaxV2 计算个人所得税
@@ -156,7 +151,7 @@ func CalculateTaxV2(c *gin.Context) {
 		return
 	}
 
-	tax, err := service.CalculateTaxV2(c, int64(taxableIncome))
+	tax, err := service.CalculateTaxV2(c, model.NewMoney(taxableIncome))
 	if err != nil {
 		sendFail(c, 500, err.Error())
 		return
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		calendarGroup := v2Group.Group("/work_calendar")
+		calendarGroup.POST("/save", SaveWorkCalendarDayV2)
+		calendarGroup.GET("/query", GetWorkCalendarDaysV2)
+		calendarGroup.GET("/month", GetWorkCalendarMonthV2)
+		calendarGroup.DELETE("/delete/:calendar_date", DeleteWorkCalendarDayV2)
+		calendarGroup.POST("/import", ImportWorkCalendarV2)
+	})
+}
+
+// SaveWorkCalendarDayV2 登记法定节假日或调休上班日
+// @Summary 登记法定节假日或调休上班日，同一日期已登记时覆盖
+// @Tags V2 WorkCalendar
+// @Accept json
+// @Produce json
+// @Param day body model.SalaryV2WorkCalendarDayDTO true "日期信息"
+// @Success 200 {object} Response
+// @Router /api/v2/work_calendar/save [post]
+func SaveWorkCalendarDayV2(c *gin.Context) {
+	var dto model.SalaryV2WorkCalendarDayDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.SaveWorkCalendarDayV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "保存工作日历成功")
+}
+
+// GetWorkCalendarDaysV2 获取工作日历登记的日期
+// @Summary 获取工作日历登记的法定节假日及调休上班日
+// @Tags V2 WorkCalendar
+// @Accept json
+// @Produce json
+// @Param year query string false "年份，如2025"
+// @Param day_type query string false "日期类型 (holiday, workday)"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/work_calendar/query [get]
+func GetWorkCalendarDaysV2(c *gin.Context) {
+	year := c.Query("year")
+	dayType := c.Query("day_type")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	days, total, err := service.GetWorkCalendarDaysV2(c, year, dayType, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  days,
+		"total": total,
+	}, "查询工作日历成功")
+}
+
+// GetWorkCalendarMonthV2 获取月度工作日历
+// @Summary 获取月度工作日历及应出勤天数
+// @Tags V2 WorkCalendar
+// @Accept json
+// @Produce json
+// @Param month query string true "月份，如2025-10"
+// @Success 200 {object} Response
+// @Router /api/v2/work_calendar/month [get]
+func GetWorkCalendarMonthV2(c *gin.Context) {
+	result, err := service.GetWorkCalendarMonthV2(c, c.Query("month"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, result, "查询月度工作日历成功")
+}
+
+// DeleteWorkCalendarDayV2 删除工作日历登记的日期
+// @Summary 删除工作日历登记的日期
+// @Tags V2 WorkCalendar
+// @Accept json
+// @Produce json
+// @Param calendar_date path string true "日期，如2025-10-01"
+// @Success 200 {object} Response
+// @Router /api/v2/work_calendar/delete/{calendar_date} [delete]
+func DeleteWorkCalendarDayV2(c *gin.Context) {
+	if err := service.DeleteWorkCalendarDayV2(c, c.Param("calendar_date")); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "删除工作日历成功")
+}
+
+// ImportWorkCalendarV2 导入工作日历
+// @Summary 从iCalendar或JSON文件导入法定节假日及调休上班日，试运行时只返回解析结果
+// @Tags V2 WorkCalendar
+// @Accept multipart/form-data
+// @Produce json
+// @Param calendar_file formData file true "工作日历文件(.ics, .json)"
+// @Param format formData string false "文件格式 (ics, json)，默认按文件扩展名判断"
+// @Param replace formData bool false "是否先清除文件所涉年份已登记的日期"
+// @Param dry_run formData bool false "是否试运行"
+// @Success 200 {object} Response
+// @Router /api/v2/work_calendar/import [post]
+func ImportWorkCalendarV2(c *gin.Context) {
+	file, err := c.FormFile("calendar_file")
+	if err != nil {
+		sendFail(c, 500, "请上传工作日历文件")
+		return
+	}
+	format := c.PostForm("format")
+	if format == "" {
+		format = "json"
+		if ext := strings.ToLower(filepath.Ext(file.Filename)); ext == ".ics" || ext == ".ical" {
+			format = "ics"
+		}
+	}
+	replace := c.PostForm("replace") == "true"
+	dryRun := c.PostForm("dry_run") == "true"
+	fileOpen, err := file.Open()
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+	defer fileOpen.Close()
+	content, err := ioutil.ReadAll(fileOpen)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	result, err := service.ImportWorkCalendarV2(c, content, format, replace, dryRun, getCurrentStaffIdStr(c))
+	if err != nil {
+		LogOperationFailure(c, getCurrentStaffId(c), getCurrentStaffName(c), "IMPORT", "PARAMETER",
+			"导入工作日历: "+file.Filename, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	if dryRun {
+		sendSuccess(c, result, "工作日历文件解析成功")
+		return
+	}
+	LogOperationSuccess(c, getCurrentStaffId(c), getCurrentStaffName(c), "IMPORT", "PARAMETER",
+		"导入工作日历: "+file.Filename)
+
+	sendSuccess(c, result, "导入工作日历成功")
+}
@@ -0,0 +1,224 @@
+package handler
+
+import (
+	"hrms/model"
+	"hrms/service"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+func init() {
+	Register(func(r *gin.RouterGroup) {
+		v2Group := r.Group("/v2")
+		shiftGroup := v2Group.Group("/shift")
+		shiftGroup.POST("/create", CreateWorkShiftV2)
+		shiftGroup.GET("/query", GetWorkShiftsV2)
+		shiftGroup.POST("/edit", UpdateWorkShiftV2)
+		shiftGroup.DELETE("/delete/:shift_id", DeleteWorkShiftV2)
+
+		scheduleGroup := v2Group.Group("/schedule")
+		scheduleGroup.POST("/create", CreateWorkScheduleV2)
+		scheduleGroup.GET("/query", GetWorkSchedulesV2)
+		scheduleGroup.DELETE("/delete/:schedule_id", DeleteWorkScheduleV2)
+		scheduleGroup.GET("/roster", GetStaffRosterV2)
+	})
+}
+
+// CreateWorkShiftV2 创建班次
+// @Summary 创建班次，未设置宽限分钟数时使用系统参数中的迟到、早退宽限时间
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param shift body model.SalaryV2WorkShiftCreateDTO true "班次信息"
+// @Success 200 {object} Response
+// @Router /api/v2/shift/create [post]
+func CreateWorkShiftV2(c *gin.Context) {
+	var dto model.SalaryV2WorkShiftCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	if err := service.CreateWorkShiftV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "CREATE", "PARAMETER",
+			"创建班次 "+dto.ShiftName, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "CREATE", "PARAMETER",
+		"创建班次 "+dto.ShiftName)
+
+	sendSuccess(c, nil, "创建班次成功")
+}
+
+// GetWorkShiftsV2 获取班次列表
+// @Summary 获取启用的班次列表
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/shift/query [get]
+func GetWorkShiftsV2(c *gin.Context) {
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	shifts, total, err := service.GetWorkShiftsV2(c, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  shifts,
+		"total": total,
+	}, "查询班次成功")
+}
+
+// UpdateWorkShiftV2 更新班次
+// @Summary 更新班次
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param shift body model.SalaryV2WorkShiftEditDTO true "班次信息"
+// @Success 200 {object} Response
+// @Router /api/v2/shift/edit [post]
+func UpdateWorkShiftV2(c *gin.Context) {
+	var dto model.SalaryV2WorkShiftEditDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	if err := service.UpdateWorkShiftV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, nil, "更新班次成功")
+}
+
+// DeleteWorkShiftV2 停用班次
+// @Summary 停用班次，仍在有效排班中使用的班次不能停用
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param shift_id path string true "班次ID"
+// @Success 200 {object} Response
+// @Router /api/v2/shift/delete/{shift_id} [delete]
+func DeleteWorkShiftV2(c *gin.Context) {
+	shiftId := c.Param("shift_id")
+	staffId := getCurrentStaffId(c)
+	if err := service.DeleteWorkShiftV2(c, shiftId, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "DELETE", "PARAMETER",
+			"停用班次 "+shiftId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "DELETE", "PARAMETER",
+		"停用班次 "+shiftId)
+
+	sendSuccess(c, nil, "停用班次成功")
+}
+
+// CreateWorkScheduleV2 为员工或部门排班
+// @Summary 为员工或部门排班，轮换规律为逗号分隔的班次ID，rest表示休息
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param schedule body model.SalaryV2WorkScheduleCreateDTO true "排班信息"
+// @Success 200 {object} Response
+// @Router /api/v2/schedule/create [post]
+func CreateWorkScheduleV2(c *gin.Context) {
+	var dto model.SalaryV2WorkScheduleCreateDTO
+	if err := c.ShouldBindJSON(&dto); err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	staffId := getCurrentStaffId(c)
+	if err := service.CreateWorkScheduleV2(c, &dto, getCurrentStaffIdStr(c)); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "CREATE", "PARAMETER",
+			"排班 "+dto.TargetType+" "+dto.TargetId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "CREATE", "PARAMETER",
+		"排班 "+dto.TargetType+" "+dto.TargetId)
+
+	sendSuccess(c, nil, "排班成功")
+}
+
+// GetWorkSchedulesV2 获取排班列表
+// @Summary 获取排班列表
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param target_type query string false "排班对象类型 (staff, department)"
+// @Param target_id query string false "员工工号或部门ID"
+// @Param start query int false "起始位置"
+// @Param limit query int false "限制数量"
+// @Success 200 {object} Response
+// @Router /api/v2/schedule/query [get]
+func GetWorkSchedulesV2(c *gin.Context) {
+	targetType := c.Query("target_type")
+	targetId := c.Query("target_id")
+	start, _ := strconv.Atoi(c.DefaultQuery("start", "0"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	schedules, total, err := service.GetWorkSchedulesV2(c, targetType, targetId, start, limit)
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, gin.H{
+		"list":  schedules,
+		"total": total,
+	}, "查询排班成功")
+}
+
+// DeleteWorkScheduleV2 删除排班
+// @Summary 删除排班，删除后相应日期按部门排班或工作日历考勤
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param schedule_id path string true "排班ID"
+// @Success 200 {object} Response
+// @Router /api/v2/schedule/delete/{schedule_id} [delete]
+func DeleteWorkScheduleV2(c *gin.Context) {
+	scheduleId := c.Param("schedule_id")
+	staffId := getCurrentStaffId(c)
+	if err := service.DeleteWorkScheduleV2(c, scheduleId); err != nil {
+		LogOperationFailure(c, staffId, getCurrentStaffName(c), "DELETE", "PARAMETER",
+			"删除排班 "+scheduleId, err.Error())
+		sendFail(c, 500, err.Error())
+		return
+	}
+	LogOperationSuccess(c, staffId, getCurrentStaffName(c), "DELETE", "PARAMETER",
+		"删除排班 "+scheduleId)
+
+	sendSuccess(c, nil, "删除排班成功")
+}
+
+// GetStaffRosterV2 获取员工月度排班表
+// @Summary 获取员工月度排班表，员工排班优先于所在部门的排班，未排班的日期按工作日历
+// @Tags V2 WorkShift
+// @Accept json
+// @Produce json
+// @Param staff_id query string true "员工工号"
+// @Param month query string true "月份，如2025-10"
+// @Success 200 {object} Response
+// @Router /api/v2/schedule/roster [get]
+func GetStaffRosterV2(c *gin.Context) {
+	days, err := service.GetStaffRosterV2(c, c.Query("staff_id"), c.Query("month"))
+	if err != nil {
+		sendFail(c, 500, err.Error())
+		return
+	}
+
+	sendSuccess(c, days, "查询排班表成功")
+}
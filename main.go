@@ -183,6 +183,8 @@ func main() {
 	if err := InitGorm(); err != nil {
 		log.Fatal(err)
 	}
+	// 检查各分公司薪资计算必需的系统参数，有问题的分公司仅拒绝核算薪资
+	service.CheckSystemParameters()
 
 	// 启动定时任务
 	service.InitCron()
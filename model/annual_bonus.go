@@ -0,0 +1,43 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2AnnualBonus 全年一次性奖金发放记录
+type SalaryV2AnnualBonus struct {
+	gorm.Model
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	BonusId        string `gorm:"column:bonus_id;uniqueIndex;not null" json:"bonus_id"`
+	StaffId        string `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName      string `gorm:"column:staff_name" json:"staff_name"`
+	BonusYear      string `gorm:"column:bonus_year;not null" json:"bonus_year"`    // 奖金所属年度
+	PayMonth       string `gorm:"column:pay_month;not null" json:"pay_month"`      // 发放月份，如2025-01
+	Amount         Money  `gorm:"column:amount;not null" json:"amount"`            // 奖金金额(元)
+	TaxMethod      string `gorm:"column:tax_method;not null" json:"tax_method"`    // separate单独计税, combined并入当月综合所得
+	Tax            Money  `gorm:"column:tax" json:"tax"`                           // 奖金应扣个税
+	NetAmount      Money  `gorm:"column:net_amount" json:"net_amount"`             // 税后奖金
+	SalaryRecordId string `gorm:"column:salary_record_id" json:"salary_record_id"` // 随发放的当月薪资记录
+	Remark         string `gorm:"column:remark" json:"remark"`
+	IsActive       bool   `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy      string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy      string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2AnnualBonusCreateDTO struct {
+	StaffId   string `json:"staff_id" binding:"required"`
+	BonusYear string `json:"bonus_year"`
+	PayMonth  string `json:"pay_month" binding:"required"`
+	Amount    Money  `json:"amount" binding:"required"`
+	TaxMethod string `json:"tax_method"` // 为空时使用员工薪资套账中的计税方式
+	Remark    string `json:"remark"`
+}
+
+type SalaryV2AnnualBonusTaxMethodDTO struct {
+	StaffId   string `json:"staff_id" binding:"required"`
+	TaxMethod string `json:"tax_method" binding:"required"`
+}
+
+func (SalaryV2AnnualBonus) TableName() string {
+	return "salary_v2_annual_bonuses"
+}
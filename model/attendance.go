@@ -11,7 +11,14 @@ type AttendanceRecord struct {
 	WorkDays     int64  `gorm:"column:work_days" json:"work_days"`
 	LeaveDays    int64  `gorm:"column:leave_days" json:"leave_days"`
 	OvertimeDays int64  `gorm:"column:overtime_days" json:"overtime_days"`
-	Approve      int64  `gorm:"column:approve" json:"approve"`
+	// 分类加班时长(小时)：工作日延时、休息日、法定节假日
+	WeekdayOvertimeHours float64 `gorm:"column:weekday_overtime_hours" json:"weekday_overtime_hours"`
+	WeekendOvertimeHours float64 `gorm:"column:weekend_overtime_hours" json:"weekend_overtime_hours"`
+	HolidayOvertimeHours float64 `gorm:"column:holiday_overtime_hours" json:"holiday_overtime_hours"`
+	ExpectedWorkDays     int64   `gorm:"column:expected_work_days" json:"expected_work_days"` // 按工作日历及排班计算的当月应出勤天数
+	LateCount            int64   `gorm:"column:late_count" json:"late_count"`                 // 按排班班次统计的迟到次数
+	EarlyLeaveCount      int64   `gorm:"column:early_leave_count" json:"early_leave_count"`   // 按排班班次统计的早退次数
+	Approve              int64   `gorm:"column:approve" json:"approve"`
 }
 
 type AttendanceRecordCreateDTO struct {
@@ -21,6 +28,10 @@ type AttendanceRecordCreateDTO struct {
 	WorkDays     int64  `gorm:"column:work_days" json:"work_days"`
 	LeaveDays    int64  `gorm:"column:leave_days" json:"leave_days"`
 	OvertimeDays int64  `gorm:"column:overtime_days" json:"overtime_days"`
+	// 分类加班时长(小时)：工作日延时、休息日、法定节假日
+	WeekdayOvertimeHours float64 `gorm:"column:weekday_overtime_hours" json:"weekday_overtime_hours"`
+	WeekendOvertimeHours float64 `gorm:"column:weekend_overtime_hours" json:"weekend_overtime_hours"`
+	HolidayOvertimeHours float64 `gorm:"column:holiday_overtime_hours" json:"holiday_overtime_hours"`
 }
 
 type AttendanceRecordEditDTO struct {
@@ -31,6 +42,10 @@ type AttendanceRecordEditDTO struct {
 	WorkDays     int64  `gorm:"column:work_days" json:"work_days"`
 	LeaveDays    int64  `gorm:"column:leave_days" json:"leave_days"`
 	OvertimeDays int64  `gorm:"column:overtime_days" json:"overtime_days"`
+	// 分类加班时长(小时)：工作日延时、休息日、法定节假日
+	WeekdayOvertimeHours float64 `gorm:"column:weekday_overtime_hours" json:"weekday_overtime_hours"`
+	WeekendOvertimeHours float64 `gorm:"column:weekend_overtime_hours" json:"weekend_overtime_hours"`
+	HolidayOvertimeHours float64 `gorm:"column:holiday_overtime_hours" json:"holiday_overtime_hours"`
 }
 
 type ClockIn struct {
@@ -0,0 +1,132 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SalaryV2BankLayout 银行代发文件格式，按银行配置代发文件字段及回盘文件解析方式
+type SalaryV2BankLayout struct {
+	gorm.Model
+	ID                  uint   `gorm:"primaryKey" json:"id"`
+	LayoutId            string `gorm:"column:layout_id;uniqueIndex;not null" json:"layout_id"`
+	BankCode            string `gorm:"column:bank_code;uniqueIndex;not null" json:"bank_code"`
+	BankName            string `gorm:"column:bank_name;not null" json:"bank_name"`
+	FileFormat          string `gorm:"column:file_format;not null" json:"file_format"` // csv分隔符文件、fixed定长文件
+	Delimiter           string `gorm:"column:delimiter" json:"delimiter"`              // csv分隔符，默认逗号
+	AmountUnit          string `gorm:"column:amount_unit" json:"amount_unit"`          // yuan元(两位小数)、cents分
+	HasHeader           bool   `gorm:"column:has_header" json:"has_header"`            // csv是否输出列标题行
+	HasTrailer          bool   `gorm:"column:has_trailer" json:"has_trailer"`          // 是否输出汇总行：笔数、总金额、校验码
+	Fields              string `gorm:"column:fields;type:text;not null" json:"fields"` // 明细字段配置，JSON数组
+	ReturnDelimiter     string `gorm:"column:return_delimiter" json:"return_delimiter"`
+	ReturnSkipLines     int    `gorm:"column:return_skip_lines" json:"return_skip_lines"`       // 回盘文件跳过的标题行数
+	ReturnRefField      string `gorm:"column:return_ref_field" json:"return_ref_field"`         // 回盘文件关联字段：line_no明细序号、line_id明细ID
+	ReturnRefColumn     int    `gorm:"column:return_ref_column" json:"return_ref_column"`       // 以下列号均从0开始
+	ReturnAmountColumn  int    `gorm:"column:return_amount_column" json:"return_amount_column"` // 为-1时不核对金额
+	ReturnStatusColumn  int    `gorm:"column:return_status_column" json:"return_status_column"`
+	ReturnSuccessCode   string `gorm:"column:return_success_code" json:"return_success_code"`     // 表示成功的状态值
+	ReturnMessageColumn int    `gorm:"column:return_message_column" json:"return_message_column"` // 为-1时无失败原因
+	IsActive            bool   `gorm:"column:is_active" json:"is_active"`
+	CreatedBy           string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy           string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+// SalaryV2BankLayoutField 代发文件明细字段
+// Field 可选 line_no、line_id、batch_id、staff_id、staff_name、card_num、amount、salary_date、literal(固定值)
+type SalaryV2BankLayoutField struct {
+	Field string `json:"field"`
+	Title string `json:"title"` // csv列标题
+	Value string `json:"value"` // literal字段的固定值
+	Width int    `json:"width"` // 定长文件字段长度
+	Align string `json:"align"` // left左对齐、right右对齐，默认左对齐
+	Pad   string `json:"pad"`   // 填充字符，默认空格
+}
+
+type SalaryV2BankLayoutCreateDTO struct {
+	BankCode            string `json:"bank_code" binding:"required"`
+	BankName            string `json:"bank_name" binding:"required"`
+	FileFormat          string `json:"file_format" binding:"required"`
+	Delimiter           string `json:"delimiter"`
+	AmountUnit          string `json:"amount_unit"`
+	HasHeader           bool   `json:"has_header"`
+	HasTrailer          bool   `json:"has_trailer"`
+	Fields              string `json:"fields" binding:"required"`
+	ReturnDelimiter     string `json:"return_delimiter"`
+	ReturnSkipLines     int    `json:"return_skip_lines"`
+	ReturnRefField      string `json:"return_ref_field"`
+	ReturnRefColumn     int    `json:"return_ref_column"`
+	ReturnAmountColumn  int    `json:"return_amount_column"`
+	ReturnStatusColumn  int    `json:"return_status_column"`
+	ReturnSuccessCode   string `json:"return_success_code" binding:"required"`
+	ReturnMessageColumn int    `json:"return_message_column"`
+}
+
+func (SalaryV2BankLayout) TableName() string {
+	return "salary_v2_bank_layouts"
+}
+
+// SalaryV2PaymentBatch 银行代发批次，薪资记录在银行回盘确认成功后才标记为已发放
+type SalaryV2PaymentBatch struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	BatchId      string `gorm:"column:batch_id;uniqueIndex;not null" json:"batch_id"`
+	BankCode     string `gorm:"column:bank_code;not null" json:"bank_code"`
+	LayoutId     string `gorm:"column:layout_id;not null" json:"layout_id"`
+	SalaryDate   string `gorm:"column:salary_date" json:"salary_date"`
+	LineCount    int64  `gorm:"column:line_count" json:"line_count"`
+	TotalAmount  Money  `gorm:"column:total_amount" json:"total_amount"`
+	FileName     string `gorm:"column:file_name" json:"file_name"`
+	FileContent  string `gorm:"column:file_content;type:longtext" json:"-"`
+	Checksum     string `gorm:"column:checksum" json:"checksum"`      // 代发文件SHA-256校验码
+	Status       string `gorm:"column:status;not null" json:"status"` // generated已生成、partial部分回盘、completed已全部回盘
+	SuccessCount int64  `gorm:"column:success_count" json:"success_count"`
+	FailedCount  int64  `gorm:"column:failed_count" json:"failed_count"`
+	CreatedBy    string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy    string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2PaymentBatchCreateDTO struct {
+	SalaryDate string   `json:"salary_date" binding:"required"`
+	StaffIds   []string `json:"staff_ids"` // 为空时包含当月全部未发放的薪资记录
+	BankCode   string   `json:"bank_code"` // 为空时使用默认代发银行
+}
+
+func (SalaryV2PaymentBatch) TableName() string {
+	return "salary_v2_payment_batches"
+}
+
+// SalaryV2PaymentLine 银行代发明细
+type SalaryV2PaymentLine struct {
+	gorm.Model
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	LineId         string     `gorm:"column:line_id;uniqueIndex;not null" json:"line_id"`
+	BatchId        string     `gorm:"column:batch_id;not null;index" json:"batch_id"`
+	LineNo         int64      `gorm:"column:line_no;not null" json:"line_no"`
+	SalaryRecordId string     `gorm:"column:salary_record_id;not null" json:"salary_record_id"`
+	StaffId        string     `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName      string     `gorm:"column:staff_name" json:"staff_name"`
+	CardNum        string     `gorm:"column:card_num;not null" json:"card_num"`
+	Amount         Money      `gorm:"column:amount" json:"amount"`
+	Status         string     `gorm:"column:status;not null" json:"status"` // pending待回盘、success成功、failed失败
+	BankMessage    string     `gorm:"column:bank_message" json:"bank_message"`
+	ConfirmedAt    *time.Time `gorm:"column:confirmed_at" json:"confirmed_at"`
+}
+
+func (SalaryV2PaymentLine) TableName() string {
+	return "salary_v2_payment_lines"
+}
+
+// SalaryV2PaymentReconciliation 代发批次对账结果
+type SalaryV2PaymentReconciliation struct {
+	BatchId       string   `json:"batch_id"`
+	LineCount     int64    `json:"line_count"`
+	TotalAmount   Money    `json:"total_amount"`
+	SuccessCount  int64    `json:"success_count"`
+	SuccessAmount Money    `json:"success_amount"`
+	FailedCount   int64    `json:"failed_count"`
+	FailedAmount  Money    `json:"failed_amount"`
+	PendingCount  int64    `json:"pending_count"`
+	PendingAmount Money    `json:"pending_amount"`
+	Mismatches    []string `json:"mismatches"` // 代发明细状态与薪资记录发放状态不一致的说明
+}
@@ -9,8 +9,10 @@ type SalaryV2CalculationRule struct {
 	ID                   uint   `gorm:"primaryKey" json:"id"`
 	CalculationRuleId    string `gorm:"column:calculation_rule_id;uniqueIndex;not null" json:"calculation_rule_id"`
 	RuleType             string `gorm:"column:rule_type;not null" json:"rule_type"` // overtime, bonus_deduction, attendance_base, tax_threshold
+	RuleCode             string `gorm:"column:rule_code" json:"rule_code"` // 规则编码，如overtime_weekday, overtime_weekend, overtime_holiday
 	RuleName             string `gorm:"column:rule_name;not null" json:"rule_name"`
 	RuleValue            float64 `gorm:"column:rule_value;not null" json:"rule_value"`
+	Formula              string `gorm:"column:formula" json:"formula"` // 计算公式，为空时直接使用规则数值
 	RuleDescription      string `gorm:"column:rule_description" json:"rule_description"`
 	IsActive             bool   `gorm:"column:is_active;default:true" json:"is_active"`
 	EffectiveDate        string `gorm:"column:effective_date;not null" json:"effective_date"`
@@ -20,8 +22,10 @@ type SalaryV2CalculationRule struct {
 
 type SalaryV2CalculationRuleCreateDTO struct {
 	RuleType             string  `json:"rule_type"`
+	RuleCode             string  `json:"rule_code"`
 	RuleName             string  `json:"rule_name"`
 	RuleValue            float64 `json:"rule_value"`
+	Formula              string  `json:"formula"`
 	RuleDescription      string  `json:"rule_description"`
 	EffectiveDate        string  `json:"effective_date"`
 }
@@ -29,13 +33,20 @@ type SalaryV2CalculationRuleCreateDTO struct {
 type SalaryV2CalculationRuleEditDTO struct {
 	ID                   uint    `json:"id"`
 	RuleType             string  `json:"rule_type"`
+	RuleCode             string  `json:"rule_code"`
 	RuleName             string  `json:"rule_name"`
 	RuleValue            float64 `json:"rule_value"`
+	Formula              string  `json:"formula"`
 	RuleDescription      string  `json:"rule_description"`
 	IsActive             bool    `json:"is_active"`
 	EffectiveDate        string  `json:"effective_date"`
 }
 
+type SalaryV2FormulaEvaluateDTO struct {
+	Formula   string             `json:"formula" binding:"required"`
+	Variables map[string]float64 `json:"variables"`
+}
+
 func (SalaryV2CalculationRule) TableName() string {
 	return "salary_v2_calculation_rules"
 }
\ No newline at end of file
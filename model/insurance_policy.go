@@ -0,0 +1,64 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2InsurancePolicy 参保方案，用于在异地缴纳社保的员工，各险种费率及缴费基数上下限记录在社保费率表中
+type SalaryV2InsurancePolicy struct {
+	gorm.Model
+	ID          uint                     `gorm:"primaryKey" json:"id"`
+	PolicyId    string                   `gorm:"column:policy_id;uniqueIndex;not null" json:"policy_id"`
+	PolicyName  string                   `gorm:"column:policy_name;not null" json:"policy_name"`
+	City        string                   `gorm:"column:city;not null" json:"city"` // 参保城市
+	Description string                   `gorm:"column:description" json:"description"`
+	IsActive    bool                     `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy   string                   `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy   string                   `gorm:"column:updated_by" json:"updated_by"`
+	Rates       []*SalaryV2InsuranceRate `gorm:"-" json:"rates,omitempty"`
+	StaffCount  int64                    `gorm:"-" json:"staff_count"` // 当前使用该方案的员工数
+}
+
+type SalaryV2InsurancePolicyCreateDTO struct {
+	PolicyName  string                            `json:"policy_name" binding:"required"`
+	City        string                            `json:"city" binding:"required"`
+	Description string                            `json:"description"`
+	Rates       []*SalaryV2InsuranceRateCreateDTO `json:"rates"` // 创建方案时一并创建的各险种费率
+}
+
+type SalaryV2InsurancePolicyEditDTO struct {
+	PolicyId    string `json:"policy_id" binding:"required"`
+	PolicyName  string `json:"policy_name" binding:"required"`
+	City        string `json:"city" binding:"required"`
+	Description string `json:"description"`
+}
+
+func (SalaryV2InsurancePolicy) TableName() string {
+	return "salary_v2_insurance_policies"
+}
+
+// SalaryV2StaffInsurancePolicy 员工参保方案，未分配方案的员工按分公司默认费率缴纳
+type SalaryV2StaffInsurancePolicy struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	AssignmentId string `gorm:"column:assignment_id;uniqueIndex;not null" json:"assignment_id"`
+	StaffId      string `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName    string `gorm:"column:staff_name" json:"staff_name"`
+	PolicyId     string `gorm:"column:policy_id;not null" json:"policy_id"`
+	StartMonth   string `gorm:"column:start_month;not null" json:"start_month"` // 起始月份，如2025-01
+	EndMonth     string `gorm:"column:end_month" json:"end_month"`              // 截止月份，为空表示长期有效
+	Remark       string `gorm:"column:remark" json:"remark"`
+	CreatedBy    string `gorm:"column:created_by" json:"created_by"`
+}
+
+type SalaryV2StaffInsurancePolicyAssignDTO struct {
+	StaffIds   []string `json:"staff_ids" binding:"required"`
+	PolicyId   string   `json:"policy_id" binding:"required"`
+	StartMonth string   `json:"start_month" binding:"required"`
+	EndMonth   string   `json:"end_month"`
+	Remark     string   `json:"remark"`
+}
+
+func (SalaryV2StaffInsurancePolicy) TableName() string {
+	return "salary_v2_staff_insurance_policies"
+}
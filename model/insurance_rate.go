@@ -8,11 +8,12 @@ type SalaryV2InsuranceRate struct {
 	gorm.Model
 	ID                   uint   `gorm:"primaryKey" json:"id"`
 	InsuranceRateId      string `gorm:"column:insurance_rate_id;uniqueIndex;not null" json:"insurance_rate_id"`
+	PolicyId             string `gorm:"column:policy_id" json:"policy_id"` // 所属参保方案，为空表示分公司默认费率
 	InsuranceType        string `gorm:"column:insurance_type;not null" json:"insurance_type"` // pension, medical, unemployment, housing, injury, maternity
 	EmployeeRate         float64 `gorm:"column:employee_rate;not null" json:"employee_rate"`
 	EmployerRate         float64 `gorm:"column:employer_rate;not null" json:"employer_rate"`
-	MinBase              int64  `gorm:"column:min_base" json:"min_base"`
-	MaxBase              int64  `gorm:"column:max_base" json:"max_base"`
+	MinBase              Money  `gorm:"column:min_base" json:"min_base"`
+	MaxBase              Money  `gorm:"column:max_base" json:"max_base"`
 	Description          string `gorm:"column:description" json:"description"`
 	IsActive             bool   `gorm:"column:is_active;default:true" json:"is_active"`
 	EffectiveDate        string `gorm:"column:effective_date;not null" json:"effective_date"`
@@ -21,11 +22,12 @@ type SalaryV2InsuranceRate struct {
 }
 
 type SalaryV2InsuranceRateCreateDTO struct {
+	PolicyId             string  `json:"policy_id"`
 	InsuranceType        string  `json:"insurance_type"`
 	EmployeeRate         float64 `json:"employee_rate"`
 	EmployerRate         float64 `json:"employer_rate"`
-	MinBase              int64   `json:"min_base"`
-	MaxBase              int64   `json:"max_base"`
+	MinBase              Money   `json:"min_base"`
+	MaxBase              Money   `json:"max_base"`
 	Description          string  `json:"description"`
 	EffectiveDate        string  `json:"effective_date"`
 }
@@ -35,8 +37,8 @@ type SalaryV2InsuranceRateEditDTO struct {
 	InsuranceType        string  `json:"insurance_type"`
 	EmployeeRate         float64 `json:"employee_rate"`
 	EmployerRate         float64 `json:"employer_rate"`
-	MinBase              int64   `json:"min_base"`
-	MaxBase              int64   `json:"max_base"`
+	MinBase              Money   `json:"min_base"`
+	MaxBase              Money   `json:"max_base"`
 	Description          string  `json:"description"`
 	IsActive             bool    `json:"is_active"`
 	EffectiveDate        string  `json:"effective_date"`
@@ -0,0 +1,69 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2RecordInsurance 薪资记录五险一金缴费明细，缴费基数已按费率配置的上下限调整
+type SalaryV2RecordInsurance struct {
+	gorm.Model
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	SalaryRecordId string  `gorm:"column:salary_record_id;not null" json:"salary_record_id"`
+	StaffId        string  `gorm:"column:staff_id;not null" json:"staff_id"`
+	SalaryDate     string  `gorm:"column:salary_date;not null" json:"salary_date"`
+	PolicyId       string  `gorm:"column:policy_id" json:"policy_id"`                    // 计算时使用的参保方案，为空表示分公司默认费率
+	InsuranceType  string  `gorm:"column:insurance_type;not null" json:"insurance_type"` // pension, medical, unemployment, housing, injury, maternity
+	Base           Money   `gorm:"column:base" json:"base"`                              // 缴费基数
+	EmployeeRate   float64 `gorm:"column:employee_rate" json:"employee_rate"`
+	EmployerRate   float64 `gorm:"column:employer_rate" json:"employer_rate"`
+	EmployeeAmount Money   `gorm:"column:employee_amount" json:"employee_amount"`
+	EmployerAmount Money   `gorm:"column:employer_amount" json:"employer_amount"`
+}
+
+func (SalaryV2RecordInsurance) TableName() string {
+	return "salary_v2_record_insurances"
+}
+
+// SalaryV2InsuranceContributionLine 月度缴费清单中员工的缴费明细
+type SalaryV2InsuranceContributionLine struct {
+	StaffId        string  `json:"staff_id"`
+	StaffName      string  `json:"staff_name"`
+	IdentityNum    string  `json:"identity_num"`
+	SalaryRecordId string  `json:"salary_record_id"`
+	PolicyId       string  `json:"policy_id"`
+	InsuranceType  string  `json:"insurance_type"`
+	Base           Money   `json:"base"`
+	EmployeeRate   float64 `json:"employee_rate"`
+	EmployerRate   float64 `json:"employer_rate"`
+	EmployeeAmount Money   `json:"employee_amount"`
+	EmployerAmount Money   `json:"employer_amount"`
+}
+
+// SalaryV2InsuranceContributionTotal 月度缴费清单按险种汇总
+type SalaryV2InsuranceContributionTotal struct {
+	InsuranceType  string `json:"insurance_type"`
+	StaffCount     int64  `json:"staff_count"`
+	Base           Money  `json:"base"`
+	EmployeeAmount Money  `json:"employee_amount"`
+	EmployerAmount Money  `json:"employer_amount"`
+}
+
+// SalaryV2InsuranceChange 当月参保增员、减员记录
+type SalaryV2InsuranceChange struct {
+	StaffId     string `json:"staff_id"`
+	StaffName   string `json:"staff_name"`
+	IdentityNum string `json:"identity_num"`
+	ChangeType  string `json:"change_type"` // enroll增员, stop减员
+	ChangeDate  string `json:"change_date"`
+	Base        Money  `json:"base"`
+	Reason      string `json:"reason"`
+}
+
+// SalaryV2InsuranceContributionReport 月度社保及住房公积金缴费报表
+type SalaryV2InsuranceContributionReport struct {
+	SalaryDate  string                                `json:"salary_date"`
+	Lines       []*SalaryV2InsuranceContributionLine  `json:"lines"`
+	Totals      []*SalaryV2InsuranceContributionTotal `json:"totals"`
+	Enrollments []*SalaryV2InsuranceChange            `json:"enrollments"`
+	Stops       []*SalaryV2InsuranceChange            `json:"stops"`
+}
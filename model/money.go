@@ -0,0 +1,194 @@
+package model
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money 金额，以最小货币单位(分)存储的定点数，数据库及JSON中以保留两位小数的元表示
+type Money int64
+
+const (
+	moneyScale = 100
+
+	// MoneyRoundingCent 金额舍入到分
+	MoneyRoundingCent = "cent"
+	// MoneyRoundingYuan 金额舍入到元
+	MoneyRoundingYuan = "yuan"
+)
+
+// NewMoney 将以元为单位的数值转换为金额，四舍五入到分
+func NewMoney(amount float64) Money {
+	return Money(math.Round(amount * moneyScale))
+}
+
+// MoneyFromYuan 将整数元转换为金额
+func MoneyFromYuan(yuan int64) Money {
+	return Money(yuan * moneyScale)
+}
+
+// ParseMoney 按十进制字符串精确解析金额，超过两位的小数四舍五入
+func ParseMoney(s string) (Money, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	negative := false
+	if s[0] == '-' || s[0] == '+' {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+	if integer == "" {
+		integer = "0"
+	}
+	yuan, err := strconv.ParseInt(integer, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("金额格式错误: %s", s)
+	}
+	for _, r := range fraction {
+		if r < '0' || r > '9' {
+			return 0, fmt.Errorf("金额格式错误: %s", s)
+		}
+	}
+	cents := yuan * moneyScale
+	padded := fraction + "00"
+	fen, _ := strconv.ParseInt(padded[:2], 10, 64)
+	cents += fen
+	if len(fraction) > 2 && fraction[2] >= '5' {
+		cents++
+	}
+	if negative {
+		cents = -cents
+	}
+	return Money(cents), nil
+}
+
+// Float64 返回以元为单位的数值
+func (m Money) Float64() float64 {
+	return float64(m) / moneyScale
+}
+
+// Cents 返回以分为单位的整数
+func (m Money) Cents() int64 {
+	return int64(m)
+}
+
+// Mul 乘以系数(费率、比例等)，结果四舍五入到分
+func (m Money) Mul(factor float64) Money {
+	return Money(math.Round(float64(m) * factor))
+}
+
+// Div 除以数值，结果四舍五入到分，除数为0时返回0
+func (m Money) Div(divisor float64) Money {
+	if divisor == 0 {
+		return 0
+	}
+	return Money(math.Round(float64(m) / divisor))
+}
+
+// Round 按舍入规则取整，yuan舍入到元，其他按分保留
+func (m Money) Round(unit string) Money {
+	if unit != MoneyRoundingYuan {
+		return m
+	}
+	return Money(math.Round(float64(m)/moneyScale) * moneyScale)
+}
+
+// IsZero 金额是否为0
+func (m Money) IsZero() bool {
+	return m == 0
+}
+
+// Abs 返回金额绝对值
+func (m Money) Abs() Money {
+	if m < 0 {
+		return -m
+	}
+	return m
+}
+
+// String 返回保留两位小数的元表示
+func (m Money) String() string {
+	sign := ""
+	cents := int64(m)
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/moneyScale, cents%moneyScale)
+}
+
+// MaxMoney 返回较大的金额
+func MaxMoney(a, b Money) Money {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// MinMoney 返回较小的金额
+func MinMoney(a, b Money) Money {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// MarshalJSON 以数值形式输出，保留两位小数
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON 支持数值及字符串形式的金额
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(strings.TrimSpace(string(data)), `"`)
+	if s == "null" {
+		*m = 0
+		return nil
+	}
+	value, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = value
+	return nil
+}
+
+// Scan 读取数据库中的 decimal 金额，兼容整数及浮点列
+func (m *Money) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*m = 0
+	case []byte:
+		parsed, err := ParseMoney(string(v))
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case string:
+		parsed, err := ParseMoney(v)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+	case int64:
+		*m = MoneyFromYuan(v)
+	case float64:
+		*m = NewMoney(v)
+	default:
+		return fmt.Errorf("不支持的金额类型: %T", value)
+	}
+	return nil
+}
+
+// Value 以保留两位小数的字符串写入数据库，避免浮点误差
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
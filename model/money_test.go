@@ -0,0 +1,79 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]Money{
+		"0":         0,
+		"12":        1200,
+		"12.3":      1230,
+		"12.34":     1234,
+		"12.345":    1235,
+		"12.344":    1234,
+		"-0.5":      -50,
+		"10000.00":  1000000,
+		" 8.10 ":    810,
+		"0.1":       10,
+		".25":       25,
+		"199.995":   20000,
+		"-199.995":  -20000,
+		"123456.78": 12345678,
+	}
+	for input, expected := range cases {
+		value, err := ParseMoney(input)
+		if err != nil {
+			t.Fatalf("ParseMoney(%q) err = %v", input, err)
+		}
+		if value != expected {
+			t.Errorf("ParseMoney(%q) = %d, want %d", input, value, expected)
+		}
+	}
+	if _, err := ParseMoney("12.3a"); err == nil {
+		t.Errorf("ParseMoney should reject invalid amount")
+	}
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	// 0.1 + 0.2 使用浮点数会产生误差，定点数应精确相等
+	if NewMoney(0.1)+NewMoney(0.2) != NewMoney(0.3) {
+		t.Errorf("0.1 + 0.2 != 0.3")
+	}
+	if got := MoneyFromYuan(10000).Mul(0.08); got.String() != "800.00" {
+		t.Errorf("Mul = %s", got)
+	}
+	if got := MoneyFromYuan(100).Div(3); got.String() != "33.33" {
+		t.Errorf("Div = %s", got)
+	}
+	if got := Money(123450).Round(MoneyRoundingYuan); got.String() != "1235.00" {
+		t.Errorf("Round yuan = %s", got)
+	}
+	if got := Money(123449).Round(MoneyRoundingYuan); got.String() != "1234.00" {
+		t.Errorf("Round yuan = %s", got)
+	}
+	if got := Money(123449).Round(MoneyRoundingCent); got != 123449 {
+		t.Errorf("Round cent = %s", got)
+	}
+	if got := Money(-5).String(); got != "-0.05" {
+		t.Errorf("String = %s", got)
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var value struct {
+		Amount Money `json:"amount"`
+		Text   Money `json:"text"`
+	}
+	if err := json.Unmarshal([]byte(`{"amount": 1234.5, "text": "0.07"}`), &value); err != nil {
+		t.Fatal(err)
+	}
+	if value.Amount != 123450 || value.Text != 7 {
+		t.Errorf("Unmarshal = %d %d", value.Amount, value.Text)
+	}
+	data, _ := json.Marshal(value)
+	if string(data) != `{"amount":1234.50,"text":0.07}` {
+		t.Errorf("Marshal = %s", data)
+	}
+}
@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SalaryV2ParameterChange 薪资参数变更申请，由一名管理员提交、另一名管理员审批后于生效日期执行
+type SalaryV2ParameterChange struct {
+	gorm.Model
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	ChangeId       string     `gorm:"column:change_id;uniqueIndex;not null" json:"change_id"`
+	ParameterType  string     `gorm:"column:parameter_type;not null" json:"parameter_type"`      // tax_bracket, insurance_rate, calculation_rule, system_parameter
+	Operation      string     `gorm:"column:operation;not null;default:update" json:"operation"` // update修改、create新增、delete停用
+	TargetId       uint       `gorm:"column:target_id;not null" json:"target_id"`                // 被修改参数记录的主键ID，新增参数时为0
+	ParameterId    string     `gorm:"column:parameter_id" json:"parameter_id"`                   // 被修改参数的业务ID
+	ParameterName  string     `gorm:"column:parameter_name" json:"parameter_name"`
+	OldValue       string     `gorm:"column:old_value;type:text" json:"old_value"` // 提交时的参数快照
+	NewValue       string     `gorm:"column:new_value;type:text" json:"new_value"` // 变更后的参数内容
+	EffectiveDate  string     `gorm:"column:effective_date" json:"effective_date"` // 生效日期，为空时审批通过后立即生效
+	Reason         string     `gorm:"column:reason" json:"reason"`
+	Status         string     `gorm:"column:status;not null" json:"status"` // pending待审批、approved已审批待生效、applied已生效、rejected已驳回、cancelled已撤销、failed生效失败
+	ApproverId     string     `gorm:"column:approver_id" json:"approver_id"`
+	ApproveComment string     `gorm:"column:approve_comment" json:"approve_comment"`
+	ApprovedAt     *time.Time `gorm:"column:approved_at" json:"approved_at"`
+	AppliedAt      *time.Time `gorm:"column:applied_at" json:"applied_at"`
+	ApplyError     string     `gorm:"column:apply_error" json:"apply_error"`
+	CreatedBy      string     `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy      string     `gorm:"column:updated_by" json:"updated_by"`
+}
+
+// SalaryV2ParameterChangeCreateDTO 提交参数变更申请，按参数类型填写对应的修改内容
+type SalaryV2ParameterChangeCreateDTO struct {
+	ParameterType   string                          `json:"parameter_type" binding:"required"`
+	TaxBracket      *SalaryV2TaxBracketEditDTO      `json:"tax_bracket"`
+	InsuranceRate   *SalaryV2InsuranceRateEditDTO   `json:"insurance_rate"`
+	CalculationRule *SalaryV2CalculationRuleEditDTO `json:"calculation_rule"`
+	SystemParameter *SalaryV2SystemParameterEditDTO `json:"system_parameter"`
+	EffectiveDate   string                          `json:"effective_date"`
+	Reason          string                          `json:"reason"`
+}
+
+type SalaryV2ParameterChangeApproveDTO struct {
+	ChangeId string `json:"change_id" binding:"required"`
+	Approved bool   `json:"approved"`
+	Comment  string `json:"comment"`
+}
+
+// SalaryV2ParameterFieldDiff 参数变更前后的字段差异
+type SalaryV2ParameterFieldDiff struct {
+	Field    string `json:"field"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// SalaryV2ParameterChangeDetail 参数变更申请及其与当前参数的差异
+type SalaryV2ParameterChangeDetail struct {
+	Change *SalaryV2ParameterChange      `json:"change"`
+	Diffs  []*SalaryV2ParameterFieldDiff `json:"diffs"`
+}
+
+func (SalaryV2ParameterChange) TableName() string {
+	return "salary_v2_parameter_changes"
+}
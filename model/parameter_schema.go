@@ -0,0 +1,14 @@
+package model
+
+// SalaryV2SystemParameterSchema 已注册系统参数的类型及取值约束
+type SalaryV2SystemParameterSchema struct {
+	ParameterKey  string   `json:"parameter_key"`
+	ParameterType string   `json:"parameter_type"` // string, number, decimal, boolean, json
+	Required      bool     `json:"required"`       // 薪资计算必需的参数，各分公司启动时检查
+	Min           *float64 `json:"min,omitempty"`
+	Max           *float64 `json:"max,omitempty"`
+	Options       []string `json:"options,omitempty"`
+	Format        string   `json:"format,omitempty"`        // date_list逗号分隔的日期列表, currency ISO 4217币种代码
+	DefaultValue  string   `json:"default_value,omitempty"` // 未配置时使用的默认值，为空表示必须配置
+	Description   string   `json:"description"`
+}
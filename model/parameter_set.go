@@ -0,0 +1,34 @@
+package model
+
+// SalaryV2ParameterDocument 参数导入导出文件，parameters中缺少的参数类型导入时保持不变
+type SalaryV2ParameterDocument struct {
+	Version    int                   `json:"version"`
+	BranchId   string                `json:"branch_id"`
+	ExportedAt string                `json:"exported_at"`
+	Parameters *SalaryV2ParameterSet `json:"parameters"`
+}
+
+// SalaryV2ParameterImportResult 参数导入结果，校验不通过或试运行时不修改参数
+type SalaryV2ParameterImportResult struct {
+	Errors         []string                         `json:"errors"`
+	Diffs          []*SalaryV2ParameterSnapshotDiff `json:"diffs"`
+	Applied        bool                             `json:"applied"`
+	PendingChanges []*SalaryV2ParameterChange       `json:"pending_changes"` // 税率区间及社保费率提交的待审批变更申请
+}
+
+// SalaryV2ParameterSyncDTO 将当前分公司参数同步至其他分公司，sections为空时同步全部参数类型
+type SalaryV2ParameterSyncDTO struct {
+	BranchIds []string `json:"branch_ids" binding:"required"`
+	Sections  []string `json:"sections"` // tax_bracket, insurance_rate, calculation_rule, system_parameter
+	DryRun    bool     `json:"dry_run"`
+}
+
+// SalaryV2ParameterSyncResult 单个分公司的参数同步结果
+type SalaryV2ParameterSyncResult struct {
+	BranchId       string                           `json:"branch_id"`
+	Success        bool                             `json:"success"`
+	Error          string                           `json:"error"`
+	Diffs          []*SalaryV2ParameterSnapshotDiff `json:"diffs"`
+	Applied        bool                             `json:"applied"`
+	PendingChanges []*SalaryV2ParameterChange       `json:"pending_changes"` // 在目标分公司提交的税率区间及社保费率变更申请，由该分公司其他管理员审批
+}
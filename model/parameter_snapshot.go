@@ -0,0 +1,52 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2ParameterSnapshot 分公司V2薪资参数的命名快照，保存创建时的全部税率区间、社保费率、计算规则及系统参数
+type SalaryV2ParameterSnapshot struct {
+	gorm.Model
+	ID                   uint   `gorm:"primaryKey" json:"id"`
+	SnapshotId           string `gorm:"column:snapshot_id;uniqueIndex;not null" json:"snapshot_id"`
+	SnapshotName         string `gorm:"column:snapshot_name;uniqueIndex;not null" json:"snapshot_name"`
+	Description          string `gorm:"column:description" json:"description"`
+	Content              string `gorm:"column:content;type:longtext" json:"content,omitempty"` // SalaryV2ParameterSet的JSON
+	TaxBracketCount      int    `gorm:"column:tax_bracket_count" json:"tax_bracket_count"`
+	InsuranceRateCount   int    `gorm:"column:insurance_rate_count" json:"insurance_rate_count"`
+	CalculationRuleCount int    `gorm:"column:calculation_rule_count" json:"calculation_rule_count"`
+	SystemParameterCount int    `gorm:"column:system_parameter_count" json:"system_parameter_count"`
+	CreatedBy            string `gorm:"column:created_by" json:"created_by"`
+}
+
+// SalaryV2ParameterSet 一组完整的V2薪资参数
+type SalaryV2ParameterSet struct {
+	TaxBrackets      []*SalaryV2TaxBracket      `json:"tax_brackets"`
+	InsuranceRates   []*SalaryV2InsuranceRate   `json:"insurance_rates"`
+	CalculationRules []*SalaryV2CalculationRule `json:"calculation_rules"`
+	SystemParameters []*SalaryV2SystemParameter `json:"system_parameters"`
+}
+
+type SalaryV2ParameterSnapshotCreateDTO struct {
+	SnapshotName string `json:"snapshot_name" binding:"required"`
+	Description  string `json:"description"`
+}
+
+// SalaryV2ParameterRestoreDTO 将参数恢复至历史版本，version为old时恢复到该次修改前，为new时恢复到该次修改后
+type SalaryV2ParameterRestoreDTO struct {
+	HistoryId string `json:"history_id" binding:"required"`
+	Version   string `json:"version"`
+}
+
+// SalaryV2ParameterSnapshotDiff 两组参数之间一条参数记录的差异
+type SalaryV2ParameterSnapshotDiff struct {
+	ParameterType string                        `json:"parameter_type"`
+	ParameterId   string                        `json:"parameter_id"`
+	ParameterName string                        `json:"parameter_name"`
+	Action        string                        `json:"action"` // added新增、removed移除、modified修改
+	Fields        []*SalaryV2ParameterFieldDiff `json:"fields"`
+}
+
+func (SalaryV2ParameterSnapshot) TableName() string {
+	return "salary_v2_parameter_snapshots"
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2PayComponent 薪资项目目录，用于五项固定薪资之外的津贴、补贴及扣款
+type SalaryV2PayComponent struct {
+	gorm.Model
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	ComponentId   string `gorm:"column:component_id;uniqueIndex;not null" json:"component_id"`
+	ComponentCode string `gorm:"column:component_code;not null" json:"component_code"` // 项目编码，如meal_allowance
+	ComponentName string `gorm:"column:component_name;not null" json:"component_name"`
+	ComponentType string `gorm:"column:component_type;not null" json:"component_type"` // earning应发项，deduction扣款项
+	// 应发项表示是否计入个税应纳税所得额，扣款项表示是否税前扣除
+	IsTaxable   bool   `gorm:"column:is_taxable" json:"is_taxable"`
+	IsInsurable bool   `gorm:"column:is_insurable" json:"is_insurable"` // 应发项是否计入五险一金缴费基数
+	SortOrder   int64  `gorm:"column:sort_order;default:0" json:"sort_order"`
+	Description string `gorm:"column:description" json:"description"`
+	IsActive    bool   `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy   string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy   string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2PayComponentCreateDTO struct {
+	ComponentCode string `json:"component_code" binding:"required"`
+	ComponentName string `json:"component_name" binding:"required"`
+	ComponentType string `json:"component_type" binding:"required"`
+	IsTaxable     bool   `json:"is_taxable"`
+	IsInsurable   bool   `json:"is_insurable"`
+	SortOrder     int64  `json:"sort_order"`
+	Description   string `json:"description"`
+}
+
+type SalaryV2PayComponentEditDTO struct {
+	ComponentId   string `json:"component_id" binding:"required"`
+	ComponentName string `json:"component_name" binding:"required"`
+	IsTaxable     bool   `json:"is_taxable"`
+	IsInsurable   bool   `json:"is_insurable"`
+	SortOrder     int64  `json:"sort_order"`
+	Description   string `json:"description"`
+}
+
+func (SalaryV2PayComponent) TableName() string {
+	return "salary_v2_pay_components"
+}
+
+// SalaryV2StaffPayComponent 员工薪资项目，按月份区间每月计入薪资
+type SalaryV2StaffPayComponent struct {
+	gorm.Model
+	ID               uint   `gorm:"primaryKey" json:"id"`
+	StaffComponentId string `gorm:"column:staff_component_id;uniqueIndex;not null" json:"staff_component_id"`
+	StaffId          string `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName        string `gorm:"column:staff_name" json:"staff_name"`
+	ComponentCode    string `gorm:"column:component_code;not null" json:"component_code"`
+	Amount           Money  `gorm:"column:amount;not null" json:"amount"`           // 每月金额(元)
+	StartMonth       string `gorm:"column:start_month;not null" json:"start_month"` // 起始月份，如2025-01
+	EndMonth         string `gorm:"column:end_month" json:"end_month"`              // 截止月份，为空表示长期有效
+	Source           string `gorm:"column:source;default:manual" json:"source"`     // manual手工维护，template薪资模板生成
+	Remark           string `gorm:"column:remark" json:"remark"`
+	IsActive         bool   `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy        string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy        string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2StaffPayComponentCreateDTO struct {
+	StaffId       string `json:"staff_id" binding:"required"`
+	ComponentCode string `json:"component_code" binding:"required"`
+	Amount        Money  `json:"amount" binding:"required"`
+	StartMonth    string `json:"start_month" binding:"required"`
+	EndMonth      string `json:"end_month"`
+	Remark        string `json:"remark"`
+}
+
+type SalaryV2StaffPayComponentEditDTO struct {
+	StaffComponentId string `json:"staff_component_id" binding:"required"`
+	Amount           Money  `json:"amount" binding:"required"`
+	StartMonth       string `json:"start_month" binding:"required"`
+	EndMonth         string `json:"end_month"`
+	Remark           string `json:"remark"`
+}
+
+func (SalaryV2StaffPayComponent) TableName() string {
+	return "salary_v2_staff_pay_components"
+}
+
+// SalaryV2RecordItem 薪资记录明细，记录当月核算时各薪资项目的金额及计税属性
+type SalaryV2RecordItem struct {
+	gorm.Model
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	SalaryRecordId string `gorm:"column:salary_record_id;not null" json:"salary_record_id"`
+	StaffId        string `gorm:"column:staff_id;not null" json:"staff_id"`
+	SalaryDate     string `gorm:"column:salary_date;not null" json:"salary_date"`
+	ComponentCode  string `gorm:"column:component_code;not null" json:"component_code"`
+	ComponentName  string `gorm:"column:component_name" json:"component_name"`
+	ComponentType  string `gorm:"column:component_type;not null" json:"component_type"`
+	IsTaxable      bool   `gorm:"column:is_taxable" json:"is_taxable"`
+	IsInsurable    bool   `gorm:"column:is_insurable" json:"is_insurable"`
+	Amount         Money  `gorm:"column:amount;not null" json:"amount"`
+}
+
+func (SalaryV2RecordItem) TableName() string {
+	return "salary_v2_record_items"
+}
@@ -0,0 +1,39 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SalaryV2PayrollPeriod 薪资期间，各分公司数据库独立维护
+// open 可核算、locked 已锁定不可重新核算或修改、closed 已结账
+type SalaryV2PayrollPeriod struct {
+	gorm.Model
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	PeriodId     string     `gorm:"column:period_id;uniqueIndex;not null" json:"period_id"`
+	PeriodMonth  string     `gorm:"column:period_month;uniqueIndex;not null" json:"period_month"` // 薪资月份，如2025-03
+	Status       string     `gorm:"column:status;not null" json:"status"`                         // open、locked、closed
+	LockedBy     string     `gorm:"column:locked_by" json:"locked_by"`
+	LockedAt     *time.Time `gorm:"column:locked_at" json:"locked_at"`
+	ClosedBy     string     `gorm:"column:closed_by" json:"closed_by"`
+	ClosedAt     *time.Time `gorm:"column:closed_at" json:"closed_at"`
+	UnlockedBy   string     `gorm:"column:unlocked_by" json:"unlocked_by"`
+	UnlockedAt   *time.Time `gorm:"column:unlocked_at" json:"unlocked_at"`
+	UnlockReason string     `gorm:"column:unlock_reason" json:"unlock_reason"` // 最近一次解锁原因，完整记录见操作日志
+	CreatedBy    string     `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy    string     `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2PayrollPeriodDTO struct {
+	PeriodMonth string `json:"period_month" binding:"required"`
+}
+
+type SalaryV2PayrollPeriodUnlockDTO struct {
+	PeriodMonth string `json:"period_month" binding:"required"`
+	Reason      string `json:"reason" binding:"required"`
+}
+
+func (SalaryV2PayrollPeriod) TableName() string {
+	return "salary_v2_payroll_periods"
+}
@@ -0,0 +1,31 @@
+package model
+
+// SalaryV2PayslipLine 工资条明细行
+type SalaryV2PayslipLine struct {
+	Name   string `json:"name"`
+	Amount Money  `json:"amount"`
+}
+
+// SalaryV2Payslip 员工月度工资条，包含应发、扣款、单位缴纳部分及本年累计
+type SalaryV2Payslip struct {
+	BranchId              string                 `json:"branch_id"`
+	BranchName            string                 `json:"branch_name"`
+	SalaryRecordId        string                 `json:"salary_record_id"`
+	SalaryDate            string                 `json:"salary_date"`
+	StaffId               string                 `json:"staff_id"`
+	StaffName             string                 `json:"staff_name"`
+	DepName               string                 `json:"dep_name"`
+	IsPay                 int64                  `json:"is_pay"`
+	Currency              string                 `json:"currency"`
+	Earnings              []*SalaryV2PayslipLine `json:"earnings"`
+	Deductions            []*SalaryV2PayslipLine `json:"deductions"`
+	EmployerContributions []*SalaryV2PayslipLine `json:"employer_contributions"`
+	TotalEarnings         Money                  `json:"total_earnings"`
+	TotalDeductions       Money                  `json:"total_deductions"`
+	TotalEmployer         Money                  `json:"total_employer"`
+	NetPay                Money                  `json:"net_pay"`
+	YtdIncome             Money                  `json:"ytd_income"`
+	YtdInsurance          Money                  `json:"ytd_insurance"`
+	YtdTax                Money                  `json:"ytd_tax"`
+	YtdNetPay             Money                  `json:"ytd_net_pay"`
+}
@@ -4,17 +4,20 @@ import "gorm.io/gorm"
 
 type Rank struct {
 	gorm.Model
-	RankId   string `gorm:"column:rank_id" json:"rank_id"`
-	RankName string `gorm:"column:rank_name" json:"rank_name"`
+	RankId    string `gorm:"column:rank_id" json:"rank_id"`
+	RankName  string `gorm:"column:rank_name" json:"rank_name"`
+	RankLevel int64  `gorm:"column:rank_level" json:"rank_level"` // 职级等级，用于薪资计算公式
 }
 
 type RankCreateDTO struct {
-	RankName string `json:"rank_name" binding:"required"`
+	RankName  string `json:"rank_name" binding:"required"`
+	RankLevel int64  `json:"rank_level"`
 }
 
 type RankEditDTO struct {
-	RankId   string `json:"rank_id" binding:"required"`
-	RankName string `json:"rank_name" binding:"required"`
+	RankId    string `json:"rank_id" binding:"required"`
+	RankName  string `json:"rank_name" binding:"required"`
+	RankLevel int64  `json:"rank_level"`
 }
 
 func (d Rank) TableName() string {
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2RetroAdjustment 追溯调整：已发放月份按更正后的考勤及参数重新核算，与已发放金额的差额计入下一个未发放的薪资月份
+type SalaryV2RetroAdjustment struct {
+	gorm.Model
+	ID                    uint   `gorm:"primaryKey" json:"id"`
+	RetroId               string `gorm:"column:retro_id;uniqueIndex;not null" json:"retro_id"`
+	StaffId               string `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName             string `gorm:"column:staff_name" json:"staff_name"`
+	SourceMonth           string `gorm:"column:source_month;not null" json:"source_month"` // 被追溯的已发放月份
+	SourceRecordId        string `gorm:"column:source_record_id" json:"source_record_id"`
+	TargetMonth           string `gorm:"column:target_month;not null" json:"target_month"` // 补发或扣回的薪资月份
+	AppliedRecordId       string `gorm:"column:applied_record_id" json:"applied_record_id"`
+	PaidIncome            Money  `gorm:"column:paid_income" json:"paid_income"`                       // 已发放的计税工资收入
+	RecalculatedIncome    Money  `gorm:"column:recalculated_income" json:"recalculated_income"`       // 重新核算的计税工资收入
+	PaidDeduction         Money  `gorm:"column:paid_deduction" json:"paid_deduction"`                 // 已发放的专项扣除及税前扣款
+	RecalculatedDeduction Money  `gorm:"column:recalculated_deduction" json:"recalculated_deduction"` // 重新核算的专项扣除及税前扣款
+	PaidTax               Money  `gorm:"column:paid_tax" json:"paid_tax"`                             // 已预扣个人所得税
+	RecalculatedTax       Money  `gorm:"column:recalculated_tax" json:"recalculated_tax"`             // 重新核算的个人所得税
+	PaidTotal             Money  `gorm:"column:paid_total" json:"paid_total"`                         // 已发放实发工资
+	RecalculatedTotal     Money  `gorm:"column:recalculated_total" json:"recalculated_total"`         // 重新核算的实发工资
+	EarningsDifference    Money  `gorm:"column:earnings_difference" json:"earnings_difference"`       // 计税收入差额，正数补发、负数扣回
+	DeductionDifference   Money  `gorm:"column:deduction_difference" json:"deduction_difference"`     // 专项扣除及税前扣款差额
+	NetDifference         Money  `gorm:"column:net_difference" json:"net_difference"`                 // 不计税收入及税后扣款差额
+	TotalDifference       Money  `gorm:"column:total_difference" json:"total_difference"`             // 实发工资差额
+	Status                string `gorm:"column:status;not null" json:"status"`                        // pending待计入、applied已计入、cancelled已撤销
+	Reason                string `gorm:"column:reason" json:"reason"`
+	CreatedBy             string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy             string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2RetroCalculateDTO struct {
+	StaffId    string `json:"staff_id"` // 为空时追溯区间内所有已发放薪资的员工
+	StartMonth string `json:"start_month" binding:"required"`
+	EndMonth   string `json:"end_month" binding:"required"`
+	Reason     string `json:"reason"`
+}
+
+func (SalaryV2RetroAdjustment) TableName() string {
+	return "salary_v2_retro_adjustments"
+}
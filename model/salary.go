@@ -9,35 +9,41 @@ type Salary struct {
 	SalaryId   string `gorm:"column:salary_id" json:"salary_id"`
 	StaffId    string `gorm:"column:staff_id" json:"staff_id"`
 	StaffName  string `gorm:"column:staff_name" json:"staff_name"`
-	Base       int64  `gorm:"column:base" json:"base"`
-	Subsidy    int64  `gorm:"column:subsidy" json:"subsidy"`
-	Bonus      int64  `gorm:"column:bonus" json:"bonus"`
-	Commission int64  `gorm:"column:commission" json:"commission"`
-	Other      int64  `gorm:"column:other" json:"other"`
+	Base       Money  `gorm:"column:base" json:"base"`
+	Subsidy    Money  `gorm:"column:subsidy" json:"subsidy"`
+	Bonus      Money  `gorm:"column:bonus" json:"bonus"`
+	Commission Money  `gorm:"column:commission" json:"commission"`
+	Other      Money  `gorm:"column:other" json:"other"`
 	Fund       int64  `gorm:"column:fund" json:"fund"`
+	// 全年一次性奖金计税方式：separate单独计税，combined并入当月综合所得
+	AnnualBonusTaxMethod string `gorm:"column:annual_bonus_tax_method;default:separate" json:"annual_bonus_tax_method"`
 }
 
 type SalaryCreateDTO struct {
 	StaffId    string `gorm:"column:staff_id" json:"staff_id"`
 	StaffName  string `gorm:"column:staff_name" json:"staff_name"`
-	Base       int64  `gorm:"column:base" json:"base"`
-	Subsidy    int64  `gorm:"column:subsidy" json:"subsidy"`
-	Bonus      int64  `gorm:"column:bonus" json:"bonus"`
-	Commission int64  `gorm:"column:commission" json:"commission"`
-	Other      int64  `gorm:"column:other" json:"other"`
+	Base       Money  `gorm:"column:base" json:"base"`
+	Subsidy    Money  `gorm:"column:subsidy" json:"subsidy"`
+	Bonus      Money  `gorm:"column:bonus" json:"bonus"`
+	Commission Money  `gorm:"column:commission" json:"commission"`
+	Other      Money  `gorm:"column:other" json:"other"`
 	Fund       int64  `gorm:"column:fund" json:"fund"`
+	// 全年一次性奖金计税方式：separate单独计税，combined并入当月综合所得
+	AnnualBonusTaxMethod string `gorm:"column:annual_bonus_tax_method;default:separate" json:"annual_bonus_tax_method"`
 }
 
 type SalaryEditDTO struct {
 	Id         int64
 	StaffId    string `gorm:"column:staff_id" json:"staff_id"`
 	StaffName  string `gorm:"column:staff_name" json:"staff_name"`
-	Base       int64  `gorm:"column:base" json:"base"`
-	Subsidy    int64  `gorm:"column:subsidy" json:"subsidy"`
-	Bonus      int64  `gorm:"column:bonus" json:"bonus"`
-	Commission int64  `gorm:"column:commission" json:"commission"`
-	Other      int64  `gorm:"column:other" json:"other"`
+	Base       Money  `gorm:"column:base" json:"base"`
+	Subsidy    Money  `gorm:"column:subsidy" json:"subsidy"`
+	Bonus      Money  `gorm:"column:bonus" json:"bonus"`
+	Commission Money  `gorm:"column:commission" json:"commission"`
+	Other      Money  `gorm:"column:other" json:"other"`
 	Fund       int64  `gorm:"column:fund" json:"fund"`
+	// 全年一次性奖金计税方式：separate单独计税，combined并入当月综合所得
+	AnnualBonusTaxMethod string `gorm:"column:annual_bonus_tax_method;default:separate" json:"annual_bonus_tax_method"`
 }
 
 type SalaryRecord struct {
@@ -45,18 +51,45 @@ type SalaryRecord struct {
 	SalaryRecordId        string  `gorm:"column:salary_record_id" json:"salary_record_id"`
 	StaffId               string  `gorm:"column:staff_id" json:"staff_id"`
 	StaffName             string  `gorm:"column:staff_name" json:"staff_name"`
-	Base                  int64   `gorm:"column:base" json:"base"`
-	Subsidy               int64   `gorm:"column:subsidy" json:"subsidy"`
-	Bonus                 int64   `gorm:"column:bonus" json:"bonus"`
-	Commission            int64   `gorm:"column:commission" json:"commission"`
-	Other                 int64   `gorm:"column:other" json:"other"`
-	PensionInsurance      float64 `gorm:"column:pension_insurance" json:"pension_insurance"`
-	UnemploymentInsurance float64 `gorm:"column:unemployment_insurance" json:"unemployment_insurance"`
-	MedicalInsurance      float64 `gorm:"column:medical_insurance" json:"medical_insurance"`
-	HousingFund           float64 `gorm:"column:housing_fund" json:"housing_fund"`
-	Tax                   float64 `gorm:"column:tax" json:"tax"`
-	Overtime              int64   `gorm:"column:overtime" json:"overtime"`
-	Total                 float64 `gorm:"column:total" json:"total"`
+	Base                  Money   `gorm:"column:base" json:"base"`
+	Subsidy               Money   `gorm:"column:subsidy" json:"subsidy"`
+	Bonus                 Money   `gorm:"column:bonus" json:"bonus"`
+	Commission            Money   `gorm:"column:commission" json:"commission"`
+	Other                 Money   `gorm:"column:other" json:"other"`
+	PensionInsurance      Money   `gorm:"column:pension_insurance" json:"pension_insurance"`
+	UnemploymentInsurance Money   `gorm:"column:unemployment_insurance" json:"unemployment_insurance"`
+	MedicalInsurance      Money   `gorm:"column:medical_insurance" json:"medical_insurance"`
+	HousingFund           Money   `gorm:"column:housing_fund" json:"housing_fund"`
+	Tax                   Money   `gorm:"column:tax" json:"tax"`
+	GrossIncome           Money   `gorm:"column:gross_income" json:"gross_income"`                           // 当月应发合计
+	SpecialDeduction      Money   `gorm:"column:special_deduction" json:"special_deduction"`                 // 当月专项附加扣除
+	CumulativeIncome      Money   `gorm:"column:cumulative_income" json:"cumulative_income"`                 // 本年累计收入
+	CumulativeDeduction   Money   `gorm:"column:cumulative_deduction" json:"cumulative_deduction"`           // 本年累计减除费用及专项扣除
+	CumulativeTaxable     Money   `gorm:"column:cumulative_taxable_income" json:"cumulative_taxable_income"` // 本年累计应纳税所得额
+	CumulativeTax         Money   `gorm:"column:cumulative_tax" json:"cumulative_tax"`                       // 本年累计应纳税额
+	AnnualBonus           Money   `gorm:"column:annual_bonus" json:"annual_bonus"`                           // 当月发放的全年一次性奖金
+	AnnualBonusTax        Money   `gorm:"column:annual_bonus_tax" json:"annual_bonus_tax"`                   // 单独计税的全年一次性奖金个税
+	ComponentEarnings     Money   `gorm:"column:component_earnings" json:"component_earnings"`               // 计税的其他薪资项目应发合计
+	TaxFreeEarnings       Money   `gorm:"column:tax_free_earnings" json:"tax_free_earnings"`                 // 不计税的其他薪资项目应发合计
+	PreTaxDeductions      Money   `gorm:"column:pre_tax_deductions" json:"pre_tax_deductions"`               // 税前扣款合计
+	PostTaxDeductions     Money   `gorm:"column:post_tax_deductions" json:"post_tax_deductions"`             // 税后扣款合计
+	Overtime              Money   `gorm:"column:overtime" json:"overtime"`
+	WeekdayOvertimeHours  float64 `gorm:"column:weekday_overtime_hours" json:"weekday_overtime_hours"` // 工作日加班时长
+	WeekendOvertimeHours  float64 `gorm:"column:weekend_overtime_hours" json:"weekend_overtime_hours"` // 休息日加班时长
+	HolidayOvertimeHours  float64 `gorm:"column:holiday_overtime_hours" json:"holiday_overtime_hours"` // 法定节假日加班时长
+	WeekdayOvertimePay    Money   `gorm:"column:weekday_overtime_pay" json:"weekday_overtime_pay"`     // 工作日加班工资
+	WeekendOvertimePay    Money   `gorm:"column:weekend_overtime_pay" json:"weekend_overtime_pay"`     // 休息日加班工资
+	HolidayOvertimePay    Money   `gorm:"column:holiday_overtime_pay" json:"holiday_overtime_pay"`     // 法定节假日加班工资
+	LeavePayout           Money   `gorm:"column:leave_payout" json:"leave_payout"`                     // 未休年休假工资报酬
+	Severance             Money   `gorm:"column:severance" json:"severance"`                           // 解除劳动合同经济补偿
+	SeveranceTax          Money   `gorm:"column:severance_tax" json:"severance_tax"`                   // 经济补偿单独计算的个人所得税
+	RetroEarnings         Money   `gorm:"column:retro_earnings" json:"retro_earnings"`                 // 追溯补发(扣回)的计税收入
+	RetroDeductions       Money   `gorm:"column:retro_deductions" json:"retro_deductions"`             // 追溯补扣(退还)的专项扣除及税前扣款
+	RetroNetAdjustment    Money   `gorm:"column:retro_net_adjustment" json:"retro_net_adjustment"`     // 追溯补发(扣回)的不计税收入及税后扣款
+	RoundingAdjustment    Money   `gorm:"column:rounding_adjustment" json:"rounding_adjustment"`       // 实发工资按舍入规则取整的差额
+	Total                 Money   `gorm:"column:total" json:"total"`
+	Currency              string  `gorm:"column:currency;default:CNY" json:"currency"` // 币种
 	IsPay                 int64   `gorm:"column:is_pay" json:"is_pay"`
 	SalaryDate            string  `gorm:"column:salary_date" json:"salary_date"`
+	RecordType            string  `gorm:"column:record_type;default:regular" json:"record_type"` // regular月度薪资，settlement离职结算
 }
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SalaryV2Adjustment 调薪申请，经直属领导、人事依次审批后于生效月份自动执行
+type SalaryV2Adjustment struct {
+	gorm.Model
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	AdjustmentId     string     `gorm:"column:adjustment_id;uniqueIndex;not null" json:"adjustment_id"`
+	StaffId          string     `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName        string     `gorm:"column:staff_name" json:"staff_name"`
+	AdjustmentType   string     `gorm:"column:adjustment_type;not null" json:"adjustment_type"` // raise调薪、demotion降薪、probation_regular转正、other其他
+	OldBase          Money      `gorm:"column:old_base" json:"old_base"`
+	NewBase          Money      `gorm:"column:new_base;not null" json:"new_base"`
+	OldRankId        string     `gorm:"column:old_rank_id" json:"old_rank_id"`
+	NewRankId        string     `gorm:"column:new_rank_id" json:"new_rank_id"`                  // 为空表示职级不变
+	EffectiveMonth   string     `gorm:"column:effective_month;not null" json:"effective_month"` // 生效月份，如2025-03
+	EffectiveDate    string     `gorm:"column:effective_date" json:"effective_date"`            // 生效日期，月中调薪时按日期拆分当月薪资
+	Reason           string     `gorm:"column:reason;not null" json:"reason"`
+	Status           string     `gorm:"column:status;not null" json:"status"` // pending_leader待领导审批、pending_hr待人事审批、approved已审批待生效、applied已生效、rejected已驳回、cancelled已撤销
+	LeaderApproverId string     `gorm:"column:leader_approver_id" json:"leader_approver_id"`
+	LeaderComment    string     `gorm:"column:leader_comment" json:"leader_comment"`
+	LeaderApprovedAt *time.Time `gorm:"column:leader_approved_at" json:"leader_approved_at"`
+	HrApproverId     string     `gorm:"column:hr_approver_id" json:"hr_approver_id"`
+	HrComment        string     `gorm:"column:hr_comment" json:"hr_comment"`
+	HrApprovedAt     *time.Time `gorm:"column:hr_approved_at" json:"hr_approved_at"`
+	AppliedAt        *time.Time `gorm:"column:applied_at" json:"applied_at"`
+	CreatedBy        string     `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy        string     `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2AdjustmentCreateDTO struct {
+	StaffId        string `json:"staff_id" binding:"required"`
+	AdjustmentType string `json:"adjustment_type" binding:"required"`
+	NewBase        Money  `json:"new_base" binding:"required"`
+	NewRankId      string `json:"new_rank_id"`
+	EffectiveMonth string `json:"effective_month"`
+	EffectiveDate  string `json:"effective_date"` // 与生效月份二选一，填写时生效月份取该日期所在月份
+	Reason         string `json:"reason" binding:"required"`
+}
+
+type SalaryV2AdjustmentApproveDTO struct {
+	AdjustmentId string `json:"adjustment_id" binding:"required"`
+	Approved     bool   `json:"approved"`
+	Comment      string `json:"comment"`
+}
+
+func (SalaryV2Adjustment) TableName() string {
+	return "salary_v2_adjustments"
+}
+
+// SalaryV2CompensationHistory 员工薪酬变动历史
+type SalaryV2CompensationHistory struct {
+	gorm.Model
+	ID             uint   `gorm:"primaryKey" json:"id"`
+	HistoryId      string `gorm:"column:history_id;uniqueIndex;not null" json:"history_id"`
+	StaffId        string `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName      string `gorm:"column:staff_name" json:"staff_name"`
+	AdjustmentId   string `gorm:"column:adjustment_id" json:"adjustment_id"`
+	ChangeType     string `gorm:"column:change_type;not null" json:"change_type"` // 与调薪申请类型一致
+	OldBase        Money  `gorm:"column:old_base" json:"old_base"`
+	NewBase        Money  `gorm:"column:new_base" json:"new_base"`
+	OldRankId      string `gorm:"column:old_rank_id" json:"old_rank_id"`
+	NewRankId      string `gorm:"column:new_rank_id" json:"new_rank_id"`
+	EffectiveMonth string `gorm:"column:effective_month" json:"effective_month"`
+	EffectiveDate  string `gorm:"column:effective_date" json:"effective_date"` // 生效日期，为月初时表示整月生效
+	Reason         string `gorm:"column:reason" json:"reason"`
+	Operator       string `gorm:"column:operator" json:"operator"`
+}
+
+func (SalaryV2CompensationHistory) TableName() string {
+	return "salary_v2_compensation_histories"
+}
@@ -0,0 +1,27 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2RecordSegment 薪资记录基本工资分段明细，入职、离职、月中调薪及试用期转正时按日期拆分
+type SalaryV2RecordSegment struct {
+	gorm.Model
+	ID             uint    `gorm:"primaryKey" json:"id"`
+	SalaryRecordId string  `gorm:"column:salary_record_id;not null" json:"salary_record_id"`
+	StaffId        string  `gorm:"column:staff_id;not null" json:"staff_id"`
+	SalaryDate     string  `gorm:"column:salary_date;not null" json:"salary_date"`
+	StartDate      string  `gorm:"column:start_date;not null" json:"start_date"`
+	EndDate        string  `gorm:"column:end_date;not null" json:"end_date"`
+	WorkDays       int64   `gorm:"column:work_days" json:"work_days"`             // 分段内应出勤工作日
+	MonthWorkDays  int64   `gorm:"column:month_work_days" json:"month_work_days"` // 当月应出勤工作日
+	BaseRate       Money   `gorm:"column:base_rate" json:"base_rate"`             // 分段适用的月基本工资
+	PayRatio       float64 `gorm:"column:pay_ratio" json:"pay_ratio"`             // 试用期工资比例，正式员工为1
+	AttendRatio    float64 `gorm:"column:attend_ratio" json:"attend_ratio"`       // 实际出勤天数占应出勤天数比例
+	Amount         Money   `gorm:"column:amount" json:"amount"`
+	Reason         string  `gorm:"column:reason" json:"reason"` // 分段起始原因：month_start月初、entry入职、adjustment调薪、regular转正
+}
+
+func (SalaryV2RecordSegment) TableName() string {
+	return "salary_v2_record_segments"
+}
@@ -31,9 +31,10 @@ type SalaryTemplateItem struct {
 	ItemID          string    `json:"item_id" gorm:"column:item_id;type:varchar(32);uniqueIndex;not null;comment:项目ID"`
 	TemplateID      string    `json:"template_id" gorm:"column:template_id;type:varchar(32);index;not null;comment:模板ID"`
 	ItemName        string    `json:"item_name" gorm:"column:item_name;type:varchar(100);not null;comment:项目名称"`
-	ItemType        string    `json:"item_type" gorm:"column:item_type;type:varchar(20);not null;comment:项目类型：base基本工资、subsidy补贴、bonus奖金、commission提成、other其他"`
+	ItemType        string    `json:"item_type" gorm:"column:item_type;type:varchar(20);not null;comment:项目类型：base基本工资、subsidy补贴、bonus奖金、commission提成、other其他、component薪资项目目录"`
+	ComponentCode   string    `json:"component_code" gorm:"column:component_code;type:varchar(50);comment:薪资项目编码，项目类型为component时有效"`
 	CalculationType string    `json:"calculation_type" gorm:"column:calculation_type;type:varchar(20);not null;comment:计算类型：fixed固定金额、percentage百分比"`
-	Amount          *int64    `json:"amount" gorm:"column:amount;type:bigint;comment:固定金额(分)"`
+	Amount          *Money    `json:"amount" gorm:"column:amount;type:decimal(12,2);comment:固定金额(元)"`
 	Percentage      *float64  `json:"percentage" gorm:"column:percentage;type:decimal(5,2);comment:百分比"`
 	BaseField       string    `json:"base_field" gorm:"column:base_field;type:varchar(50);comment:百分比计算基准字段"`
 	SortOrder       int       `json:"sort_order" gorm:"column:sort_order;type:int;default:0;comment:排序顺序"`
@@ -57,16 +58,47 @@ type SalaryTemplateWithItems struct {
 type TemplateApplyRequest struct {
 	TemplateID string `json:"template_id" binding:"required"`
 	StaffID    string `json:"staff_id" binding:"required"`
-	BaseSalary int64  `json:"base_salary" binding:"required"`
+	BaseSalary Money  `json:"base_salary" binding:"required"`
 }
 
 // TemplateApplyResponse 模板应用响应
 type TemplateApplyResponse struct {
-	Base      int64 `json:"base"`
-	Subsidy   int64 `json:"subsidy"`
-	Bonus     int64 `json:"bonus"`
-	Commission int64 `json:"commission"`
-	Other     int64 `json:"other"`
+	Base      Money `json:"base"`
+	Subsidy   Money `json:"subsidy"`
+	Bonus     Money `json:"bonus"`
+	Commission Money `json:"commission"`
+	Other     Money `json:"other"`
+	// 薪资项目目录中的项目金额(元)，按项目编码汇总
+	Components map[string]Money `json:"components,omitempty"`
+}
+
+// StaffSalaryTemplate 员工薪资模板分配，生效后按模板生成员工薪资套账
+type StaffSalaryTemplate struct {
+	ID            int64      `json:"id" gorm:"primaryKey;autoIncrement"`
+	AssignmentID  string     `json:"assignment_id" gorm:"column:assignment_id;type:varchar(32);uniqueIndex;not null;comment:分配ID"`
+	StaffID       string     `json:"staff_id" gorm:"column:staff_id;type:varchar(32);index;not null;comment:员工工号"`
+	StaffName     string     `json:"staff_name" gorm:"column:staff_name;type:varchar(50);comment:员工姓名"`
+	TemplateID    string     `json:"template_id" gorm:"column:template_id;type:varchar(32);index;not null;comment:模板ID"`
+	BaseSalary    Money      `json:"base_salary" gorm:"column:base_salary;type:decimal(12,2);default:0;comment:基本工资(元)，为0时使用员工档案基本工资"`
+	EffectiveDate string     `json:"effective_date" gorm:"column:effective_date;type:date;not null;comment:生效日期"`
+	Status        string     `json:"status" gorm:"column:status;type:varchar(20);not null;comment:状态：pending待生效、active生效中、expired已失效"`
+	AppliedAt     *time.Time `json:"applied_at" gorm:"column:applied_at;type:datetime;comment:最近一次生成薪资套账时间"`
+	CreatedBy     string     `json:"created_by" gorm:"column:created_by;type:varchar(32);comment:创建人"`
+	CreatedAt     time.Time  `json:"created_at" gorm:"column:created_at;type:datetime;default:CURRENT_TIMESTAMP;comment:创建时间"`
+	UpdatedAt     time.Time  `json:"updated_at" gorm:"column:updated_at;type:datetime;default:CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP;comment:更新时间"`
+	DeletedAt     *time.Time `json:"deleted_at" gorm:"column:deleted_at;type:datetime;comment:软删除时间"`
+}
+
+func (StaffSalaryTemplate) TableName() string {
+	return "salary_v2_staff_templates"
+}
+
+// TemplateAssignRequest 员工模板分配请求
+type TemplateAssignRequest struct {
+	TemplateID    string `json:"template_id" binding:"required"`
+	StaffID       string `json:"staff_id" binding:"required"`
+	BaseSalary    Money  `json:"base_salary"`
+	EffectiveDate string `json:"effective_date"` // 为空时立即生效
 }
 
 // TemplateQueryRequest 模板查询请求
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2Settlement 离职结算单，结算金额随离职当月的离职结算薪资记录发放
+type SalaryV2Settlement struct {
+	gorm.Model
+	ID                   uint    `gorm:"primaryKey" json:"id"`
+	SettlementId         string  `gorm:"column:settlement_id;uniqueIndex;not null" json:"settlement_id"`
+	StaffId              string  `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName            string  `gorm:"column:staff_name" json:"staff_name"`
+	EntryDate            string  `gorm:"column:entry_date" json:"entry_date"`
+	ResignationDate      string  `gorm:"column:resignation_date;not null" json:"resignation_date"`
+	SalaryRecordId       string  `gorm:"column:salary_record_id" json:"salary_record_id"`
+	SalaryDate           string  `gorm:"column:salary_date" json:"salary_date"`
+	ProratedSalary       Money   `gorm:"column:prorated_salary" json:"prorated_salary"`             // 当月折算工资应发合计
+	MonthlyWage          Money   `gorm:"column:monthly_wage" json:"monthly_wage"`                   // 离职前十二个月平均工资
+	SeveranceType        string  `gorm:"column:severance_type" json:"severance_type"`               // none无补偿，n按工作年限，n_plus_1另加一个月代通知金
+	SeveranceYears       float64 `gorm:"column:severance_years" json:"severance_years"`             // 经济补偿计算年限
+	Severance            Money   `gorm:"column:severance" json:"severance"`                         // 经济补偿(含代通知金)
+	SeveranceTaxFree     Money   `gorm:"column:severance_tax_free" json:"severance_tax_free"`       // 经济补偿免税额
+	SeveranceTax         Money   `gorm:"column:severance_tax" json:"severance_tax"`                 // 经济补偿个人所得税
+	UnusedLeaveDays      float64 `gorm:"column:unused_leave_days" json:"unused_leave_days"`         // 未休年休假天数
+	LeavePayout          Money   `gorm:"column:leave_payout" json:"leave_payout"`                   // 未休年休假工资报酬
+	OutstandingDeduction Money   `gorm:"column:outstanding_deduction" json:"outstanding_deduction"` // 离职时一次扣回的借款等款项
+	Tax                  Money   `gorm:"column:tax" json:"tax"`                                     // 当月工资薪金个人所得税
+	Total                Money   `gorm:"column:total" json:"total"`                                 // 实发合计
+	Remark               string  `gorm:"column:remark" json:"remark"`
+	CreatedBy            string  `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy            string  `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2SettlementCreateDTO struct {
+	StaffId              string  `json:"staff_id" binding:"required"`
+	SeveranceType        string  `json:"severance_type"`
+	UnusedLeaveDays      float64 `json:"unused_leave_days"`
+	OutstandingDeduction Money   `json:"outstanding_deduction"`
+	Remark               string  `json:"remark"`
+}
+
+func (SalaryV2Settlement) TableName() string {
+	return "salary_v2_settlements"
+}
@@ -0,0 +1,48 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2SpecialDeduction 个人所得税专项附加扣除申报
+type SalaryV2SpecialDeduction struct {
+	gorm.Model
+	ID            uint   `gorm:"primaryKey" json:"id"`
+	DeductionId   string `gorm:"column:deduction_id;uniqueIndex;not null" json:"deduction_id"`
+	StaffId       string `gorm:"column:staff_id;not null" json:"staff_id"`
+	StaffName     string `gorm:"column:staff_name" json:"staff_name"`
+	DeductionType string `gorm:"column:deduction_type;not null" json:"deduction_type"` // children_education, continuing_education, housing_loan, housing_rent, elderly_support, infant_care
+	MonthlyAmount Money  `gorm:"column:monthly_amount;not null" json:"monthly_amount"` // 每月扣除金额(元)
+	Quantity      int64  `gorm:"column:quantity;default:1" json:"quantity"`            // 子女人数等计数
+	StartMonth    string `gorm:"column:start_month;not null" json:"start_month"`       // 起始月份，如2025-01
+	EndMonth      string `gorm:"column:end_month" json:"end_month"`                    // 截止月份，为空表示长期有效
+	Remark        string `gorm:"column:remark" json:"remark"`
+	IsActive      bool   `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy     string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy     string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2SpecialDeductionCreateDTO struct {
+	StaffId       string `json:"staff_id"`
+	StaffName     string `json:"staff_name"`
+	DeductionType string `json:"deduction_type" binding:"required"`
+	MonthlyAmount Money  `json:"monthly_amount" binding:"required"`
+	Quantity      int64  `json:"quantity"`
+	StartMonth    string `json:"start_month" binding:"required"`
+	EndMonth      string `json:"end_month"`
+	Remark        string `json:"remark"`
+}
+
+type SalaryV2SpecialDeductionEditDTO struct {
+	DeductionId   string `json:"deduction_id" binding:"required"`
+	DeductionType string `json:"deduction_type" binding:"required"`
+	MonthlyAmount Money  `json:"monthly_amount" binding:"required"`
+	Quantity      int64  `json:"quantity"`
+	StartMonth    string `json:"start_month" binding:"required"`
+	EndMonth      string `json:"end_month"`
+	Remark        string `json:"remark"`
+}
+
+func (SalaryV2SpecialDeduction) TableName() string {
+	return "salary_v2_special_deductions"
+}
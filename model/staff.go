@@ -18,7 +18,7 @@ type Staff struct {
 	School            string `gorm:"column:school" json:"school"`
 	Major             string `gorm:"column:major" json:"major"`
 	EduLevel          string `gorm:"column:edu_level" json:"edu_level"`
-	BaseSalary        int64  `gorm:"column:base_salary" json:"base_salary"`
+	BaseSalary        Money  `gorm:"column:base_salary" json:"base_salary"`
 	CardNum           string `gorm:"column:card_num" json:"card_num"`
 	RankId            string `gorm:"column:rank_id" json:"rank_id"`
 	DepId             string `gorm:"column:dep_id" json:"dep_id"`
@@ -61,7 +61,7 @@ type StaffCreateDTO struct {
 	School            string `gorm:"column:school" json:"school" binding:"required"`
 	Major             string `gorm:"column:major" json:"major" binding:"required"`
 	EduLevel          string `gorm:"column:edu_level" json:"edu_level" binding:"required"`
-	BaseSalary        int64  `gorm:"column:base_salary" json:"base_salary" binding:"required"`
+	BaseSalary        Money  `gorm:"column:base_salary" json:"base_salary" binding:"required"`
 	CardNum           string `gorm:"column:card_num" json:"card_num" binding:"required"`
 	RankId            string `gorm:"column:rank_id" json:"rank_id" binding:"required"`
 	DepId             string `gorm:"column:dep_id" json:"dep_id" binding:"required"`
@@ -88,7 +88,7 @@ type StaffEditDTO struct {
 	School            string `json:"school"`
 	Major             string `json:"major"`
 	EduLevel          string `json:"edu_level"`
-	BaseSalary        int64  `json:"base_salary"`
+	BaseSalary        Money  `json:"base_salary"`
 	CardNum           string `json:"card_num"`
 	RankId            string `json:"rank_id"`
 	DepId             string `json:"dep_id"`
@@ -114,7 +114,7 @@ type StaffOnboardDTO struct {
 	School            string `json:"school" binding:"required"`
 	Major             string `json:"major" binding:"required"`
 	EduLevel          string `json:"edu_level" binding:"required"`
-	BaseSalary        int64  `json:"base_salary" binding:"required"`
+	BaseSalary        Money  `json:"base_salary" binding:"required"`
 	CardNum           string `json:"card_num" binding:"required"`
 	RankId            string `json:"rank_id" binding:"required"`
 	DepId             string `json:"dep_id" binding:"required"`
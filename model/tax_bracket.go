@@ -8,10 +8,11 @@ type SalaryV2TaxBracket struct {
 	gorm.Model
 	ID                 uint   `gorm:"primaryKey" json:"id"`
 	TaxBracketId       string `gorm:"column:tax_bracket_id;uniqueIndex;not null" json:"tax_bracket_id"`
-	MinIncome          int64  `gorm:"column:min_income;not null" json:"min_income"`
-	MaxIncome          int64  `gorm:"column:max_income" json:"max_income"`
+	BracketType        string `gorm:"column:bracket_type;default:monthly" json:"bracket_type"` // monthly月度税率表, annual年度累计预扣税率表
+	MinIncome          Money  `gorm:"column:min_income;not null" json:"min_income"`
+	MaxIncome          Money  `gorm:"column:max_income" json:"max_income"`
 	TaxRate            float64 `gorm:"column:tax_rate;not null" json:"tax_rate"`
-	QuickDeduction     int64  `gorm:"column:quick_deduction;not null" json:"quick_deduction"`
+	QuickDeduction     Money  `gorm:"column:quick_deduction;not null" json:"quick_deduction"`
 	Description        string `gorm:"column:description" json:"description"`
 	IsActive           bool   `gorm:"column:is_active;default:true" json:"is_active"`
 	EffectiveDate      string `gorm:"column:effective_date;not null" json:"effective_date"`
@@ -20,20 +21,22 @@ type SalaryV2TaxBracket struct {
 }
 
 type SalaryV2TaxBracketCreateDTO struct {
-	MinIncome          int64   `json:"min_income"`
-	MaxIncome          int64   `json:"max_income"`
+	BracketType        string  `json:"bracket_type"`
+	MinIncome          Money   `json:"min_income"`
+	MaxIncome          Money   `json:"max_income"`
 	TaxRate            float64 `json:"tax_rate"`
-	QuickDeduction     int64   `json:"quick_deduction"`
+	QuickDeduction     Money   `json:"quick_deduction"`
 	Description        string  `json:"description"`
 	EffectiveDate      string  `json:"effective_date"`
 }
 
 type SalaryV2TaxBracketEditDTO struct {
 	ID                 uint    `json:"id"`
-	MinIncome          int64   `json:"min_income"`
-	MaxIncome          int64   `json:"max_income"`
+	BracketType        string  `json:"bracket_type"`
+	MinIncome          Money   `json:"min_income"`
+	MaxIncome          Money   `json:"max_income"`
 	TaxRate            float64 `json:"tax_rate"`
-	QuickDeduction     int64   `json:"quick_deduction"`
+	QuickDeduction     Money   `json:"quick_deduction"`
 	Description        string  `json:"description"`
 	IsActive           bool    `json:"is_active"`
 	EffectiveDate      string  `json:"effective_date"`
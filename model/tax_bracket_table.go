@@ -0,0 +1,30 @@
+package model
+
+// SalaryV2TaxBracketItem 税率表中的一档区间，上限为0表示无上限
+type SalaryV2TaxBracketItem struct {
+	MinIncome      Money   `json:"min_income"`
+	MaxIncome      Money   `json:"max_income"`
+	TaxRate        float64 `json:"tax_rate"`
+	QuickDeduction Money   `json:"quick_deduction"`
+	Description    string  `json:"description"`
+}
+
+// SalaryV2TaxBracketReplaceDTO 整体替换一张税率表
+type SalaryV2TaxBracketReplaceDTO struct {
+	BracketType   string                    `json:"bracket_type" binding:"required"`
+	EffectiveDate string                    `json:"effective_date"`
+	Brackets      []*SalaryV2TaxBracketItem `json:"brackets" binding:"required"`
+}
+
+// SalaryV2TaxBracketPreset 内置税率表
+type SalaryV2TaxBracketPreset struct {
+	PresetCode  string                    `json:"preset_code"`
+	PresetName  string                    `json:"preset_name"`
+	BracketType string                    `json:"bracket_type"`
+	Brackets    []*SalaryV2TaxBracketItem `json:"brackets"`
+}
+
+type SalaryV2TaxBracketPresetLoadDTO struct {
+	PresetCode    string `json:"preset_code" binding:"required"`
+	EffectiveDate string `json:"effective_date"`
+}
@@ -0,0 +1,71 @@
+package model
+
+// SalaryV2TaxFilingWage 个税扣缴申报正常工资薪金所得明细，按扣缴客户端导入模板字段组织，专项附加扣除为本年累计金额
+type SalaryV2TaxFilingWage struct {
+	StaffId               string `json:"staff_id"`
+	StaffName             string `json:"staff_name"`
+	IdentityType          string `json:"identity_type"`
+	IdentityNum           string `json:"identity_num"`
+	SalaryRecordId        string `json:"salary_record_id"`
+	Income                Money  `json:"income"`          // 本期收入
+	TaxFreeIncome         Money  `json:"tax_free_income"` // 本期免税收入
+	PensionInsurance      Money  `json:"pension_insurance"`
+	MedicalInsurance      Money  `json:"medical_insurance"`
+	UnemploymentInsurance Money  `json:"unemployment_insurance"`
+	HousingFund           Money  `json:"housing_fund"`
+	ChildrenEducation     Money  `json:"children_education"`
+	ContinuingEducation   Money  `json:"continuing_education"`
+	HousingLoan           Money  `json:"housing_loan"`
+	HousingRent           Money  `json:"housing_rent"`
+	ElderlySupport        Money  `json:"elderly_support"`
+	InfantCare            Money  `json:"infant_care"`
+	OtherDeduction        Money  `json:"other_deduction"` // 其他税前扣除
+	TaxWithheld           Money  `json:"tax_withheld"`    // 本期已扣缴税额
+	Remark                string `json:"remark"`
+}
+
+// SalaryV2TaxFilingSeparate 单独计税所得明细：全年一次性奖金、解除劳动合同一次性补偿金
+type SalaryV2TaxFilingSeparate struct {
+	StaffId       string `json:"staff_id"`
+	StaffName     string `json:"staff_name"`
+	IdentityType  string `json:"identity_type"`
+	IdentityNum   string `json:"identity_num"`
+	Income        Money  `json:"income"`
+	TaxFreeIncome Money  `json:"tax_free_income"`
+	TaxWithheld   Money  `json:"tax_withheld"`
+	Remark        string `json:"remark"`
+}
+
+// SalaryV2TaxFilingDeclaration 月度个税扣缴申报，仅包含已发放的薪资
+type SalaryV2TaxFilingDeclaration struct {
+	SalaryDate    string                       `json:"salary_date"`
+	Wages         []*SalaryV2TaxFilingWage     `json:"wages"`
+	AnnualBonuses []*SalaryV2TaxFilingSeparate `json:"annual_bonuses"`
+	Severances    []*SalaryV2TaxFilingSeparate `json:"severances"`
+	StaffCount    int64                        `json:"staff_count"`
+	TotalIncome   Money                        `json:"total_income"`
+	TotalTax      Money                        `json:"total_tax"`
+	Warnings      []string                     `json:"warnings"`
+}
+
+// SalaryV2TaxAnnualSummary 员工年度个税汇总，用于年度汇算清缴前核对
+type SalaryV2TaxAnnualSummary struct {
+	StaffId             string `json:"staff_id"`
+	StaffName           string `json:"staff_name"`
+	IdentityNum         string `json:"identity_num"`
+	Year                string `json:"year"`
+	Months              int64  `json:"months"`               // 本年已发放薪资月数
+	Income              Money  `json:"income"`               // 综合所得收入(含并入综合所得的全年一次性奖金)
+	TaxFreeIncome       Money  `json:"tax_free_income"`      // 免税收入
+	SpecialDeduction    Money  `json:"special_deduction"`    // 专项扣除及税前扣款
+	AdditionalDeduction Money  `json:"additional_deduction"` // 专项附加扣除
+	BasicDeduction      Money  `json:"basic_deduction"`      // 基本减除费用，按全年计算
+	TaxableIncome       Money  `json:"taxable_income"`       // 年度应纳税所得额
+	TaxPayable          Money  `json:"tax_payable"`          // 年度应纳税额
+	TaxWithheld         Money  `json:"tax_withheld"`         // 本年已预扣预缴税额
+	TaxDifference       Money  `json:"tax_difference"`       // 应补(正数)或应退(负数)税额
+	SeparateBonus       Money  `json:"separate_bonus"`       // 单独计税的全年一次性奖金
+	SeparateBonusTax    Money  `json:"separate_bonus_tax"`
+	Severance           Money  `json:"severance"` // 解除劳动合同一次性补偿金，单独计税
+	SeveranceTax        Money  `json:"severance_tax"`
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2WorkCalendarDay 工作日历中的特殊日期，未登记的日期周一至周五为工作日、周六周日为休息日
+type SalaryV2WorkCalendarDay struct {
+	gorm.Model
+	ID           uint   `gorm:"primaryKey" json:"id"`
+	CalendarDate string `gorm:"column:calendar_date;uniqueIndex;not null" json:"calendar_date"` // 日期，如2025-10-01
+	DayType      string `gorm:"column:day_type;not null" json:"day_type"`                       // holiday法定节假日, workday调休上班日
+	DayName      string `gorm:"column:day_name" json:"day_name"`                                // 节日名称，如国庆节
+	Source       string `gorm:"column:source" json:"source"`                                    // manual手工登记, ics, json
+	CreatedBy    string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy    string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2WorkCalendarDayDTO struct {
+	CalendarDate string `json:"calendar_date" binding:"required"`
+	DayType      string `json:"day_type" binding:"required"`
+	DayName      string `json:"day_name"`
+}
+
+func (SalaryV2WorkCalendarDay) TableName() string {
+	return "salary_v2_work_calendar_days"
+}
+
+// SalaryV2WorkCalendarImportResult 工作日历导入结果
+type SalaryV2WorkCalendarImportResult struct {
+	Created int64                         `json:"created"`
+	Updated int64                         `json:"updated"`
+	Days    []*SalaryV2WorkCalendarDayDTO `json:"days"`
+}
+
+// SalaryV2WorkCalendarMonth 月度工作日历
+type SalaryV2WorkCalendarMonth struct {
+	Month       string                     `json:"month"`
+	WorkingDays int64                      `json:"working_days"` // 当月应出勤天数
+	RestDays    int64                      `json:"rest_days"`
+	Holidays    []*SalaryV2WorkCalendarDay `json:"holidays"`
+	Workdays    []*SalaryV2WorkCalendarDay `json:"workdays"`
+}
@@ -0,0 +1,93 @@
+package model
+
+import (
+	"gorm.io/gorm"
+)
+
+// SalaryV2WorkShift 班次定义，下班时间早于上班时间的班次须标记为跨零点
+type SalaryV2WorkShift struct {
+	gorm.Model
+	ID                     uint   `gorm:"primaryKey" json:"id"`
+	ShiftId                string `gorm:"column:shift_id;uniqueIndex;not null" json:"shift_id"`
+	ShiftName              string `gorm:"column:shift_name;not null" json:"shift_name"`
+	StartTime              string `gorm:"column:start_time;not null" json:"start_time"` // 上班时间，如08:00
+	EndTime                string `gorm:"column:end_time;not null" json:"end_time"`     // 下班时间，如17:00
+	BreakMinutes           int64  `gorm:"column:break_minutes" json:"break_minutes"`    // 班内休息分钟数，不计工时
+	CrossMidnight          bool   `gorm:"column:cross_midnight" json:"cross_midnight"`  // 是否跨零点，下班时间为次日
+	LateGraceMinutes       int64  `gorm:"column:late_grace_minutes" json:"late_grace_minutes"`
+	EarlyLeaveGraceMinutes int64  `gorm:"column:early_leave_grace_minutes" json:"early_leave_grace_minutes"`
+	Description            string `gorm:"column:description" json:"description"`
+	IsActive               bool   `gorm:"column:is_active;default:true" json:"is_active"`
+	CreatedBy              string `gorm:"column:created_by" json:"created_by"`
+	UpdatedBy              string `gorm:"column:updated_by" json:"updated_by"`
+}
+
+type SalaryV2WorkShiftCreateDTO struct {
+	ShiftName              string `json:"shift_name" binding:"required"`
+	StartTime              string `json:"start_time" binding:"required"`
+	EndTime                string `json:"end_time" binding:"required"`
+	BreakMinutes           int64  `json:"break_minutes"`
+	CrossMidnight          bool   `json:"cross_midnight"`
+	LateGraceMinutes       int64  `json:"late_grace_minutes"`
+	EarlyLeaveGraceMinutes int64  `json:"early_leave_grace_minutes"`
+	Description            string `json:"description"`
+}
+
+type SalaryV2WorkShiftEditDTO struct {
+	ShiftId                string `json:"shift_id" binding:"required"`
+	ShiftName              string `json:"shift_name" binding:"required"`
+	StartTime              string `json:"start_time" binding:"required"`
+	EndTime                string `json:"end_time" binding:"required"`
+	BreakMinutes           int64  `json:"break_minutes"`
+	CrossMidnight          bool   `json:"cross_midnight"`
+	LateGraceMinutes       int64  `json:"late_grace_minutes"`
+	EarlyLeaveGraceMinutes int64  `json:"early_leave_grace_minutes"`
+	Description            string `json:"description"`
+}
+
+func (SalaryV2WorkShift) TableName() string {
+	return "salary_v2_work_shifts"
+}
+
+// SalaryV2WorkSchedule 排班，按员工或部门指定班次轮换规律，员工排班优先于所在部门的排班
+type SalaryV2WorkSchedule struct {
+	gorm.Model
+	ID                uint   `gorm:"primaryKey" json:"id"`
+	ScheduleId        string `gorm:"column:schedule_id;uniqueIndex;not null" json:"schedule_id"`
+	TargetType        string `gorm:"column:target_type;not null" json:"target_type"` // staff员工, department部门
+	TargetId          string `gorm:"column:target_id;not null" json:"target_id"`     // 员工工号或部门ID
+	TargetName        string `gorm:"column:target_name" json:"target_name"`
+	RotationPattern   string `gorm:"column:rotation_pattern;not null" json:"rotation_pattern"`       // 逗号分隔的班次ID，rest表示休息，按天循环
+	RotationStartDate string `gorm:"column:rotation_start_date;not null" json:"rotation_start_date"` // 轮换起始日期，该日使用第一个班次
+	FollowCalendar    bool   `gorm:"column:follow_calendar" json:"follow_calendar"`                  // 是否按工作日历休息，固定班次使用
+	StartDate         string `gorm:"column:start_date;not null" json:"start_date"`
+	EndDate           string `gorm:"column:end_date" json:"end_date"` // 为空表示长期有效
+	Remark            string `gorm:"column:remark" json:"remark"`
+	CreatedBy         string `gorm:"column:created_by" json:"created_by"`
+}
+
+type SalaryV2WorkScheduleCreateDTO struct {
+	TargetType        string `json:"target_type" binding:"required"`
+	TargetId          string `json:"target_id" binding:"required"`
+	RotationPattern   string `json:"rotation_pattern" binding:"required"`
+	RotationStartDate string `json:"rotation_start_date"` // 为空时使用排班起始日期
+	FollowCalendar    bool   `json:"follow_calendar"`
+	StartDate         string `json:"start_date" binding:"required"`
+	EndDate           string `json:"end_date"`
+	Remark            string `json:"remark"`
+}
+
+func (SalaryV2WorkSchedule) TableName() string {
+	return "salary_v2_work_schedules"
+}
+
+// SalaryV2RosterDay 员工某日的排班
+type SalaryV2RosterDay struct {
+	Date       string `json:"date"`
+	ScheduleId string `json:"schedule_id"` // 为空表示未排班，按工作日历及标准工时考勤
+	ShiftId    string `json:"shift_id"`    // 为空表示休息
+	ShiftName  string `json:"shift_name"`
+	StartTime  string `json:"start_time"`
+	EndTime    string `json:"end_time"`
+	IsWorkday  bool   `json:"is_workday"`
+}
@@ -0,0 +1,276 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func checkAnnualBonusTaxMethod(taxMethod string) error {
+	if taxMethod != "separate" && taxMethod != "combined" {
+		return errors.New("不支持的全年一次性奖金计税方式: " + taxMethod)
+	}
+	return nil
+}
+
+// CreateAnnualBonusV2 登记全年一次性奖金，当月薪资已核算时一并更新当月薪资记录
+func CreateAnnualBonusV2(c *gin.Context, dto *model.SalaryV2AnnualBonusCreateDTO, createdBy string) error {
+	if dto.Amount <= 0 {
+		return errors.New("奖金金额必须大于0")
+	}
+	if _, err := time.Parse("2006-01", dto.PayMonth); err != nil {
+		return errors.New("发放月份格式错误: " + dto.PayMonth)
+	}
+	var staff model.Staff
+	if err := resource.HrmsDB(c).Where("staff_id = ?", dto.StaffId).First(&staff).Error; err != nil {
+		return errors.New("不存在该员工")
+	}
+
+	var bonus model.SalaryV2AnnualBonus
+	Transfer(&dto, &bonus)
+	if bonus.BonusYear == "" {
+		bonus.BonusYear = bonus.PayMonth[:4]
+	}
+	if bonus.TaxMethod == "" {
+		salaryInfo, err := getSalaryInfoByStaffId(resource.HrmsDB(c), bonus.StaffId)
+		if err != nil {
+			return err
+		}
+		bonus.TaxMethod = salaryInfo.AnnualBonusTaxMethod
+		if bonus.TaxMethod == "" {
+			bonus.TaxMethod = "separate"
+		}
+	}
+	if err := checkAnnualBonusTaxMethod(bonus.TaxMethod); err != nil {
+		return err
+	}
+
+	bonus.BonusId = RandomID("annual_bonus")
+	bonus.StaffName = staff.StaffName
+	bonus.IsActive = true
+	bonus.CreatedBy = createdBy
+	bonus.UpdatedBy = createdBy
+
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if bonus.TaxMethod == "separate" {
+			// 一个纳税年度内单独计税办法只允许采用一次，锁定员工记录及已登记的奖金避免并发登记
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("staff_id = ?", bonus.StaffId).First(&staff).Error; err != nil {
+				return errors.New("不存在该员工")
+			}
+			var count int64
+			if err := tx.Model(&model.SalaryV2AnnualBonus{}).Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("staff_id = ? and pay_month like ? and tax_method = ? and is_active = ?", bonus.StaffId, bonus.PayMonth[:4]+"-%", "separate", true).
+				Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return errors.New("该员工本纳税年度已有单独计税的全年一次性奖金，请选择并入综合所得计税")
+			}
+			tax, err := CalculateAnnualBonusTax(c, bonus.Amount)
+			if err != nil {
+				return err
+			}
+			bonus.Tax = tax
+			bonus.NetAmount = bonus.Amount - tax
+		}
+		if err := tx.Create(&bonus).Error; err != nil {
+			log.Printf("CreateAnnualBonusV2 err = %v", err)
+			return err
+		}
+		return settleAnnualBonusesByMonth(c, tx, bonus.StaffId, bonus.PayMonth)
+	})
+}
+
+func GetAnnualBonusesV2(c *gin.Context, staffId string, bonusYear string, start int, limit int) ([]*model.SalaryV2AnnualBonus, int64, error) {
+	var bonuses []*model.SalaryV2AnnualBonus
+	var err error
+	query := resource.HrmsDB(c).Where("is_active = ?", true)
+	if staffId != "" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+	if bonusYear != "" {
+		query = query.Where("bonus_year = ?", bonusYear)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2AnnualBonus{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("pay_month desc").Find(&bonuses).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("pay_month desc").Find(&bonuses).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return bonuses, total, nil
+}
+
+// DeleteAnnualBonusV2 作废全年一次性奖金，已随薪资发放的奖金不允许作废
+func DeleteAnnualBonusV2(c *gin.Context, id uint, deletedBy string) error {
+	var bonus model.SalaryV2AnnualBonus
+	if err := resource.HrmsDB(c).First(&bonus, id).Error; err != nil {
+		return err
+	}
+	if !bonus.IsActive {
+		return errors.New("该全年一次性奖金已作废")
+	}
+
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.SalaryV2AnnualBonus{}).Where("id = ? and is_active = ?", id, true).
+			Updates(map[string]interface{}{
+				"is_active":  false,
+				"updated_by": deletedBy,
+			})
+		if result.Error != nil {
+			log.Printf("DeleteAnnualBonusV2 err = %v", result.Error)
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errors.New("该全年一次性奖金已作废")
+		}
+		return settleAnnualBonusesByMonth(c, tx, bonus.StaffId, bonus.PayMonth)
+	})
+}
+
+// UpdateAnnualBonusTaxMethodV2 设置员工全年一次性奖金计税方式
+func UpdateAnnualBonusTaxMethodV2(c *gin.Context, dto *model.SalaryV2AnnualBonusTaxMethodDTO) error {
+	if err := checkAnnualBonusTaxMethod(dto.TaxMethod); err != nil {
+		return err
+	}
+	result := resource.HrmsDB(c).Model(&model.Salary{}).Where("staff_id = ?", dto.StaffId).
+		Update("annual_bonus_tax_method", dto.TaxMethod)
+	if result.Error != nil {
+		log.Printf("UpdateAnnualBonusTaxMethodV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该薪资套账")
+	}
+	return nil
+}
+
+// CalculateAnnualBonusTax 全年一次性奖金单独计税：以奖金除以12个月的商数确定月度税率和速算扣除数
+func CalculateAnnualBonusTax(c *gin.Context, amount model.Money) (model.Money, error) {
+	brackets, err := GetTaxBracketsByType(c, "monthly")
+	if err != nil {
+		return 0, err
+	}
+	if len(brackets) == 0 {
+		return 0, errors.New("未配置个人所得税税率表")
+	}
+	monthlyAmount := amount.Div(12)
+	var matched *model.SalaryV2TaxBracket
+	for _, bracket := range brackets {
+		if monthlyAmount > bracket.MinIncome && (bracket.MaxIncome == 0 || monthlyAmount <= bracket.MaxIncome) {
+			matched = bracket
+			break
+		}
+	}
+	if matched == nil {
+		// 商数为0或超过所有税率区间时分别取最低、最高税率
+		matched = brackets[0]
+		for _, bracket := range brackets {
+			if monthlyAmount > 0 && bracket.MaxIncome == 0 {
+				matched = bracket
+			}
+		}
+	}
+	return model.MaxMoney(0, amount.Mul(matched.TaxRate/100.0)-matched.QuickDeduction), nil
+}
+
+// applyAnnualBonuses 将当月发放的全年一次性奖金计入薪资记录并计算个人所得税
+// 并入综合所得的奖金按累计预扣法与当月工资合并计税，单独计税的奖金税额单独列示
+// regularIncome 为不含全年一次性奖金的当月计税收入，deduction 为当月专项扣除及税前扣款
+func applyAnnualBonuses(c *gin.Context, tx *gorm.DB, salaryRecord *model.SalaryRecord, regularIncome, deduction model.Money) error {
+	var bonuses []*model.SalaryV2AnnualBonus
+	if err := tx.Where("staff_id = ? and pay_month = ? and is_active = ?", salaryRecord.StaffId, salaryRecord.SalaryDate, true).
+		Find(&bonuses).Error; err != nil {
+		return err
+	}
+	var combinedAmount, separateAmount, separateTax model.Money
+	for _, bonus := range bonuses {
+		if bonus.TaxMethod == "combined" {
+			combinedAmount += bonus.Amount
+		} else {
+			separateAmount += bonus.Amount
+			separateTax += bonus.Tax
+		}
+	}
+
+	// 先计算不含奖金的当月税额，用于拆分并入综合所得的奖金应扣税额
+	if err := CalculateCumulativeIncomeTax(c, tx, salaryRecord, salaryRecord.StaffId, salaryRecord.SalaryDate, regularIncome, deduction); err != nil {
+		return err
+	}
+	regularTax := salaryRecord.Tax
+	if combinedAmount > 0 {
+		if err := CalculateCumulativeIncomeTax(c, tx, salaryRecord, salaryRecord.StaffId, salaryRecord.SalaryDate, regularIncome+combinedAmount, deduction); err != nil {
+			return err
+		}
+	}
+	combinedTax := salaryRecord.Tax - regularTax
+
+	for _, bonus := range bonuses {
+		updates := map[string]interface{}{"salary_record_id": salaryRecord.SalaryRecordId}
+		if bonus.TaxMethod == "combined" {
+			tax := combinedTax.Mul(float64(bonus.Amount) / float64(combinedAmount))
+			updates["tax"] = tax
+			updates["net_amount"] = bonus.Amount - tax
+		}
+		if err := tx.Model(&model.SalaryV2AnnualBonus{}).Where("id = ?", bonus.ID).Updates(updates).Error; err != nil {
+			return err
+		}
+	}
+
+	salaryRecord.GrossIncome = regularIncome + combinedAmount
+	salaryRecord.AnnualBonus = combinedAmount + separateAmount
+	salaryRecord.AnnualBonusTax = separateTax
+	// 不计税的薪资项目、税后扣款、单独计税的离职经济补偿及追溯的税后差额直接计入实发工资
+	total := salaryRecord.GrossIncome - deduction - salaryRecord.Tax + separateAmount - separateTax +
+		salaryRecord.TaxFreeEarnings - salaryRecord.PostTaxDeductions + salaryRecord.Severance - salaryRecord.SeveranceTax +
+		salaryRecord.RetroNetAdjustment
+	// 实发工资按分公司舍入规则取整，舍入差额单独记录
+	salaryRecord.Total = total.Round(getSystemParameterString(tx, "money_rounding", model.MoneyRoundingCent))
+	salaryRecord.RoundingAdjustment = salaryRecord.Total - total
+	return nil
+}
+
+// settleAnnualBonusesByMonth 奖金变动后重新核算已生成的当月薪资记录，当月薪资尚未核算时在考勤审批通过后核算
+func settleAnnualBonusesByMonth(c *gin.Context, tx *gorm.DB, staffId, month string) error {
+	var records []*model.SalaryRecord
+	if err := tx.Where("staff_id = ? and salary_date = ?", staffId, month).Find(&records).Error; err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return nil
+	}
+	salaryRecord := records[0]
+	if err := checkSalaryRecordEditable(tx, salaryRecord); err != nil {
+		return errors.New(err.Error() + "，请选择其他发放月份")
+	}
+	if err := applyAnnualBonuses(c, tx, salaryRecord, salaryRecordRegularIncome(salaryRecord), salaryRecordDeduction(salaryRecord)); err != nil {
+		return err
+	}
+	return tx.Model(&model.SalaryRecord{}).Where("id = ?", salaryRecord.ID).
+		Updates(map[string]interface{}{
+			"tax":                       salaryRecord.Tax,
+			"gross_income":              salaryRecord.GrossIncome,
+			"special_deduction":         salaryRecord.SpecialDeduction,
+			"cumulative_income":         salaryRecord.CumulativeIncome,
+			"cumulative_deduction":      salaryRecord.CumulativeDeduction,
+			"cumulative_taxable_income": salaryRecord.CumulativeTaxable,
+			"cumulative_tax":            salaryRecord.CumulativeTax,
+			"annual_bonus":              salaryRecord.AnnualBonus,
+			"annual_bonus_tax":          salaryRecord.AnnualBonusTax,
+			"total":                     salaryRecord.Total,
+			"rounding_adjustment":       salaryRecord.RoundingAdjustment,
+		}).Error
+}
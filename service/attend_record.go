@@ -9,7 +9,7 @@ import (
 	"hrms/resource"
 	"log"
 	"math"
-	"strconv"
+	"time"
 )
 
 func CreateAttendanceRecord(c *gin.Context, dto *model.AttendanceRecordCreateDTO) error {
@@ -44,6 +44,9 @@ func UpdateAttendRecordById(c *gin.Context, dto *model.AttendanceRecordEditDTO)
 		Update("staff_id", attentRecord.StaffId).
 		Update("staff_name", attentRecord.StaffName).
 		Update("overtime_days", attentRecord.OvertimeDays).
+		Update("weekday_overtime_hours", attentRecord.WeekdayOvertimeHours).
+		Update("weekend_overtime_hours", attentRecord.WeekendOvertimeHours).
+		Update("holiday_overtime_hours", attentRecord.HolidayOvertimeHours).
 		Update("leave_days", attentRecord.LeaveDays).
 		Update("work_days", attentRecord.WorkDays).
 		Update("date", attentRecord.Date).
@@ -178,34 +181,50 @@ func Compute(c *gin.Context, attendId string) error {
 		if err != nil {
 			return err
 		}
-		// 获取员工工号及当月出勤天数、缺勤天数、加班天数及月份
-		staffId := attendInfo.StaffId
-		workDays := attendInfo.WorkDays
-		leaveDays := attendInfo.LeaveDays
-		overtimeDays := attendInfo.OvertimeDays
-		month := attendInfo.Date
+		// 已生成离职结算的月份由离职结算重新核算
+		var settlementCount int64
+		tx.Model(&model.SalaryRecord{}).Where("staff_id = ? and salary_date = ? and record_type = ?",
+			attendInfo.StaffId, attendInfo.Date, "settlement").Count(&settlementCount)
+		if settlementCount > 0 {
+			return errors.New("该员工当月已生成离职结算，请重新生成离职结算")
+		}
+		// 已发放的薪资记录不再修改，更正后的考勤与已发放金额的差额计入下一个未发放的薪资月份
+		var paidCount int64
+		tx.Model(&model.SalaryRecord{}).Where("staff_id = ? and salary_date = ? and is_pay = 2",
+			attendInfo.StaffId, attendInfo.Date).Count(&paidCount)
+		if paidCount > 0 {
+			_, err := createRetroAdjustments(c, tx, &model.SalaryV2RetroCalculateDTO{
+				StaffId:    attendInfo.StaffId,
+				StartMonth: attendInfo.Date,
+				EndMonth:   attendInfo.Date,
+				Reason:     "考勤更正",
+			}, "system")
+			return err
+		}
+		var existRecords []*model.SalaryRecord
+		tx.Where("staff_id = ? and salary_date = ?", attendInfo.StaffId, attendInfo.Date).Find(&existRecords)
+		if len(existRecords) != 0 {
+			if err := checkSalaryRecordEditable(tx, existRecords[0]); err != nil {
+				return err
+			}
+		} else if err := checkPayrollPeriodOpen(tx, attendInfo.Date); err != nil {
+			return err
+		}
 		// 获取该员工薪资套账
-		salaryInfo, err := getSalaryInfoByStaffId(tx, staffId)
+		salaryInfo, err := getSalaryInfoByStaffId(tx, attendInfo.StaffId)
 		if err != nil {
 			return err
 		}
-		// 获员工姓名、基本薪资、住房补贴、绩效奖金、提成薪资、其他薪资、是否缴纳五险一金
-		staffName := salaryInfo.StaffName
-		base := salaryInfo.Base
-		subsidy := salaryInfo.Subsidy
-		bonus := salaryInfo.Bonus
-		commission := salaryInfo.Commission
-		other := salaryInfo.Other
-		fund := salaryInfo.Fund
 		
 		// 使用V2参数系统计算薪资
-		salaryRecord, err := CalculateSalaryV2(c, tx, staffId, staffName, base, subsidy, bonus, commission, other, fund, workDays, leaveDays, overtimeDays, month)
+		salaryRecord, err := CalculateSalaryV2(c, tx, salaryInfo, attendInfo)
 		if err != nil {
 			return err
 		}
 		
-		// 创建或更新薪资记录
-		affected := tx.Where("staff_id = ? and salary_date = ?", staffId, month).Updates(&salaryRecord).RowsAffected
+		// 创建或更新薪资记录，重新核算时金额为0的项目也需要覆盖
+		affected := tx.Where("staff_id = ? and salary_date = ?", attendInfo.StaffId, attendInfo.Date).
+			Select("*").Omit("id", "created_at", "deleted_at").Updates(&salaryRecord).RowsAffected
 		if affected != 0 {
 			// 已更新记录
 			return nil
@@ -237,59 +256,78 @@ func getAttendInfoByAttendId(tx *gorm.DB, attendId string) (*model.AttendanceRec
 	return records[0], nil
 }
 
-// CalculateSalaryV2 使用V2参数系统计算薪资
-func CalculateSalaryV2(c *gin.Context, tx *gorm.DB, staffId, staffName string, base, subsidy, bonus, commission, other, fund, workDays, leaveDays, overtimeDays int64, month string) (model.SalaryRecord, error) {
+// CalculateSalaryV2 使用V2参数系统，按员工薪资套账及当月考勤计算薪资
+func CalculateSalaryV2(c *gin.Context, tx *gorm.DB, salaryInfo *model.Salary, attendInfo *model.AttendanceRecord) (model.SalaryRecord, error) {
 	var salaryRecord model.SalaryRecord
+	staffId := attendInfo.StaffId
+	staffName := salaryInfo.StaffName
+	month := attendInfo.Date
+	subsidy := salaryInfo.Subsidy
+	commission := salaryInfo.Commission
+	other := salaryInfo.Other
+	fund := salaryInfo.Fund
+	
+	// 系统参数缺失或配置错误的分公司拒绝核算薪资
+	if err := checkPayrollSystemParameters(tx); err != nil {
+		return salaryRecord, err
+	}
 	
 	// 获取系统参数
-	monthlyWorkDays, err := GetSystemParameter(c, "monthly_work_days")
+	monthlyWorkDaysFloat, err := requireSystemParameterFloat(tx, "monthly_work_days")
 	if err != nil {
 		return salaryRecord, err
 	}
 	
-	// 按出勤天数更新基本工资
-	workDaysFloat := float64(workDays)
-	monthlyWorkDaysFloat, _ := strconv.ParseFloat(monthlyWorkDays.ParameterValue, 64)
-	base = int64((float64(base) / monthlyWorkDaysFloat) * workDaysFloat)
+	// 按工作日历及在职区间、调薪、试用期分段折算基本工资
+	base, segments, err := prorateBaseSalary(tx, salaryInfo, attendInfo)
+	if err != nil {
+		return salaryRecord, err
+	}
+	
+	// 计算规则公式变量
+	variables, err := buildFormulaVariables(tx, salaryInfo, attendInfo, monthlyWorkDaysFloat)
+	if err != nil {
+		return salaryRecord, err
+	}
 	
 	// 使用计算规则更新绩效奖金
-	bonus, err = CalculateBonusByRule(c, bonus, leaveDays)
+	bonus, err := CalculateBonusByRule(c, salaryInfo.Bonus, variables)
+	if err != nil {
+		return salaryRecord, err
+	}
+	
+	// 使用计算规则按加班类别计算加班工资，加班工资以月基本工资折算的小时工资为基数
+	overtimeSalary, err := CalculateOvertimeByRule(c, salaryInfo.Base, attendInfo, &salaryRecord, variables)
 	if err != nil {
 		return salaryRecord, err
 	}
 	
-	// 使用计算规则计算加班工资
-	overtimeSalary, err := CalculateOvertimeByRule(c, base, overtimeDays)
+	// 计入当月生效的其他薪资项目
+	items, err := getStaffPayComponentItems(tx, staffId, month)
 	if err != nil {
 		return salaryRecord, err
 	}
+	insurableEarnings := applyPayComponentItems(&salaryRecord, items)
 	
 	// 计算应发工资总额
-	amount := float64(overtimeSalary + base + subsidy + bonus + commission + other)
+	amount := overtimeSalary + base + subsidy + bonus + commission + other
 	
 	// 如果缴纳五险一金，计算个人缴纳部分
+	var insurances []*model.SalaryV2RecordInsurance
 	if fund == 1 {
-		err = CalculateInsuranceDeductions(c, &salaryRecord, amount)
+		insurances, err = CalculateInsuranceDeductions(c, staffId, &salaryRecord, month, amount+insurableEarnings)
 		if err != nil {
 			return salaryRecord, err
 		}
 	}
 	
-	// 计算扣除五险一金后的应纳税所得额
-	taxableAmount := amount - salaryRecord.PensionInsurance - salaryRecord.MedicalInsurance -
-		salaryRecord.UnemploymentInsurance - salaryRecord.HousingFund
-	
-	// 计算个人所得税
-	tax, err := CalculateIncomeTax(c, taxableAmount)
-	if err != nil {
-		return salaryRecord, err
-	}
-	
-	// 计算税后工资
-	total := taxableAmount - tax
-	
-	// 设置薪资记录
+	// 已核算过的月份沿用原薪资记录ID，保持与奖金等发放记录的关联
 	salaryRecord.SalaryRecordId = RandomID("salary_record")
+	var existRecords []*model.SalaryRecord
+	tx.Where("staff_id = ? and salary_date = ?", staffId, month).Find(&existRecords)
+	if len(existRecords) != 0 {
+		salaryRecord.SalaryRecordId = existRecords[0].SalaryRecordId
+	}
 	salaryRecord.StaffId = staffId
 	salaryRecord.StaffName = staffName
 	salaryRecord.Base = base
@@ -298,120 +336,208 @@ func CalculateSalaryV2(c *gin.Context, tx *gorm.DB, staffId, staffName string, b
 	salaryRecord.Commission = commission
 	salaryRecord.Overtime = overtimeSalary
 	salaryRecord.Other = other
-	salaryRecord.Tax = tax
-	salaryRecord.Total = total
 	salaryRecord.IsPay = 1
 	salaryRecord.SalaryDate = month
+	salaryRecord.RecordType = "regular"
+	salaryRecord.Currency = getSystemParameterString(tx, "currency", "CNY")
+	
+	// 计入当月的追溯补发及扣回
+	if err = applyRetroAdjustments(tx, &salaryRecord); err != nil {
+		return salaryRecord, err
+	}
+	
+	// 计入当月发放的全年一次性奖金，按累计预扣法计算个人所得税及税后工资
+	if err = applyAnnualBonuses(c, tx, &salaryRecord, salaryRecordRegularIncome(&salaryRecord), salaryRecordDeduction(&salaryRecord)); err != nil {
+		return salaryRecord, err
+	}
+	
+	// 保存薪资项目及基本工资分段明细
+	if err = saveSalaryRecordItems(tx, salaryRecord.SalaryRecordId, items); err != nil {
+		return salaryRecord, err
+	}
+	if err = saveSalaryRecordSegments(tx, salaryRecord.SalaryRecordId, segments); err != nil {
+		return salaryRecord, err
+	}
+	if err = saveSalaryRecordInsurances(tx, &salaryRecord, insurances); err != nil {
+		return salaryRecord, err
+	}
 	
 	return salaryRecord, nil
 }
 
-// CalculateBonusByRule 根据计算规则计算绩效奖金
-func CalculateBonusByRule(c *gin.Context, originalBonus, leaveDays int64) (int64, error) {
-	// 获取绩效奖金计算规则
-	rules, err := GetCalculationRulesByType(c, "leave")
+// buildFormulaVariables 根据员工薪资套账、当月考勤、职级及司龄构造计算规则公式变量
+func buildFormulaVariables(tx *gorm.DB, salaryInfo *model.Salary, attendInfo *model.AttendanceRecord, monthlyWorkDays float64) (map[string]float64, error) {
+	var staff model.Staff
+	if err := tx.Where("staff_id = ?", attendInfo.StaffId).First(&staff).Error; err != nil {
+		return nil, errors.New("不存在该员工")
+	}
+	var rank model.Rank
+	tx.Where("rank_id = ?", staff.RankId).Find(&rank)
+	expectedWorkDays, err := getStaffExpectedWorkDays(tx, staff.StaffId, attendInfo.Date)
 	if err != nil {
-		return originalBonus, err
+		return nil, err
 	}
 	
-	// 查找事假扣款规则
-	for _, rule := range rules {
-		if rule.RuleName == "事假扣款计算" {
-			// 简化处理：每缺勤一天扣1/5，超过5天全扣
-			if leaveDays > 5 {
-				return 0, nil
-			}
-			x := float64(5-leaveDays) / 5.0
-			return int64(float64(originalBonus) * x), nil
+	return map[string]float64{
+		"base":                   salaryInfo.Base.Float64(),
+		"bonus":                  salaryInfo.Bonus.Float64(),
+		"subsidy":                salaryInfo.Subsidy.Float64(),
+		"commission":             salaryInfo.Commission.Float64(),
+		"work_days":              float64(attendInfo.WorkDays),
+		"leave_days":             float64(attendInfo.LeaveDays),
+		"overtime_days":          float64(attendInfo.OvertimeDays),
+		"overtime_hours":         attendInfo.WeekdayOvertimeHours + attendInfo.WeekendOvertimeHours + attendInfo.HolidayOvertimeHours,
+		"weekday_overtime_hours": attendInfo.WeekdayOvertimeHours,
+		"weekend_overtime_hours": attendInfo.WeekendOvertimeHours,
+		"holiday_overtime_hours": attendInfo.HolidayOvertimeHours,
+		"monthly_work_days":      monthlyWorkDays,
+		"expected_work_days":     float64(expectedWorkDays),
+		"rank":                   float64(rank.RankLevel),
+		"tenure":                 tenureYears(staff.EntryDate, attendInfo.Date),
+	}, nil
+}
+
+// tenureYears 计算截至薪资月份月末的司龄(年)，按整月折算保留两位小数
+func tenureYears(entryDate string, month string) float64 {
+	if len(entryDate) < 7 {
+		return 0
+	}
+	entry, err := time.Parse("2006-01", entryDate[:7])
+	if err != nil {
+		return 0
+	}
+	current, err := time.Parse("2006-01", month)
+	if err != nil {
+		return 0
+	}
+	months := (current.Year()-entry.Year())*12 + int(current.Month()-entry.Month()) + 1
+	if months <= 0 {
+		return 0
+	}
+	return math.Round(float64(months)/12*100) / 100
+}
+
+// CalculateBonusByRule 根据计算规则(bonus_leave_ratio)计算绩效奖金发放系数并更新绩效奖金
+func CalculateBonusByRule(c *gin.Context, originalBonus model.Money, variables map[string]float64) (model.Money, error) {
+	rule, err := GetCalculationRuleByCode(c, "bonus_leave_ratio")
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return originalBonus, err
 		}
+		// 如果没有配置规则，使用默认逻辑：每缺勤一天扣1/5，超过5天全扣
+		leaveDays := variables["leave_days"]
+		if leaveDays > 5 {
+			return 0, nil
+		}
+		x := (5 - leaveDays) / 5.0
+		return originalBonus.Mul(x), nil
 	}
 	
-	// 如果没有找到规则，使用默认逻辑
-	if leaveDays > 5 {
-		return 0, nil
+	ratio, err := EvaluateCalculationRule(rule, variables)
+	if err != nil {
+		return originalBonus, err
 	}
-	x := float64(5-leaveDays) / 5.0
-	return int64(float64(originalBonus) * x), nil
+	return originalBonus.Mul(math.Max(0, ratio)), nil
 }
 
-// CalculateOvertimeByRule 根据计算规则计算加班工资
-func CalculateOvertimeByRule(c *gin.Context, base, overtimeDays int64) (int64, error) {
-	if overtimeDays == 0 {
+// overtimeCategories 加班类别对应的计算规则编码
+var overtimeCategories = []struct {
+	RuleCode string
+	Name     string
+}{
+	{"overtime_weekday", "工作日加班"},
+	{"overtime_weekend", "休息日加班"},
+	{"overtime_holiday", "法定节假日加班"},
+}
+
+// CalculateOvertimeByRule 根据计算规则按加班类别及时长计算加班工资，分类时长及工资写入薪资记录
+func CalculateOvertimeByRule(c *gin.Context, base model.Money, attendInfo *model.AttendanceRecord, salaryRecord *model.SalaryRecord, variables map[string]float64) (model.Money, error) {
+	hours := []float64{attendInfo.WeekdayOvertimeHours, attendInfo.WeekendOvertimeHours, attendInfo.HolidayOvertimeHours}
+	if hours[0] == 0 && hours[1] == 0 && hours[2] == 0 && attendInfo.OvertimeDays == 0 {
 		return 0, nil
 	}
 	
-	// 获取月工作日数
-	monthlyWorkDays, err := GetSystemParameter(c, "monthly_work_days")
+	// 获取月工作日数及每日工作小时数
+	monthlyWorkDaysFloat, err := requireSystemParameterFloat(resource.HrmsDB(c), "monthly_work_days")
 	if err != nil {
 		return 0, err
 	}
-	
-	monthlyWorkDaysFloat, _ := strconv.ParseFloat(monthlyWorkDays.ParameterValue, 64)
-	dailyRate := float64(base) / monthlyWorkDaysFloat
-	
-	// 获取加班计算规则
-	rules, err := GetCalculationRulesByType(c, "overtime")
+	dailyWorkHoursFloat, err := requireSystemParameterFloat(resource.HrmsDB(c), "daily_work_hours")
 	if err != nil {
 		return 0, err
 	}
+	hourlyRate := base.Float64() / monthlyWorkDaysFloat / dailyWorkHoursFloat
 	
-	// 默认使用工作日加班规则（1.5倍）
-	multiplier := 1.5
+	// 兼容只登记加班天数的历史考勤数据，按工作日加班折算时长
+	if hours[0] == 0 && hours[1] == 0 && hours[2] == 0 {
+		hours[0] = float64(attendInfo.OvertimeDays) * dailyWorkHoursFloat
+	}
 	
-	// 查找加班规则，这里简化处理，实际应该根据加班类型选择
-	for _, rule := range rules {
-		if rule.RuleName == "工作日加班计算" {
-			multiplier = 1.5
-			break
-		} else if rule.RuleName == "周末加班计算" {
-			multiplier = 2.0
-			break
-		} else if rule.RuleName == "法定节假日加班计算" {
-			multiplier = 3.0
-			break
+	pays := make([]model.Money, len(overtimeCategories))
+	var total model.Money
+	for i, category := range overtimeCategories {
+		if hours[i] <= 0 {
+			continue
 		}
+		rule, err := GetCalculationRuleByCode(c, category.RuleCode)
+		if err != nil {
+			return 0, errors.New("未配置" + category.Name + "计算规则: " + category.RuleCode)
+		}
+		multiplier, err := EvaluateCalculationRule(rule, variables)
+		if err != nil {
+			return 0, err
+		}
+		pays[i] = model.NewMoney(hourlyRate * multiplier * hours[i])
+		total += pays[i]
 	}
 	
-	overtimeSalary := int64(dailyRate * multiplier * float64(overtimeDays))
-	return overtimeSalary, nil
+	salaryRecord.WeekdayOvertimeHours = hours[0]
+	salaryRecord.WeekendOvertimeHours = hours[1]
+	salaryRecord.HolidayOvertimeHours = hours[2]
+	salaryRecord.WeekdayOvertimePay = pays[0]
+	salaryRecord.WeekendOvertimePay = pays[1]
+	salaryRecord.HolidayOvertimePay = pays[2]
+	return total, nil
 }
 
 // CalculateInsuranceDeductions 计算五险一金扣除
-func CalculateInsuranceDeductions(c *gin.Context, salaryRecord *model.SalaryRecord, amount float64) error {
-	// 获取社保费率配置
-	rates, err := GetInsuranceRates(c)
+// 缴费基数按费率配置的上下限调整，返回各险种个人及单位缴费明细
+func CalculateInsuranceDeductions(c *gin.Context, staffId string, salaryRecord *model.SalaryRecord, month string, amount model.Money) ([]*model.SalaryV2RecordInsurance, error) {
+	// 获取员工当月适用的社保费率，有参保方案时按方案所在城市的费率及缴费基数上下限计算
+	policyId, rates, err := getStaffInsuranceRates(resource.HrmsDB(c), staffId, month)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	
 	// 计算各项保险和公积金
+	var details []*model.SalaryV2RecordInsurance
 	for _, rate := range rates {
-		personalRate := rate.EmployeeRate / 100.0
+		detail := calculateInsuranceContribution(rate, amount)
+		detail.PolicyId = policyId
 		switch rate.InsuranceType {
 		case "pension":
-			salaryRecord.PensionInsurance = amount * personalRate
+			salaryRecord.PensionInsurance = detail.EmployeeAmount
 		case "medical":
-			salaryRecord.MedicalInsurance = amount * personalRate
+			salaryRecord.MedicalInsurance = detail.EmployeeAmount
 		case "unemployment":
-			salaryRecord.UnemploymentInsurance = amount * personalRate
+			salaryRecord.UnemploymentInsurance = detail.EmployeeAmount
 		case "housing":
-			salaryRecord.HousingFund = amount * personalRate
+			salaryRecord.HousingFund = detail.EmployeeAmount
 		}
+		details = append(details, detail)
 	}
 	
-	return nil
+	return details, nil
 }
 
-// CalculateIncomeTax 计算个人所得税
-func CalculateIncomeTax(c *gin.Context, amount float64) (float64, error) {
+// CalculateIncomeTax 按月度税率表计算个人所得税
+func CalculateIncomeTax(c *gin.Context, amount model.Money) (model.Money, error) {
 	// 获取个税起征点
-	taxThreshold, err := GetSystemParameter(c, "tax_threshold")
+	threshold, err := requireSystemParameterMoney(resource.HrmsDB(c), "tax_threshold")
 	if err != nil {
 		return 0, err
 	}
 	
-	threshold, _ := strconv.ParseFloat(taxThreshold.ParameterValue, 64)
-	
 	// 如果应纳税所得额小于等于起征点，不征税
 	if amount <= threshold {
 		return 0, nil
@@ -423,26 +549,6 @@ func CalculateIncomeTax(c *gin.Context, amount float64) (float64, error) {
 		return 0, err
 	}
 	
-	// 计算应纳税所得额
-	taxableAmount := amount - threshold
-	
 	// 根据税率配置计算税额
-	for _, bracket := range taxBrackets {
-		if taxableAmount >= float64(bracket.MinIncome) && (bracket.MaxIncome == 0 || taxableAmount <= float64(bracket.MaxIncome)) {
-			taxRate := bracket.TaxRate / 100.0
-			tax := taxableAmount*taxRate - float64(bracket.QuickDeduction)
-			return math.Max(0, tax), nil
-		}
-	}
-	
-	// 如果超过所有税率区间，使用最高税率
-	for _, bracket := range taxBrackets {
-		if bracket.MaxIncome == 0 {
-			taxRate := bracket.TaxRate / 100.0
-			tax := taxableAmount*taxRate - float64(bracket.QuickDeduction)
-			return math.Max(0, tax), nil
-		}
-	}
-	
-	return 0, nil
+	return calculateTaxByBrackets(taxBrackets, amount-threshold), nil
 }
@@ -28,6 +28,9 @@ func AutoUpdateAttendanceReports() {
 		// 格式化日期
 		monthStr := fmt.Sprintf("%d-%02d", year, month)
 		
+		// 按分公司工作日历及员工排班统计应出勤天数及加班类别
+		calendar := loadWorkCalendar(db)
+		
 		// 获取所有员工
 		var staffs []model.Staff
 		if err := db.Where("status != 2").Find(&staffs).Error; err != nil {
@@ -37,7 +40,7 @@ func AutoUpdateAttendanceReports() {
 		
 		// 为每个员工计算考勤数据
 		for _, staff := range staffs {
-			attendanceData, err := calculateAttendanceData(db, staff.StaffId, firstDay, lastDay)
+			attendanceData, err := calculateAttendanceData(db, calendar, staff.StaffId, firstDay, lastDay)
 			if err != nil {
 				log.Printf("计算员工 %s 考勤数据失败: %v", staff.StaffName, err)
 				continue
@@ -52,6 +55,12 @@ func AutoUpdateAttendanceReports() {
 				existingRecord.WorkDays = attendanceData.WorkDays
 				existingRecord.LeaveDays = attendanceData.LeaveDays
 				existingRecord.OvertimeDays = attendanceData.OvertimeDays
+				existingRecord.WeekdayOvertimeHours = attendanceData.WeekdayOvertimeHours
+				existingRecord.WeekendOvertimeHours = attendanceData.WeekendOvertimeHours
+				existingRecord.HolidayOvertimeHours = attendanceData.HolidayOvertimeHours
+				existingRecord.ExpectedWorkDays = attendanceData.ExpectedWorkDays
+				existingRecord.LateCount = attendanceData.LateCount
+				existingRecord.EarlyLeaveCount = attendanceData.EarlyLeaveCount
 				existingRecord.Approve = 1 // 自动批准
 				
 				if err := db.Save(&existingRecord).Error; err != nil {
@@ -70,6 +79,12 @@ func AutoUpdateAttendanceReports() {
 					LeaveDays:    attendanceData.LeaveDays,
 					OvertimeDays: attendanceData.OvertimeDays,
 					Approve:      1, // 自动批准
+					WeekdayOvertimeHours: attendanceData.WeekdayOvertimeHours,
+					WeekendOvertimeHours: attendanceData.WeekendOvertimeHours,
+					HolidayOvertimeHours: attendanceData.HolidayOvertimeHours,
+					ExpectedWorkDays:     attendanceData.ExpectedWorkDays,
+					LateCount:            attendanceData.LateCount,
+					EarlyLeaveCount:      attendanceData.EarlyLeaveCount,
 				}
 				
 				if err := db.Create(&newRecord).Error; err != nil {
@@ -86,14 +101,20 @@ func AutoUpdateAttendanceReports() {
 	}
 }
 
-// calculateAttendanceData 计算员工考勤数据
-func calculateAttendanceData(db *gorm.DB, staffId string, firstDay, lastDay time.Time) (*model.AttendanceRecordCreateDTO, error) {
+// calculateAttendanceData 按员工排班计算考勤数据，出勤及请假天数只统计应出勤日，未排班的日期按工作日历计算
+func calculateAttendanceData(db *gorm.DB, calendar *workCalendar, staffId string, firstDay, lastDay time.Time) (*model.AttendanceRecord, error) {
+	roster, err := loadStaffRoster(db, calendar, staffId)
+	if err != nil {
+		return nil, err
+	}
+	
 	// 初始化考勤数据
-	attendanceData := &model.AttendanceRecordCreateDTO{
-		StaffId:      staffId,
-		WorkDays:     0,
-		LeaveDays:    0,
-		OvertimeDays: 0,
+	attendanceData := &model.AttendanceRecord{
+		StaffId:          staffId,
+		WorkDays:         0,
+		LeaveDays:        0,
+		OvertimeDays:     0,
+		ExpectedWorkDays: roster.WorkingDays(firstDay, lastDay),
 	}
 	
 	// 计算工作天数（基于打卡记录）
@@ -107,17 +128,25 @@ func calculateAttendanceData(db *gorm.DB, staffId string, firstDay, lastDay time
 	// 统计有效工作日
 	for _, clockIn := range clockIns {
 		if clockIn.CheckInTime != nil && clockIn.CheckOutTime != nil {
-			attendanceData.WorkDays++
-			
-			// 简单的加班判断：如果下班时间超过18:30，算加班
-			checkOutTime := *clockIn.CheckOutTime
-			if len(checkOutTime) >= 5 {
-				hour := int(checkOutTime[0]-'0')*10 + int(checkOutTime[1]-'0')
-				minute := int(checkOutTime[3]-'0')*10 + int(checkOutTime[4]-'0')
-				if hour > 18 || (hour == 18 && minute > 30) {
-					attendanceData.OvertimeDays++
+			evaluation := roster.EvaluateClockIn(&clockIn)
+			// 休息日及法定节假日出勤不计出勤天数，全部计为加班
+			if day, err := time.ParseInLocation("2006-01-02", clockIn.Date, time.Local); err != nil || roster.IsWorkingDay(day) {
+				attendanceData.WorkDays++
+				if evaluation.Late {
+					attendanceData.LateCount++
+				}
+				if evaluation.EarlyLeave {
+					attendanceData.EarlyLeaveCount++
 				}
 			}
+			
+			// 按工作日、休息日、法定节假日分别统计加班时长，超出班次下班时间的出勤计为加班
+			attendanceData.WeekdayOvertimeHours += evaluation.WeekdayHours
+			attendanceData.WeekendOvertimeHours += evaluation.WeekendHours
+			attendanceData.HolidayOvertimeHours += evaluation.HolidayHours
+			if evaluation.OvertimeHours() > 0 {
+				attendanceData.OvertimeDays++
+			}
 		}
 	}
 	
@@ -147,8 +176,7 @@ func calculateAttendanceData(db *gorm.DB, staffId string, firstDay, lastDay time
 		}
 		
 		if !leaveEnd.Before(leaveStart) {
-			days := int(leaveEnd.Sub(leaveStart).Hours()/24) + 1
-			attendanceData.LeaveDays += int64(days)
+			attendanceData.LeaveDays += roster.WorkingDays(leaveStart, leaveEnd)
 		}
 	}
 	
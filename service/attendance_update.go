@@ -2,9 +2,12 @@ package service
 
 import (
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 	"hrms/model"
 	"hrms/resource"
 	"log"
+	"math"
+	"strconv"
 	"time"
 )
 
@@ -20,20 +23,41 @@ func UpdateAttendanceRecordFromClockIn(c *gin.Context, staffId string, date stri
 	var workDays int64 = 0
 	var leaveDays int64 = 0
 	var overtimeDays int64 = 0
+	var lateCount, earlyLeaveCount int64
+	var weekdayOvertimeHours, weekendOvertimeHours, holidayOvertimeHours float64
+	first, last, err := monthRange(month)
+	if err != nil {
+		return err
+	}
+	// 按员工排班考勤，未排班的日期按工作日历及每日标准工时计算
+	roster, err := loadStaffRoster(resource.HrmsDB(c), loadWorkCalendar(resource.HrmsDB(c)), staffId)
+	if err != nil {
+		return err
+	}
+	expectedWorkDays := roster.WorkingDays(first, last)
 	
 	for _, clockIn := range clockIns {
+		evaluation := roster.EvaluateClockIn(clockIn)
+		weekdayOvertimeHours += evaluation.WeekdayHours
+		weekendOvertimeHours += evaluation.WeekendHours
+		holidayOvertimeHours += evaluation.HolidayHours
+		if evaluation.OvertimeHours() > 0 {
+			overtimeDays++
+		}
+		// 出勤、请假及迟到早退只统计应出勤日，休息日及节假日出勤计为加班
+		if day, err := time.ParseInLocation("2006-01-02", clockIn.Date, time.Local); err == nil && !roster.IsWorkingDay(day) {
+			continue
+		}
 		if clockIn.Status == 0 {
 			workDays++
 		} else if clockIn.Status == 3 {
 			leaveDays++
 		}
-		if clockIn.CheckOutTime != nil {
-			checkInTime, _ := time.Parse("2006-01-02 15:04:05", *clockIn.CheckInTime)
-			checkOutTime, _ := time.Parse("2006-01-02 15:04:05", *clockIn.CheckOutTime)
-			hours := checkOutTime.Sub(checkInTime).Hours()
-			if hours > 9 {
-				overtimeDays++
-			}
+		if evaluation.Late {
+			lateCount++
+		}
+		if evaluation.EarlyLeave {
+			earlyLeaveCount++
 		}
 	}
 	
@@ -44,6 +68,12 @@ func UpdateAttendanceRecordFromClockIn(c *gin.Context, staffId string, date stri
 		attendance.WorkDays = workDays
 		attendance.LeaveDays = leaveDays
 		attendance.OvertimeDays = overtimeDays
+		attendance.WeekdayOvertimeHours = weekdayOvertimeHours
+		attendance.WeekendOvertimeHours = weekendOvertimeHours
+		attendance.HolidayOvertimeHours = holidayOvertimeHours
+		attendance.ExpectedWorkDays = expectedWorkDays
+		attendance.LateCount = lateCount
+		attendance.EarlyLeaveCount = earlyLeaveCount
 		attendance.Approve = 0
 		resource.HrmsDB(c).Save(&attendance)
 	} else {
@@ -55,6 +85,12 @@ func UpdateAttendanceRecordFromClockIn(c *gin.Context, staffId string, date stri
 			LeaveDays:    leaveDays,
 			OvertimeDays: overtimeDays,
 			Approve:      0,
+			WeekdayOvertimeHours: weekdayOvertimeHours,
+			WeekendOvertimeHours: weekendOvertimeHours,
+			HolidayOvertimeHours: holidayOvertimeHours,
+			ExpectedWorkDays:     expectedWorkDays,
+			LateCount:            lateCount,
+			EarlyLeaveCount:      earlyLeaveCount,
 		}
 		if err := resource.HrmsDB(c).Create(&newAttendance).Error; err != nil {
 			log.Printf("UpdateAttendanceRecordFromClockIn err = %v", err)
@@ -63,4 +99,53 @@ func UpdateAttendanceRecordFromClockIn(c *gin.Context, staffId string, date stri
 	}
 	
 	return nil
-}
\ No newline at end of file
+}
+
+// clockInOvertimeHours 根据打卡记录及工作日历计算工作日、休息日、法定节假日加班时长，
+// 休息日及法定节假日出勤全部计为加班，工作日超出标准工时及1小时午休的部分计为工作日加班，按半小时向下取整
+func clockInOvertimeHours(clockIn *model.ClockIn, dailyWorkHours float64, calendar *workCalendar) (float64, float64, float64) {
+	if clockIn.CheckInTime == nil || clockIn.CheckOutTime == nil {
+		return 0, 0, 0
+	}
+	checkIn, ok1 := parseClockTime(clockIn.Date, *clockIn.CheckInTime)
+	checkOut, ok2 := parseClockTime(clockIn.Date, *clockIn.CheckOutTime)
+	if !ok1 || !ok2 || !checkOut.After(checkIn) {
+		return 0, 0, 0
+	}
+	hours := checkOut.Sub(checkIn).Hours()
+	switch calendar.OvertimeCategory(checkIn) {
+	case "holiday":
+		return 0, 0, math.Floor(hours*2) / 2
+	case "weekend":
+		return 0, math.Floor(hours*2) / 2, 0
+	}
+	extra := hours - dailyWorkHours - 1
+	if extra <= 0 {
+		return 0, 0, 0
+	}
+	return math.Floor(extra*2) / 2, 0, 0
+}
+
+// parseClockTime 解析打卡时间，兼容完整时间及仅含时分(秒)的格式
+func parseClockTime(date, clockTime string) (time.Time, bool) {
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", clockTime, time.Local); err == nil {
+		return t, true
+	}
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		if t, err := time.ParseInLocation(layout, date+" "+clockTime, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// getDailyWorkHours 获取每日标准工作小时数，未配置时默认8小时
+func getDailyWorkHours(db *gorm.DB) float64 {
+	var parameter model.SalaryV2SystemParameter
+	if err := db.Where("parameter_key = ? and is_active = ?", "daily_work_hours", true).First(&parameter).Error; err == nil {
+		if hours, err := strconv.ParseFloat(parameter.ParameterValue, 64); err == nil && hours > 0 {
+			return hours
+		}
+	}
+	return 8
+}
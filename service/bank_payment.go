@@ -0,0 +1,665 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SaveBankLayoutV2 保存银行代发文件格式，同一银行已存在格式时覆盖
+func SaveBankLayoutV2(c *gin.Context, dto *model.SalaryV2BankLayoutCreateDTO, operator string) error {
+	var layout model.SalaryV2BankLayout
+	Transfer(&dto, &layout)
+	if layout.Delimiter == "" {
+		layout.Delimiter = ","
+	}
+	if layout.ReturnDelimiter == "" {
+		layout.ReturnDelimiter = ","
+	}
+	if layout.AmountUnit == "" {
+		layout.AmountUnit = "yuan"
+	}
+	if layout.ReturnRefField == "" {
+		layout.ReturnRefField = "line_no"
+	}
+	if err := validateBankLayout(&layout); err != nil {
+		return err
+	}
+
+	var exists []*model.SalaryV2BankLayout
+	resource.HrmsDB(c).Where("bank_code = ?", layout.BankCode).Find(&exists)
+	layout.IsActive = true
+	layout.UpdatedBy = operator
+	if len(exists) != 0 {
+		layout.ID = exists[0].ID
+		layout.LayoutId = exists[0].LayoutId
+		layout.CreatedBy = exists[0].CreatedBy
+		layout.CreatedAt = exists[0].CreatedAt
+		if err := resource.HrmsDB(c).Save(&layout).Error; err != nil {
+			log.Printf("SaveBankLayoutV2 err = %v", err)
+			return err
+		}
+		return nil
+	}
+	layout.LayoutId = RandomID("bank_layout")
+	layout.CreatedBy = operator
+	if err := resource.HrmsDB(c).Create(&layout).Error; err != nil {
+		log.Printf("SaveBankLayoutV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetBankLayoutsV2 查询启用的银行代发文件格式
+func GetBankLayoutsV2(c *gin.Context) ([]*model.SalaryV2BankLayout, error) {
+	var layouts []*model.SalaryV2BankLayout
+	if err := resource.HrmsDB(c).Where("is_active = ?", true).Order("bank_code asc").Find(&layouts).Error; err != nil {
+		log.Printf("GetBankLayoutsV2 err = %v", err)
+		return nil, err
+	}
+	return layouts, nil
+}
+
+// DeleteBankLayoutV2 停用银行代发文件格式
+func DeleteBankLayoutV2(c *gin.Context, layoutId string, operator string) error {
+	result := resource.HrmsDB(c).Model(&model.SalaryV2BankLayout{}).Where("layout_id = ? and is_active = ?", layoutId, true).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": operator,
+		})
+	if result.Error != nil {
+		log.Printf("DeleteBankLayoutV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该代发文件格式")
+	}
+	return nil
+}
+
+func validateBankLayout(layout *model.SalaryV2BankLayout) error {
+	if layout.FileFormat != "csv" && layout.FileFormat != "fixed" {
+		return errors.New("不支持的代发文件格式: " + layout.FileFormat)
+	}
+	if layout.AmountUnit != "yuan" && layout.AmountUnit != "cents" {
+		return errors.New("不支持的金额单位: " + layout.AmountUnit)
+	}
+	if layout.ReturnRefField != "line_no" && layout.ReturnRefField != "line_id" {
+		return errors.New("不支持的回盘关联字段: " + layout.ReturnRefField)
+	}
+	if utf8.RuneCountInString(layout.Delimiter) != 1 || utf8.RuneCountInString(layout.ReturnDelimiter) != 1 {
+		return errors.New("分隔符须为单个字符")
+	}
+	if layout.ReturnRefColumn < 0 || layout.ReturnStatusColumn < 0 {
+		return errors.New("回盘文件关联列及状态列不能为负数")
+	}
+	fields, err := parseBankLayoutFields(layout)
+	if err != nil {
+		return err
+	}
+	hasCard, hasAmount := false, false
+	for _, field := range fields {
+		switch field.Field {
+		case "card_num":
+			hasCard = true
+		case "amount":
+			hasAmount = true
+		case "line_no", "line_id", "batch_id", "staff_id", "staff_name", "salary_date", "literal":
+		default:
+			return errors.New("不支持的代发文件字段: " + field.Field)
+		}
+		if layout.FileFormat == "fixed" && field.Width <= 0 {
+			return errors.New("定长文件字段须配置长度: " + field.Field)
+		}
+	}
+	if !hasCard || !hasAmount {
+		return errors.New("代发文件须包含银行卡号及金额字段")
+	}
+	return nil
+}
+
+func parseBankLayoutFields(layout *model.SalaryV2BankLayout) ([]*model.SalaryV2BankLayoutField, error) {
+	var fields []*model.SalaryV2BankLayoutField
+	if err := json.Unmarshal([]byte(layout.Fields), &fields); err != nil {
+		return nil, errors.New("代发文件字段配置格式错误: " + err.Error())
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("代发文件字段配置不能为空")
+	}
+	return fields, nil
+}
+
+// getBankLayout 获取银行代发文件格式，未指定银行时使用系统参数 default_bank_code 配置的默认代发银行
+func getBankLayout(tx *gorm.DB, bankCode string) (*model.SalaryV2BankLayout, error) {
+	if bankCode == "" {
+		bankCode = getSystemParameterString(tx, "default_bank_code", "")
+		if bankCode == "" {
+			return nil, errors.New("未配置默认代发银行(default_bank_code)")
+		}
+	}
+	var layout model.SalaryV2BankLayout
+	if err := tx.Where("bank_code = ? and is_active = ?", bankCode, true).First(&layout).Error; err != nil {
+		return nil, errors.New("未配置该银行的代发文件格式: " + bankCode)
+	}
+	return &layout, nil
+}
+
+// PaySalaryRecordById 为单条薪资记录生成默认代发银行的代发批次，银行回盘确认成功后标记为已发放
+func PaySalaryRecordById(c *gin.Context, id int64, operator string) (*model.SalaryV2PaymentBatch, error) {
+	var batch *model.SalaryV2PaymentBatch
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var records []*model.SalaryRecord
+		tx.Where("id = ?", id).Find(&records)
+		if len(records) == 0 {
+			return errors.New("不存在该薪资记录")
+		}
+		layout, err := getBankLayout(tx, "")
+		if err != nil {
+			return err
+		}
+		batch, err = createPaymentBatch(tx, layout, records[0].SalaryDate, records, operator)
+		return err
+	})
+	if err != nil {
+		log.Printf("PaySalaryRecordById err = %v", err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// CreatePaymentBatchV2 为指定月份未发放的薪资记录批量生成代发批次
+func CreatePaymentBatchV2(c *gin.Context, dto *model.SalaryV2PaymentBatchCreateDTO, operator string) (*model.SalaryV2PaymentBatch, error) {
+	var batch *model.SalaryV2PaymentBatch
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		layout, err := getBankLayout(tx, dto.BankCode)
+		if err != nil {
+			return err
+		}
+		query := tx.Where("salary_date = ? and is_pay = 1", dto.SalaryDate)
+		if len(dto.StaffIds) != 0 {
+			query = query.Where("staff_id in ?", dto.StaffIds)
+		}
+		var records []*model.SalaryRecord
+		if err := query.Order("staff_id asc").Find(&records).Error; err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return errors.New("没有待发放的薪资记录")
+		}
+		batch, err = createPaymentBatch(tx, layout, dto.SalaryDate, records, operator)
+		return err
+	})
+	if err != nil {
+		log.Printf("CreatePaymentBatchV2 err = %v", err)
+		return nil, err
+	}
+	return batch, nil
+}
+
+// createPaymentBatch 生成代发批次、明细及代发文件，薪资记录标记为银行处理中；
+// 实发工资不大于0的薪资记录无需银行代发，直接标记为已发放，全部无需代发时返回nil批次
+func createPaymentBatch(tx *gorm.DB, layout *model.SalaryV2BankLayout, salaryDate string, records []*model.SalaryRecord, operator string) (*model.SalaryV2PaymentBatch, error) {
+	fields, err := parseBankLayoutFields(layout)
+	if err != nil {
+		return nil, err
+	}
+	batch := &model.SalaryV2PaymentBatch{
+		BatchId:    RandomID("pay_batch"),
+		BankCode:   layout.BankCode,
+		LayoutId:   layout.LayoutId,
+		SalaryDate: salaryDate,
+		Status:     "generated",
+		CreatedBy:  operator,
+		UpdatedBy:  operator,
+	}
+
+	var lines []*model.SalaryV2PaymentLine
+	for _, record := range records {
+		if record.IsPay != 1 {
+			return nil, errors.New(record.StaffName + "的薪资记录已发放或正在银行处理中")
+		}
+		if record.Total <= 0 {
+			result := tx.Model(&model.SalaryRecord{}).Where("salary_record_id = ? and is_pay = 1", record.SalaryRecordId).Update("is_pay", 2)
+			if result.Error != nil {
+				return nil, result.Error
+			}
+			if result.RowsAffected == 0 {
+				return nil, errors.New(record.StaffName + "的薪资记录已发放或正在银行处理中")
+			}
+			continue
+		}
+		var staff model.Staff
+		if err := tx.Where("staff_id = ?", record.StaffId).First(&staff).Error; err != nil {
+			return nil, errors.New("不存在该员工: " + record.StaffId)
+		}
+		if strings.TrimSpace(staff.CardNum) == "" {
+			return nil, errors.New(record.StaffName + "未登记银行卡号")
+		}
+		lines = append(lines, &model.SalaryV2PaymentLine{
+			LineId:         RandomID("pay_line"),
+			BatchId:        batch.BatchId,
+			LineNo:         int64(len(lines) + 1),
+			SalaryRecordId: record.SalaryRecordId,
+			StaffId:        record.StaffId,
+			StaffName:      record.StaffName,
+			CardNum:        strings.TrimSpace(staff.CardNum),
+			Amount:         record.Total,
+			Status:         "pending",
+		})
+		batch.TotalAmount += record.Total
+	}
+	if len(lines) == 0 {
+		return nil, nil
+	}
+	batch.LineCount = int64(len(lines))
+
+	content, checksum, err := buildPaymentFile(layout, fields, batch, lines)
+	if err != nil {
+		return nil, err
+	}
+	batch.FileContent = content
+	batch.Checksum = checksum
+	extension := "txt"
+	if layout.FileFormat == "csv" {
+		extension = "csv"
+	}
+	batch.FileName = fmt.Sprintf("%s_%s_%s.%s", layout.BankCode, salaryDate, batch.BatchId, extension)
+
+	if err := tx.Create(batch).Error; err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if err := tx.Create(line).Error; err != nil {
+			return nil, err
+		}
+		result := tx.Model(&model.SalaryRecord{}).Where("salary_record_id = ? and is_pay = 1", line.SalaryRecordId).Update("is_pay", 3)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil, errors.New(line.StaffName + "的薪资记录已发放或正在银行处理中")
+		}
+	}
+	return batch, nil
+}
+
+// buildPaymentFile 按代发文件格式生成文件内容，校验码为明细行的SHA-256摘要，配置汇总行时写入汇总行
+func buildPaymentFile(layout *model.SalaryV2BankLayout, fields []*model.SalaryV2BankLayoutField, batch *model.SalaryV2PaymentBatch, lines []*model.SalaryV2PaymentLine) (string, string, error) {
+	var details []string
+	for _, line := range lines {
+		var values []string
+		for _, field := range fields {
+			values = append(values, paymentFieldValue(layout, field, batch, line))
+		}
+		detail, err := formatPaymentRow(layout, fields, values)
+		if err != nil {
+			return "", "", err
+		}
+		details = append(details, detail)
+	}
+	digest := sha256.Sum256([]byte(strings.Join(details, "\r\n")))
+	checksum := hex.EncodeToString(digest[:])
+
+	var rows []string
+	if layout.FileFormat == "csv" && layout.HasHeader {
+		var titles []string
+		for _, field := range fields {
+			title := field.Title
+			if title == "" {
+				title = field.Field
+			}
+			titles = append(titles, title)
+		}
+		header, err := formatPaymentRow(layout, nil, titles)
+		if err != nil {
+			return "", "", err
+		}
+		rows = append(rows, header)
+	}
+	rows = append(rows, details...)
+	if layout.HasTrailer {
+		count := strconv.FormatInt(batch.LineCount, 10)
+		total := formatPaymentAmount(layout, batch.TotalAmount)
+		if layout.FileFormat == "csv" {
+			trailer, err := formatPaymentRow(layout, nil, []string{"T", batch.BatchId, count, total, checksum})
+			if err != nil {
+				return "", "", err
+			}
+			rows = append(rows, trailer)
+		} else {
+			rows = append(rows, "T"+padPaymentField(count, 8, "right", "0")+padPaymentField(total, 18, "right", "0")+checksum)
+		}
+	}
+	return strings.Join(rows, "\r\n") + "\r\n", checksum, nil
+}
+
+// formatPaymentRow 生成一行代发文件内容，csv按分隔符输出，定长文件按字段长度补齐或截断
+func formatPaymentRow(layout *model.SalaryV2BankLayout, fields []*model.SalaryV2BankLayoutField, values []string) (string, error) {
+	if layout.FileFormat == "fixed" {
+		var row strings.Builder
+		for i, value := range values {
+			row.WriteString(padPaymentField(value, fields[i].Width, fields[i].Align, fields[i].Pad))
+		}
+		return row.String(), nil
+	}
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+	writer.Comma, _ = utf8.DecodeRuneInString(layout.Delimiter)
+	if err := writer.Write(values); err != nil {
+		return "", err
+	}
+	writer.Flush()
+	return strings.TrimRight(buffer.String(), "\r\n"), writer.Error()
+}
+
+func paymentFieldValue(layout *model.SalaryV2BankLayout, field *model.SalaryV2BankLayoutField, batch *model.SalaryV2PaymentBatch, line *model.SalaryV2PaymentLine) string {
+	switch field.Field {
+	case "line_no":
+		return strconv.FormatInt(line.LineNo, 10)
+	case "line_id":
+		return line.LineId
+	case "batch_id":
+		return batch.BatchId
+	case "staff_id":
+		return line.StaffId
+	case "staff_name":
+		return line.StaffName
+	case "card_num":
+		return line.CardNum
+	case "amount":
+		return formatPaymentAmount(layout, line.Amount)
+	case "salary_date":
+		return batch.SalaryDate
+	}
+	return field.Value
+}
+
+func formatPaymentAmount(layout *model.SalaryV2BankLayout, amount model.Money) string {
+	if layout.AmountUnit == "cents" {
+		return strconv.FormatInt(amount.Cents(), 10)
+	}
+	return amount.String()
+}
+
+func parsePaymentAmount(layout *model.SalaryV2BankLayout, value string) (model.Money, error) {
+	if layout.AmountUnit == "cents" {
+		cents, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			return 0, err
+		}
+		return model.Money(cents), nil
+	}
+	return model.ParseMoney(value)
+}
+
+// padPaymentField 按字符数补齐或截断定长字段，默认左对齐、空格填充
+func padPaymentField(value string, width int, align string, pad string) string {
+	runes := []rune(value)
+	if len(runes) >= width {
+		if align == "right" {
+			return string(runes[len(runes)-width:])
+		}
+		return string(runes[:width])
+	}
+	if pad == "" {
+		pad = " "
+	}
+	padding := strings.Repeat(string([]rune(pad)[0]), width-len(runes))
+	if align == "right" {
+		return padding + value
+	}
+	return value + padding
+}
+
+// GetPaymentBatchesV2 查询代发批次
+func GetPaymentBatchesV2(c *gin.Context, salaryDate string, status string, start int, limit int) ([]*model.SalaryV2PaymentBatch, int64, error) {
+	var batches []*model.SalaryV2PaymentBatch
+	var total int64
+	query := resource.HrmsDB(c).Model(&model.SalaryV2PaymentBatch{})
+	if salaryDate != "" {
+		query = query.Where("salary_date = ?", salaryDate)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+	if start >= 0 && limit > 0 {
+		query = query.Offset(start).Limit(limit)
+	}
+	if err := query.Order("created_at desc").Find(&batches).Error; err != nil {
+		log.Printf("GetPaymentBatchesV2 err = %v", err)
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
+
+// GetPaymentLinesV2 查询代发批次明细
+func GetPaymentLinesV2(c *gin.Context, batchId string) ([]*model.SalaryV2PaymentLine, error) {
+	var lines []*model.SalaryV2PaymentLine
+	if err := resource.HrmsDB(c).Where("batch_id = ?", batchId).Order("line_no asc").Find(&lines).Error; err != nil {
+		log.Printf("GetPaymentLinesV2 err = %v", err)
+		return nil, err
+	}
+	return lines, nil
+}
+
+// GetPaymentBatchFileV2 获取代发批次文件
+func GetPaymentBatchFileV2(c *gin.Context, batchId string) (*model.SalaryV2PaymentBatch, error) {
+	var batch model.SalaryV2PaymentBatch
+	if err := resource.HrmsDB(c).Where("batch_id = ?", batchId).First(&batch).Error; err != nil {
+		return nil, errors.New("不存在该代发批次")
+	}
+	return &batch, nil
+}
+
+// ImportPaymentReturnV2 导入银行回盘文件，逐笔更新代发明细状态：
+// 成功的薪资记录标记为已发放，失败或金额不一致的薪资记录恢复为未发放以便重新代发，返回未匹配到明细的回盘行数
+func ImportPaymentReturnV2(c *gin.Context, batchId string, content []byte, operator string) (*model.SalaryV2PaymentBatch, int, error) {
+	var batch model.SalaryV2PaymentBatch
+	var unmatched int
+	var paidRecords []*model.SalaryRecord
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("batch_id = ?", batchId).First(&batch).Error; err != nil {
+			return errors.New("不存在该代发批次")
+		}
+		var layout model.SalaryV2BankLayout
+		if err := tx.Where("layout_id = ?", batch.LayoutId).First(&layout).Error; err != nil {
+			return errors.New("不存在该批次的代发文件格式")
+		}
+
+		rows, err := parsePaymentReturn(&layout, content)
+		if err != nil {
+			return err
+		}
+		var lines []*model.SalaryV2PaymentLine
+		if err := tx.Where("batch_id = ?", batchId).Find(&lines).Error; err != nil {
+			return err
+		}
+		lineByRef := make(map[string]*model.SalaryV2PaymentLine)
+		for _, line := range lines {
+			if layout.ReturnRefField == "line_id" {
+				lineByRef[line.LineId] = line
+			} else {
+				lineByRef[strconv.FormatInt(line.LineNo, 10)] = line
+			}
+		}
+
+		now := time.Now()
+		for _, row := range rows {
+			line, ok := lineByRef[strings.TrimSpace(row[layout.ReturnRefColumn])]
+			if !ok {
+				unmatched++
+				continue
+			}
+			// 已回盘的明细不重复处理
+			if line.Status != "pending" {
+				continue
+			}
+			status, message := "failed", ""
+			if strings.TrimSpace(row[layout.ReturnStatusColumn]) == layout.ReturnSuccessCode {
+				status = "success"
+			}
+			if layout.ReturnMessageColumn >= 0 && layout.ReturnMessageColumn < len(row) {
+				message = strings.TrimSpace(row[layout.ReturnMessageColumn])
+			}
+			if layout.ReturnAmountColumn >= 0 && layout.ReturnAmountColumn < len(row) {
+				amount, err := parsePaymentAmount(&layout, row[layout.ReturnAmountColumn])
+				if err != nil || amount != line.Amount {
+					status, message = "failed", "回盘金额与代发金额不一致: "+row[layout.ReturnAmountColumn]
+				}
+			}
+
+			if err := tx.Model(&model.SalaryV2PaymentLine{}).Where("id = ?", line.ID).Updates(map[string]interface{}{
+				"status":       status,
+				"bank_message": message,
+				"confirmed_at": &now,
+			}).Error; err != nil {
+				return err
+			}
+			line.Status = status
+			isPay := 1
+			if status == "success" {
+				isPay = 2
+			}
+			if err := tx.Model(&model.SalaryRecord{}).Where("salary_record_id = ? and is_pay = 3", line.SalaryRecordId).
+				Update("is_pay", isPay).Error; err != nil {
+				return err
+			}
+			if status == "success" {
+				var record model.SalaryRecord
+				if err := tx.Where("salary_record_id = ?", line.SalaryRecordId).First(&record).Error; err == nil {
+					paidRecords = append(paidRecords, &record)
+				}
+			}
+		}
+
+		var successCount, failedCount, pendingCount int64
+		for _, line := range lines {
+			switch line.Status {
+			case "success":
+				successCount++
+			case "failed":
+				failedCount++
+			default:
+				pendingCount++
+			}
+		}
+		batch.SuccessCount = successCount
+		batch.FailedCount = failedCount
+		batch.Status = "partial"
+		if pendingCount == 0 {
+			batch.Status = "completed"
+		}
+		batch.UpdatedBy = operator
+		return tx.Model(&model.SalaryV2PaymentBatch{}).Where("id = ?", batch.ID).Updates(map[string]interface{}{
+			"success_count": batch.SuccessCount,
+			"failed_count":  batch.FailedCount,
+			"status":        batch.Status,
+			"updated_by":    operator,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("ImportPaymentReturnV2 err = %v", err)
+		return nil, 0, err
+	}
+
+	for _, record := range paidRecords {
+		// 发送短信通知员工薪资已发放
+		sendNoticeMsg("salary", getStaffPhoneByStaffId(c, record.StaffId), []string{record.SalaryDate})
+	}
+	return &batch, unmatched, nil
+}
+
+// parsePaymentReturn 解析回盘文件，跳过标题行及空行，列数不足的行视为格式错误
+func parsePaymentReturn(layout *model.SalaryV2BankLayout, content []byte) ([][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(content))
+	reader.Comma, _ = utf8.DecodeRuneInString(layout.ReturnDelimiter)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.New("回盘文件格式错误: " + err.Error())
+	}
+	minColumns := layout.ReturnRefColumn
+	if layout.ReturnStatusColumn > minColumns {
+		minColumns = layout.ReturnStatusColumn
+	}
+	var rows [][]string
+	for i, record := range records {
+		if i < layout.ReturnSkipLines || (len(record) == 1 && strings.TrimSpace(record[0]) == "") {
+			continue
+		}
+		if len(record) <= minColumns {
+			return nil, fmt.Errorf("回盘文件第%d行列数不足", i+1)
+		}
+		rows = append(rows, record)
+	}
+	return rows, nil
+}
+
+// ReconcilePaymentBatchV2 代发批次对账：汇总各状态笔数及金额，并核对代发明细与薪资记录发放状态是否一致
+func ReconcilePaymentBatchV2(c *gin.Context, batchId string) (*model.SalaryV2PaymentReconciliation, error) {
+	batch, err := GetPaymentBatchFileV2(c, batchId)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := GetPaymentLinesV2(c, batchId)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.SalaryV2PaymentReconciliation{
+		BatchId:     batch.BatchId,
+		LineCount:   batch.LineCount,
+		TotalAmount: batch.TotalAmount,
+		Mismatches:  []string{},
+	}
+	expectIsPay := map[string]int64{"success": 2, "failed": 1, "pending": 3}
+	for _, line := range lines {
+		switch line.Status {
+		case "success":
+			result.SuccessCount++
+			result.SuccessAmount += line.Amount
+		case "failed":
+			result.FailedCount++
+			result.FailedAmount += line.Amount
+		default:
+			result.PendingCount++
+			result.PendingAmount += line.Amount
+		}
+		var record model.SalaryRecord
+		if err := resource.HrmsDB(c).Where("salary_record_id = ?", line.SalaryRecordId).First(&record).Error; err != nil {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("第%d笔%s：薪资记录不存在", line.LineNo, line.StaffName))
+			continue
+		}
+		if record.Total != line.Amount {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("第%d笔%s：代发金额%s与实发工资%s不一致",
+				line.LineNo, line.StaffName, line.Amount, record.Total))
+		}
+		// 失败后已重新代发的薪资记录不视为差异
+		if line.Status == "failed" && record.IsPay != 1 {
+			continue
+		}
+		if record.IsPay != expectIsPay[line.Status] {
+			result.Mismatches = append(result.Mismatches, fmt.Sprintf("第%d笔%s：代发状态%s与薪资记录发放状态%d不一致",
+				line.LineNo, line.StaffName, line.Status, record.IsPay))
+		}
+	}
+	if len(lines) != int(batch.LineCount) {
+		result.Mismatches = append(result.Mismatches, fmt.Sprintf("代发明细%d笔与批次笔数%d不一致", len(lines), batch.LineCount))
+	}
+	return result, nil
+}
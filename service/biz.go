@@ -13,6 +13,8 @@ import (
 	"math/rand"
 	"strconv"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 func AcceptPage(c *gin.Context) (int, int) {
@@ -33,7 +35,6 @@ func AcceptPage(c *gin.Context) (int, int) {
 }
 
 func RandomID(pre string) string {
-	rand.Seed(time.Now().Unix())
 	return fmt.Sprintf("%v_%v", pre, rand.Uint32())
 }
 
@@ -235,11 +236,18 @@ func PromoteStaff(c *gin.Context, dto *model.StaffPromotionDTO, operatorId strin
 
 // 员工调岗
 func TransferStaff(c *gin.Context, dto *model.StaffTransferDTO, operatorId string) error {
-	if err := resource.HrmsDB(c).Model(&model.Staff{}).Where("staff_id = ?", dto.StaffId).
-		Updates(map[string]interface{}{
-			"dep_id": dto.DepId,
-			"rank_id": dto.RankId,
-		}).Error; err != nil {
+	// 调岗后按已分配的薪资模板刷新薪资套账，刷新失败时调岗一并回滚
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Staff{}).Where("staff_id = ?", dto.StaffId).
+			Updates(map[string]interface{}{
+				"dep_id": dto.DepId,
+				"rank_id": dto.RankId,
+			}).Error; err != nil {
+			return err
+		}
+		return refreshStaffSalaryFromTemplate(tx, dto.StaffId)
+	})
+	if err != nil {
 		log.Printf("TransferStaff err = %v", err)
 		return err
 	}
@@ -17,6 +17,27 @@ func InitCron() {
 		log.Println("月末考勤报表自动更新完成")
 	})
 
+	// 每天1:00生效到期的员工薪资模板分配
+	c.AddFunc("0 1 * * *", func() {
+		log.Println("开始执行薪资模板分配生效...")
+		ApplyPendingSalaryTemplates()
+		log.Println("薪资模板分配生效完成")
+	})
+
+	// 每天1:10执行到达生效月份的调薪申请
+	c.AddFunc("10 1 * * *", func() {
+		log.Println("开始执行调薪申请生效...")
+		ApplyDueSalaryAdjustments()
+		log.Println("调薪申请生效完成")
+	})
+
+	// 每天0:05执行已审批且到达生效日期的参数变更申请
+	c.AddFunc("5 0 * * *", func() {
+		log.Println("开始执行参数变更申请生效...")
+		ApplyDueParameterChanges()
+		log.Println("参数变更申请生效完成")
+	})
+
 	c.Start()
 	log.Println("定时任务初始化成功")
 }
\ No newline at end of file
@@ -0,0 +1,481 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 计算规则公式引擎：只支持数值运算、比较、逻辑运算及白名单函数，不执行任何外部代码
+// 示例：if(leave_days > 5, 0, (5 - leave_days) / 5)
+
+const (
+	formulaMaxLength = 1024
+	formulaMaxDepth  = 32
+)
+
+// FormulaVariables 公式中允许使用的变量及说明
+var FormulaVariables = map[string]string{
+	"base":                   "月基本工资(元)",
+	"bonus":                  "绩效奖金(元)",
+	"subsidy":                "住房补贴(元)",
+	"commission":             "提成薪资(元)",
+	"work_days":              "出勤天数",
+	"leave_days":             "请假天数",
+	"overtime_days":          "加班天数",
+	"overtime_hours":         "加班总时长(小时)",
+	"weekday_overtime_hours": "工作日加班时长(小时)",
+	"weekend_overtime_hours": "休息日加班时长(小时)",
+	"holiday_overtime_hours": "法定节假日加班时长(小时)",
+	"monthly_work_days":      "每月标准工作日数",
+	"expected_work_days":     "按员工排班及工作日历计算的当月应出勤天数",
+	"rank":                   "职级等级",
+	"tenure":                 "司龄(年)",
+	"rule_value":             "规则数值",
+}
+
+// formulaFunctions 公式中允许调用的函数及参数个数，-1表示至少一个参数
+var formulaFunctions = map[string]int{
+	"min":   -1,
+	"max":   -1,
+	"abs":   1,
+	"floor": 1,
+	"ceil":  1,
+	"round": -1,
+	"if":    3,
+}
+
+// Formula 已解析的计算公式
+type Formula struct {
+	source    string
+	root      formulaNode
+	variables []string
+}
+
+// ParseFormula 解析计算公式
+func ParseFormula(source string) (*Formula, error) {
+	if strings.TrimSpace(source) == "" {
+		return nil, errors.New("公式不能为空")
+	}
+	if len(source) > formulaMaxLength {
+		return nil, fmt.Errorf("公式长度不能超过%d个字符", formulaMaxLength)
+	}
+	tokens, err := tokenizeFormula(source)
+	if err != nil {
+		return nil, err
+	}
+	p := &formulaParser{tokens: tokens, variables: map[string]bool{}}
+	root, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("公式第%d个字符附近存在多余内容: %s", p.tokens[p.pos].offset+1, p.tokens[p.pos].text)
+	}
+	formula := &Formula{source: source, root: root}
+	for name := range p.variables {
+		formula.variables = append(formula.variables, name)
+	}
+	sort.Strings(formula.variables)
+	return formula, nil
+}
+
+// Variables 获取公式引用的变量
+func (f *Formula) Variables() []string {
+	return f.variables
+}
+
+// Evaluate 使用给定变量值计算公式结果
+func (f *Formula) Evaluate(variables map[string]float64) (float64, error) {
+	for _, name := range f.variables {
+		if _, ok := variables[name]; !ok {
+			return 0, errors.New("缺少公式变量: " + name)
+		}
+	}
+	result, err := f.root.eval(variables)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) {
+		return 0, errors.New("公式计算结果无效")
+	}
+	return result, nil
+}
+
+// ValidateFormula 校验公式语法及引用的变量是否在允许范围内
+func ValidateFormula(source string) error {
+	_, err := parseAllowedFormula(source)
+	return err
+}
+
+// EvaluateFormula 校验并计算公式
+func EvaluateFormula(source string, variables map[string]float64) (float64, error) {
+	formula, err := parseAllowedFormula(source)
+	if err != nil {
+		return 0, err
+	}
+	return formula.Evaluate(variables)
+}
+
+func parseAllowedFormula(source string) (*Formula, error) {
+	formula, err := ParseFormula(source)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range formula.variables {
+		if _, ok := FormulaVariables[name]; !ok {
+			return nil, errors.New("不支持的公式变量: " + name)
+		}
+	}
+	return formula, nil
+}
+
+type formulaToken struct {
+	kind   string // number, ident, op
+	text   string
+	value  float64
+	offset int
+}
+
+func tokenizeFormula(source string) ([]formulaToken, error) {
+	var tokens []formulaToken
+	for i := 0; i < len(source); {
+		ch := source[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch >= '0' && ch <= '9' || ch == '.':
+			start := i
+			for i < len(source) && (source[i] >= '0' && source[i] <= '9' || source[i] == '.') {
+				i++
+			}
+			value, err := strconv.ParseFloat(source[start:i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("公式第%d个字符附近数字格式错误: %s", start+1, source[start:i])
+			}
+			tokens = append(tokens, formulaToken{kind: "number", text: source[start:i], value: value, offset: start})
+		case ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z':
+			start := i
+			for i < len(source) && (source[i] == '_' || source[i] >= 'a' && source[i] <= 'z' ||
+				source[i] >= 'A' && source[i] <= 'Z' || source[i] >= '0' && source[i] <= '9') {
+				i++
+			}
+			tokens = append(tokens, formulaToken{kind: "ident", text: source[start:i], offset: start})
+		default:
+			op := ""
+			if i+1 < len(source) {
+				switch source[i : i+2] {
+				case "<=", ">=", "==", "!=", "&&", "||":
+					op = source[i : i+2]
+				}
+			}
+			if op == "" && strings.IndexByte("+-*/%()<>!,", ch) >= 0 {
+				op = string(ch)
+			}
+			if op == "" {
+				return nil, fmt.Errorf("公式第%d个字符为非法字符: %q", i+1, ch)
+			}
+			tokens = append(tokens, formulaToken{kind: "op", text: op, offset: i})
+			i += len(op)
+		}
+	}
+	return tokens, nil
+}
+
+// formulaParser 递归下降解析器，优先级由低到高：|| && 比较 加减 乘除取模 一元运算
+type formulaParser struct {
+	tokens    []formulaToken
+	pos       int
+	depth     int
+	variables map[string]bool
+}
+
+func (p *formulaParser) peek(texts ...string) bool {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != "op" {
+		return false
+	}
+	for _, text := range texts {
+		if p.tokens[p.pos].text == text {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *formulaParser) parseExpression() (formulaNode, error) {
+	p.depth++
+	defer func() { p.depth-- }()
+	if p.depth > formulaMaxDepth {
+		return nil, fmt.Errorf("公式嵌套层数不能超过%d层", formulaMaxDepth)
+	}
+	return p.parseBinary(0)
+}
+
+var formulaPrecedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"<", "<=", ">", ">=", "==", "!="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+func (p *formulaParser) parseBinary(level int) (formulaNode, error) {
+	if level >= len(formulaPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek(formulaPrecedence[level]...) {
+		op := p.tokens[p.pos].text
+		p.pos++
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *formulaParser) parseUnary() (formulaNode, error) {
+	if p.peek("-", "+", "!") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		p.depth++
+		defer func() { p.depth-- }()
+		if p.depth > formulaMaxDepth {
+			return nil, fmt.Errorf("公式嵌套层数不能超过%d层", formulaMaxDepth)
+		}
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: op, operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *formulaParser) parsePrimary() (formulaNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("公式不完整")
+	}
+	token := p.tokens[p.pos]
+	p.pos++
+	switch token.kind {
+	case "number":
+		return &numberNode{value: token.value}, nil
+	case "ident":
+		if !p.peek("(") {
+			p.variables[token.text] = true
+			return &variableNode{name: token.text}, nil
+		}
+		arity, ok := formulaFunctions[token.text]
+		if !ok {
+			return nil, errors.New("不支持的公式函数: " + token.text)
+		}
+		p.pos++
+		var args []formulaNode
+		if !p.peek(")") {
+			for {
+				arg, err := p.parseExpression()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if !p.peek(",") {
+					break
+				}
+				p.pos++
+			}
+		}
+		if !p.peek(")") {
+			return nil, fmt.Errorf("函数%s缺少右括号", token.text)
+		}
+		p.pos++
+		if (arity == -1 && len(args) == 0) || (arity > 0 && len(args) != arity) {
+			return nil, fmt.Errorf("函数%s参数个数错误", token.text)
+		}
+		if token.text == "round" && len(args) > 2 {
+			return nil, errors.New("函数round参数个数错误")
+		}
+		return &callNode{name: token.text, args: args}, nil
+	default:
+		if token.text == "(" {
+			node, err := p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+			if !p.peek(")") {
+				return nil, errors.New("公式缺少右括号")
+			}
+			p.pos++
+			return node, nil
+		}
+		return nil, fmt.Errorf("公式第%d个字符附近语法错误: %s", token.offset+1, token.text)
+	}
+}
+
+type formulaNode interface {
+	eval(variables map[string]float64) (float64, error)
+}
+
+type numberNode struct {
+	value float64
+}
+
+func (n *numberNode) eval(map[string]float64) (float64, error) {
+	return n.value, nil
+}
+
+type variableNode struct {
+	name string
+}
+
+func (n *variableNode) eval(variables map[string]float64) (float64, error) {
+	value, ok := variables[n.name]
+	if !ok {
+		return 0, errors.New("缺少公式变量: " + n.name)
+	}
+	return value, nil
+}
+
+type unaryNode struct {
+	op      string
+	operand formulaNode
+}
+
+func (n *unaryNode) eval(variables map[string]float64) (float64, error) {
+	value, err := n.operand.eval(variables)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "-":
+		return -value, nil
+	case "!":
+		return formulaBool(value == 0), nil
+	}
+	return value, nil
+}
+
+type binaryNode struct {
+	op          string
+	left, right formulaNode
+}
+
+func (n *binaryNode) eval(variables map[string]float64) (float64, error) {
+	left, err := n.left.eval(variables)
+	if err != nil {
+		return 0, err
+	}
+	// 逻辑运算短路求值
+	if n.op == "&&" && left == 0 {
+		return 0, nil
+	}
+	if n.op == "||" && left != 0 {
+		return 1, nil
+	}
+	right, err := n.right.eval(variables)
+	if err != nil {
+		return 0, err
+	}
+	switch n.op {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return 0, errors.New("公式计算除数为0")
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return 0, errors.New("公式计算除数为0")
+		}
+		return math.Mod(left, right), nil
+	case "<":
+		return formulaBool(left < right), nil
+	case "<=":
+		return formulaBool(left <= right), nil
+	case ">":
+		return formulaBool(left > right), nil
+	case ">=":
+		return formulaBool(left >= right), nil
+	case "==":
+		return formulaBool(left == right), nil
+	case "!=":
+		return formulaBool(left != right), nil
+	case "&&", "||":
+		return formulaBool(right != 0), nil
+	}
+	return 0, errors.New("不支持的运算符: " + n.op)
+}
+
+type callNode struct {
+	name string
+	args []formulaNode
+}
+
+func (n *callNode) eval(variables map[string]float64) (float64, error) {
+	// if 只计算命中的分支
+	if n.name == "if" {
+		cond, err := n.args[0].eval(variables)
+		if err != nil {
+			return 0, err
+		}
+		if cond != 0 {
+			return n.args[1].eval(variables)
+		}
+		return n.args[2].eval(variables)
+	}
+	values := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := arg.eval(variables)
+		if err != nil {
+			return 0, err
+		}
+		values[i] = value
+	}
+	switch n.name {
+	case "min":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Min(result, value)
+		}
+		return result, nil
+	case "max":
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Max(result, value)
+		}
+		return result, nil
+	case "abs":
+		return math.Abs(values[0]), nil
+	case "floor":
+		return math.Floor(values[0]), nil
+	case "ceil":
+		return math.Ceil(values[0]), nil
+	case "round":
+		if len(values) == 1 {
+			return math.Round(values[0]), nil
+		}
+		scale := math.Pow(10, math.Round(values[1]))
+		return math.Round(values[0]*scale) / scale, nil
+	}
+	return 0, errors.New("不支持的公式函数: " + n.name)
+}
+
+func formulaBool(value bool) float64 {
+	if value {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEvaluateFormula(t *testing.T) {
+	variables := map[string]float64{
+		"base":       10000,
+		"bonus":      2000,
+		"leave_days": 2,
+		"rank":       3,
+		"tenure":     4.5,
+		"rule_value": 1.5,
+	}
+	cases := []struct {
+		formula string
+		want    float64
+	}{
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3", 9},
+		{"-base / 100", -100},
+		{"10 % 4", 2},
+		{"if(leave_days > 5, 0, (5 - leave_days) / 5)", 0.6},
+		{"bonus * if(leave_days > 5, 0, (5 - leave_days) / 5)", 1200},
+		{"rule_value + min(tenure, 3) * 0.1", 1.8},
+		{"max(rank, 1, 2)", 3},
+		{"round(10 / 3, 2)", 3.33},
+		{"floor(tenure) + ceil(0.2) + abs(-1)", 6},
+		{"leave_days >= 2 && rank == 3", 1},
+		{"leave_days > 2 || !rank", 0},
+		{"if(1, 2, 1 / 0)", 2},
+	}
+	for _, tc := range cases {
+		got, err := EvaluateFormula(tc.formula, variables)
+		if err != nil {
+			t.Errorf("EvaluateFormula(%q) err = %v", tc.formula, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("EvaluateFormula(%q) = %v, want %v", tc.formula, got, tc.want)
+		}
+	}
+}
+
+func TestValidateFormulaRejects(t *testing.T) {
+	cases := []string{
+		"",
+		"1 +",
+		"(1 + 2",
+		"unknown_var * 2",
+		"exec(1)",
+		"base; 1",
+		"min()",
+		"if(1, 2)",
+		"1 2",
+		strings.Repeat("(", 40) + "1" + strings.Repeat(")", 40),
+	}
+	for _, formula := range cases {
+		if err := ValidateFormula(formula); err == nil {
+			t.Errorf("ValidateFormula(%q) expected error", formula)
+		}
+	}
+	if _, err := EvaluateFormula("base / (leave_days - 2)", map[string]float64{"base": 1, "leave_days": 2}); err == nil {
+		t.Errorf("expected division by zero error")
+	}
+	if _, err := EvaluateFormula("base + bonus", map[string]float64{"base": 1}); err == nil {
+		t.Errorf("expected missing variable error")
+	}
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CalculateCumulativeIncomeTax 按累计预扣法计算当月应预扣预缴个人所得税
+// income 为当月应税收入，deduction 为当月专项扣除（个人缴纳五险一金），专项附加扣除按员工申报累计计算
+// 计算结果写入 salaryRecord 的 Tax 及累计字段
+func CalculateCumulativeIncomeTax(c *gin.Context, tx *gorm.DB, salaryRecord *model.SalaryRecord, staffId, month string, income, deduction model.Money) error {
+	// 获取个税起征点（每月减除费用）
+	threshold, err := requireSystemParameterMoney(tx, "tax_threshold")
+	if err != nil {
+		return err
+	}
+
+	// 获取员工入职、离职日期，计算本年度在本单位任职受雇月份数
+	var staff model.Staff
+	if err := tx.Where("staff_id = ?", staffId).First(&staff).Error; err != nil {
+		return errors.New("不存在该员工")
+	}
+	start, end, err := taxMonthRange(staff.EntryDate, staff.ResignationDate, month)
+	if err != nil {
+		return err
+	}
+	months := int64(end.Month()-start.Month()) + 1
+
+	// 累计专项附加扣除
+	cumulativeSpecial, currentSpecial, err := getCumulativeSpecialDeduction(tx, staffId, start, end)
+	if err != nil {
+		return err
+	}
+
+	// 汇总本年度此前月份的收入、扣除及已预缴税额
+	var priorRecords []*model.SalaryRecord
+	if err := tx.Where("staff_id = ? and salary_date like ? and salary_date < ?", staffId, month[:4]+"-%", month).
+		Find(&priorRecords).Error; err != nil {
+		return err
+	}
+	var priorIncome, priorDeduction, priorTax model.Money
+	for _, record := range priorRecords {
+		priorIncome += salaryRecordGrossIncome(record)
+		priorDeduction += salaryRecordDeduction(record)
+		priorTax += record.Tax
+	}
+
+	cumulativeIncome := priorIncome + income
+	cumulativeDeduction := priorDeduction + deduction + threshold*model.Money(months) + cumulativeSpecial
+	cumulativeTaxable := model.MaxMoney(0, cumulativeIncome-cumulativeDeduction)
+
+	// 按年度累计预扣税率表计算累计应纳税额
+	brackets, err := getAnnualTaxBrackets(c)
+	if err != nil {
+		return err
+	}
+	cumulativeTax := calculateTaxByBrackets(brackets, cumulativeTaxable)
+
+	// 本月应预扣税额 = 累计应纳税额 - 累计已预缴税额，为负数时本月暂不预扣
+	salaryRecord.SpecialDeduction = currentSpecial
+	salaryRecord.CumulativeIncome = cumulativeIncome
+	salaryRecord.CumulativeDeduction = cumulativeDeduction
+	salaryRecord.CumulativeTaxable = cumulativeTaxable
+	salaryRecord.CumulativeTax = cumulativeTax
+	salaryRecord.Tax = model.MaxMoney(0, cumulativeTax-priorTax)
+	return nil
+}
+
+// taxMonthRange 计算本年度截至当月的任职受雇起止月份，年中入职从入职当月起算，离职后不再累计
+func taxMonthRange(entryDate string, resignationDate *string, month string) (time.Time, time.Time, error) {
+	current, err := time.Parse("2006-01", month)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("薪资月份格式错误: " + month)
+	}
+	start := time.Date(current.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	if len(entryDate) >= 7 {
+		if entry, err := time.Parse("2006-01", entryDate[:7]); err == nil && entry.Year() == current.Year() && entry.After(start) {
+			start = time.Date(entry.Year(), entry.Month(), 1, 0, 0, 0, 0, time.Local)
+		}
+	}
+	end := time.Date(current.Year(), current.Month(), 1, 0, 0, 0, 0, time.Local)
+	if resignationDate != nil && len(*resignationDate) >= 7 {
+		if resign, err := time.Parse("2006-01", (*resignationDate)[:7]); err == nil && resign.Year() == current.Year() && resign.Before(end) {
+			end = time.Date(resign.Year(), resign.Month(), 1, 0, 0, 0, 0, time.Local)
+		}
+	}
+	if end.Before(start) {
+		start = end
+	}
+	return start, end, nil
+}
+
+// salaryRecordGrossIncome 获取薪资记录的应发合计，兼容未记录应发合计的历史数据
+func salaryRecordGrossIncome(record *model.SalaryRecord) model.Money {
+	if record.GrossIncome != 0 {
+		return record.GrossIncome
+	}
+	return salaryRecordRegularIncome(record)
+}
+
+// salaryRecordRegularIncome 获取薪资记录中不含全年一次性奖金的当月计税工资收入
+func salaryRecordRegularIncome(record *model.SalaryRecord) model.Money {
+	return record.Base + record.Subsidy + record.Bonus + record.Commission + record.Other + record.Overtime +
+		record.ComponentEarnings + record.LeavePayout + record.RetroEarnings
+}
+
+// salaryRecordDeduction 获取薪资记录的专项扣除及税前扣款合计
+func salaryRecordDeduction(record *model.SalaryRecord) model.Money {
+	return record.PensionInsurance + record.MedicalInsurance + record.UnemploymentInsurance + record.HousingFund + record.PreTaxDeductions + record.RetroDeductions
+}
+
+// getAnnualTaxBrackets 获取年度累计预扣税率表，未配置时按月度税率表折算
+func getAnnualTaxBrackets(c *gin.Context) ([]*model.SalaryV2TaxBracket, error) {
+	brackets, err := GetTaxBracketsByType(c, "annual")
+	if err != nil {
+		return nil, err
+	}
+	if len(brackets) > 0 {
+		return brackets, nil
+	}
+	monthly, err := GetTaxBracketsByType(c, "monthly")
+	if err != nil {
+		return nil, err
+	}
+	if len(monthly) == 0 {
+		return nil, errors.New("未配置个人所得税税率表")
+	}
+	for _, bracket := range monthly {
+		bracket.MinIncome = bracket.MinIncome * 12
+		bracket.MaxIncome = bracket.MaxIncome * 12
+		bracket.QuickDeduction = bracket.QuickDeduction * 12
+	}
+	return monthly, nil
+}
+
+// calculateTaxByBrackets 按税率表及速算扣除数计算应纳税额，税额四舍五入到分
+func calculateTaxByBrackets(brackets []*model.SalaryV2TaxBracket, taxableAmount model.Money) model.Money {
+	if taxableAmount <= 0 {
+		return 0
+	}
+	for _, bracket := range brackets {
+		if taxableAmount > bracket.MinIncome && (bracket.MaxIncome == 0 || taxableAmount <= bracket.MaxIncome) {
+			return model.MaxMoney(0, taxableAmount.Mul(bracket.TaxRate/100.0)-bracket.QuickDeduction)
+		}
+	}
+	// 超过所有税率区间，使用最高税率
+	for _, bracket := range brackets {
+		if bracket.MaxIncome == 0 {
+			return model.MaxMoney(0, taxableAmount.Mul(bracket.TaxRate/100.0)-bracket.QuickDeduction)
+		}
+	}
+	return 0
+}
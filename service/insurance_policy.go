@@ -0,0 +1,288 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateInsurancePolicyV2 创建参保方案，同时创建方案下的各险种费率
+func CreateInsurancePolicyV2(c *gin.Context, dto *model.SalaryV2InsurancePolicyCreateDTO, createdBy string) (*model.SalaryV2InsurancePolicy, error) {
+	var policy model.SalaryV2InsurancePolicy
+	Transfer(&dto, &policy)
+	policy.PolicyId = RandomID("insurance_policy")
+	policy.IsActive = true
+	policy.CreatedBy = createdBy
+	policy.UpdatedBy = createdBy
+
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if err := checkInsurancePolicyName(tx, policy.PolicyName, ""); err != nil {
+			return err
+		}
+		if err := tx.Create(&policy).Error; err != nil {
+			log.Printf("CreateInsurancePolicyV2 err = %v", err)
+			return err
+		}
+		types := make(map[string]bool)
+		for _, rateDTO := range dto.Rates {
+			if types[rateDTO.InsuranceType] {
+				return errors.New("险种重复: " + rateDTO.InsuranceType)
+			}
+			types[rateDTO.InsuranceType] = true
+			rateDTO.PolicyId = policy.PolicyId
+			if err := createInsuranceRate(tx, rateDTO, createdBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+func GetInsurancePoliciesV2(c *gin.Context, city string, start int, limit int) ([]*model.SalaryV2InsurancePolicy, int64, error) {
+	var policies []*model.SalaryV2InsurancePolicy
+	var err error
+	var query *gorm.DB
+
+	if city != "" {
+		query = resource.HrmsDB(c).Where("city = ? and is_active = ?", city, true)
+	} else {
+		query = resource.HrmsDB(c).Where("is_active = ?", true)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2InsurancePolicy{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("city asc, policy_name asc").Find(&policies).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("city asc, policy_name asc").Find(&policies).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	month := time.Now().Format("2006-01")
+	for _, policy := range policies {
+		resource.HrmsDB(c).Model(&model.SalaryV2StaffInsurancePolicy{}).
+			Where("policy_id = ? and start_month <= ? and (end_month = '' or end_month is null or end_month >= ?)", policy.PolicyId, month, month).
+			Count(&policy.StaffCount)
+	}
+
+	return policies, total, nil
+}
+
+// GetInsurancePolicyV2 获取参保方案及方案下的各险种费率
+func GetInsurancePolicyV2(c *gin.Context, policyId string) (*model.SalaryV2InsurancePolicy, error) {
+	policy, err := getInsurancePolicy(resource.HrmsDB(c), policyId)
+	if err != nil {
+		return nil, err
+	}
+	policy.Rates, _, err = GetInsuranceRatesV2(c, "", policyId, -1, -1)
+	if err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func UpdateInsurancePolicyV2(c *gin.Context, dto *model.SalaryV2InsurancePolicyEditDTO, updatedBy string) error {
+	db := resource.HrmsDB(c)
+	if _, err := getInsurancePolicy(db, dto.PolicyId); err != nil {
+		return err
+	}
+	if err := checkInsurancePolicyName(db, dto.PolicyName, dto.PolicyId); err != nil {
+		return err
+	}
+
+	if err := db.Model(&model.SalaryV2InsurancePolicy{}).Where("policy_id = ?", dto.PolicyId).
+		Updates(map[string]interface{}{
+			"policy_name": dto.PolicyName,
+			"city":        dto.City,
+			"description": dto.Description,
+			"updated_by":  updatedBy,
+		}).Error; err != nil {
+		log.Printf("UpdateInsurancePolicyV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteInsurancePolicyV2 停用参保方案，仍有员工使用的方案不能停用
+func DeleteInsurancePolicyV2(c *gin.Context, policyId string, deletedBy string) error {
+	db := resource.HrmsDB(c)
+	if _, err := getInsurancePolicy(db, policyId); err != nil {
+		return err
+	}
+	month := time.Now().Format("2006-01")
+	var count int64
+	db.Model(&model.SalaryV2StaffInsurancePolicy{}).
+		Where("policy_id = ? and (end_month = '' or end_month is null or end_month >= ?)", policyId, month).
+		Count(&count)
+	if count > 0 {
+		return fmt.Errorf("仍有%d名员工使用该参保方案，请先调整员工参保方案", count)
+	}
+
+	if err := db.Model(&model.SalaryV2InsurancePolicy{}).Where("policy_id = ?", policyId).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": deletedBy,
+		}).Error; err != nil {
+		log.Printf("DeleteInsurancePolicyV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// AssignStaffInsurancePolicyV2 为员工分配参保方案，员工原有长期有效的方案截止到新方案起始月份的上月
+func AssignStaffInsurancePolicyV2(c *gin.Context, dto *model.SalaryV2StaffInsurancePolicyAssignDTO, createdBy string) error {
+	start, err := time.Parse("2006-01", dto.StartMonth)
+	if err != nil {
+		return errors.New("起始月份格式错误: " + dto.StartMonth)
+	}
+	if dto.EndMonth != "" {
+		if _, err := time.Parse("2006-01", dto.EndMonth); err != nil {
+			return errors.New("截止月份格式错误: " + dto.EndMonth)
+		}
+		if dto.EndMonth < dto.StartMonth {
+			return errors.New("截止月份不能早于起始月份")
+		}
+	}
+	previousMonth := start.AddDate(0, -1, 0).Format("2006-01")
+
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if _, err := getInsurancePolicy(tx, dto.PolicyId); err != nil {
+			return err
+		}
+		staffs, err := getStaffMapByIds(tx, dto.StaffIds)
+		if err != nil {
+			return err
+		}
+		for _, staffId := range dto.StaffIds {
+			staff, ok := staffs[staffId]
+			if !ok {
+				return errors.New("不存在该员工: " + staffId)
+			}
+			if err := tx.Model(&model.SalaryV2StaffInsurancePolicy{}).
+				Where("staff_id = ? and start_month < ? and (end_month = '' or end_month is null)", staffId, dto.StartMonth).
+				Update("end_month", previousMonth).Error; err != nil {
+				return err
+			}
+			var overlaps []*model.SalaryV2StaffInsurancePolicy
+			query := tx.Where("staff_id = ? and (end_month = '' or end_month is null or end_month >= ?)", staffId, dto.StartMonth)
+			if dto.EndMonth != "" {
+				query = query.Where("start_month <= ?", dto.EndMonth)
+			}
+			if err := query.Find(&overlaps).Error; err != nil {
+				return err
+			}
+			if len(overlaps) > 0 {
+				return fmt.Errorf("员工 %s 自%s起已有参保方案，请先删除后再分配", staff.StaffName, overlaps[0].StartMonth)
+			}
+			assignment := &model.SalaryV2StaffInsurancePolicy{
+				AssignmentId: RandomID("insurance_assign"),
+				StaffId:      staffId,
+				StaffName:    staff.StaffName,
+				PolicyId:     dto.PolicyId,
+				StartMonth:   dto.StartMonth,
+				EndMonth:     dto.EndMonth,
+				Remark:       dto.Remark,
+				CreatedBy:    createdBy,
+			}
+			if err := tx.Create(assignment).Error; err != nil {
+				log.Printf("AssignStaffInsurancePolicyV2 err = %v", err)
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func GetStaffInsurancePoliciesV2(c *gin.Context, staffId string, policyId string, start int, limit int) ([]*model.SalaryV2StaffInsurancePolicy, int64, error) {
+	var assignments []*model.SalaryV2StaffInsurancePolicy
+	var err error
+	query := resource.HrmsDB(c).Model(&model.SalaryV2StaffInsurancePolicy{})
+	if staffId != "" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+	if policyId != "" {
+		query = query.Where("policy_id = ?", policyId)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("staff_id asc, start_month desc").Find(&assignments).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("staff_id asc, start_month desc").Find(&assignments).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return assignments, total, nil
+}
+
+// DeleteStaffInsurancePolicyV2 删除员工参保方案，删除后相应月份按分公司默认费率缴纳
+func DeleteStaffInsurancePolicyV2(c *gin.Context, assignmentId string) error {
+	result := resource.HrmsDB(c).Where("assignment_id = ?", assignmentId).Delete(&model.SalaryV2StaffInsurancePolicy{})
+	if result.Error != nil {
+		log.Printf("DeleteStaffInsurancePolicyV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该员工参保方案")
+	}
+	return nil
+}
+
+// getStaffInsuranceRates 获取员工指定月份适用的社保费率，员工当月有参保方案时使用方案费率，否则使用分公司默认费率
+func getStaffInsuranceRates(db *gorm.DB, staffId string, month string) (string, []*model.SalaryV2InsuranceRate, error) {
+	var assignment model.SalaryV2StaffInsurancePolicy
+	err := db.Where("staff_id = ? and start_month <= ? and (end_month = '' or end_month is null or end_month >= ?)", staffId, month, month).
+		Order("start_month desc").First(&assignment).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil, err
+	}
+	policyId := assignment.PolicyId
+	if policyId != "" {
+		if _, err := getInsurancePolicy(db, policyId); err != nil {
+			return "", nil, fmt.Errorf("员工 %s 的参保方案已停用", staffId)
+		}
+	}
+
+	var rates []*model.SalaryV2InsuranceRate
+	if err := db.Where("policy_id = ? and is_active = ?", policyId, true).Order("effective_date desc").Find(&rates).Error; err != nil {
+		return "", nil, err
+	}
+	return policyId, getEffectiveInsuranceRates(rates, month), nil
+}
+
+func getInsurancePolicy(db *gorm.DB, policyId string) (*model.SalaryV2InsurancePolicy, error) {
+	var policy model.SalaryV2InsurancePolicy
+	if err := db.Where("policy_id = ? and is_active = ?", policyId, true).First(&policy).Error; err != nil {
+		return nil, errors.New("不存在该参保方案")
+	}
+	return &policy, nil
+}
+
+func checkInsurancePolicyName(db *gorm.DB, policyName string, excludePolicyId string) error {
+	var count int64
+	db.Model(&model.SalaryV2InsurancePolicy{}).
+		Where("policy_name = ? and policy_id <> ? and is_active = ?", policyName, excludePolicyId, true).Count(&count)
+	if count > 0 {
+		return errors.New("参保方案名称已存在: " + policyName)
+	}
+	return nil
+}
@@ -0,0 +1,353 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tealeg/xlsx"
+	"gorm.io/gorm"
+)
+
+// insuranceTypes 险种及报表显示顺序
+var insuranceTypes = []struct {
+	Code string
+	Name string
+}{
+	{"pension", "养老保险"},
+	{"medical", "医疗保险"},
+	{"unemployment", "失业保险"},
+	{"injury", "工伤保险"},
+	{"maternity", "生育保险"},
+	{"housing", "住房公积金"},
+}
+
+// getEffectiveInsuranceRates 按险种选取薪资月份已生效的最新费率，按险种显示顺序返回
+func getEffectiveInsuranceRates(rates []*model.SalaryV2InsuranceRate, month string) []*model.SalaryV2InsuranceRate {
+	effective := make(map[string]*model.SalaryV2InsuranceRate)
+	for _, rate := range rates {
+		if len(rate.EffectiveDate) >= 7 && rate.EffectiveDate[:7] > month {
+			continue
+		}
+		if current, ok := effective[rate.InsuranceType]; !ok || rate.EffectiveDate > current.EffectiveDate {
+			effective[rate.InsuranceType] = rate
+		}
+	}
+	var result []*model.SalaryV2InsuranceRate
+	for _, insuranceType := range insuranceTypes {
+		if rate, ok := effective[insuranceType.Code]; ok {
+			result = append(result, rate)
+		}
+	}
+	return result
+}
+
+// calculateInsuranceContribution 按费率配置的缴费基数上下限调整基数，计算个人及单位缴费金额
+func calculateInsuranceContribution(rate *model.SalaryV2InsuranceRate, amount model.Money) *model.SalaryV2RecordInsurance {
+	base := amount
+	if rate.MinBase > 0 && base < rate.MinBase {
+		base = rate.MinBase
+	}
+	if rate.MaxBase > 0 && base > rate.MaxBase {
+		base = rate.MaxBase
+	}
+	return &model.SalaryV2RecordInsurance{
+		InsuranceType:  rate.InsuranceType,
+		Base:           base,
+		EmployeeRate:   rate.EmployeeRate,
+		EmployerRate:   rate.EmployerRate,
+		EmployeeAmount: base.Mul(rate.EmployeeRate / 100.0),
+		EmployerAmount: base.Mul(rate.EmployerRate / 100.0),
+	}
+}
+
+// saveSalaryRecordInsurances 保存薪资记录五险一金缴费明细，重新核算时覆盖原明细
+func saveSalaryRecordInsurances(tx *gorm.DB, salaryRecord *model.SalaryRecord, details []*model.SalaryV2RecordInsurance) error {
+	if err := tx.Unscoped().Where("salary_record_id = ?", salaryRecord.SalaryRecordId).Delete(&model.SalaryV2RecordInsurance{}).Error; err != nil {
+		return err
+	}
+	for _, detail := range details {
+		detail.SalaryRecordId = salaryRecord.SalaryRecordId
+		detail.StaffId = salaryRecord.StaffId
+		detail.SalaryDate = salaryRecord.SalaryDate
+		if err := tx.Create(detail).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetInsuranceContributionReportV2 生成月度社保及住房公积金缴费报表，增减员名单按员工入职、离职日期生成
+func GetInsuranceContributionReportV2(c *gin.Context, month string, insuranceType string) (*model.SalaryV2InsuranceContributionReport, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, errors.New("薪资月份格式错误: " + month)
+	}
+	db := resource.HrmsDB(c)
+	report := &model.SalaryV2InsuranceContributionReport{
+		SalaryDate:  month,
+		Lines:       []*model.SalaryV2InsuranceContributionLine{},
+		Totals:      []*model.SalaryV2InsuranceContributionTotal{},
+		Enrollments: []*model.SalaryV2InsuranceChange{},
+		Stops:       []*model.SalaryV2InsuranceChange{},
+	}
+
+	var records []*model.SalaryRecord
+	if err := db.Where("salary_date = ?", month).Order("staff_id asc").Find(&records).Error; err != nil {
+		log.Printf("GetInsuranceContributionReportV2 err = %v", err)
+		return nil, err
+	}
+	recordIds := make([]string, 0, len(records))
+	recordMap := make(map[string]*model.SalaryRecord)
+	for _, record := range records {
+		recordIds = append(recordIds, record.SalaryRecordId)
+		recordMap[record.SalaryRecordId] = record
+	}
+	var details []*model.SalaryV2RecordInsurance
+	if len(recordIds) > 0 {
+		query := db.Where("salary_record_id in ?", recordIds)
+		if insuranceType != "" {
+			query = query.Where("insurance_type = ?", insuranceType)
+		}
+		if err := query.Order("staff_id asc, id asc").Find(&details).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	staffs, err := getStaffMapByIds(db, append(detailStaffIds(details), getInsuranceChangeStaffIds(db, month)...))
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[string]*model.SalaryV2InsuranceContributionTotal)
+	bases := make(map[string]model.Money)
+	for _, detail := range details {
+		staff := staffs[detail.StaffId]
+		line := &model.SalaryV2InsuranceContributionLine{
+			StaffId:        detail.StaffId,
+			StaffName:      recordMap[detail.SalaryRecordId].StaffName,
+			SalaryRecordId: detail.SalaryRecordId,
+			PolicyId:       detail.PolicyId,
+			InsuranceType:  detail.InsuranceType,
+			Base:           detail.Base,
+			EmployeeRate:   detail.EmployeeRate,
+			EmployerRate:   detail.EmployerRate,
+			EmployeeAmount: detail.EmployeeAmount,
+			EmployerAmount: detail.EmployerAmount,
+		}
+		if staff != nil {
+			line.IdentityNum = staff.IdentityNum
+		}
+		report.Lines = append(report.Lines, line)
+
+		total, ok := totals[detail.InsuranceType]
+		if !ok {
+			total = &model.SalaryV2InsuranceContributionTotal{InsuranceType: detail.InsuranceType}
+			totals[detail.InsuranceType] = total
+		}
+		total.StaffCount++
+		total.Base += detail.Base
+		total.EmployeeAmount += detail.EmployeeAmount
+		total.EmployerAmount += detail.EmployerAmount
+		if _, ok := bases[detail.StaffId]; !ok || detail.InsuranceType == "pension" {
+			bases[detail.StaffId] = detail.Base
+		}
+	}
+	for _, insuranceType := range insuranceTypes {
+		if total, ok := totals[insuranceType.Code]; ok {
+			report.Totals = append(report.Totals, total)
+		}
+	}
+
+	// 当月入职且缴纳五险一金的员工增员，当月离职的员工减员
+	for _, staff := range staffs {
+		if !isStaffInsured(db, staff.StaffId) {
+			continue
+		}
+		if len(staff.EntryDate) >= 7 && staff.EntryDate[:7] == month {
+			report.Enrollments = append(report.Enrollments, &model.SalaryV2InsuranceChange{
+				StaffId:     staff.StaffId,
+				StaffName:   staff.StaffName,
+				IdentityNum: staff.IdentityNum,
+				ChangeType:  "enroll",
+				ChangeDate:  staff.EntryDate,
+				Base:        bases[staff.StaffId],
+				Reason:      "新入职",
+			})
+		}
+		if staff.ResignationDate != nil && len(*staff.ResignationDate) >= 7 && (*staff.ResignationDate)[:7] == month {
+			report.Stops = append(report.Stops, &model.SalaryV2InsuranceChange{
+				StaffId:     staff.StaffId,
+				StaffName:   staff.StaffName,
+				IdentityNum: staff.IdentityNum,
+				ChangeType:  "stop",
+				ChangeDate:  *staff.ResignationDate,
+				Base:        bases[staff.StaffId],
+				Reason:      staff.ResignationReason,
+			})
+		}
+	}
+	sortInsuranceChanges(report.Enrollments)
+	sortInsuranceChanges(report.Stops)
+	return report, nil
+}
+
+// ExportInsuranceContributionReportV2 导出月度缴费报表，社会保险与住房公积金分别生成缴费清单
+func ExportInsuranceContributionReportV2(c *gin.Context, month string) ([]byte, error) {
+	report, err := GetInsuranceContributionReportV2(c, month, "")
+	if err != nil {
+		return nil, err
+	}
+	file := xlsx.NewFile()
+
+	var socialTypes, housingTypes []string
+	for _, insuranceType := range insuranceTypes {
+		if insuranceType.Code == "housing" {
+			housingTypes = append(housingTypes, insuranceType.Code)
+		} else {
+			socialTypes = append(socialTypes, insuranceType.Code)
+		}
+	}
+	if err := addInsuranceContributionSheet(file, "社会保险缴费明细", report.Lines, socialTypes); err != nil {
+		return nil, err
+	}
+	if err := addInsuranceContributionSheet(file, "住房公积金缴费明细", report.Lines, housingTypes); err != nil {
+		return nil, err
+	}
+
+	sheet, err := file.AddSheet("险种汇总")
+	if err != nil {
+		return nil, err
+	}
+	addXlsxRow(sheet, "险种", "缴费人数", "缴费基数合计", "个人缴纳合计", "单位缴纳合计")
+	for _, total := range report.Totals {
+		addXlsxRow(sheet, insuranceTypeName(total.InsuranceType), total.StaffCount, total.Base, total.EmployeeAmount, total.EmployerAmount)
+	}
+
+	changeSheets := []struct {
+		name    string
+		changes []*model.SalaryV2InsuranceChange
+	}{
+		{"增员名单", report.Enrollments},
+		{"减员名单", report.Stops},
+	}
+	for _, changeSheet := range changeSheets {
+		sheet, err := file.AddSheet(changeSheet.name)
+		if err != nil {
+			return nil, err
+		}
+		addXlsxRow(sheet, "工号", "姓名", "证件号码", "变动日期", "缴费基数", "变动原因")
+		for _, change := range changeSheet.changes {
+			addXlsxRow(sheet, change.StaffId, change.StaffName, change.IdentityNum, change.ChangeDate, change.Base, change.Reason)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		log.Printf("ExportInsuranceContributionReportV2 err = %v", err)
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// addInsuranceContributionSheet 按员工逐行列示各险种缴费基数及个人、单位缴费金额
+func addInsuranceContributionSheet(file *xlsx.File, name string, lines []*model.SalaryV2InsuranceContributionLine, types []string) error {
+	sheet, err := file.AddSheet(name)
+	if err != nil {
+		return err
+	}
+	header := []interface{}{"工号", "姓名", "证件号码"}
+	for _, insuranceType := range types {
+		typeName := insuranceTypeName(insuranceType)
+		header = append(header, typeName+"缴费基数", typeName+"个人缴纳", typeName+"单位缴纳")
+	}
+	addXlsxRow(sheet, header...)
+
+	var staffIds []string
+	staffLines := make(map[string]map[string]*model.SalaryV2InsuranceContributionLine)
+	for _, line := range lines {
+		if _, ok := staffLines[line.StaffId]; !ok {
+			staffLines[line.StaffId] = make(map[string]*model.SalaryV2InsuranceContributionLine)
+			staffIds = append(staffIds, line.StaffId)
+		}
+		staffLines[line.StaffId][line.InsuranceType] = line
+	}
+	for _, staffId := range staffIds {
+		var row []interface{}
+		for _, insuranceType := range types {
+			if line, ok := staffLines[staffId][insuranceType]; ok {
+				row = []interface{}{line.StaffId, line.StaffName, line.IdentityNum}
+				break
+			}
+		}
+		// 未缴纳该类险种的员工不列入清单
+		if row == nil {
+			continue
+		}
+		for _, insuranceType := range types {
+			if line, ok := staffLines[staffId][insuranceType]; ok {
+				row = append(row, line.Base, line.EmployeeAmount, line.EmployerAmount)
+			} else {
+				row = append(row, "", "", "")
+			}
+		}
+		addXlsxRow(sheet, row...)
+	}
+	return nil
+}
+
+func insuranceTypeName(code string) string {
+	for _, insuranceType := range insuranceTypes {
+		if insuranceType.Code == code {
+			return insuranceType.Name
+		}
+	}
+	return code
+}
+
+func detailStaffIds(details []*model.SalaryV2RecordInsurance) []string {
+	staffIds := make([]string, 0, len(details))
+	for _, detail := range details {
+		staffIds = append(staffIds, detail.StaffId)
+	}
+	return staffIds
+}
+
+// getInsuranceChangeStaffIds 获取当月入职或离职的员工
+func getInsuranceChangeStaffIds(db *gorm.DB, month string) []string {
+	var staffIds []string
+	db.Model(&model.Staff{}).Where("entry_date like ? or resignation_date like ?", month+"%", month+"%").
+		Pluck("staff_id", &staffIds)
+	return staffIds
+}
+
+func getStaffMapByIds(db *gorm.DB, staffIds []string) (map[string]*model.Staff, error) {
+	result := make(map[string]*model.Staff)
+	if len(staffIds) == 0 {
+		return result, nil
+	}
+	var staffs []*model.Staff
+	if err := db.Where("staff_id in ?", staffIds).Find(&staffs).Error; err != nil {
+		return nil, err
+	}
+	for _, staff := range staffs {
+		result[staff.StaffId] = staff
+	}
+	return result, nil
+}
+
+// isStaffInsured 员工薪资套账是否缴纳五险一金
+func isStaffInsured(db *gorm.DB, staffId string) bool {
+	var count int64
+	db.Model(&model.Salary{}).Where("staff_id = ? and fund = 1", staffId).Count(&count)
+	return count != 0
+}
+
+func sortInsuranceChanges(changes []*model.SalaryV2InsuranceChange) {
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].StaffId < changes[j].StaffId
+	})
+}
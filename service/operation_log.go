@@ -198,7 +198,7 @@ func (s *OperationLogService) LogOperation(c *gin.Context, staffId uint64, staff
 func (s *OperationLogService) GetOperationLogOptions() map[string]interface{} {
 	return map[string]interface{}{
 		"operation_types": []string{
-			"CREATE", "UPDATE", "DELETE", "QUERY", "LOGIN", "LOGOUT", "EXPORT", "IMPORT",
+			"CREATE", "UPDATE", "DELETE", "QUERY", "LOGIN", "LOGOUT", "EXPORT", "IMPORT", "UNLOCK",
 		},
 		"operation_modules": []string{
 			"STAFF", "DEPARTMENT", "ATTENDANCE", "SALARY", "RECRUITMENT",
@@ -0,0 +1,527 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// CreateParameterChangeV2 提交参数变更申请，待其他管理员审批
+func CreateParameterChangeV2(c *gin.Context, dto *model.SalaryV2ParameterChangeCreateDTO, createdBy string) (*model.SalaryV2ParameterChange, error) {
+	if dto.EffectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", dto.EffectiveDate); err != nil {
+			return nil, errors.New("生效日期格式错误: " + dto.EffectiveDate)
+		}
+		if dto.EffectiveDate < time.Now().Format("2006-01-02") {
+			return nil, errors.New("生效日期不能早于今天")
+		}
+	}
+	db := resource.HrmsDB(c)
+	change, err := buildParameterChange(db, dto)
+	if err != nil {
+		return nil, err
+	}
+	change.EffectiveDate = dto.EffectiveDate
+	change.Reason = dto.Reason
+	return submitParameterChange(db, change, createdBy)
+}
+
+// submitParameterChange 保存待审批的参数变更申请，同一参数同时只允许存在一个未完成的变更申请，避免相互覆盖
+func submitParameterChange(db *gorm.DB, change *model.SalaryV2ParameterChange, createdBy string) (*model.SalaryV2ParameterChange, error) {
+	var count int64
+	db.Model(&model.SalaryV2ParameterChange{}).
+		Where("parameter_type = ? and parameter_id = ? and status in ?", change.ParameterType, change.ParameterId, []string{"pending", "approved"}).
+		Count(&count)
+	if count > 0 {
+		return nil, errors.New("该参数已有未生效的变更申请: " + change.ParameterName)
+	}
+
+	if change.Operation == "" {
+		change.Operation = "update"
+	}
+	change.ChangeId = RandomID("parameter_change")
+	change.Status = "pending"
+	change.CreatedBy = createdBy
+	change.UpdatedBy = createdBy
+	if err := db.Create(change).Error; err != nil {
+		log.Printf("submitParameterChange err = %v", err)
+		return nil, err
+	}
+	return change, nil
+}
+
+// submitParameterCreate 提交新增参数的变更申请，审批生效时按record的内容创建参数记录，
+// 参数生效日期在今天之后时变更申请到期自动生效
+func submitParameterCreate(db *gorm.DB, parameterType string, parameterId string, parameterName string, record interface{}, effectiveDate string, reason string, createdBy string) (*model.SalaryV2ParameterChange, error) {
+	if effectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", effectiveDate); err != nil {
+			return nil, errors.New("生效日期格式错误: " + effectiveDate)
+		}
+	}
+	if effectiveDate <= time.Now().Format("2006-01-02") {
+		effectiveDate = ""
+	}
+	content, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	return submitParameterChange(db, &model.SalaryV2ParameterChange{
+		ParameterType: parameterType,
+		Operation:     "create",
+		ParameterId:   parameterId,
+		ParameterName: parameterName,
+		NewValue:      string(content),
+		EffectiveDate: effectiveDate,
+		Reason:        reason,
+	}, createdBy)
+}
+
+// submitParameterDeactivate 提交停用参数的变更申请
+func submitParameterDeactivate(db *gorm.DB, parameterType string, id uint, reason string, createdBy string) (*model.SalaryV2ParameterChange, error) {
+	oldValue, _, _, err := loadParameterRecord(db, parameterType, id)
+	if err != nil {
+		return nil, err
+	}
+	var old struct {
+		IsActive bool `json:"is_active"`
+	}
+	json.Unmarshal([]byte(oldValue), &old)
+	if !old.IsActive {
+		return nil, errors.New("该参数已停用")
+	}
+	dto, err := parameterEditChange(parameterType, id, deactivateParameterValue(oldValue))
+	if err != nil {
+		return nil, err
+	}
+	change, err := buildParameterChange(db, dto)
+	if err != nil {
+		return nil, err
+	}
+	change.Operation = "delete"
+	change.Reason = reason
+	return submitParameterChange(db, change, createdBy)
+}
+
+// parameterEditChange 按参数JSON内容生成修改指定税率区间或社保费率的变更申请内容
+func parameterEditChange(parameterType string, id uint, value string) (*model.SalaryV2ParameterChangeCreateDTO, error) {
+	dto := &model.SalaryV2ParameterChangeCreateDTO{ParameterType: parameterType}
+	var err error
+	switch parameterType {
+	case "tax_bracket":
+		dto.TaxBracket = &model.SalaryV2TaxBracketEditDTO{}
+		err = json.Unmarshal([]byte(value), dto.TaxBracket)
+		dto.TaxBracket.ID = id
+		dto.TaxBracket.EffectiveDate = normalizeParameterDate(dto.TaxBracket.EffectiveDate)
+	case "insurance_rate":
+		dto.InsuranceRate = &model.SalaryV2InsuranceRateEditDTO{}
+		err = json.Unmarshal([]byte(value), dto.InsuranceRate)
+		dto.InsuranceRate.ID = id
+		dto.InsuranceRate.EffectiveDate = normalizeParameterDate(dto.InsuranceRate.EffectiveDate)
+	default:
+		err = errors.New("不支持的参数类型: " + parameterType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dto, nil
+}
+
+// PreviewParameterChangeV2 预览参数变更内容与当前参数的差异，不保存申请
+func PreviewParameterChangeV2(c *gin.Context, dto *model.SalaryV2ParameterChangeCreateDTO) ([]*model.SalaryV2ParameterFieldDiff, error) {
+	change, err := buildParameterChange(resource.HrmsDB(c), dto)
+	if err != nil {
+		return nil, err
+	}
+	return diffParameterValues(change.OldValue, change.NewValue, parameterValueFields(change.NewValue)), nil
+}
+
+// GetParameterChangesV2 查询参数变更申请
+func GetParameterChangesV2(c *gin.Context, parameterType string, status string, start int, limit int) ([]*model.SalaryV2ParameterChange, int64, error) {
+	var changes []*model.SalaryV2ParameterChange
+	query := resource.HrmsDB(c).Model(&model.SalaryV2ParameterChange{})
+	if parameterType != "" {
+		query = query.Where("parameter_type = ?", parameterType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Count(&total)
+	var err error
+	if start == -1 && limit == -1 {
+		err = query.Order("created_at desc").Find(&changes).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("created_at desc").Find(&changes).Error
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return changes, total, nil
+}
+
+// GetParameterChangeV2 查询参数变更申请详情，未生效的申请与当前参数比较差异，已生效的申请与提交时的快照比较
+func GetParameterChangeV2(c *gin.Context, changeId string) (*model.SalaryV2ParameterChangeDetail, error) {
+	db := resource.HrmsDB(c)
+	change, err := getParameterChange(db, changeId)
+	if err != nil {
+		return nil, err
+	}
+	oldValue := change.OldValue
+	if change.Operation != "create" && (change.Status == "pending" || change.Status == "approved") {
+		if current, _, _, err := loadParameterRecord(db, change.ParameterType, change.TargetId); err == nil {
+			oldValue = current
+		}
+	}
+	// 新增参数的内容为完整参数记录，不比较主键及审计字段
+	fields := parameterValueFields(change.NewValue)
+	if change.Operation == "create" {
+		fields = parameterSetFields(change.NewValue)
+	}
+	return &model.SalaryV2ParameterChangeDetail{
+		Change: change,
+		Diffs:  diffParameterValues(oldValue, change.NewValue, fields),
+	}, nil
+}
+
+// ApproveParameterChangeV2 审批参数变更申请，审批人不能为提交人，生效日期已到时立即执行
+func ApproveParameterChangeV2(c *gin.Context, dto *model.SalaryV2ParameterChangeApproveDTO, approverId string) error {
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		change, err := getParameterChange(tx, dto.ChangeId)
+		if err != nil {
+			return err
+		}
+		if change.Status != "pending" {
+			return errors.New("该变更申请不在待审批状态")
+		}
+		if change.CreatedBy == approverId {
+			return errors.New("变更申请须由提交人以外的管理员审批")
+		}
+
+		status := "approved"
+		if !dto.Approved {
+			status = "rejected"
+		}
+		now := time.Now()
+		if err := tx.Model(&model.SalaryV2ParameterChange{}).Where("change_id = ?", dto.ChangeId).
+			Updates(map[string]interface{}{
+				"status":          status,
+				"approver_id":     approverId,
+				"approve_comment": dto.Comment,
+				"approved_at":     &now,
+				"updated_by":      approverId,
+			}).Error; err != nil {
+			log.Printf("ApproveParameterChangeV2 err = %v", err)
+			return err
+		}
+		if status == "approved" && change.EffectiveDate <= now.Format("2006-01-02") {
+			return applyParameterChange(tx, change, approverId)
+		}
+		return nil
+	})
+}
+
+// CancelParameterChangeV2 撤销尚未生效的参数变更申请
+func CancelParameterChangeV2(c *gin.Context, changeId string, operator string) error {
+	result := resource.HrmsDB(c).Model(&model.SalaryV2ParameterChange{}).
+		Where("change_id = ? and status in ?", changeId, []string{"pending", "approved"}).
+		Updates(map[string]interface{}{
+			"status":     "cancelled",
+			"updated_by": operator,
+		})
+	if result.Error != nil {
+		log.Printf("CancelParameterChangeV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("该变更申请已生效或已结束，无法撤销")
+	}
+	return nil
+}
+
+// ApplyDueParameterChanges 执行已审批且到达生效日期的参数变更申请，由定时任务每日执行
+func ApplyDueParameterChanges() {
+	today := time.Now().Format("2006-01-02")
+	for dbName, db := range resource.DbMapper {
+		var changes []*model.SalaryV2ParameterChange
+		if err := db.Where("status = ? and effective_date <= ?", "approved", today).Order("effective_date asc, id asc").
+			Find(&changes).Error; err != nil {
+			log.Printf("分公司 %s 查询待生效参数变更失败: %v", dbName, err)
+			continue
+		}
+		for _, change := range changes {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return applyParameterChange(tx, change, "system")
+			})
+			if err != nil {
+				log.Printf("分公司 %s 参数变更 %s 生效失败: %v", dbName, change.ChangeId, err)
+				db.Model(&model.SalaryV2ParameterChange{}).Where("change_id = ?", change.ChangeId).
+					Updates(map[string]interface{}{"status": "failed", "apply_error": err.Error(), "updated_by": "system"})
+			}
+		}
+	}
+}
+
+func getParameterChange(db *gorm.DB, changeId string) (*model.SalaryV2ParameterChange, error) {
+	var change model.SalaryV2ParameterChange
+	if err := db.Where("change_id = ?", changeId).First(&change).Error; err != nil {
+		return nil, errors.New("不存在该参数变更申请")
+	}
+	return &change, nil
+}
+
+// applyParameterChange 执行参数变更，新增参数时按申请内容创建记录，修改及停用时提交后参数已被其他途径修改的拒绝覆盖
+func applyParameterChange(tx *gorm.DB, change *model.SalaryV2ParameterChange, operator string) error {
+	reason := "Parameter change " + change.ChangeId
+	if change.Reason != "" {
+		reason += ": " + change.Reason
+	}
+	if change.Operation == "create" {
+		if _, err := findParameterRecordId(tx, change.ParameterType, change.ParameterId); err == nil {
+			return errors.New("参数已存在: " + change.ParameterId)
+		}
+		if err := createParameterRecord(tx, change.ParameterType, change.NewValue, operator, reason); err != nil {
+			return err
+		}
+	} else {
+		current, _, _, err := loadParameterRecord(tx, change.ParameterType, change.TargetId)
+		if err != nil {
+			return err
+		}
+		if conflicts := diffParameterValues(change.OldValue, current, parameterValueFields(change.NewValue)); len(conflicts) > 0 {
+			return fmt.Errorf("参数在申请提交后已被修改(%s)，请重新提交变更申请", conflicts[0].Field)
+		}
+		if err := updateParameterRecord(tx, change.ParameterType, change.TargetId, change.NewValue, operator, reason); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	return tx.Model(&model.SalaryV2ParameterChange{}).Where("change_id = ?", change.ChangeId).
+		Updates(map[string]interface{}{
+			"status":      "applied",
+			"applied_at":  &now,
+			"apply_error": "",
+			"updated_by":  operator,
+		}).Error
+}
+
+// updateParameterRecord 按参数类型将参数JSON内容写入指定记录，变更申请、历史版本及快照恢复时调用
+func updateParameterRecord(tx *gorm.DB, parameterType string, id uint, value string, operator string, reason string) error {
+	var err error
+	switch parameterType {
+	case "tax_bracket":
+		var dto model.SalaryV2TaxBracketEditDTO
+		if err = json.Unmarshal([]byte(value), &dto); err == nil {
+			dto.ID, dto.EffectiveDate = id, normalizeParameterDate(dto.EffectiveDate)
+			err = updateTaxBracket(tx, &dto, operator, reason)
+		}
+	case "insurance_rate":
+		var dto model.SalaryV2InsuranceRateEditDTO
+		if err = json.Unmarshal([]byte(value), &dto); err == nil {
+			dto.ID, dto.EffectiveDate = id, normalizeParameterDate(dto.EffectiveDate)
+			err = updateInsuranceRate(tx, &dto, operator, reason)
+		}
+	case "calculation_rule":
+		var dto model.SalaryV2CalculationRuleEditDTO
+		if err = json.Unmarshal([]byte(value), &dto); err == nil {
+			dto.ID, dto.EffectiveDate = id, normalizeParameterDate(dto.EffectiveDate)
+			err = updateCalculationRule(tx, &dto, operator, reason)
+		}
+	case "system_parameter":
+		var dto model.SalaryV2SystemParameterEditDTO
+		if err = json.Unmarshal([]byte(value), &dto); err == nil {
+			dto.ID = id
+			err = updateSystemParameter(tx, &dto, operator, reason)
+		}
+	default:
+		err = errors.New("不支持的参数类型: " + parameterType)
+	}
+	return err
+}
+
+// normalizeParameterDate 数据库中读出的日期可能带有时间部分，只保留日期
+func normalizeParameterDate(date string) string {
+	if len(date) > 10 {
+		return date[:10]
+	}
+	return date
+}
+
+// buildParameterChange 按参数类型取出修改内容及当前参数快照，生效日期为空的参数沿用原生效日期
+func buildParameterChange(db *gorm.DB, dto *model.SalaryV2ParameterChangeCreateDTO) (*model.SalaryV2ParameterChange, error) {
+	var targetId uint
+	var newValue interface{}
+	switch dto.ParameterType {
+	case "tax_bracket":
+		if dto.TaxBracket == nil {
+			return nil, errors.New("请填写税率区间修改内容")
+		}
+		targetId, newValue = dto.TaxBracket.ID, dto.TaxBracket
+	case "insurance_rate":
+		if dto.InsuranceRate == nil {
+			return nil, errors.New("请填写社保费率修改内容")
+		}
+		targetId, newValue = dto.InsuranceRate.ID, dto.InsuranceRate
+	case "calculation_rule":
+		if dto.CalculationRule == nil {
+			return nil, errors.New("请填写计算规则修改内容")
+		}
+		if dto.CalculationRule.Formula != "" {
+			if err := ValidateFormula(dto.CalculationRule.Formula); err != nil {
+				return nil, err
+			}
+		}
+		targetId, newValue = dto.CalculationRule.ID, dto.CalculationRule
+	case "system_parameter":
+		if dto.SystemParameter == nil {
+			return nil, errors.New("请填写系统参数修改内容")
+		}
+		targetId, newValue = dto.SystemParameter.ID, dto.SystemParameter
+	default:
+		return nil, errors.New("不支持的参数类型: " + dto.ParameterType)
+	}
+
+	oldValue, parameterId, parameterName, err := loadParameterRecord(db, dto.ParameterType, targetId)
+	if err != nil {
+		return nil, err
+	}
+	switch value := newValue.(type) {
+	case *model.SalaryV2TaxBracketEditDTO:
+		if value.EffectiveDate == "" {
+			value.EffectiveDate = parameterEffectiveDate(oldValue, dto.EffectiveDate)
+		}
+		var bracket model.SalaryV2TaxBracket
+		json.Unmarshal([]byte(oldValue), &bracket)
+		bracketType := bracket.BracketType
+		Transfer(value, &bracket)
+		if bracket.BracketType == "" {
+			bracket.BracketType = bracketType
+		}
+		if err := validateTaxBracket(&bracket); err != nil {
+			return nil, err
+		}
+	case *model.SalaryV2InsuranceRateEditDTO:
+		if value.EffectiveDate == "" {
+			value.EffectiveDate = parameterEffectiveDate(oldValue, dto.EffectiveDate)
+		}
+	case *model.SalaryV2CalculationRuleEditDTO:
+		if value.EffectiveDate == "" {
+			value.EffectiveDate = parameterEffectiveDate(oldValue, dto.EffectiveDate)
+		}
+	}
+	content, err := json.Marshal(newValue)
+	if err != nil {
+		return nil, err
+	}
+	return &model.SalaryV2ParameterChange{
+		ParameterType: dto.ParameterType,
+		TargetId:      targetId,
+		ParameterId:   parameterId,
+		ParameterName: parameterName,
+		OldValue:      oldValue,
+		NewValue:      string(content),
+	}, nil
+}
+
+// parameterEffectiveDate 修改内容未填写生效日期时，使用申请的生效日期，否则沿用原生效日期
+func parameterEffectiveDate(oldValue string, changeEffectiveDate string) string {
+	if changeEffectiveDate != "" {
+		return changeEffectiveDate
+	}
+	var old struct {
+		EffectiveDate string `json:"effective_date"`
+	}
+	json.Unmarshal([]byte(oldValue), &old)
+	return normalizeParameterDate(old.EffectiveDate)
+}
+
+// loadParameterRecord 读取参数当前值，返回JSON快照、业务ID及名称
+func loadParameterRecord(db *gorm.DB, parameterType string, id uint) (string, string, string, error) {
+	var record interface{}
+	var parameterId, parameterName string
+	switch parameterType {
+	case "tax_bracket":
+		var bracket model.SalaryV2TaxBracket
+		if err := db.First(&bracket, id).Error; err != nil {
+			return "", "", "", errors.New("不存在该税率区间")
+		}
+		record, parameterId, parameterName = &bracket, bracket.TaxBracketId, bracket.Description
+	case "insurance_rate":
+		var rate model.SalaryV2InsuranceRate
+		if err := db.First(&rate, id).Error; err != nil {
+			return "", "", "", errors.New("不存在该社保费率")
+		}
+		record, parameterId, parameterName = &rate, rate.InsuranceRateId, insuranceTypeName(rate.InsuranceType)
+	case "calculation_rule":
+		var rule model.SalaryV2CalculationRule
+		if err := db.First(&rule, id).Error; err != nil {
+			return "", "", "", errors.New("不存在该计算规则")
+		}
+		record, parameterId, parameterName = &rule, rule.CalculationRuleId, rule.RuleName
+	case "system_parameter":
+		var parameter model.SalaryV2SystemParameter
+		if err := db.First(&parameter, id).Error; err != nil {
+			return "", "", "", errors.New("不存在该系统参数")
+		}
+		record, parameterId, parameterName = &parameter, parameter.ParameterId, parameter.ParameterKey
+	default:
+		return "", "", "", errors.New("不支持的参数类型: " + parameterType)
+	}
+	content, err := json.Marshal(record)
+	if err != nil {
+		return "", "", "", err
+	}
+	return string(content), parameterId, parameterName, nil
+}
+
+// parameterValueFields 获取变更内容中的字段名，主键不参与比较
+func parameterValueFields(value string) []string {
+	var values map[string]interface{}
+	json.Unmarshal([]byte(value), &values)
+	var fields []string
+	for field := range values {
+		if field != "id" {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// diffParameterValues 比较两个参数JSON快照在指定字段上的差异
+func diffParameterValues(oldValue, newValue string, fields []string) []*model.SalaryV2ParameterFieldDiff {
+	var oldValues, newValues map[string]interface{}
+	json.Unmarshal([]byte(oldValue), &oldValues)
+	json.Unmarshal([]byte(newValue), &newValues)
+	var diffs []*model.SalaryV2ParameterFieldDiff
+	for _, field := range fields {
+		before, after := formatParameterValue(oldValues[field]), formatParameterValue(newValues[field])
+		if field == "effective_date" {
+			before, after = normalizeParameterDate(before), normalizeParameterDate(after)
+		}
+		if before != after {
+			diffs = append(diffs, &model.SalaryV2ParameterFieldDiff{Field: field, OldValue: before, NewValue: after})
+		}
+	}
+	return diffs
+}
+
+func formatParameterValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	}
+	content, _ := json.Marshal(value)
+	return string(content)
+}
@@ -0,0 +1,29 @@
+package service
+
+import "testing"
+
+func TestDiffParameterValues(t *testing.T) {
+	oldValue := `{"id":3,"tax_rate":0.1,"quick_deduction":2520.00,"description":"3%-10%","effective_date":"2024-01-01T00:00:00+08:00"}`
+	newValue := `{"id":3,"tax_rate":0.12,"quick_deduction":2520,"description":"3%-10%","effective_date":"2024-01-01"}`
+	diffs := diffParameterValues(oldValue, newValue, parameterValueFields(newValue))
+	if len(diffs) != 1 {
+		t.Fatalf("diffs = %d, want 1", len(diffs))
+	}
+	if diffs[0].Field != "tax_rate" || diffs[0].OldValue != "0.1" || diffs[0].NewValue != "0.12" {
+		t.Errorf("diff = %+v", diffs[0])
+	}
+}
+
+func TestParameterEditChangeDeactivate(t *testing.T) {
+	value := `{"id":7,"tax_bracket_id":"tax_bracket_1","bracket_type":"monthly","tax_rate":10,"is_active":true,"effective_date":"2024-01-01T00:00:00+08:00"}`
+	dto, err := parameterEditChange("tax_bracket", 7, deactivateParameterValue(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dto.TaxBracket.ID != 7 || dto.TaxBracket.IsActive || dto.TaxBracket.TaxRate != 10 || dto.TaxBracket.EffectiveDate != "2024-01-01" {
+		t.Errorf("tax bracket = %+v", dto.TaxBracket)
+	}
+	if _, err := parameterEditChange("system_parameter", 1, value); err == nil {
+		t.Error("system_parameter should not be supported")
+	}
+}
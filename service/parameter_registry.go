@@ -0,0 +1,232 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func floatLimit(value float64) *float64 {
+	return &value
+}
+
+// systemParameterSchemas 已注册的系统参数，新增系统参数时须在此登记类型及取值约束
+var systemParameterSchemas = []*model.SalaryV2SystemParameterSchema{
+	{ParameterKey: "monthly_work_days", ParameterType: "decimal", Required: true, Min: floatLimit(1), Max: floatLimit(31), Description: "月计薪天数"},
+	{ParameterKey: "daily_work_hours", ParameterType: "number", Required: true, Min: floatLimit(1), Max: floatLimit(24), Description: "每日标准工作小时数"},
+	{ParameterKey: "min_wage_standard", ParameterType: "decimal", Min: floatLimit(0), Description: "最低工资标准"},
+	{ParameterKey: "insurance_base_min", ParameterType: "decimal", Min: floatLimit(0), Description: "社保缴费基数下限"},
+	{ParameterKey: "insurance_base_max", ParameterType: "decimal", Min: floatLimit(0), Description: "社保缴费基数上限"},
+	{ParameterKey: "housing_base_min", ParameterType: "decimal", Min: floatLimit(0), Description: "公积金缴费基数下限"},
+	{ParameterKey: "housing_base_max", ParameterType: "decimal", Min: floatLimit(0), Description: "公积金缴费基数上限"},
+	{ParameterKey: "tax_threshold", ParameterType: "decimal", Required: true, Min: floatLimit(0), Description: "个税起征点(每月减除费用)"},
+	{ParameterKey: "full_attendance_bonus", ParameterType: "decimal", Min: floatLimit(0), Description: "全勤奖"},
+	{ParameterKey: "late_grace_minutes", ParameterType: "number", Min: floatLimit(0), Max: floatLimit(1440), Description: "迟到宽限分钟数"},
+	{ParameterKey: "early_leave_grace_minutes", ParameterType: "number", Min: floatLimit(0), Max: floatLimit(1440), Description: "早退宽限分钟数"},
+	{ParameterKey: "overtime_apply_hours", ParameterType: "number", Min: floatLimit(0), Description: "加班申请提前小时数"},
+	{ParameterKey: "leave_apply_hours", ParameterType: "number", Min: floatLimit(0), Description: "请假申请提前小时数"},
+	{ParameterKey: "salary_calculation_precision", ParameterType: "number", Min: floatLimit(0), Max: floatLimit(2), Description: "薪资计算精度(小数位数)"},
+	{ParameterKey: "salary_payment_day", ParameterType: "number", Min: floatLimit(1), Max: floatLimit(31), Description: "每月发薪日"},
+	{ParameterKey: "enable_salary_slip", ParameterType: "boolean", Description: "是否启用工资条"},
+	{ParameterKey: "salary_slip_method", ParameterType: "string", Description: "工资条发送方式"},
+	{ParameterKey: "attendance_cycle", ParameterType: "string", Description: "考勤周期"},
+	{ParameterKey: "overtime_approval_flow", ParameterType: "string", Description: "加班审批流程"},
+	{ParameterKey: "leave_approval_flow", ParameterType: "string", Description: "请假审批流程"},
+	{ParameterKey: "default_subsidy", ParameterType: "number", Min: floatLimit(0), Description: "默认津贴补贴"},
+	{ParameterKey: "default_bonus", ParameterType: "number", Min: floatLimit(0), Description: "默认绩效奖金"},
+	{ParameterKey: "default_commission", ParameterType: "number", Min: floatLimit(0), Description: "默认提成"},
+	{ParameterKey: "default_other", ParameterType: "number", Min: floatLimit(0), Description: "默认其他收入"},
+	{ParameterKey: "default_fund_enabled", ParameterType: "boolean", Description: "默认是否缴纳公积金"},
+	{ParameterKey: "probation_pay_ratio", ParameterType: "decimal", Min: floatLimit(0.01), Max: floatLimit(1), DefaultValue: "1", Description: "试用期工资比例"},
+	{ParameterKey: "public_holidays", ParameterType: "string", Format: "date_list", Description: "法定节假日(兼容旧配置，请在工作日历中维护)"},
+	{ParameterKey: "adjusted_workdays", ParameterType: "string", Format: "date_list", Description: "调休上班日(兼容旧配置，请在工作日历中维护)"},
+	{ParameterKey: "local_average_wage", ParameterType: "decimal", Min: floatLimit(0), DefaultValue: "0", Description: "当地上年度职工月平均工资"},
+	{ParameterKey: "default_bank_code", ParameterType: "string", Description: "默认代发银行"},
+	{ParameterKey: "payslip_password_digits", ParameterType: "number", Min: floatLimit(0), Max: floatLimit(18), DefaultValue: "0", Description: "工资条PDF密码使用的证件号码位数，0表示不加密"},
+	{ParameterKey: "money_rounding", ParameterType: "string", Options: []string{model.MoneyRoundingCent, model.MoneyRoundingYuan}, DefaultValue: model.MoneyRoundingCent, Description: "实发工资舍入规则"},
+	{ParameterKey: "currency", ParameterType: "string", Format: "currency", DefaultValue: "CNY", Description: "薪资发放币种"},
+}
+
+// GetSystemParameterSchemasV2 获取已注册的系统参数约束
+func GetSystemParameterSchemasV2() []*model.SalaryV2SystemParameterSchema {
+	return systemParameterSchemas
+}
+
+func getSystemParameterSchema(parameterKey string) *model.SalaryV2SystemParameterSchema {
+	for _, schema := range systemParameterSchemas {
+		if schema.ParameterKey == parameterKey {
+			return schema
+		}
+	}
+	return nil
+}
+
+// validateSystemParameter 校验系统参数值，已注册的参数按注册表约束校验，其余参数只按参数类型校验
+func validateSystemParameter(parameterKey string, parameterType string, value string) error {
+	schema := getSystemParameterSchema(parameterKey)
+	if schema == nil {
+		return validateSystemParameterValue(parameterType, value)
+	}
+	if parameterType != schema.ParameterType {
+		return fmt.Errorf("参数类型应为%s", schema.ParameterType)
+	}
+	value = strings.TrimSpace(value)
+	if value == "" {
+		if schema.Required {
+			return errors.New("参数值不能为空")
+		}
+		if schema.ParameterType == "string" {
+			return nil
+		}
+	}
+	if err := validateSystemParameterValue(schema.ParameterType, value); err != nil {
+		return err
+	}
+	if schema.Min != nil || schema.Max != nil {
+		number, _ := strconv.ParseFloat(value, 64)
+		if schema.Min != nil && number < *schema.Min {
+			return fmt.Errorf("参数值不能小于%v", *schema.Min)
+		}
+		if schema.Max != nil && number > *schema.Max {
+			return fmt.Errorf("参数值不能大于%v", *schema.Max)
+		}
+	}
+	if len(schema.Options) > 0 {
+		valid := false
+		for _, option := range schema.Options {
+			valid = valid || option == value
+		}
+		if !valid {
+			return fmt.Errorf("参数值应为%s之一", strings.Join(schema.Options, "、"))
+		}
+	}
+	switch schema.Format {
+	case "date_list":
+		for _, date := range strings.Split(value, ",") {
+			if date = strings.TrimSpace(date); date == "" {
+				continue
+			}
+			if _, err := time.Parse("2006-01-02", date); err != nil {
+				return errors.New("日期格式错误: " + date)
+			}
+		}
+	case "currency":
+		if len(value) != 3 || strings.ToUpper(value) != value {
+			return errors.New("币种应为3位大写ISO 4217代码")
+		}
+	}
+	return nil
+}
+
+// validateSystemParameterValue 按参数类型校验系统参数值
+func validateSystemParameterValue(parameterType string, value string) error {
+	switch parameterType {
+	case "number", "decimal":
+		if _, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err != nil {
+			return errors.New("参数值不是有效数字: " + value)
+		}
+	case "boolean":
+		if _, err := strconv.ParseBool(strings.TrimSpace(value)); err != nil {
+			return errors.New("参数值不是有效布尔值: " + value)
+		}
+	case "json":
+		if !json.Valid([]byte(value)) {
+			return errors.New("参数值不是有效JSON")
+		}
+	}
+	return nil
+}
+
+// requireSystemParameter 读取必须有效的系统参数值，未配置时使用注册表默认值，没有默认值或参数值不符合约束时返回错误
+func requireSystemParameter(db *gorm.DB, parameterKey string) (string, error) {
+	schema := getSystemParameterSchema(parameterKey)
+	var parameter model.SalaryV2SystemParameter
+	if err := db.Where("parameter_key = ? and is_active = ?", parameterKey, true).First(&parameter).Error; err != nil {
+		if schema != nil && schema.DefaultValue != "" {
+			return schema.DefaultValue, nil
+		}
+		return "", fmt.Errorf("未配置系统参数 %s", parameterKey)
+	}
+	if err := validateSystemParameter(parameterKey, parameter.ParameterType, parameter.ParameterValue); err != nil {
+		return "", fmt.Errorf("系统参数 %s 配置错误: %v", parameterKey, err)
+	}
+	return strings.TrimSpace(parameter.ParameterValue), nil
+}
+
+// requireSystemParameterFloat 读取数值型系统参数，未配置或配置错误时返回错误
+func requireSystemParameterFloat(db *gorm.DB, parameterKey string) (float64, error) {
+	value, err := requireSystemParameter(db, parameterKey)
+	if err != nil {
+		return 0, err
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, fmt.Errorf("系统参数 %s 不是有效数字: %s", parameterKey, value)
+	}
+	return number, nil
+}
+
+// requireSystemParameterMoney 读取金额型系统参数，未配置或配置错误时返回错误
+func requireSystemParameterMoney(db *gorm.DB, parameterKey string) (model.Money, error) {
+	value, err := requireSystemParameter(db, parameterKey)
+	if err != nil {
+		return 0, err
+	}
+	amount, err := model.ParseMoney(value)
+	if err != nil {
+		return 0, fmt.Errorf("系统参数 %s 不是有效金额: %s", parameterKey, value)
+	}
+	return amount, nil
+}
+
+// CheckSystemParameters 启动时检查各分公司必需的系统参数已配置，且已配置的注册参数符合约束；
+// 有问题的分公司逐项记录日志，核算薪资时由 checkPayrollSystemParameters 拒绝，不影响其他分公司
+func CheckSystemParameters() {
+	for dbName, db := range resource.DbMapper {
+		problems := systemParameterProblems(db)
+		for _, problem := range problems {
+			log.Printf("分公司 %s %s", dbName, problem)
+		}
+		if len(problems) > 0 {
+			log.Printf("分公司 %s 系统参数检查未通过，共%d项问题，修正前该分公司将拒绝核算薪资", dbName, len(problems))
+		}
+	}
+}
+
+// checkPayrollSystemParameters 核算薪资前检查当前分公司的系统参数，检查未通过时拒绝核算
+func checkPayrollSystemParameters(db *gorm.DB) error {
+	if problems := systemParameterProblems(db); len(problems) > 0 {
+		return errors.New("系统参数检查未通过，拒绝核算薪资: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// systemParameterProblems 检查必需的系统参数已配置，且已配置的注册参数符合约束，返回全部问题
+func systemParameterProblems(db *gorm.DB) []string {
+	var parameters []*model.SalaryV2SystemParameter
+	if err := db.Where("is_active = ?", true).Find(&parameters).Error; err != nil {
+		return []string{fmt.Sprintf("读取系统参数失败: %v", err)}
+	}
+	var problems []string
+	configured := make(map[string]bool)
+	for _, parameter := range parameters {
+		configured[parameter.ParameterKey] = true
+		if err := validateSystemParameter(parameter.ParameterKey, parameter.ParameterType, parameter.ParameterValue); err != nil {
+			problems = append(problems, fmt.Sprintf("系统参数 %s 配置错误: %v", parameter.ParameterKey, err))
+		}
+	}
+	for _, schema := range systemParameterSchemas {
+		if schema.Required && !configured[schema.ParameterKey] {
+			problems = append(problems, fmt.Sprintf("缺少必需的系统参数 %s(%s)", schema.ParameterKey, schema.Description))
+		}
+	}
+	return problems
+}
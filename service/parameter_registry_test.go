@@ -0,0 +1,33 @@
+package service
+
+import "testing"
+
+func TestValidateSystemParameter(t *testing.T) {
+	cases := []struct {
+		key, parameterType, value string
+		valid                     bool
+	}{
+		{"monthly_work_days", "decimal", "21.75", true},
+		{"monthly_work_days", "decimal", "", false},
+		{"monthly_work_days", "decimal", "0", false},
+		{"monthly_work_days", "string", "21.75", false},
+		{"daily_work_hours", "number", "8", true},
+		{"money_rounding", "string", "yuan", true},
+		{"money_rounding", "string", "jiao", false},
+		{"currency", "string", "HKD", true},
+		{"currency", "string", "hkd", false},
+		{"public_holidays", "string", "", true},
+		{"public_holidays", "string", "2025-01-01, 2025-10-01", true},
+		{"public_holidays", "string", "2025-13-01", false},
+		{"enable_salary_slip", "boolean", "true", true},
+		{"enable_salary_slip", "boolean", "yes", false},
+		{"custom_key", "number", "abc", false},
+		{"custom_key", "string", "anything", true},
+	}
+	for _, tc := range cases {
+		err := validateSystemParameter(tc.key, tc.parameterType, tc.value)
+		if (err == nil) != tc.valid {
+			t.Errorf("validateSystemParameter(%s, %s, %q) err = %v, want valid %v", tc.key, tc.parameterType, tc.value, err, tc.valid)
+		}
+	}
+}
@@ -3,9 +3,12 @@ package service
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"hrms/model"
 	"hrms/resource"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -13,68 +16,75 @@ import (
 )
 
 // Tax Bracket Services
-func CreateTaxBracketV2(c *gin.Context, dto *model.SalaryV2TaxBracketCreateDTO, createdBy string) error {
+
+// CreateTaxBracketV2 提交新增税率区间的变更申请，经其他管理员审批后生效
+func CreateTaxBracketV2(c *gin.Context, dto *model.SalaryV2TaxBracketCreateDTO, createdBy string) (*model.SalaryV2ParameterChange, error) {
 	var taxBracket model.SalaryV2TaxBracket
 	Transfer(&dto, &taxBracket)
-	// 将元转换为分存储
-	taxBracket.MinIncome = int64(dto.MinIncome * 100)
-	taxBracket.MaxIncome = int64(dto.MaxIncome * 100)
-	taxBracket.QuickDeduction = int64(dto.QuickDeduction * 100)
 	taxBracket.TaxBracketId = RandomID("tax_bracket")
-	taxBracket.CreatedBy = createdBy
-	taxBracket.UpdatedBy = createdBy
-	
-	if err := resource.HrmsDB(c).Create(&taxBracket).Error; err != nil {
-		log.Printf("CreateTaxBracketV2 err = %v", err)
-		return err
+	if taxBracket.BracketType == "" {
+		taxBracket.BracketType = "monthly"
 	}
-	return nil
+	taxBracket.IsActive = true
+	if err := validateTaxBracket(&taxBracket); err != nil {
+		return nil, err
+	}
+	
+	return submitParameterCreate(resource.HrmsDB(c), "tax_bracket", taxBracket.TaxBracketId, taxBracket.Description,
+		&taxBracket, taxBracket.EffectiveDate, "", createdBy)
 }
 
-func GetTaxBracketsV2(c *gin.Context, start int, limit int) ([]*model.SalaryV2TaxBracket, int64, error) {
+func GetTaxBracketsV2(c *gin.Context, bracketType string, start int, limit int) ([]*model.SalaryV2TaxBracket, int64, error) {
 	var taxBrackets []*model.SalaryV2TaxBracket
 	var err error
+	var query *gorm.DB
+	
+	if bracketType != "" {
+		query = resource.HrmsDB(c).Where("bracket_type = ? and is_active = ?", bracketType, true)
+	} else {
+		query = resource.HrmsDB(c).Where("is_active = ?", true)
+	}
+	
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2TaxBracket{}).Count(&total)
 	
 	if start == -1 && limit == -1 {
-		err = resource.HrmsDB(c).Where("is_active = ?", true).Order("min_income asc").Find(&taxBrackets).Error
+		err = query.Order("min_income asc").Find(&taxBrackets).Error
 	} else {
-		err = resource.HrmsDB(c).Where("is_active = ?", true).Offset(start).Limit(limit).Order("min_income asc").Find(&taxBrackets).Error
+		err = query.Offset(start).Limit(limit).Order("min_income asc").Find(&taxBrackets).Error
 	}
 	
 	if err != nil {
 		return nil, 0, err
 	}
 	
-	// 将分转换为元返回
-	for _, bracket := range taxBrackets {
-		bracket.MinIncome = bracket.MinIncome / 100
-		if bracket.MaxIncome > 0 {
-			bracket.MaxIncome = bracket.MaxIncome / 100
-		}
-		bracket.QuickDeduction = bracket.QuickDeduction / 100
-	}
-	
-	var total int64
-	resource.HrmsDB(c).Model(&model.SalaryV2TaxBracket{}).Where("is_active = ?", true).Count(&total)
 	return taxBrackets, total, nil
 }
 
 func UpdateTaxBracketV2(c *gin.Context, dto *model.SalaryV2TaxBracketEditDTO, updatedBy string) error {
+	return updateTaxBracket(resource.HrmsDB(c), dto, updatedBy, "Tax bracket updated")
+}
+
+// updateTaxBracket 修改税率区间并记录参数变更历史，直接修改及变更申请生效时调用
+func updateTaxBracket(db *gorm.DB, dto *model.SalaryV2TaxBracketEditDTO, updatedBy string, changeReason string) error {
 	var oldTaxBracket model.SalaryV2TaxBracket
-	if err := resource.HrmsDB(c).First(&oldTaxBracket, dto.ID).Error; err != nil {
+	if err := db.First(&oldTaxBracket, dto.ID).Error; err != nil {
 		return err
 	}
 	
 	var taxBracket model.SalaryV2TaxBracket
 	Transfer(&dto, &taxBracket)
-	// 将元转换为分存储
-	taxBracket.MinIncome = int64(dto.MinIncome * 100)
-	taxBracket.MaxIncome = int64(dto.MaxIncome * 100)
-	taxBracket.QuickDeduction = int64(dto.QuickDeduction * 100)
 	taxBracket.UpdatedBy = updatedBy
+	if taxBracket.BracketType == "" {
+		taxBracket.BracketType = oldTaxBracket.BracketType
+	}
+	if err := validateTaxBracket(&taxBracket); err != nil {
+		return err
+	}
 	
-	if err := resource.HrmsDB(c).Model(&model.SalaryV2TaxBracket{}).Where("id = ?", dto.ID).
+	if err := db.Model(&model.SalaryV2TaxBracket{}).Where("id = ?", dto.ID).
 		Updates(map[string]interface{}{
+			"bracket_type":    taxBracket.BracketType,
 			"min_income":      taxBracket.MinIncome,
 			"max_income":      taxBracket.MaxIncome,
 			"tax_rate":        taxBracket.TaxRate,
@@ -91,53 +101,57 @@ func UpdateTaxBracketV2(c *gin.Context, dto *model.SalaryV2TaxBracketEditDTO, up
 	// Record history
 	oldValue, _ := json.Marshal(oldTaxBracket)
 	newValue, _ := json.Marshal(taxBracket)
-	recordParameterHistory(c, oldTaxBracket.TaxBracketId, "tax_bracket", string(oldValue), string(newValue), "Tax bracket updated", updatedBy)
+	recordParameterHistory(db, oldTaxBracket.TaxBracketId, "tax_bracket", string(oldValue), string(newValue), changeReason, updatedBy)
 	
 	return nil
 }
 
-func DeleteTaxBracketV2(c *gin.Context, id uint, deletedBy string) error {
-	var taxBracket model.SalaryV2TaxBracket
-	if err := resource.HrmsDB(c).First(&taxBracket, id).Error; err != nil {
-		return err
-	}
-	
-	if err := resource.HrmsDB(c).Model(&model.SalaryV2TaxBracket{}).Where("id = ?", id).
-		Update("is_active", false).Error; err != nil {
-		log.Printf("DeleteTaxBracketV2 err = %v", err)
-		return err
-	}
-	
-	// Record history
-	oldValue, _ := json.Marshal(taxBracket)
-	recordParameterHistory(c, taxBracket.TaxBracketId, "tax_bracket", string(oldValue), "", "Tax bracket deleted", deletedBy)
-	
-	return nil
+// DeleteTaxBracketV2 提交停用税率区间的变更申请，经其他管理员审批后生效
+func DeleteTaxBracketV2(c *gin.Context, id uint, deletedBy string) (*model.SalaryV2ParameterChange, error) {
+	return submitParameterDeactivate(resource.HrmsDB(c), "tax_bracket", id, "", deletedBy)
 }
 
 // Insurance Rate Services
-func CreateInsuranceRateV2(c *gin.Context, dto *model.SalaryV2InsuranceRateCreateDTO, createdBy string) error {
+
+// CreateInsuranceRateV2 提交新增社保费率的变更申请，经其他管理员审批后生效
+func CreateInsuranceRateV2(c *gin.Context, dto *model.SalaryV2InsuranceRateCreateDTO, createdBy string) (*model.SalaryV2ParameterChange, error) {
+	db := resource.HrmsDB(c)
+	if dto.PolicyId != "" {
+		if _, err := getInsurancePolicy(db, dto.PolicyId); err != nil {
+			return nil, err
+		}
+	}
 	var insuranceRate model.SalaryV2InsuranceRate
 	Transfer(&dto, &insuranceRate)
-	// 将元转换为分存储
-	if dto.MinBase > 0 {
-		insuranceRate.MinBase = int64(dto.MinBase * 100)
-	}
-	if dto.MaxBase > 0 {
-		insuranceRate.MaxBase = int64(dto.MaxBase * 100)
+	insuranceRate.InsuranceRateId = RandomID("insurance_rate")
+	insuranceRate.IsActive = true
+	
+	return submitParameterCreate(db, "insurance_rate", insuranceRate.InsuranceRateId, insuranceTypeName(insuranceRate.InsuranceType),
+		&insuranceRate, insuranceRate.EffectiveDate, "", createdBy)
+}
+
+// createInsuranceRate 创建社保费率，policy_id 不为空时创建参保方案下的费率
+func createInsuranceRate(db *gorm.DB, dto *model.SalaryV2InsuranceRateCreateDTO, createdBy string) error {
+	if dto.PolicyId != "" {
+		if _, err := getInsurancePolicy(db, dto.PolicyId); err != nil {
+			return err
+		}
 	}
+	var insuranceRate model.SalaryV2InsuranceRate
+	Transfer(&dto, &insuranceRate)
 	insuranceRate.InsuranceRateId = RandomID("insurance_rate")
 	insuranceRate.CreatedBy = createdBy
 	insuranceRate.UpdatedBy = createdBy
 	
-	if err := resource.HrmsDB(c).Create(&insuranceRate).Error; err != nil {
+	if err := db.Create(&insuranceRate).Error; err != nil {
 		log.Printf("CreateInsuranceRateV2 err = %v", err)
 		return err
 	}
 	return nil
 }
 
-func GetInsuranceRatesV2(c *gin.Context, insuranceType string, start int, limit int) ([]*model.SalaryV2InsuranceRate, int64, error) {
+// GetInsuranceRatesV2 获取社保费率列表，policyId 为空时获取分公司默认费率
+func GetInsuranceRatesV2(c *gin.Context, insuranceType string, policyId string, start int, limit int) ([]*model.SalaryV2InsuranceRate, int64, error) {
 	var insuranceRates []*model.SalaryV2InsuranceRate
 	var err error
 	var query *gorm.DB
@@ -147,6 +161,10 @@ func GetInsuranceRatesV2(c *gin.Context, insuranceType string, start int, limit
 	} else {
 		query = resource.HrmsDB(c).Where("is_active = ?", true)
 	}
+	query = query.Where("policy_id = ?", policyId)
+	
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2InsuranceRate{}).Count(&total)
 	
 	if start == -1 && limit == -1 {
 		err = query.Order("effective_date desc").Find(&insuranceRates).Error
@@ -158,39 +176,26 @@ func GetInsuranceRatesV2(c *gin.Context, insuranceType string, start int, limit
 		return nil, 0, err
 	}
 	
-	// 将分转换为元返回
-	for _, rate := range insuranceRates {
-		if rate.MinBase > 0 {
-			rate.MinBase = rate.MinBase / 100
-		}
-		if rate.MaxBase > 0 {
-			rate.MaxBase = rate.MaxBase / 100
-		}
-	}
-	
-	var total int64
-	query.Model(&model.SalaryV2InsuranceRate{}).Count(&total)
 	return insuranceRates, total, nil
 }
 
 func UpdateInsuranceRateV2(c *gin.Context, dto *model.SalaryV2InsuranceRateEditDTO, updatedBy string) error {
+	return updateInsuranceRate(resource.HrmsDB(c), dto, updatedBy, "Insurance rate updated")
+}
+
+// updateInsuranceRate 修改社保费率并记录参数变更历史，直接修改及变更申请生效时调用
+func updateInsuranceRate(db *gorm.DB, dto *model.SalaryV2InsuranceRateEditDTO, updatedBy string, changeReason string) error {
 	var oldInsuranceRate model.SalaryV2InsuranceRate
-	if err := resource.HrmsDB(c).First(&oldInsuranceRate, dto.ID).Error; err != nil {
+	if err := db.First(&oldInsuranceRate, dto.ID).Error; err != nil {
 		return err
 	}
 	
 	var insuranceRate model.SalaryV2InsuranceRate
 	Transfer(&dto, &insuranceRate)
-	// 将元转换为分存储
-	if dto.MinBase > 0 {
-		insuranceRate.MinBase = int64(dto.MinBase * 100)
-	}
-	if dto.MaxBase > 0 {
-		insuranceRate.MaxBase = int64(dto.MaxBase * 100)
-	}
+	insuranceRate.PolicyId = oldInsuranceRate.PolicyId
 	insuranceRate.UpdatedBy = updatedBy
 	
-	if err := resource.HrmsDB(c).Model(&model.SalaryV2InsuranceRate{}).Where("id = ?", dto.ID).
+	if err := db.Model(&model.SalaryV2InsuranceRate{}).Where("id = ?", dto.ID).
 		Updates(map[string]interface{}{
 			"insurance_type": insuranceRate.InsuranceType,
 			"employee_rate":  insuranceRate.EmployeeRate,
@@ -209,34 +214,28 @@ func UpdateInsuranceRateV2(c *gin.Context, dto *model.SalaryV2InsuranceRateEditD
 	// Record history
 	oldValue, _ := json.Marshal(oldInsuranceRate)
 	newValue, _ := json.Marshal(insuranceRate)
-	recordParameterHistory(c, oldInsuranceRate.InsuranceRateId, "insurance_rate", string(oldValue), string(newValue), "Insurance rate updated", updatedBy)
+	recordParameterHistory(db, oldInsuranceRate.InsuranceRateId, "insurance_rate", string(oldValue), string(newValue), changeReason, updatedBy)
 	
 	return nil
 }
 
-func DeleteInsuranceRateV2(c *gin.Context, id uint, deletedBy string) error {
-	var insuranceRate model.SalaryV2InsuranceRate
-	if err := resource.HrmsDB(c).First(&insuranceRate, id).Error; err != nil {
-		return err
-	}
-	
-	if err := resource.HrmsDB(c).Model(&model.SalaryV2InsuranceRate{}).Where("id = ?", id).
-		Update("is_active", false).Error; err != nil {
-		log.Printf("DeleteInsuranceRateV2 err = %v", err)
-		return err
-	}
-	
-	// Record history
-	oldValue, _ := json.Marshal(insuranceRate)
-	recordParameterHistory(c, insuranceRate.InsuranceRateId, "insurance_rate", string(oldValue), "", "Insurance rate deleted", deletedBy)
-	
-	return nil
+// DeleteInsuranceRateV2 提交停用社保费率的变更申请，经其他管理员审批后生效
+func DeleteInsuranceRateV2(c *gin.Context, id uint, deletedBy string) (*model.SalaryV2ParameterChange, error) {
+	return submitParameterDeactivate(resource.HrmsDB(c), "insurance_rate", id, "", deletedBy)
 }
 
 // Calculation Rule Services
 func CreateCalculationRuleV2(c *gin.Context, dto *model.SalaryV2CalculationRuleCreateDTO, createdBy string) error {
 	var calculationRule model.SalaryV2CalculationRule
 	Transfer(&dto, &calculationRule)
+	if err := checkCalculationRuleCode(resource.HrmsDB(c), calculationRule.RuleCode, 0); err != nil {
+		return err
+	}
+	if calculationRule.Formula != "" {
+		if err := ValidateFormula(calculationRule.Formula); err != nil {
+			return err
+		}
+	}
 	calculationRule.CalculationRuleId = RandomID("calculation_rule")
 	calculationRule.CreatedBy = createdBy
 	calculationRule.UpdatedBy = createdBy
@@ -275,20 +274,37 @@ func GetCalculationRulesV2(c *gin.Context, ruleType string, start int, limit int
 }
 
 func UpdateCalculationRuleV2(c *gin.Context, dto *model.SalaryV2CalculationRuleEditDTO, updatedBy string) error {
+	return updateCalculationRule(resource.HrmsDB(c), dto, updatedBy, "Calculation rule updated")
+}
+
+// updateCalculationRule 修改计算规则并记录参数变更历史，直接修改及变更申请生效时调用
+func updateCalculationRule(db *gorm.DB, dto *model.SalaryV2CalculationRuleEditDTO, updatedBy string, changeReason string) error {
 	var oldCalculationRule model.SalaryV2CalculationRule
-	if err := resource.HrmsDB(c).First(&oldCalculationRule, dto.ID).Error; err != nil {
+	if err := db.First(&oldCalculationRule, dto.ID).Error; err != nil {
 		return err
 	}
 	
 	var calculationRule model.SalaryV2CalculationRule
 	Transfer(&dto, &calculationRule)
+	if calculationRule.IsActive {
+		if err := checkCalculationRuleCode(db, calculationRule.RuleCode, dto.ID); err != nil {
+			return err
+		}
+	}
+	if calculationRule.Formula != "" {
+		if err := ValidateFormula(calculationRule.Formula); err != nil {
+			return err
+		}
+	}
 	calculationRule.UpdatedBy = updatedBy
 	
-	if err := resource.HrmsDB(c).Model(&model.SalaryV2CalculationRule{}).Where("id = ?", dto.ID).
+	if err := db.Model(&model.SalaryV2CalculationRule{}).Where("id = ?", dto.ID).
 		Updates(map[string]interface{}{
 			"rule_type":        calculationRule.RuleType,
+			"rule_code":        calculationRule.RuleCode,
 			"rule_name":        calculationRule.RuleName,
 			"rule_value":       calculationRule.RuleValue,
+			"formula":          calculationRule.Formula,
 			"rule_description": calculationRule.RuleDescription,
 			"is_active":        calculationRule.IsActive,
 			"effective_date":   calculationRule.EffectiveDate,
@@ -301,11 +317,25 @@ func UpdateCalculationRuleV2(c *gin.Context, dto *model.SalaryV2CalculationRuleE
 	// Record history
 	oldValue, _ := json.Marshal(oldCalculationRule)
 	newValue, _ := json.Marshal(calculationRule)
-	recordParameterHistory(c, oldCalculationRule.CalculationRuleId, "calculation_rule", string(oldValue), string(newValue), "Calculation rule updated", updatedBy)
+	recordParameterHistory(db, oldCalculationRule.CalculationRuleId, "calculation_rule", string(oldValue), string(newValue), changeReason, updatedBy)
 	
 	return nil
 }
 
+// checkCalculationRuleCode 同一规则编码只允许存在一条启用的规则，保证计算时取值唯一
+func checkCalculationRuleCode(db *gorm.DB, ruleCode string, excludeId uint) error {
+	if ruleCode == "" {
+		return nil
+	}
+	var count int64
+	db.Model(&model.SalaryV2CalculationRule{}).
+		Where("rule_code = ? and is_active = ? and id <> ?", ruleCode, true, excludeId).Count(&count)
+	if count > 0 {
+		return errors.New("已存在启用的规则编码: " + ruleCode)
+	}
+	return nil
+}
+
 func DeleteCalculationRuleV2(c *gin.Context, id uint, deletedBy string) error {
 	var calculationRule model.SalaryV2CalculationRule
 	if err := resource.HrmsDB(c).First(&calculationRule, id).Error; err != nil {
@@ -320,7 +350,7 @@ func DeleteCalculationRuleV2(c *gin.Context, id uint, deletedBy string) error {
 	
 	// Record history
 	oldValue, _ := json.Marshal(calculationRule)
-	recordParameterHistory(c, calculationRule.CalculationRuleId, "calculation_rule", string(oldValue), "", "Calculation rule deleted", deletedBy)
+	recordParameterHistory(resource.HrmsDB(c), calculationRule.CalculationRuleId, "calculation_rule", string(oldValue), "", "Calculation rule deleted", deletedBy)
 	
 	return nil
 }
@@ -329,6 +359,9 @@ func DeleteCalculationRuleV2(c *gin.Context, id uint, deletedBy string) error {
 func CreateSystemParameterV2(c *gin.Context, dto *model.SalaryV2SystemParameterCreateDTO, createdBy string) error {
 	var systemParameter model.SalaryV2SystemParameter
 	Transfer(&dto, &systemParameter)
+	if err := validateSystemParameter(systemParameter.ParameterKey, systemParameter.ParameterType, systemParameter.ParameterValue); err != nil {
+		return errors.New("系统参数 " + systemParameter.ParameterKey + " " + err.Error())
+	}
 	systemParameter.ParameterId = RandomID("parameter")
 	systemParameter.CreatedBy = createdBy
 	systemParameter.UpdatedBy = createdBy
@@ -367,16 +400,28 @@ func GetSystemParametersV2(c *gin.Context, category string, start int, limit int
 }
 
 func UpdateSystemParameterV2(c *gin.Context, dto *model.SalaryV2SystemParameterEditDTO, updatedBy string) error {
+	return updateSystemParameter(resource.HrmsDB(c), dto, updatedBy, "System parameter updated")
+}
+
+// updateSystemParameter 修改系统参数并记录参数变更历史，直接修改及变更申请生效时调用
+func updateSystemParameter(db *gorm.DB, dto *model.SalaryV2SystemParameterEditDTO, updatedBy string, changeReason string) error {
 	var oldSystemParameter model.SalaryV2SystemParameter
-	if err := resource.HrmsDB(c).First(&oldSystemParameter, dto.ID).Error; err != nil {
+	if err := db.First(&oldSystemParameter, dto.ID).Error; err != nil {
 		return err
 	}
 	
 	var systemParameter model.SalaryV2SystemParameter
 	Transfer(&dto, &systemParameter)
+	if err := validateSystemParameter(systemParameter.ParameterKey, systemParameter.ParameterType, systemParameter.ParameterValue); err != nil {
+		return errors.New("系统参数 " + systemParameter.ParameterKey + " " + err.Error())
+	}
+	if schema := getSystemParameterSchema(oldSystemParameter.ParameterKey); schema != nil && schema.Required &&
+		(!systemParameter.IsActive || systemParameter.ParameterKey != oldSystemParameter.ParameterKey) {
+		return errors.New("系统参数 " + oldSystemParameter.ParameterKey + " 为薪资计算必需参数，不能停用或修改参数键")
+	}
 	systemParameter.UpdatedBy = updatedBy
 	
-	if err := resource.HrmsDB(c).Model(&model.SalaryV2SystemParameter{}).Where("id = ?", dto.ID).
+	if err := db.Model(&model.SalaryV2SystemParameter{}).Where("id = ?", dto.ID).
 		Updates(map[string]interface{}{
 			"parameter_key":         systemParameter.ParameterKey,
 			"parameter_value":       systemParameter.ParameterValue,
@@ -394,7 +439,7 @@ func UpdateSystemParameterV2(c *gin.Context, dto *model.SalaryV2SystemParameterE
 	// Record history
 	oldValue, _ := json.Marshal(oldSystemParameter)
 	newValue, _ := json.Marshal(systemParameter)
-	recordParameterHistory(c, oldSystemParameter.ParameterId, "system_parameter", string(oldValue), string(newValue), "System parameter updated", updatedBy)
+	recordParameterHistory(db, oldSystemParameter.ParameterId, "system_parameter", string(oldValue), string(newValue), changeReason, updatedBy)
 	
 	return nil
 }
@@ -404,6 +449,9 @@ func DeleteSystemParameterV2(c *gin.Context, id uint, deletedBy string) error {
 	if err := resource.HrmsDB(c).First(&systemParameter, id).Error; err != nil {
 		return err
 	}
+	if schema := getSystemParameterSchema(systemParameter.ParameterKey); schema != nil && schema.Required {
+		return errors.New("系统参数 " + systemParameter.ParameterKey + " 为薪资计算必需参数，不能删除")
+	}
 	
 	if err := resource.HrmsDB(c).Model(&model.SalaryV2SystemParameter{}).Where("id = ?", id).
 		Update("is_active", false).Error; err != nil {
@@ -413,7 +461,7 @@ func DeleteSystemParameterV2(c *gin.Context, id uint, deletedBy string) error {
 	
 	// Record history
 	oldValue, _ := json.Marshal(systemParameter)
-	recordParameterHistory(c, systemParameter.ParameterId, "system_parameter", string(oldValue), "", "System parameter deleted", deletedBy)
+	recordParameterHistory(resource.HrmsDB(c), systemParameter.ParameterId, "system_parameter", string(oldValue), "", "System parameter deleted", deletedBy)
 	
 	return nil
 }
@@ -448,7 +496,7 @@ func GetParameterHistoryV2(c *gin.Context, parameterType string, parameterId str
 }
 
 // Helper function to record parameter history
-func recordParameterHistory(c *gin.Context, parameterId string, parameterType string, oldValue string, newValue string, changeReason string, changedBy string) {
+func recordParameterHistory(db *gorm.DB, parameterId string, parameterType string, oldValue string, newValue string, changeReason string, changedBy string) {
 	history := model.SalaryV2ParameterHistory{
 		HistoryId:     RandomID("history"),
 		ParameterId:   parameterId,
@@ -460,14 +508,14 @@ func recordParameterHistory(c *gin.Context, parameterId string, parameterType st
 		ChangeDate:    time.Now().Format("2006-01-02 15:04:05"),
 	}
 	
-	if err := resource.HrmsDB(c).Create(&history).Error; err != nil {
+	if err := db.Create(&history).Error; err != nil {
 		log.Printf("recordParameterHistory err = %v", err)
 	}
 }
 
 // Salary Calculation Services using V2 parameters
-func CalculateTaxV2(c *gin.Context, taxableIncome int64) (float64, error) {
-	taxBrackets, _, err := GetTaxBracketsV2(c, -1, -1)
+func CalculateTaxV2(c *gin.Context, taxableIncome model.Money) (model.Money, error) {
+	taxBrackets, _, err := GetTaxBracketsV2(c, "monthly", -1, -1)
 	if err != nil {
 		return 0, err
 	}
@@ -476,45 +524,14 @@ func CalculateTaxV2(c *gin.Context, taxableIncome int64) (float64, error) {
 		return 0, errors.New("no active tax brackets found")
 	}
 	
-	var tax float64 = 0
-	// 前端传入的是元，转换为分进行计算
-	remainingIncome := taxableIncome * 100
-	
-	for _, bracket := range taxBrackets {
-		if remainingIncome <= 0 {
-			break
-		}
-		
-		var taxableAmount int64
-		if bracket.MaxIncome > 0 && remainingIncome > bracket.MaxIncome {
-			taxableAmount = bracket.MaxIncome - bracket.MinIncome + 1
-		} else {
-			taxableAmount = remainingIncome - bracket.MinIncome + 1
-		}
-		
-		if taxableAmount > 0 {
-			tax += float64(taxableAmount) * bracket.TaxRate
-			remainingIncome -= taxableAmount
-		}
-	}
-	
-	// Apply quick deduction for the applicable bracket
-	for _, bracket := range taxBrackets {
-		if taxableIncome*100 >= bracket.MinIncome && (bracket.MaxIncome == 0 || taxableIncome*100 <= bracket.MaxIncome) {
-			tax -= float64(bracket.QuickDeduction)
-			break
-		}
-	}
-	
-	// 返回元单位的税额
-	return tax / 100, nil
+	return calculateTaxByBrackets(taxBrackets, taxableIncome), nil
 }
 
-func CalculateInsuranceV2(c *gin.Context, salary float64, insuranceTypes []string) (map[string]float64, error) {
-	result := make(map[string]float64)
+func CalculateInsuranceV2(c *gin.Context, salary model.Money, insuranceTypes []string) (map[string]model.Money, error) {
+	result := make(map[string]model.Money)
 	
 	for _, insuranceType := range insuranceTypes {
-		rates, _, err := GetInsuranceRatesV2(c, insuranceType, -1, -1)
+		rates, _, err := GetInsuranceRatesV2(c, insuranceType, "", -1, -1)
 		if err != nil {
 			return nil, err
 		}
@@ -523,21 +540,8 @@ func CalculateInsuranceV2(c *gin.Context, salary float64, insuranceTypes []strin
 			continue
 		}
 		
-		// Use the most recent active rate
-		rate := rates[0]
-		// 前端传入的是元，转换为分进行计算
-		var baseSalary float64 = salary * 100
-		
-		// Apply min/max base constraints
-		if rate.MinBase > 0 && salary*100 < float64(rate.MinBase) {
-			baseSalary = float64(rate.MinBase)
-		}
-		if rate.MaxBase > 0 && salary*100 > float64(rate.MaxBase) {
-			baseSalary = float64(rate.MaxBase)
-		}
-		
-		// 返回元单位的保险费用
-		result[insuranceType] = (baseSalary * rate.EmployeeRate) / 100
+		// Use the most recent active rate, apply min/max base constraints
+		result[insuranceType] = calculateInsuranceContribution(rates[0], salary).EmployeeAmount
 	}
 	
 	return result, nil
@@ -575,21 +579,58 @@ func GetCalculationRulesByType(c *gin.Context, ruleType string) ([]*model.Salary
 	return rules, nil
 }
 
-// GetInsuranceRates 获取社保费率
+// GetCalculationRuleByCode 根据规则编码获取启用的计算规则
+func GetCalculationRuleByCode(c *gin.Context, ruleCode string) (*model.SalaryV2CalculationRule, error) {
+	var rule model.SalaryV2CalculationRule
+	if err := resource.HrmsDB(c).Where("rule_code = ? and is_active = ?", ruleCode, true).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// EvaluateCalculationRule 计算规则取值，配置了公式时按公式计算，否则使用规则数值
+func EvaluateCalculationRule(rule *model.SalaryV2CalculationRule, variables map[string]float64) (float64, error) {
+	if rule.Formula == "" {
+		return rule.RuleValue, nil
+	}
+	ruleVariables := make(map[string]float64, len(variables)+1)
+	for name, value := range variables {
+		ruleVariables[name] = value
+	}
+	ruleVariables["rule_value"] = rule.RuleValue
+	value, err := EvaluateFormula(rule.Formula, ruleVariables)
+	if err != nil {
+		return 0, errors.New("计算规则" + rule.RuleName + "公式计算失败: " + err.Error())
+	}
+	return value, nil
+}
+
+// GetInsuranceRates 获取分公司默认社保费率
 func GetInsuranceRates(c *gin.Context) ([]*model.SalaryV2InsuranceRate, error) {
-	rates, _, err := GetInsuranceRatesV2(c, "", -1, -1)
+	rates, _, err := GetInsuranceRatesV2(c, "", "", -1, -1)
 	if err != nil {
 		return nil, err
 	}
 	return rates, nil
 }
 
-// GetTaxBrackets 获取税率配置
+// GetTaxBrackets 获取月度税率配置
 func GetTaxBrackets(c *gin.Context) ([]*model.SalaryV2TaxBracket, error) {
-	brackets, _, err := GetTaxBracketsV2(c, -1, -1)
+	return GetTaxBracketsByType(c, "monthly")
+}
+
+// GetTaxBracketsByType 根据税率表类型获取税率配置
+func GetTaxBracketsByType(c *gin.Context, bracketType string) ([]*model.SalaryV2TaxBracket, error) {
+	brackets, _, err := GetTaxBracketsV2(c, bracketType, -1, -1)
 	if err != nil {
 		return nil, err
 	}
+	// 税率表有误时拒绝计税，避免按错误的区间扣税
+	if len(brackets) > 0 {
+		if errs := validateTaxBracketTable(brackets); len(errs) > 0 {
+			return nil, fmt.Errorf("%s税率表配置错误: %s", bracketTypeName(bracketType), strings.Join(errs, "; "))
+		}
+	}
 	return brackets, nil
 }
 
@@ -600,4 +641,30 @@ func GetSystemParameterValueV2(c *gin.Context, parameterKey string) (string, err
 	}
 	
 	return parameter.ParameterValue, nil
-}
\ No newline at end of file
+}
+
+// getSystemParameterFloat 按数据库连接读取数值型系统参数，未配置或格式错误时返回默认值
+func getSystemParameterFloat(db *gorm.DB, parameterKey string, defaultValue float64) float64 {
+	var parameter model.SalaryV2SystemParameter
+	if err := db.Where("parameter_key = ? and is_active = ?", parameterKey, true).First(&parameter).Error; err != nil {
+		return defaultValue
+	}
+	value, err := strconv.ParseFloat(strings.TrimSpace(parameter.ParameterValue), 64)
+	if err != nil {
+		log.Printf("系统参数 %s 不是有效数字: %s，使用默认值 %v", parameterKey, parameter.ParameterValue, defaultValue)
+		return defaultValue
+	}
+	return value
+}
+
+// getSystemParameterString 按数据库连接读取字符串型系统参数，未配置时返回默认值
+func getSystemParameterString(db *gorm.DB, parameterKey string, defaultValue string) string {
+	var parameter model.SalaryV2SystemParameter
+	if err := db.Where("parameter_key = ? and is_active = ?", parameterKey, true).First(&parameter).Error; err != nil {
+		return defaultValue
+	}
+	if value := strings.TrimSpace(parameter.ParameterValue); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+// parameterDocumentVersion 参数导入导出文件格式版本
+const parameterDocumentVersion = 1
+
+// parameterExportIgnoredFields 导出时去除的主键及审计字段，分公司之间按业务ID对应参数
+var parameterExportIgnoredFields = []string{"id", "ID", "CreatedAt", "UpdatedAt", "DeletedAt", "created_by", "updated_by"}
+
+// ExportParameterSetV2 导出当前分公司全部V2薪资参数，format为json或yaml
+func ExportParameterSetV2(c *gin.Context, format string) ([]byte, error) {
+	set, err := loadParameterSet(resource.HrmsDB(c))
+	if err != nil {
+		return nil, err
+	}
+	branchId, _ := getCurrentBranch(c)
+	parameters, err := exportParameterSet(set)
+	if err != nil {
+		return nil, err
+	}
+	document := map[string]interface{}{
+		"version":     parameterDocumentVersion,
+		"branch_id":   branchId,
+		"exported_at": time.Now().Format("2006-01-02 15:04:05"),
+		"parameters":  parameters,
+	}
+	switch format {
+	case "yaml":
+		return yaml.Marshal(yamlValue(document))
+	case "json", "":
+		return json.MarshalIndent(document, "", "  ")
+	}
+	return nil, errors.New("不支持的导出格式: " + format)
+}
+
+// ImportParameterSetV2 导入参数文件，校验通过且非试运行时整体替换文件中包含的参数类型，任一参数失败时全部回滚；
+// 税率区间及社保费率不直接修改，提交变更申请待其他管理员审批
+func ImportParameterSetV2(c *gin.Context, content []byte, format string, dryRun bool, operator string) (*model.SalaryV2ParameterImportResult, error) {
+	document, err := decodeParameterDocument(content, format)
+	if err != nil {
+		return nil, err
+	}
+	result := &model.SalaryV2ParameterImportResult{Errors: validateParameterSet(document.Parameters)}
+	db := resource.HrmsDB(c)
+	current, err := loadParameterSet(db)
+	if err != nil {
+		return nil, err
+	}
+	target := mergeParameterSet(current, document.Parameters)
+	result.Diffs = diffParameterSets(current, target)
+	if len(result.Errors) > 0 || dryRun || len(result.Diffs) == 0 {
+		return result, nil
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		backupName := "导入参数前自动备份 " + time.Now().Format("2006-01-02 15:04:05")
+		if _, err := createParameterSnapshot(tx, backupName, "", operator); err != nil {
+			return err
+		}
+		changes, err := restoreParameterSet(tx, current, target, operator, "Imported parameter set from "+document.BranchId)
+		result.PendingChanges = changes
+		return err
+	})
+	if err != nil {
+		log.Printf("ImportParameterSetV2 err = %v", err)
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// SyncParameterSetV2 将当前分公司的参数同步至选定分公司，各分公司分别在事务中执行并返回各自结果；
+// 税率区间及社保费率在目标分公司提交变更申请，由该分公司其他管理员审批后生效
+func SyncParameterSetV2(c *gin.Context, dto *model.SalaryV2ParameterSyncDTO, operator string) ([]*model.SalaryV2ParameterSyncResult, error) {
+	source, err := loadParameterSet(resource.HrmsDB(c))
+	if err != nil {
+		return nil, err
+	}
+	source, err = filterParameterSet(source, dto.Sections)
+	if err != nil {
+		return nil, err
+	}
+	if errs := validateParameterSet(source); len(errs) > 0 {
+		return nil, errors.New("当前分公司参数校验不通过: " + strings.Join(errs, "; "))
+	}
+	// 同步内容去除主键，目标分公司按业务ID对应
+	content, err := json.Marshal(source)
+	if err != nil {
+		return nil, err
+	}
+	sourceBranchId, _ := getCurrentBranch(c)
+
+	var results []*model.SalaryV2ParameterSyncResult
+	for _, branchId := range dto.BranchIds {
+		result := &model.SalaryV2ParameterSyncResult{BranchId: branchId}
+		results = append(results, result)
+		if branchId == sourceBranchId {
+			result.Error = "不能同步至当前分公司"
+			continue
+		}
+		db, ok := resource.DbMapper["hrms_"+branchId]
+		if !ok {
+			result.Error = "不存在该分公司数据库"
+			continue
+		}
+		var target model.SalaryV2ParameterSet
+		json.Unmarshal(content, &target)
+		err := db.Transaction(func(tx *gorm.DB) error {
+			current, err := loadParameterSet(tx)
+			if err != nil {
+				return err
+			}
+			merged := mergeParameterSet(current, &target)
+			result.Diffs = diffParameterSets(current, merged)
+			if dto.DryRun || len(result.Diffs) == 0 {
+				return nil
+			}
+			backupName := "同步参数前自动备份 " + time.Now().Format("2006-01-02 15:04:05")
+			if _, err := createParameterSnapshot(tx, backupName, "由分公司 "+sourceBranchId+" 同步", operator); err != nil {
+				return err
+			}
+			changes, err := restoreParameterSet(tx, current, merged, operator, "Synced parameter set from "+sourceBranchId)
+			if err != nil {
+				return err
+			}
+			result.Applied, result.PendingChanges = true, changes
+			return nil
+		})
+		if err != nil {
+			log.Printf("分公司 %s 同步参数失败: %v", branchId, err)
+			result.Error, result.Applied, result.PendingChanges = err.Error(), false, nil
+			continue
+		}
+		result.Success = true
+	}
+	return results, nil
+}
+
+// validateParameterSet 校验参数集，返回全部校验错误
+func validateParameterSet(set *model.SalaryV2ParameterSet) []string {
+	var errs []string
+	addError := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Sprintf(format, args...))
+	}
+	ids := make(map[string]bool)
+	checkId := func(parameterType string, id string) {
+		if id == "" {
+			addError("%s 缺少业务ID", parameterType)
+		} else if ids[parameterType+":"+id] {
+			addError("%s 业务ID重复: %s", parameterType, id)
+		}
+		ids[parameterType+":"+id] = true
+	}
+
+	for _, bracket := range set.TaxBrackets {
+		checkId("tax_bracket", bracket.TaxBracketId)
+		if bracket.BracketType != "monthly" && bracket.BracketType != "annual" {
+			addError("税率区间 %s 税率表类型错误: %s", bracket.TaxBracketId, bracket.BracketType)
+		}
+		if bracket.TaxRate < 0 || bracket.TaxRate > 100 {
+			addError("税率区间 %s 税率超出范围: %v", bracket.TaxBracketId, bracket.TaxRate)
+		}
+		if bracket.MinIncome < 0 || (bracket.MaxIncome > 0 && bracket.MaxIncome <= bracket.MinIncome) {
+			addError("税率区间 %s 收入区间错误", bracket.TaxBracketId)
+		}
+	}
+	errs = append(errs, validateParameterSetTaxBrackets(set.TaxBrackets)...)
+	for _, rate := range set.InsuranceRates {
+		checkId("insurance_rate", rate.InsuranceRateId)
+		if rate.InsuranceType == "" {
+			addError("社保费率 %s 缺少险种", rate.InsuranceRateId)
+		}
+		if rate.EmployeeRate < 0 || rate.EmployeeRate > 100 || rate.EmployerRate < 0 || rate.EmployerRate > 100 {
+			addError("社保费率 %s 费率超出范围", rate.InsuranceRateId)
+		}
+		if rate.MaxBase > 0 && rate.MaxBase < rate.MinBase {
+			addError("社保费率 %s 缴费基数上限低于下限", rate.InsuranceRateId)
+		}
+	}
+	ruleCodes := make(map[string]bool)
+	for _, rule := range set.CalculationRules {
+		checkId("calculation_rule", rule.CalculationRuleId)
+		if rule.RuleName == "" {
+			addError("计算规则 %s 缺少规则名称", rule.CalculationRuleId)
+		}
+		if rule.Formula != "" {
+			if err := ValidateFormula(rule.Formula); err != nil {
+				addError("计算规则 %s 公式错误: %v", rule.CalculationRuleId, err)
+			}
+		}
+		if rule.IsActive && rule.RuleCode != "" {
+			if ruleCodes[rule.RuleCode] {
+				addError("计算规则编码重复: %s", rule.RuleCode)
+			}
+			ruleCodes[rule.RuleCode] = true
+		}
+	}
+	keys := make(map[string]bool)
+	for _, parameter := range set.SystemParameters {
+		checkId("system_parameter", parameter.ParameterId)
+		if parameter.ParameterKey == "" {
+			addError("系统参数 %s 缺少参数键", parameter.ParameterId)
+		} else if keys[parameter.ParameterKey] {
+			addError("系统参数键重复: %s", parameter.ParameterKey)
+		}
+		keys[parameter.ParameterKey] = true
+		if err := validateSystemParameter(parameter.ParameterKey, parameter.ParameterType, parameter.ParameterValue); err != nil {
+			addError("系统参数 %s %v", parameter.ParameterKey, err)
+		}
+	}
+	if set.SystemParameters != nil {
+		active := make(map[string]bool)
+		for _, parameter := range set.SystemParameters {
+			active[parameter.ParameterKey] = active[parameter.ParameterKey] || parameter.IsActive
+		}
+		for _, schema := range systemParameterSchemas {
+			if schema.Required && !active[schema.ParameterKey] {
+				addError("缺少必需的系统参数 %s", schema.ParameterKey)
+			}
+		}
+	}
+	return errs
+}
+
+// validateParameterSetTaxBrackets 按税率表类型校验参数集中启用的税率区间
+func validateParameterSetTaxBrackets(brackets []*model.SalaryV2TaxBracket) []string {
+	var errs []string
+	for _, bracketType := range []string{"monthly", "annual"} {
+		var active []*model.SalaryV2TaxBracket
+		for _, bracket := range brackets {
+			if bracket.BracketType == bracketType && bracket.IsActive {
+				active = append(active, bracket)
+			}
+		}
+		if len(active) == 0 {
+			continue
+		}
+		for _, err := range validateTaxBracketTable(active) {
+			errs = append(errs, fmt.Sprintf("%s税率表%s", bracketTypeName(bracketType), err))
+		}
+	}
+	return errs
+}
+
+// mergeParameterSet 目标参数集中缺少的参数类型沿用当前参数
+func mergeParameterSet(current *model.SalaryV2ParameterSet, target *model.SalaryV2ParameterSet) *model.SalaryV2ParameterSet {
+	merged := *target
+	if merged.TaxBrackets == nil {
+		merged.TaxBrackets = current.TaxBrackets
+	}
+	if merged.InsuranceRates == nil {
+		merged.InsuranceRates = current.InsuranceRates
+	}
+	if merged.CalculationRules == nil {
+		merged.CalculationRules = current.CalculationRules
+	}
+	if merged.SystemParameters == nil {
+		merged.SystemParameters = current.SystemParameters
+	}
+	return &merged
+}
+
+// filterParameterSet 只保留指定的参数类型，sections为空时保留全部
+func filterParameterSet(set *model.SalaryV2ParameterSet, sections []string) (*model.SalaryV2ParameterSet, error) {
+	if len(sections) == 0 {
+		return set, nil
+	}
+	filtered := &model.SalaryV2ParameterSet{}
+	for _, section := range sections {
+		switch section {
+		case "tax_bracket":
+			filtered.TaxBrackets = set.TaxBrackets
+		case "insurance_rate":
+			filtered.InsuranceRates = set.InsuranceRates
+		case "calculation_rule":
+			filtered.CalculationRules = set.CalculationRules
+		case "system_parameter":
+			filtered.SystemParameters = set.SystemParameters
+		default:
+			return nil, errors.New("不支持的参数类型: " + section)
+		}
+	}
+	return filtered, nil
+}
+
+// exportParameterSet 将参数集转换为去除主键及审计字段的通用结构
+func exportParameterSet(set *model.SalaryV2ParameterSet) (map[string][]map[string]interface{}, error) {
+	content, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	var sections map[string][]map[string]interface{}
+	if err := json.Unmarshal(content, &sections); err != nil {
+		return nil, err
+	}
+	for name, records := range sections {
+		if records == nil {
+			sections[name] = []map[string]interface{}{}
+		}
+		for _, record := range records {
+			for _, field := range parameterExportIgnoredFields {
+				delete(record, field)
+			}
+			if date, ok := record["effective_date"].(string); ok {
+				record["effective_date"] = normalizeParameterDate(date)
+			}
+		}
+	}
+	return sections, nil
+}
+
+// decodeParameterDocument 解析参数导入文件，YAML先转换为JSON再按JSON字段解析
+func decodeParameterDocument(content []byte, format string) (*model.SalaryV2ParameterDocument, error) {
+	switch format {
+	case "yaml":
+		var value interface{}
+		if err := yaml.Unmarshal(content, &value); err != nil {
+			return nil, errors.New("YAML格式错误: " + err.Error())
+		}
+		var err error
+		if content, err = json.Marshal(value); err != nil {
+			return nil, errors.New("YAML格式错误: " + err.Error())
+		}
+	case "json", "":
+	default:
+		return nil, errors.New("不支持的导入格式: " + format)
+	}
+	var document model.SalaryV2ParameterDocument
+	if err := json.Unmarshal(content, &document); err != nil {
+		return nil, errors.New("参数文件格式错误: " + err.Error())
+	}
+	if document.Version != parameterDocumentVersion {
+		return nil, fmt.Errorf("不支持的参数文件版本: %d", document.Version)
+	}
+	if document.Parameters == nil {
+		return nil, errors.New("参数文件中没有参数")
+	}
+	return &document, nil
+}
+
+// yamlValue 整数金额转换为整型，避免YAML中输出为科学计数法
+func yamlValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = yamlValue(item)
+		}
+	case map[string][]map[string]interface{}:
+		for _, records := range v {
+			for _, record := range records {
+				yamlValue(record)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = yamlValue(item)
+		}
+	case float64:
+		if v == float64(int64(v)) {
+			return int64(v)
+		}
+	}
+	return value
+}
@@ -0,0 +1,62 @@
+package service
+
+import (
+	"hrms/model"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestParameterDocumentYAMLRoundTrip(t *testing.T) {
+	set := &model.SalaryV2ParameterSet{
+		TaxBrackets: []*model.SalaryV2TaxBracket{
+			{ID: 6, TaxBracketId: "tax_bracket_101", BracketType: "annual", MinIncome: 0, MaxIncome: model.NewMoney(36000.5),
+				TaxRate: 3, IsActive: true, EffectiveDate: "2024-01-01T00:00:00+08:00"},
+			{ID: 7, TaxBracketId: "tax_bracket_102", BracketType: "annual", MinIncome: model.NewMoney(36000.5),
+				MaxIncome: 0, TaxRate: 10, QuickDeduction: model.NewMoney(2520.04),
+				IsActive: true, EffectiveDate: "2024-01-01T00:00:00+08:00"},
+		},
+		SystemParameters: []*model.SalaryV2SystemParameter{
+			{ID: 1, ParameterId: "param_001", ParameterKey: "monthly_work_days", ParameterValue: "21.75", ParameterType: "decimal", IsActive: true},
+			{ID: 2, ParameterId: "param_002", ParameterKey: "daily_work_hours", ParameterValue: "8", ParameterType: "number", IsActive: true},
+			{ID: 3, ParameterId: "param_008", ParameterKey: "tax_threshold", ParameterValue: "5000", ParameterType: "decimal", IsActive: true},
+		},
+	}
+	parameters, err := exportParameterSet(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, err := yaml.Marshal(yamlValue(map[string]interface{}{
+		"version":    parameterDocumentVersion,
+		"parameters": parameters,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	document, err := decodeParameterDocument(content, "yaml")
+	if err != nil {
+		t.Fatalf("decode err = %v\n%s", err, content)
+	}
+	if diffs := diffParameterSets(set, document.Parameters); len(diffs) != 0 {
+		t.Errorf("round trip diffs = %+v\n%s", diffs[0].Fields, content)
+	}
+	if errs := validateParameterSet(document.Parameters); len(errs) != 0 {
+		t.Errorf("validate errs = %v", errs)
+	}
+}
+
+func TestValidateParameterSet(t *testing.T) {
+	set := &model.SalaryV2ParameterSet{
+		TaxBrackets: []*model.SalaryV2TaxBracket{
+			{TaxBracketId: "tax_bracket_001", BracketType: "monthly", MinIncome: 900, MaxIncome: 300, TaxRate: 3},
+		},
+		SystemParameters: []*model.SalaryV2SystemParameter{
+			{ParameterId: "param_001", ParameterKey: "monthly_work_days", ParameterValue: "abc", ParameterType: "decimal"},
+			{ParameterId: "param_001", ParameterKey: "monthly_work_days", ParameterValue: "21.75", ParameterType: "decimal", IsActive: true},
+		},
+	}
+	// 区间错误、参数值错误、业务ID及参数键重复，以及缺少daily_work_hours、tax_threshold
+	if errs := validateParameterSet(set); len(errs) != 6 {
+		t.Errorf("validate errs = %v, want 6", errs)
+	}
+}
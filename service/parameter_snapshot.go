@@ -0,0 +1,471 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// RestoreParameterHistoryV2 将参数恢复至历史版本，税率区间及社保费率提交变更申请待审批，其余参数立即恢复
+func RestoreParameterHistoryV2(c *gin.Context, dto *model.SalaryV2ParameterRestoreDTO, operator string) (*model.SalaryV2ParameterChange, error) {
+	db := resource.HrmsDB(c)
+	var history model.SalaryV2ParameterHistory
+	if err := db.Where("history_id = ?", dto.HistoryId).First(&history).Error; err != nil {
+		return nil, errors.New("不存在该参数历史记录")
+	}
+	value := history.OldValue
+	if dto.Version == "new" {
+		value = history.NewValue
+	}
+	if value == "" {
+		return nil, errors.New("该历史版本没有参数内容，无法恢复")
+	}
+	id, err := findParameterRecordId(db, history.ParameterType, history.ParameterId)
+	if err != nil {
+		return nil, err
+	}
+
+	if parameterRequiresApproval(history.ParameterType) {
+		change, err := parameterEditChange(history.ParameterType, id, value)
+		if err != nil {
+			return nil, err
+		}
+		change.Reason = "恢复至历史版本 " + history.HistoryId
+		return CreateParameterChangeV2(c, change, operator)
+	}
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		return updateParameterRecord(tx, history.ParameterType, id, value, operator, "Restored from history "+history.HistoryId)
+	})
+	return nil, err
+}
+
+// CreateParameterSnapshotV2 保存当前分公司全部V2薪资参数的命名快照
+func CreateParameterSnapshotV2(c *gin.Context, dto *model.SalaryV2ParameterSnapshotCreateDTO, createdBy string) (*model.SalaryV2ParameterSnapshot, error) {
+	return createParameterSnapshot(resource.HrmsDB(c), dto.SnapshotName, dto.Description, createdBy)
+}
+
+func createParameterSnapshot(db *gorm.DB, name string, description string, createdBy string) (*model.SalaryV2ParameterSnapshot, error) {
+	var count int64
+	db.Model(&model.SalaryV2ParameterSnapshot{}).Where("snapshot_name = ?", name).Count(&count)
+	if count > 0 {
+		return nil, errors.New("快照名称已存在: " + name)
+	}
+	set, err := loadParameterSet(db)
+	if err != nil {
+		return nil, err
+	}
+	content, err := json.Marshal(set)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &model.SalaryV2ParameterSnapshot{
+		SnapshotId:           RandomID("parameter_snapshot"),
+		SnapshotName:         name,
+		Description:          description,
+		Content:              string(content),
+		TaxBracketCount:      len(set.TaxBrackets),
+		InsuranceRateCount:   len(set.InsuranceRates),
+		CalculationRuleCount: len(set.CalculationRules),
+		SystemParameterCount: len(set.SystemParameters),
+		CreatedBy:            createdBy,
+	}
+	if err := db.Create(snapshot).Error; err != nil {
+		log.Printf("CreateParameterSnapshotV2 err = %v", err)
+		return nil, err
+	}
+	snapshot.Content = ""
+	return snapshot, nil
+}
+
+// GetParameterSnapshotsV2 查询参数快照列表，不返回快照内容
+func GetParameterSnapshotsV2(c *gin.Context, start int, limit int) ([]*model.SalaryV2ParameterSnapshot, int64, error) {
+	var snapshots []*model.SalaryV2ParameterSnapshot
+	query := resource.HrmsDB(c).Model(&model.SalaryV2ParameterSnapshot{})
+	var total int64
+	query.Count(&total)
+	var err error
+	if start == -1 && limit == -1 {
+		err = query.Omit("content").Order("created_at desc").Find(&snapshots).Error
+	} else {
+		err = query.Omit("content").Offset(start).Limit(limit).Order("created_at desc").Find(&snapshots).Error
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	return snapshots, total, nil
+}
+
+// DeleteParameterSnapshotV2 删除参数快照
+func DeleteParameterSnapshotV2(c *gin.Context, snapshotId string) error {
+	result := resource.HrmsDB(c).Where("snapshot_id = ?", snapshotId).Delete(&model.SalaryV2ParameterSnapshot{})
+	if result.Error != nil {
+		log.Printf("DeleteParameterSnapshotV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该参数快照")
+	}
+	return nil
+}
+
+// CompareParameterSnapshotsV2 比较两组参数的差异，快照ID为current时表示当前参数
+func CompareParameterSnapshotsV2(c *gin.Context, fromId string, toId string) ([]*model.SalaryV2ParameterSnapshotDiff, error) {
+	db := resource.HrmsDB(c)
+	from, err := getParameterSnapshotSet(db, fromId)
+	if err != nil {
+		return nil, err
+	}
+	to, err := getParameterSnapshotSet(db, toId)
+	if err != nil {
+		return nil, err
+	}
+	return diffParameterSets(from, to), nil
+}
+
+// RestoreParameterSnapshotV2 将当前参数整体恢复为快照内容，恢复前自动保存当前参数快照，任一参数恢复失败时全部回滚；
+// 税率区间及社保费率提交变更申请待审批，返回提交的变更申请
+func RestoreParameterSnapshotV2(c *gin.Context, snapshotId string, operator string) ([]*model.SalaryV2ParameterChange, error) {
+	var changes []*model.SalaryV2ParameterChange
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var snapshot model.SalaryV2ParameterSnapshot
+		if err := tx.Where("snapshot_id = ?", snapshotId).First(&snapshot).Error; err != nil {
+			return errors.New("不存在该参数快照")
+		}
+		var target model.SalaryV2ParameterSet
+		if err := json.Unmarshal([]byte(snapshot.Content), &target); err != nil {
+			return err
+		}
+		backupName := "恢复" + snapshot.SnapshotName + "前自动备份 " + time.Now().Format("2006-01-02 15:04:05")
+		if _, err := createParameterSnapshot(tx, backupName, "", operator); err != nil {
+			return err
+		}
+		current, err := loadParameterSet(tx)
+		if err != nil {
+			return err
+		}
+		changes, err = restoreParameterSet(tx, current, &target, operator, "Restored from snapshot "+snapshot.SnapshotName)
+		return err
+	})
+	if err != nil {
+		log.Printf("RestoreParameterSnapshotV2 err = %v", err)
+		return nil, err
+	}
+	return changes, nil
+}
+
+// parameterRequiresApproval 税率区间及社保费率的修改须经其他管理员审批
+func parameterRequiresApproval(parameterType string) bool {
+	return parameterType == "tax_bracket" || parameterType == "insurance_rate"
+}
+
+// restoreParameterSet 将当前参数恢复为目标参数：快照中没有的参数停用，缺失的参数重新创建，其余参数按快照内容修改；
+// 税率区间及社保费率不直接修改，逐条提交变更申请，返回提交的变更申请
+func restoreParameterSet(tx *gorm.DB, current *model.SalaryV2ParameterSet, target *model.SalaryV2ParameterSet, operator string, reason string) ([]*model.SalaryV2ParameterChange, error) {
+	// 税率区间审批后逐条生效，提交前按目标参数整体校验税率表
+	if errs := validateParameterSetTaxBrackets(target.TaxBrackets); len(errs) > 0 {
+		return nil, errors.New("税率表校验不通过: " + strings.Join(errs, "; "))
+	}
+	var changes []*model.SalaryV2ParameterChange
+	submit := func(change *model.SalaryV2ParameterChange, err error) error {
+		if err != nil {
+			return err
+		}
+		changes = append(changes, change)
+		return nil
+	}
+
+	currentRecords := make(map[string]*parameterSetRecord)
+	for _, record := range parameterSetRecords(current) {
+		currentRecords[record.key()] = record
+	}
+	targetRecords := parameterSetRecords(target)
+	targetKeys := make(map[string]bool)
+	for _, record := range targetRecords {
+		targetKeys[record.key()] = true
+	}
+
+	// 先停用再启用，避免计算规则编码唯一性校验冲突
+	for _, record := range parameterSetRecords(current) {
+		if targetKeys[record.key()] || !record.IsActive {
+			continue
+		}
+		if parameterRequiresApproval(record.ParameterType) {
+			if err := submit(submitParameterDeactivate(tx, record.ParameterType, record.Id, reason, operator)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := updateParameterRecord(tx, record.ParameterType, record.Id, deactivateParameterValue(record.Value), operator, reason); err != nil {
+			return nil, err
+		}
+	}
+	sort.SliceStable(targetRecords, func(i, j int) bool {
+		return !targetRecords[i].IsActive && targetRecords[j].IsActive
+	})
+	for _, record := range targetRecords {
+		existing, ok := currentRecords[record.key()]
+		if !ok {
+			if parameterRequiresApproval(record.ParameterType) {
+				err := submit(submitParameterCreate(tx, record.ParameterType, record.ParameterId, record.ParameterName,
+					json.RawMessage(record.Value), parameterEffectiveDate(record.Value, ""), reason, operator))
+				if err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := createParameterRecord(tx, record.ParameterType, record.Value, operator, reason); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(diffParameterValues(existing.Value, record.Value, parameterSetFields(record.Value))) == 0 {
+			continue
+		}
+		if parameterRequiresApproval(record.ParameterType) {
+			dto, err := parameterEditChange(record.ParameterType, existing.Id, record.Value)
+			if err != nil {
+				return nil, err
+			}
+			change, err := buildParameterChange(tx, dto)
+			if err != nil {
+				return nil, err
+			}
+			change.Reason = reason
+			if err := submit(submitParameterChange(tx, change, operator)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := updateParameterRecord(tx, record.ParameterType, existing.Id, record.Value, operator, reason); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// diffParameterSets 按参数业务ID比较两组参数
+func diffParameterSets(from *model.SalaryV2ParameterSet, to *model.SalaryV2ParameterSet) []*model.SalaryV2ParameterSnapshotDiff {
+	fromRecords := make(map[string]*parameterSetRecord)
+	for _, record := range parameterSetRecords(from) {
+		fromRecords[record.key()] = record
+	}
+	var diffs []*model.SalaryV2ParameterSnapshotDiff
+	seen := make(map[string]bool)
+	for _, record := range parameterSetRecords(to) {
+		seen[record.key()] = true
+		old, ok := fromRecords[record.key()]
+		if !ok {
+			diffs = append(diffs, record.diff("added", nil))
+			continue
+		}
+		if fields := diffParameterValues(old.Value, record.Value, parameterSetFields(record.Value)); len(fields) > 0 {
+			diffs = append(diffs, record.diff("modified", fields))
+		}
+	}
+	for _, record := range parameterSetRecords(from) {
+		if !seen[record.key()] {
+			diffs = append(diffs, record.diff("removed", nil))
+		}
+	}
+	return diffs
+}
+
+// parameterSetRecord 参数集中的一条参数记录
+type parameterSetRecord struct {
+	ParameterType string
+	ParameterId   string
+	ParameterName string
+	Id            uint
+	IsActive      bool
+	Value         string
+}
+
+func (r *parameterSetRecord) key() string {
+	return r.ParameterType + ":" + r.ParameterId
+}
+
+func (r *parameterSetRecord) diff(action string, fields []*model.SalaryV2ParameterFieldDiff) *model.SalaryV2ParameterSnapshotDiff {
+	return &model.SalaryV2ParameterSnapshotDiff{
+		ParameterType: r.ParameterType,
+		ParameterId:   r.ParameterId,
+		ParameterName: r.ParameterName,
+		Action:        action,
+		Fields:        fields,
+	}
+}
+
+func parameterSetRecords(set *model.SalaryV2ParameterSet) []*parameterSetRecord {
+	var records []*parameterSetRecord
+	add := func(parameterType, parameterId, parameterName string, id uint, isActive bool, value interface{}) {
+		content, _ := json.Marshal(value)
+		records = append(records, &parameterSetRecord{
+			ParameterType: parameterType,
+			ParameterId:   parameterId,
+			ParameterName: parameterName,
+			Id:            id,
+			IsActive:      isActive,
+			Value:         string(content),
+		})
+	}
+	for _, bracket := range set.TaxBrackets {
+		add("tax_bracket", bracket.TaxBracketId, bracket.Description, bracket.ID, bracket.IsActive, bracket)
+	}
+	for _, rate := range set.InsuranceRates {
+		add("insurance_rate", rate.InsuranceRateId, insuranceTypeName(rate.InsuranceType), rate.ID, rate.IsActive, rate)
+	}
+	for _, rule := range set.CalculationRules {
+		add("calculation_rule", rule.CalculationRuleId, rule.RuleName, rule.ID, rule.IsActive, rule)
+	}
+	for _, parameter := range set.SystemParameters {
+		add("system_parameter", parameter.ParameterId, parameter.ParameterKey, parameter.ID, parameter.IsActive, parameter)
+	}
+	return records
+}
+
+// parameterSetIgnoredFields 比较参数时忽略的主键、业务ID及审计字段
+var parameterSetIgnoredFields = map[string]bool{
+	"id": true, "ID": true, "CreatedAt": true, "UpdatedAt": true, "DeletedAt": true, "created_by": true, "updated_by": true,
+	"tax_bracket_id": true, "insurance_rate_id": true, "calculation_rule_id": true, "parameter_id": true,
+}
+
+func parameterSetFields(value string) []string {
+	var fields []string
+	for _, field := range parameterValueFields(value) {
+		if !parameterSetIgnoredFields[field] {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+// loadParameterSet 读取当前分公司全部V2薪资参数，包括已停用的参数
+func loadParameterSet(db *gorm.DB) (*model.SalaryV2ParameterSet, error) {
+	set := &model.SalaryV2ParameterSet{}
+	if err := db.Order("id asc").Find(&set.TaxBrackets).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id asc").Find(&set.InsuranceRates).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id asc").Find(&set.CalculationRules).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Order("id asc").Find(&set.SystemParameters).Error; err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func getParameterSnapshotSet(db *gorm.DB, snapshotId string) (*model.SalaryV2ParameterSet, error) {
+	if snapshotId == "current" {
+		return loadParameterSet(db)
+	}
+	var snapshot model.SalaryV2ParameterSnapshot
+	if err := db.Where("snapshot_id = ?", snapshotId).First(&snapshot).Error; err != nil {
+		return nil, errors.New("不存在该参数快照")
+	}
+	var set model.SalaryV2ParameterSet
+	if err := json.Unmarshal([]byte(snapshot.Content), &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// findParameterRecordId 按参数业务ID查找记录主键
+func findParameterRecordId(db *gorm.DB, parameterType string, parameterId string) (uint, error) {
+	var id uint
+	var err error
+	switch parameterType {
+	case "tax_bracket":
+		err = db.Model(&model.SalaryV2TaxBracket{}).Where("tax_bracket_id = ?", parameterId).Select("id").Scan(&id).Error
+	case "insurance_rate":
+		err = db.Model(&model.SalaryV2InsuranceRate{}).Where("insurance_rate_id = ?", parameterId).Select("id").Scan(&id).Error
+	case "calculation_rule":
+		err = db.Model(&model.SalaryV2CalculationRule{}).Where("calculation_rule_id = ?", parameterId).Select("id").Scan(&id).Error
+	case "system_parameter":
+		err = db.Model(&model.SalaryV2SystemParameter{}).Where("parameter_id = ?", parameterId).Select("id").Scan(&id).Error
+	default:
+		return 0, errors.New("不支持的参数类型: " + parameterType)
+	}
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, errors.New("参数已不存在: " + parameterId)
+	}
+	return id, nil
+}
+
+// createParameterRecord 按参数JSON内容重新创建参数记录，保留原业务ID
+func createParameterRecord(tx *gorm.DB, parameterType string, value string, operator string, reason string) error {
+	var record interface{}
+	var parameterId string
+	switch parameterType {
+	case "tax_bracket":
+		var bracket model.SalaryV2TaxBracket
+		if err := json.Unmarshal([]byte(value), &bracket); err != nil {
+			return err
+		}
+		bracket.Model, bracket.ID = gorm.Model{}, 0
+		bracket.EffectiveDate = normalizeParameterDate(bracket.EffectiveDate)
+		bracket.CreatedBy, bracket.UpdatedBy = operator, operator
+		record, parameterId = &bracket, bracket.TaxBracketId
+	case "insurance_rate":
+		var rate model.SalaryV2InsuranceRate
+		if err := json.Unmarshal([]byte(value), &rate); err != nil {
+			return err
+		}
+		rate.Model, rate.ID = gorm.Model{}, 0
+		rate.EffectiveDate = normalizeParameterDate(rate.EffectiveDate)
+		rate.CreatedBy, rate.UpdatedBy = operator, operator
+		record, parameterId = &rate, rate.InsuranceRateId
+	case "calculation_rule":
+		var rule model.SalaryV2CalculationRule
+		if err := json.Unmarshal([]byte(value), &rule); err != nil {
+			return err
+		}
+		if rule.IsActive {
+			if err := checkCalculationRuleCode(tx, rule.RuleCode, 0); err != nil {
+				return err
+			}
+		}
+		rule.Model, rule.ID = gorm.Model{}, 0
+		rule.EffectiveDate = normalizeParameterDate(rule.EffectiveDate)
+		rule.CreatedBy, rule.UpdatedBy = operator, operator
+		record, parameterId = &rule, rule.CalculationRuleId
+	case "system_parameter":
+		var parameter model.SalaryV2SystemParameter
+		if err := json.Unmarshal([]byte(value), &parameter); err != nil {
+			return err
+		}
+		parameter.Model, parameter.ID = gorm.Model{}, 0
+		parameter.CreatedBy, parameter.UpdatedBy = operator, operator
+		record, parameterId = &parameter, parameter.ParameterId
+	default:
+		return errors.New("不支持的参数类型: " + parameterType)
+	}
+	if err := tx.Create(record).Error; err != nil {
+		log.Printf("createParameterRecord err = %v", err)
+		return err
+	}
+	recordParameterHistory(tx, parameterId, parameterType, "", value, reason, operator)
+	return nil
+}
+
+// deactivateParameterValue 将参数JSON内容标记为停用
+func deactivateParameterValue(value string) string {
+	var values map[string]interface{}
+	json.Unmarshal([]byte(value), &values)
+	values["is_active"] = false
+	content, _ := json.Marshal(values)
+	return string(content)
+}
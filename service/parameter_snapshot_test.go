@@ -0,0 +1,42 @@
+package service
+
+import (
+	"hrms/model"
+	"testing"
+)
+
+func TestDiffParameterSets(t *testing.T) {
+	from := &model.SalaryV2ParameterSet{
+		TaxBrackets: []*model.SalaryV2TaxBracket{
+			{ID: 1, TaxBracketId: "tax_bracket_1", TaxRate: 0.03, IsActive: true, EffectiveDate: "2019-01-01T00:00:00+08:00"},
+			{ID: 2, TaxBracketId: "tax_bracket_2", TaxRate: 0.1, IsActive: true},
+		},
+		SystemParameters: []*model.SalaryV2SystemParameter{
+			{ID: 1, ParameterId: "param_001", ParameterKey: "currency", ParameterValue: "CNY", UpdatedBy: "1"},
+		},
+	}
+	to := &model.SalaryV2ParameterSet{
+		TaxBrackets: []*model.SalaryV2TaxBracket{
+			{ID: 1, TaxBracketId: "tax_bracket_1", TaxRate: 0.03, IsActive: true, EffectiveDate: "2019-01-01"},
+		},
+		SystemParameters: []*model.SalaryV2SystemParameter{
+			{ID: 1, ParameterId: "param_001", ParameterKey: "currency", ParameterValue: "HKD", UpdatedBy: "2"},
+			{ID: 2, ParameterId: "param_002", ParameterKey: "money_rounding", ParameterValue: "cent"},
+		},
+	}
+
+	actions := make(map[string]*model.SalaryV2ParameterSnapshotDiff)
+	for _, diff := range diffParameterSets(from, to) {
+		actions[diff.ParameterId] = diff
+	}
+	if len(actions) != 3 {
+		t.Fatalf("diffs = %d, want 3", len(actions))
+	}
+	if actions["tax_bracket_2"].Action != "removed" || actions["param_002"].Action != "added" {
+		t.Errorf("unexpected actions %+v %+v", actions["tax_bracket_2"], actions["param_002"])
+	}
+	modified := actions["param_001"]
+	if modified.Action != "modified" || len(modified.Fields) != 1 || modified.Fields[0].Field != "parameter_value" {
+		t.Errorf("modified = %+v", modified)
+	}
+}
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func checkPayComponentType(componentType string) error {
+	if componentType != "earning" && componentType != "deduction" {
+		return errors.New("不支持的薪资项目类型: " + componentType)
+	}
+	return nil
+}
+
+func checkPayComponentMonths(startMonth, endMonth string) error {
+	if _, err := time.Parse("2006-01", startMonth); err != nil {
+		return errors.New("起始月份格式错误: " + startMonth)
+	}
+	if endMonth != "" {
+		if _, err := time.Parse("2006-01", endMonth); err != nil {
+			return errors.New("截止月份格式错误: " + endMonth)
+		}
+		if endMonth < startMonth {
+			return errors.New("截止月份不能早于起始月份")
+		}
+	}
+	return nil
+}
+
+// CreatePayComponentV2 创建薪资项目，项目编码全局唯一
+func CreatePayComponentV2(c *gin.Context, dto *model.SalaryV2PayComponentCreateDTO, createdBy string) error {
+	if err := checkPayComponentType(dto.ComponentType); err != nil {
+		return err
+	}
+	var count int64
+	resource.HrmsDB(c).Model(&model.SalaryV2PayComponent{}).Where("component_code = ?", dto.ComponentCode).Count(&count)
+	if count > 0 {
+		return errors.New("薪资项目编码已存在: " + dto.ComponentCode)
+	}
+
+	var component model.SalaryV2PayComponent
+	Transfer(&dto, &component)
+	component.ComponentId = RandomID("pay_component")
+	component.IsActive = true
+	component.CreatedBy = createdBy
+	component.UpdatedBy = createdBy
+
+	if err := resource.HrmsDB(c).Create(&component).Error; err != nil {
+		log.Printf("CreatePayComponentV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func GetPayComponentsV2(c *gin.Context, componentType string, start int, limit int) ([]*model.SalaryV2PayComponent, int64, error) {
+	var components []*model.SalaryV2PayComponent
+	var err error
+	query := resource.HrmsDB(c).Where("is_active = ?", true)
+	if componentType != "" {
+		query = query.Where("component_type = ?", componentType)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2PayComponent{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("sort_order asc, id asc").Find(&components).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("sort_order asc, id asc").Find(&components).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return components, total, nil
+}
+
+// UpdatePayComponentV2 更新薪资项目，项目编码及类型创建后不允许修改
+func UpdatePayComponentV2(c *gin.Context, dto *model.SalaryV2PayComponentEditDTO, updatedBy string) error {
+	result := resource.HrmsDB(c).Model(&model.SalaryV2PayComponent{}).Where("component_id = ? and is_active = ?", dto.ComponentId, true).
+		Updates(map[string]interface{}{
+			"component_name": dto.ComponentName,
+			"is_taxable":     dto.IsTaxable,
+			"is_insurable":   dto.IsInsurable,
+			"sort_order":     dto.SortOrder,
+			"description":    dto.Description,
+			"updated_by":     updatedBy,
+		})
+	if result.Error != nil {
+		log.Printf("UpdatePayComponentV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该薪资项目")
+	}
+	return nil
+}
+
+// DeletePayComponentV2 停用薪资项目，仍有员工使用的项目不允许停用
+func DeletePayComponentV2(c *gin.Context, id uint, deletedBy string) error {
+	var component model.SalaryV2PayComponent
+	if err := resource.HrmsDB(c).First(&component, id).Error; err != nil {
+		return err
+	}
+	var count int64
+	resource.HrmsDB(c).Model(&model.SalaryV2StaffPayComponent{}).
+		Where("component_code = ? and is_active = ?", component.ComponentCode, true).Count(&count)
+	if count > 0 {
+		return errors.New("仍有员工使用该薪资项目，请先删除员工薪资项目")
+	}
+
+	if err := resource.HrmsDB(c).Model(&model.SalaryV2PayComponent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": deletedBy,
+		}).Error; err != nil {
+		log.Printf("DeletePayComponentV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// getActivePayComponent 按编码获取启用中的薪资项目
+func getActivePayComponent(db *gorm.DB, componentCode string) (*model.SalaryV2PayComponent, error) {
+	var component model.SalaryV2PayComponent
+	if err := db.Where("component_code = ? and is_active = ?", componentCode, true).First(&component).Error; err != nil {
+		return nil, errors.New("不存在该薪资项目: " + componentCode)
+	}
+	return &component, nil
+}
+
+// validateStaffPayComponent 校验员工薪资项目，同一项目的生效月份不允许重叠
+func validateStaffPayComponent(db *gorm.DB, staffComponent *model.SalaryV2StaffPayComponent) error {
+	if staffComponent.Amount <= 0 {
+		return errors.New("薪资项目金额必须大于0")
+	}
+	if err := checkPayComponentMonths(staffComponent.StartMonth, staffComponent.EndMonth); err != nil {
+		return err
+	}
+	if _, err := getActivePayComponent(db, staffComponent.ComponentCode); err != nil {
+		return err
+	}
+
+	var existing []*model.SalaryV2StaffPayComponent
+	db.Where("staff_id = ? and component_code = ? and is_active = ? and staff_component_id <> ?",
+		staffComponent.StaffId, staffComponent.ComponentCode, true, staffComponent.StaffComponentId).Find(&existing)
+	for _, other := range existing {
+		if monthRangesOverlap(staffComponent.StartMonth, staffComponent.EndMonth, other.StartMonth, other.EndMonth) {
+			return errors.New("该员工在相同月份已有该薪资项目")
+		}
+	}
+	return nil
+}
+
+// monthRangesOverlap 判断两个月份区间是否重叠，截止月份为空表示长期有效
+func monthRangesOverlap(start1, end1, start2, end2 string) bool {
+	if end1 != "" && end1 < start2 {
+		return false
+	}
+	if end2 != "" && end2 < start1 {
+		return false
+	}
+	return true
+}
+
+func CreateStaffPayComponentV2(c *gin.Context, dto *model.SalaryV2StaffPayComponentCreateDTO, createdBy string) error {
+	var staff model.Staff
+	if err := resource.HrmsDB(c).Where("staff_id = ?", dto.StaffId).First(&staff).Error; err != nil {
+		return errors.New("不存在该员工")
+	}
+	var staffComponent model.SalaryV2StaffPayComponent
+	Transfer(&dto, &staffComponent)
+	staffComponent.StaffComponentId = RandomID("staff_component")
+	staffComponent.StaffName = staff.StaffName
+	staffComponent.Source = "manual"
+	if err := validateStaffPayComponent(resource.HrmsDB(c), &staffComponent); err != nil {
+		return err
+	}
+	staffComponent.IsActive = true
+	staffComponent.CreatedBy = createdBy
+	staffComponent.UpdatedBy = createdBy
+
+	if err := resource.HrmsDB(c).Create(&staffComponent).Error; err != nil {
+		log.Printf("CreateStaffPayComponentV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func GetStaffPayComponentsV2(c *gin.Context, staffId string, start int, limit int) ([]*model.SalaryV2StaffPayComponent, int64, error) {
+	var staffComponents []*model.SalaryV2StaffPayComponent
+	var err error
+	query := resource.HrmsDB(c).Where("is_active = ?", true)
+	if staffId != "" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2StaffPayComponent{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("staff_id asc, start_month desc").Find(&staffComponents).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("staff_id asc, start_month desc").Find(&staffComponents).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return staffComponents, total, nil
+}
+
+func UpdateStaffPayComponentV2(c *gin.Context, dto *model.SalaryV2StaffPayComponentEditDTO, updatedBy string) error {
+	var staffComponent model.SalaryV2StaffPayComponent
+	if err := resource.HrmsDB(c).Where("staff_component_id = ? and is_active = ?", dto.StaffComponentId, true).First(&staffComponent).Error; err != nil {
+		return errors.New("不存在该员工薪资项目")
+	}
+	staffComponent.Amount = dto.Amount
+	staffComponent.StartMonth = dto.StartMonth
+	staffComponent.EndMonth = dto.EndMonth
+	staffComponent.Remark = dto.Remark
+	if err := validateStaffPayComponent(resource.HrmsDB(c), &staffComponent); err != nil {
+		return err
+	}
+
+	if err := resource.HrmsDB(c).Model(&model.SalaryV2StaffPayComponent{}).Where("staff_component_id = ?", dto.StaffComponentId).
+		Updates(map[string]interface{}{
+			"amount":      staffComponent.Amount,
+			"start_month": staffComponent.StartMonth,
+			"end_month":   staffComponent.EndMonth,
+			"remark":      staffComponent.Remark,
+			"updated_by":  updatedBy,
+		}).Error; err != nil {
+		log.Printf("UpdateStaffPayComponentV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func DeleteStaffPayComponentV2(c *gin.Context, id uint, deletedBy string) error {
+	if err := resource.HrmsDB(c).Model(&model.SalaryV2StaffPayComponent{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": deletedBy,
+		}).Error; err != nil {
+		log.Printf("DeleteStaffPayComponentV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// GetSalaryRecordItemsV2 查询薪资记录的薪资项目明细
+func GetSalaryRecordItemsV2(c *gin.Context, salaryRecordId string) ([]*model.SalaryV2RecordItem, error) {
+	var items []*model.SalaryV2RecordItem
+	if err := resource.HrmsDB(c).Where("salary_record_id = ?", salaryRecordId).Order("component_type desc, id asc").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// getStaffPayComponentItems 获取员工当月生效的薪资项目明细，已停用的薪资项目不再计入
+func getStaffPayComponentItems(tx *gorm.DB, staffId, month string) ([]*model.SalaryV2RecordItem, error) {
+	var staffComponents []*model.SalaryV2StaffPayComponent
+	if err := tx.Where("staff_id = ? and is_active = ? and start_month <= ? and (end_month = '' or end_month is null or end_month >= ?)",
+		staffId, true, month, month).Find(&staffComponents).Error; err != nil {
+		return nil, err
+	}
+	var items []*model.SalaryV2RecordItem
+	for _, staffComponent := range staffComponents {
+		component, err := getActivePayComponent(tx, staffComponent.ComponentCode)
+		if err != nil {
+			log.Printf("getStaffPayComponentItems staff_id = %v, err = %v", staffId, err)
+			continue
+		}
+		items = append(items, &model.SalaryV2RecordItem{
+			StaffId:       staffId,
+			SalaryDate:    month,
+			ComponentCode: component.ComponentCode,
+			ComponentName: component.ComponentName,
+			ComponentType: component.ComponentType,
+			IsTaxable:     component.IsTaxable,
+			IsInsurable:   component.IsInsurable,
+			Amount:        staffComponent.Amount,
+		})
+	}
+	return items, nil
+}
+
+// applyPayComponentItems 按计税属性汇总薪资项目明细到薪资记录，返回计入五险一金缴费基数的金额
+func applyPayComponentItems(salaryRecord *model.SalaryRecord, items []*model.SalaryV2RecordItem) model.Money {
+	var insurable model.Money
+	salaryRecord.ComponentEarnings = 0
+	salaryRecord.TaxFreeEarnings = 0
+	salaryRecord.PreTaxDeductions = 0
+	salaryRecord.PostTaxDeductions = 0
+	for _, item := range items {
+		switch {
+		case item.ComponentType == "earning" && item.IsTaxable:
+			salaryRecord.ComponentEarnings += item.Amount
+		case item.ComponentType == "earning":
+			salaryRecord.TaxFreeEarnings += item.Amount
+		case item.IsTaxable:
+			salaryRecord.PreTaxDeductions += item.Amount
+		default:
+			salaryRecord.PostTaxDeductions += item.Amount
+		}
+		if item.ComponentType == "earning" && item.IsInsurable {
+			insurable += item.Amount
+		}
+	}
+	return insurable
+}
+
+// saveSalaryRecordItems 保存薪资记录的薪资项目明细，重新核算时覆盖原明细
+func saveSalaryRecordItems(tx *gorm.DB, salaryRecordId string, items []*model.SalaryV2RecordItem) error {
+	if err := tx.Unscoped().Where("salary_record_id = ?", salaryRecordId).Delete(&model.SalaryV2RecordItem{}).Error; err != nil {
+		return err
+	}
+	for _, item := range items {
+		item.SalaryRecordId = salaryRecordId
+		if err := tx.Create(item).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncTemplatePayComponents 按薪资模板中的薪资项目重新生成员工薪资项目，手工维护的项目不受影响
+// 原模板项目截止到起始月份的前一个月，保留用于之前月份的追溯及结算重新核算
+func syncTemplatePayComponents(tx *gorm.DB, staff *model.Staff, startMonth string, amounts map[string]model.Money, operator string) error {
+	start, err := time.Parse("2006-01", startMonth)
+	if err != nil {
+		return errors.New("起始月份格式错误: " + startMonth)
+	}
+	previousMonth := start.AddDate(0, -1, 0).Format("2006-01")
+	query := tx.Model(&model.SalaryV2StaffPayComponent{}).
+		Where("staff_id = ? and source = ? and is_active = ?", staff.StaffId, "template", true)
+	// 起始月份及之后才生效的原模板项目不再生效
+	if err := query.Session(&gorm.Session{}).Where("start_month >= ?", startMonth).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": operator,
+		}).Error; err != nil {
+		return err
+	}
+	if err := query.Session(&gorm.Session{}).
+		Where("start_month < ? and (end_month = '' or end_month is null or end_month >= ?)", startMonth, startMonth).
+		Updates(map[string]interface{}{
+			"end_month":  previousMonth,
+			"updated_by": operator,
+		}).Error; err != nil {
+		return err
+	}
+	for code, amount := range amounts {
+		if amount <= 0 {
+			continue
+		}
+		staffComponent := model.SalaryV2StaffPayComponent{
+			StaffComponentId: RandomID("staff_component"),
+			StaffId:          staff.StaffId,
+			StaffName:        staff.StaffName,
+			ComponentCode:    code,
+			Amount:           amount,
+			StartMonth:       startMonth,
+			Source:           "template",
+			IsActive:         true,
+			CreatedBy:        operator,
+			UpdatedBy:        operator,
+		}
+		if err := validateStaffPayComponent(tx, &staffComponent); err != nil {
+			return err
+		}
+		if err := tx.Create(&staffComponent).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
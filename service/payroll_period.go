@@ -0,0 +1,187 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetPayrollPeriodsV2 查询薪资期间
+func GetPayrollPeriodsV2(c *gin.Context, status string, start int, limit int) ([]*model.SalaryV2PayrollPeriod, int64, error) {
+	var periods []*model.SalaryV2PayrollPeriod
+	var total int64
+	query := resource.HrmsDB(c).Model(&model.SalaryV2PayrollPeriod{})
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+	if start >= 0 && limit > 0 {
+		query = query.Offset(start).Limit(limit)
+	}
+	if err := query.Order("period_month desc").Find(&periods).Error; err != nil {
+		log.Printf("GetPayrollPeriodsV2 err = %v", err)
+		return nil, 0, err
+	}
+	return periods, total, nil
+}
+
+// LockPayrollPeriodV2 锁定薪资期间，锁定后当月薪资不可重新核算、修改或删除
+func LockPayrollPeriodV2(c *gin.Context, month string, operator string) error {
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		period, err := getOrCreatePayrollPeriod(tx, month, operator)
+		if err != nil {
+			return err
+		}
+		if period.Status != "open" {
+			return errors.New("该薪资期间已锁定或已结账")
+		}
+		now := time.Now()
+		return tx.Model(&model.SalaryV2PayrollPeriod{}).Where("id = ?", period.ID).Updates(map[string]interface{}{
+			"status":     "locked",
+			"locked_by":  operator,
+			"locked_at":  &now,
+			"updated_by": operator,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("LockPayrollPeriodV2 err = %v", err)
+	}
+	return err
+}
+
+// ClosePayrollPeriodV2 结账已锁定的薪资期间，当月薪资须全部发放
+func ClosePayrollPeriodV2(c *gin.Context, month string, operator string) error {
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		period, err := getOrCreatePayrollPeriod(tx, month, operator)
+		if err != nil {
+			return err
+		}
+		if period.Status != "locked" {
+			return errors.New("请先锁定该薪资期间")
+		}
+		var unpaid int64
+		tx.Model(&model.SalaryRecord{}).Where("salary_date = ? and is_pay <> 2", month).Count(&unpaid)
+		if unpaid > 0 {
+			return errors.New("该薪资期间存在未发放的薪资记录")
+		}
+		now := time.Now()
+		return tx.Model(&model.SalaryV2PayrollPeriod{}).Where("id = ?", period.ID).Updates(map[string]interface{}{
+			"status":     "closed",
+			"closed_by":  operator,
+			"closed_at":  &now,
+			"updated_by": operator,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("ClosePayrollPeriodV2 err = %v", err)
+	}
+	return err
+}
+
+// UnlockPayrollPeriodV2 重新开放已锁定或已结账的薪资期间，解锁原因写入操作日志
+// 已发放的薪资记录在解锁后仍不可修改，差额须通过追溯调整处理
+func UnlockPayrollPeriodV2(c *gin.Context, dto *model.SalaryV2PayrollPeriodUnlockDTO, operator string, operatorName string) error {
+	reason := strings.TrimSpace(dto.Reason)
+	if reason == "" {
+		return errors.New("请填写解锁原因")
+	}
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var period model.SalaryV2PayrollPeriod
+		if err := tx.Where("period_month = ?", dto.PeriodMonth).First(&period).Error; err != nil {
+			return errors.New("该薪资期间未锁定")
+		}
+		if period.Status == "open" {
+			return errors.New("该薪资期间未锁定")
+		}
+		now := time.Now()
+		if err := tx.Model(&model.SalaryV2PayrollPeriod{}).Where("id = ?", period.ID).Updates(map[string]interface{}{
+			"status":        "open",
+			"unlocked_by":   operator,
+			"unlocked_at":   &now,
+			"unlock_reason": reason,
+			"updated_by":    operator,
+		}).Error; err != nil {
+			return err
+		}
+
+		staffId, _ := strconv.ParseUint(operator, 10, 64)
+		return tx.Create(&model.OperationLog{
+			StaffId:         staffId,
+			StaffName:       operatorName,
+			OperationType:   "UNLOCK",
+			OperationModule: "SALARY",
+			OperationDesc:   "解锁薪资期间" + period.PeriodMonth + "(原状态" + period.Status + ")，原因：" + reason,
+			RequestMethod:   c.Request.Method,
+			RequestUrl:      c.Request.URL.String(),
+			IpAddress:       c.ClientIP(),
+			UserAgent:       c.Request.UserAgent(),
+			OperationStatus: 1,
+			OperationTime:   now,
+		}).Error
+	})
+	if err != nil {
+		log.Printf("UnlockPayrollPeriodV2 err = %v", err)
+	}
+	return err
+}
+
+func getOrCreatePayrollPeriod(tx *gorm.DB, month string, operator string) (*model.SalaryV2PayrollPeriod, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, errors.New("薪资月份格式错误: " + month)
+	}
+	var periods []*model.SalaryV2PayrollPeriod
+	tx.Where("period_month = ?", month).Find(&periods)
+	if len(periods) != 0 {
+		return periods[0], nil
+	}
+	period := &model.SalaryV2PayrollPeriod{
+		PeriodId:    RandomID("payroll_period"),
+		PeriodMonth: month,
+		Status:      "open",
+		CreatedBy:   operator,
+		UpdatedBy:   operator,
+	}
+	if err := tx.Create(period).Error; err != nil {
+		return nil, err
+	}
+	return period, nil
+}
+
+// getPayrollPeriodStatus 获取薪资期间状态，未建立期间的月份视为开放
+func getPayrollPeriodStatus(tx *gorm.DB, month string) string {
+	var periods []*model.SalaryV2PayrollPeriod
+	tx.Where("period_month = ?", month).Find(&periods)
+	if len(periods) == 0 {
+		return "open"
+	}
+	return periods[0].Status
+}
+
+// checkPayrollPeriodOpen 校验薪资期间可核算
+func checkPayrollPeriodOpen(tx *gorm.DB, month string) error {
+	switch getPayrollPeriodStatus(tx, month) {
+	case "locked":
+		return errors.New("薪资期间" + month + "已锁定")
+	case "closed":
+		return errors.New("薪资期间" + month + "已结账")
+	}
+	return nil
+}
+
+// checkSalaryRecordEditable 校验薪资记录可重新核算、修改或删除：已发放的记录及锁定期间内的记录均不可变更
+func checkSalaryRecordEditable(tx *gorm.DB, record *model.SalaryRecord) error {
+	if record.IsPay == 2 {
+		return errors.New("该员工" + record.SalaryDate + "薪资已发放")
+	}
+	if record.IsPay == 3 {
+		return errors.New("该员工" + record.SalaryDate + "薪资正在银行代发处理中")
+	}
+	return checkPayrollPeriodOpen(tx, record.SalaryDate)
+}
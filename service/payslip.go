@@ -0,0 +1,231 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPayslipV2 生成员工指定月份的工资条
+func GetPayslipV2(c *gin.Context, staffId string, month string) (*model.SalaryV2Payslip, error) {
+	db := resource.HrmsDB(c)
+	var record model.SalaryRecord
+	if err := db.Where("staff_id = ? and salary_date = ?", staffId, month).First(&record).Error; err != nil {
+		return nil, errors.New("不存在该月份薪资记录")
+	}
+	var staff model.Staff
+	db.Where("staff_id = ?", staffId).Find(&staff)
+	var department model.Department
+	if staff.DepId != "" {
+		db.Where("dep_id = ?", staff.DepId).Find(&department)
+	}
+
+	payslip := &model.SalaryV2Payslip{
+		SalaryRecordId: record.SalaryRecordId,
+		SalaryDate:     record.SalaryDate,
+		StaffId:        record.StaffId,
+		StaffName:      record.StaffName,
+		DepName:        department.DepName,
+		IsPay:          record.IsPay,
+		Currency:       record.Currency,
+	}
+	payslip.BranchId, payslip.BranchName = getCurrentBranch(c)
+
+	var items []*model.SalaryV2RecordItem
+	if err := db.Where("salary_record_id = ?", record.SalaryRecordId).Order("id asc").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	var insurances []*model.SalaryV2RecordInsurance
+	if err := db.Where("salary_record_id = ?", record.SalaryRecordId).Order("id asc").Find(&insurances).Error; err != nil {
+		return nil, err
+	}
+
+	addEarning := func(name string, amount model.Money) {
+		if !amount.IsZero() {
+			payslip.Earnings = append(payslip.Earnings, &model.SalaryV2PayslipLine{Name: name, Amount: amount})
+			payslip.TotalEarnings += amount
+		}
+	}
+	addDeduction := func(name string, amount model.Money) {
+		if !amount.IsZero() {
+			payslip.Deductions = append(payslip.Deductions, &model.SalaryV2PayslipLine{Name: name, Amount: amount})
+			payslip.TotalDeductions += amount
+		}
+	}
+	addEarning("基本工资", record.Base)
+	addEarning("津贴补贴", record.Subsidy)
+	addEarning("绩效奖金", record.Bonus)
+	addEarning("提成", record.Commission)
+	addEarning("加班工资", record.Overtime)
+	addEarning("其他", record.Other)
+	for _, item := range items {
+		if item.ComponentType == "earning" {
+			addEarning(item.ComponentName, item.Amount)
+		}
+	}
+	addEarning("未休年休假工资报酬", record.LeavePayout)
+	addEarning("全年一次性奖金", record.AnnualBonus)
+	addEarning("解除劳动合同经济补偿", record.Severance)
+	addEarning("追溯补发(扣回)", record.RetroEarnings)
+
+	addDeduction("养老保险", record.PensionInsurance)
+	addDeduction("医疗保险", record.MedicalInsurance)
+	addDeduction("失业保险", record.UnemploymentInsurance)
+	addDeduction("住房公积金", record.HousingFund)
+	// 税后扣款中未列入薪资项目明细的部分(如离职结算一次扣回的借款等款项)单列一行
+	uncoveredPostTax := record.PostTaxDeductions
+	for _, item := range items {
+		if item.ComponentType == "deduction" {
+			addDeduction(item.ComponentName, item.Amount)
+			if !item.IsTaxable {
+				uncoveredPostTax -= item.Amount
+			}
+		}
+	}
+	addDeduction("其他税后扣款", uncoveredPostTax)
+	addDeduction("追溯补扣(退还)", record.RetroDeductions)
+	addDeduction("个人所得税", record.Tax)
+	addDeduction("全年一次性奖金个人所得税", record.AnnualBonusTax)
+	addDeduction("经济补偿个人所得税", record.SeveranceTax)
+	if !record.RetroNetAdjustment.IsZero() {
+		// 不计税的追溯调整为正数时计入应发，为负数时计入扣款
+		if record.RetroNetAdjustment > 0 {
+			addEarning("追溯调整(不计税)", record.RetroNetAdjustment)
+		} else {
+			addDeduction("追溯调整(不计税)", -record.RetroNetAdjustment)
+		}
+	}
+	// 实发工资按舍入规则取整产生的差额
+	if record.RoundingAdjustment > 0 {
+		addEarning("舍入调整", record.RoundingAdjustment)
+	} else {
+		addDeduction("舍入调整", -record.RoundingAdjustment)
+	}
+
+	for _, insurance := range insurances {
+		if !insurance.EmployerAmount.IsZero() {
+			payslip.EmployerContributions = append(payslip.EmployerContributions, &model.SalaryV2PayslipLine{
+				Name:   insuranceTypeName(insurance.InsuranceType),
+				Amount: insurance.EmployerAmount,
+			})
+			payslip.TotalEmployer += insurance.EmployerAmount
+		}
+	}
+
+	payslip.NetPay = record.Total
+
+	// 本年累计按当年截至本月的薪资记录汇总
+	var records []*model.SalaryRecord
+	if err := db.Where("staff_id = ? and salary_date like ? and salary_date <= ?", staffId, month[:4]+"-%", month).
+		Find(&records).Error; err != nil {
+		return nil, err
+	}
+	for _, r := range records {
+		payslip.YtdIncome += salaryRecordGrossIncome(r) + r.TaxFreeEarnings + r.Severance
+		payslip.YtdInsurance += r.PensionInsurance + r.MedicalInsurance + r.UnemploymentInsurance + r.HousingFund
+		payslip.YtdTax += r.Tax + r.AnnualBonusTax + r.SeveranceTax
+		payslip.YtdNetPay += r.Total
+	}
+	return payslip, nil
+}
+
+// RenderPayslipPDFV2 生成工资条PDF，按系统参数使用身份证号后几位作为打开密码
+func RenderPayslipPDFV2(c *gin.Context, staffId string, month string) ([]byte, error) {
+	if len(month) < 7 {
+		return nil, errors.New("薪资月份格式错误: " + month)
+	}
+	payslip, err := GetPayslipV2(c, staffId, month)
+	if err != nil {
+		return nil, err
+	}
+	doc := newPDFDocument()
+
+	const left, right = 60.0, 535.0
+	title := payslip.BranchName
+	if title == "" {
+		title = payslip.BranchId
+	}
+	doc.TextCenter(60, 18, title)
+	doc.TextCenter(88, 14, payslip.SalaryDate+" 工资条")
+	doc.Text(left, 120, 10, "工号: "+payslip.StaffId)
+	doc.Text(left+160, 120, 10, "姓名: "+payslip.StaffName)
+	doc.Text(left+320, 120, 10, "部门: "+payslip.DepName)
+	doc.Line(left, 130, right, 130, 1)
+
+	y := 130.0
+	// advance 换行，超出页面底部时另起一页
+	advance := func(dy float64) {
+		y += dy
+		if y > pdfPageHeight-50 {
+			doc.AddPage()
+			y = 60
+		}
+	}
+	section := func(name string, lines []*model.SalaryV2PayslipLine, totalName string, total model.Money) {
+		advance(24)
+		doc.Text(left, y, 12, name)
+		doc.Line(left, y+6, right, y+6, 0.5)
+		for _, line := range lines {
+			advance(18)
+			doc.Text(left+12, y, 10, line.Name)
+			doc.TextRight(right, y, 10, line.Amount.String())
+		}
+		advance(20)
+		doc.Text(left+12, y, 10, totalName)
+		doc.TextRight(right, y, 10, total.String())
+	}
+	section("应发项目", payslip.Earnings, "应发合计", payslip.TotalEarnings)
+	section("扣款项目", payslip.Deductions, "扣款合计", payslip.TotalDeductions)
+	advance(28)
+	doc.Line(left, y-16, right, y-16, 1)
+	doc.Text(left, y, 13, "实发工资("+payslip.Currency+")")
+	doc.TextRight(right, y, 13, payslip.NetPay.String())
+	if len(payslip.EmployerContributions) > 0 {
+		section("单位缴纳(不计入实发)", payslip.EmployerContributions, "单位缴纳合计", payslip.TotalEmployer)
+	}
+	section(month[:4]+"年累计", []*model.SalaryV2PayslipLine{
+		{Name: "累计收入", Amount: payslip.YtdIncome},
+		{Name: "累计个人缴纳社保公积金", Amount: payslip.YtdInsurance},
+		{Name: "累计个人所得税", Amount: payslip.YtdTax},
+	}, "累计实发", payslip.YtdNetPay)
+
+	advance(40)
+	doc.Text(left, y, 8, "本工资条由系统生成，生成时间 "+time.Now().Format("2006-01-02 15:04:05")+"，如有疑问请联系人力资源部。")
+
+	digits := int(getSystemParameterFloat(resource.HrmsDB(c), "payslip_password_digits", 0))
+	if digits > 0 {
+		var staff model.Staff
+		resource.HrmsDB(c).Where("staff_id = ?", staffId).Find(&staff)
+		identityNum := strings.TrimSpace(staff.IdentityNum)
+		if len(identityNum) < digits {
+			return nil, errors.New("员工未登记有效证件号码，无法生成加密工资条")
+		}
+		doc.SetPassword(identityNum[len(identityNum)-digits:])
+	}
+	content, err := doc.Bytes()
+	if err != nil {
+		log.Printf("RenderPayslipPDFV2 err = %v", err)
+		return nil, err
+	}
+	return content, nil
+}
+
+// getCurrentBranch 解析cookie中的分公司Id并获取分公司名称
+func getCurrentBranch(c *gin.Context) (string, string) {
+	cookie, err := c.Cookie("user_cookie")
+	if err != nil || cookie == "" {
+		return "", ""
+	}
+	user := strings.Split(cookie, "_")
+	if len(user) < 3 {
+		return "", ""
+	}
+	var branch model.BranchCompany
+	resource.HrmsDB(c).Where("branch_id = ?", user[2]).Find(&branch)
+	return user[2], branch.Name
+}
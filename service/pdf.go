@@ -0,0 +1,219 @@
+package service
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/rc4"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// 简易PDF生成，使用阅读器内置的宋体(STSong-Light)显示中文，无需嵌入字体文件
+// 支持标准安全处理器(RC4 40位)设置打开密码
+
+const (
+	pdfPageWidth  = 595.0
+	pdfPageHeight = 842.0
+	// pdfPermissions 允许打印及复制，禁止修改
+	pdfPermissions int32 = -44
+)
+
+// pdfPasswordPadding 标准安全处理器口令填充串
+var pdfPasswordPadding = []byte{
+	0x28, 0xBF, 0x4E, 0x5E, 0x4E, 0x75, 0x8A, 0x41, 0x64, 0x00, 0x4E, 0x56, 0xFF, 0xFA, 0x01, 0x08,
+	0x2E, 0x2E, 0x00, 0xB6, 0xD0, 0x68, 0x3E, 0x80, 0x2F, 0x0C, 0xA9, 0xFE, 0x64, 0x53, 0x69, 0x7A,
+}
+
+type pdfDocument struct {
+	pages    []*bytes.Buffer
+	current  *bytes.Buffer
+	password string
+}
+
+func newPDFDocument() *pdfDocument {
+	doc := &pdfDocument{}
+	doc.AddPage()
+	return doc
+}
+
+// SetPassword 设置打开文档所需的密码，为空时不加密
+func (doc *pdfDocument) SetPassword(password string) {
+	doc.password = password
+}
+
+func (doc *pdfDocument) AddPage() {
+	doc.current = &bytes.Buffer{}
+	doc.pages = append(doc.pages, doc.current)
+}
+
+// Text 在指定位置输出文本，坐标原点为页面左上角
+func (doc *pdfDocument) Text(x, y, size float64, text string) {
+	fmt.Fprintf(doc.current, "BT /F1 %.1f Tf %.2f %.2f Td <%s> Tj ET\n", size, x, pdfPageHeight-y, pdfTextHex(text))
+}
+
+// TextRight 以 x 为右边界右对齐输出文本
+func (doc *pdfDocument) TextRight(x, y, size float64, text string) {
+	doc.Text(x-pdfTextWidth(text, size), y, size, text)
+}
+
+// TextCenter 在页面水平居中输出文本
+func (doc *pdfDocument) TextCenter(y, size float64, text string) {
+	doc.Text((pdfPageWidth-pdfTextWidth(text, size))/2, y, size, text)
+}
+
+// Line 绘制直线
+func (doc *pdfDocument) Line(x1, y1, x2, y2, width float64) {
+	fmt.Fprintf(doc.current, "%.2f w %.2f %.2f m %.2f %.2f l S\n", width, x1, pdfPageHeight-y1, x2, pdfPageHeight-y2)
+}
+
+// Bytes 生成PDF文件内容
+func (doc *pdfDocument) Bytes() ([]byte, error) {
+	var objects []string
+	addObject := func(content string) int {
+		objects = append(objects, content)
+		return len(objects)
+	}
+	catalog := addObject("")
+	pagesObj := addObject("")
+	font := addObject("")
+	cidFont := addObject("")
+	descriptor := addObject("<< /Type /FontDescriptor /FontName /STSong-Light /Flags 6 /FontBBox [-25 -254 1000 880] " +
+		"/ItalicAngle 0 /Ascent 880 /Descent -120 /CapHeight 880 /StemV 93 >>")
+	objects[cidFont-1] = fmt.Sprintf("<< /Type /Font /Subtype /CIDFontType0 /BaseFont /STSong-Light "+
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (GB1) /Supplement 2 >> /FontDescriptor %d 0 R "+
+		"/DW 1000 /W [1 95 500 814 939 500] >>", descriptor)
+	objects[font-1] = fmt.Sprintf("<< /Type /Font /Subtype /Type0 /BaseFont /STSong-Light /Encoding /UniGB-UCS2-H "+
+		"/DescendantFonts [%d 0 R] >>", cidFont)
+	objects[catalog-1] = fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pagesObj)
+
+	// 内容流在写出时按对象号加密，此处记录内容流对象号
+	streams := make(map[int][]byte)
+	var kids []string
+	for _, page := range doc.pages {
+		content := addObject("")
+		streams[content] = page.Bytes()
+		pageObj := addObject(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.0f %.0f] "+
+			"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>", pagesObj, pdfPageWidth, pdfPageHeight, font, content))
+		kids = append(kids, fmt.Sprintf("%d 0 R", pageObj))
+	}
+	objects[pagesObj-1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(kids))
+
+	fileId := make([]byte, 16)
+	if _, err := rand.Read(fileId); err != nil {
+		return nil, err
+	}
+	var encryptKey []byte
+	encrypt := 0
+	if doc.password != "" {
+		ownerPassword := make([]byte, 16)
+		if _, err := rand.Read(ownerPassword); err != nil {
+			return nil, err
+		}
+		var ownerValue, userValue []byte
+		encryptKey, ownerValue, userValue = pdfEncryptionValues([]byte(doc.password), ownerPassword, fileId)
+		encrypt = addObject(fmt.Sprintf("<< /Filter /Standard /V 1 /R 2 /O <%s> /U <%s> /P %d >>",
+			hex.EncodeToString(ownerValue), hex.EncodeToString(userValue), pdfPermissions))
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xE2\xE3\xCF\xD3\n")
+	offsets := make([]int, len(objects)+1)
+	for i, content := range objects {
+		number := i + 1
+		offsets[number] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", number)
+		if stream, ok := streams[number]; ok {
+			if encryptKey != nil {
+				stream = pdfRC4(pdfObjectKey(encryptKey, number), stream)
+			}
+			fmt.Fprintf(&out, "<< /Length %d >>\nstream\n", len(stream))
+			out.Write(stream)
+			out.WriteString("\nendstream")
+		} else {
+			out.WriteString(content)
+		}
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets[1:] {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	idHex := hex.EncodeToString(fileId)
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /ID [<%s> <%s>]", len(objects)+1, catalog, idHex, idHex)
+	if encrypt != 0 {
+		fmt.Fprintf(&out, " /Encrypt %d 0 R", encrypt)
+	}
+	fmt.Fprintf(&out, " >>\nstartxref\n%d\n%%%%EOF\n", xref)
+	return out.Bytes(), nil
+}
+
+// pdfEncryptionValues 按标准安全处理器第2版计算文档密钥及 O、U 校验值
+func pdfEncryptionValues(userPassword, ownerPassword, fileId []byte) (key, ownerValue, userValue []byte) {
+	ownerHash := md5.Sum(pdfPadPassword(ownerPassword))
+	ownerValue = pdfRC4(ownerHash[:5], pdfPadPassword(userPassword))
+
+	permissionFlags := pdfPermissions
+	permissions := make([]byte, 4)
+	binary.LittleEndian.PutUint32(permissions, uint32(permissionFlags))
+	hash := md5.New()
+	hash.Write(pdfPadPassword(userPassword))
+	hash.Write(ownerValue)
+	hash.Write(permissions)
+	hash.Write(fileId)
+	key = hash.Sum(nil)[:5]
+
+	userValue = pdfRC4(key, pdfPasswordPadding)
+	return key, ownerValue, userValue
+}
+
+// pdfObjectKey 计算对象加密密钥，生成号固定为0
+func pdfObjectKey(key []byte, number int) []byte {
+	data := append([]byte{}, key...)
+	data = append(data, byte(number), byte(number>>8), byte(number>>16), 0, 0)
+	sum := md5.Sum(data)
+	return sum[:len(key)+5]
+}
+
+func pdfPadPassword(password []byte) []byte {
+	padded := make([]byte, 0, 32)
+	if len(password) > 32 {
+		password = password[:32]
+	}
+	padded = append(padded, password...)
+	return append(padded, pdfPasswordPadding[:32-len(password)]...)
+}
+
+func pdfRC4(key, data []byte) []byte {
+	cipher, _ := rc4.NewCipher(key)
+	out := make([]byte, len(data))
+	cipher.XORKeyStream(out, data)
+	return out
+}
+
+// pdfTextHex 将文本转为 UCS-2 大端编码的十六进制串
+func pdfTextHex(text string) string {
+	var buf strings.Builder
+	for _, unit := range utf16.Encode([]rune(text)) {
+		fmt.Fprintf(&buf, "%04X", unit)
+	}
+	return buf.String()
+}
+
+// pdfTextWidth 估算文本宽度，半角字符按半个字宽计算
+func pdfTextWidth(text string, size float64) float64 {
+	var width float64
+	for _, r := range text {
+		if r < 0x80 {
+			width += size / 2
+		} else {
+			width += size
+		}
+	}
+	return width
+}
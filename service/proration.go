@@ -0,0 +1,193 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// parseStaffDate 解析员工档案中的日期，兼容带时间的日期格式
+func parseStaffDate(date string) (time.Time, bool) {
+	if len(date) < 10 {
+		return time.Time{}, false
+	}
+	day, err := time.ParseInLocation("2006-01-02", date[:10], time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return day, true
+}
+
+// getProbationPayRatio 获取试用期工资比例，未配置时按1处理
+func getProbationPayRatio(db *gorm.DB) float64 {
+	ratio := getSystemParameterFloat(db, "probation_pay_ratio", 1)
+	if ratio <= 0 || ratio > 1 {
+		return 1
+	}
+	return ratio
+}
+
+// prorateBaseSalary 按员工排班及工作日历折算当月基本工资，应出勤天数与考勤统计的出勤天数口径一致
+func prorateBaseSalary(tx *gorm.DB, salaryInfo *model.Salary, attendInfo *model.AttendanceRecord) (model.Money, []*model.SalaryV2RecordSegment, error) {
+	current, err := time.ParseInLocation("2006-01", attendInfo.Date, time.Local)
+	if err != nil {
+		return 0, nil, errors.New("薪资月份格式错误: " + attendInfo.Date)
+	}
+
+	var staff model.Staff
+	if err := tx.Where("staff_id = ?", attendInfo.StaffId).First(&staff).Error; err != nil {
+		return 0, nil, errors.New("不存在该员工")
+	}
+	roster, err := loadStaffRoster(tx, loadWorkCalendar(tx), staff.StaffId)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// 当月首日之后生效的调薪记录，在职区间开始前生效的记录在分段时忽略
+	var histories []*model.SalaryV2CompensationHistory
+	if err := tx.Where("staff_id = ? and effective_date > ?", staff.StaffId, current.Format("2006-01-02")).
+		Order("effective_date asc, id asc").Find(&histories).Error; err != nil {
+		return 0, nil, err
+	}
+	return splitBaseSalary(roster, &staff, salaryInfo.Base, histories, getProbationPayRatio(tx), attendInfo)
+}
+
+// splitBaseSalary 当月在职区间按入职日期、离职日期截取，并在月中调薪生效日、试用期结束次日拆分为多个分段，
+// 各分段按适用的月基本工资及试用期工资比例折算，最后按实际出勤天数占应出勤天数的比例核减
+func splitBaseSalary(roster *staffRoster, staff *model.Staff, base model.Money, histories []*model.SalaryV2CompensationHistory,
+	probationRatio float64, attendInfo *model.AttendanceRecord) (model.Money, []*model.SalaryV2RecordSegment, error) {
+	monthStart, err := time.ParseInLocation("2006-01", attendInfo.Date, time.Local)
+	if err != nil {
+		return 0, nil, errors.New("薪资月份格式错误: " + attendInfo.Date)
+	}
+	monthEnd := monthStart.AddDate(0, 1, -1)
+	monthWorkDays := roster.WorkingDays(monthStart, monthEnd)
+	if monthWorkDays == 0 {
+		return 0, nil, errors.New("当月没有应出勤日，请检查工作日历及排班配置")
+	}
+
+	// 截取当月在职区间
+	windowStart, windowEnd := monthStart, monthEnd
+	startReason := "month_start"
+	if entry, ok := parseStaffDate(staff.EntryDate); ok && entry.After(windowStart) {
+		windowStart = entry
+		startReason = "entry"
+	}
+	if staff.ResignationDate != nil {
+		if resign, ok := parseStaffDate(*staff.ResignationDate); ok && resign.Before(windowEnd) {
+			windowEnd = resign
+		}
+	}
+	if windowEnd.Before(windowStart) {
+		return 0, nil, nil
+	}
+
+	// 试用期截止日期，试用期员工未填写转正日期时整月按试用期计算
+	var probationEnd time.Time
+	probationAll := false
+	if staff.ProbationEndDate != nil {
+		probationEnd, _ = parseStaffDate(*staff.ProbationEndDate)
+	}
+	if probationEnd.IsZero() && staff.Status == 0 {
+		probationAll = true
+	}
+
+	// 在职区间开始后生效的调薪记录，区间内的用于拆分分段，之后的用于还原当月适用的基本工资；
+	// 生效日期统一为yyyy-mm-dd，格式错误的记录不参与拆分
+	var validHistories []*model.SalaryV2CompensationHistory
+	for _, history := range histories {
+		if effective, ok := parseStaffDate(history.EffectiveDate); ok && effective.After(windowStart) {
+			history.EffectiveDate = effective.Format("2006-01-02")
+			validHistories = append(validHistories, history)
+		}
+	}
+	histories = validHistories
+
+	cuts := map[string]string{windowStart.Format("2006-01-02"): startReason}
+	for _, history := range histories {
+		if history.EffectiveDate <= windowEnd.Format("2006-01-02") {
+			cuts[history.EffectiveDate] = "adjustment"
+		}
+	}
+	if !probationEnd.IsZero() {
+		regularStart := probationEnd.AddDate(0, 0, 1)
+		if regularStart.After(windowStart) && !regularStart.After(windowEnd) {
+			cuts[regularStart.Format("2006-01-02")] = "regular"
+		}
+	}
+	var starts []string
+	for date := range cuts {
+		starts = append(starts, date)
+	}
+	sort.Strings(starts)
+
+	var segments []*model.SalaryV2RecordSegment
+	var scheduledDays int64
+	for i, date := range starts {
+		segmentStart, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		segmentEnd := windowEnd
+		if i+1 < len(starts) {
+			next, _ := time.ParseInLocation("2006-01-02", starts[i+1], time.Local)
+			segmentEnd = next.AddDate(0, 0, -1)
+		}
+
+		// 分段适用的月基本工资：之后仍有调薪时取下一次调薪前的基本工资，否则取当前薪资套账
+		baseRate := base
+		for _, history := range histories {
+			if history.EffectiveDate > date {
+				baseRate = history.OldBase
+				break
+			}
+		}
+		payRatio := 1.0
+		if probationAll || (!probationEnd.IsZero() && !segmentStart.After(probationEnd)) {
+			payRatio = probationRatio
+		}
+
+		workDays := roster.WorkingDays(segmentStart, segmentEnd)
+		scheduledDays += workDays
+		segments = append(segments, &model.SalaryV2RecordSegment{
+			StaffId:       staff.StaffId,
+			SalaryDate:    attendInfo.Date,
+			StartDate:     date,
+			EndDate:       segmentEnd.Format("2006-01-02"),
+			WorkDays:      workDays,
+			MonthWorkDays: monthWorkDays,
+			BaseRate:      baseRate,
+			PayRatio:      payRatio,
+			Reason:        cuts[date],
+		})
+	}
+
+	// 实际出勤少于应出勤天数时按比例核减
+	attendRatio := 1.0
+	if scheduledDays > 0 && float64(attendInfo.WorkDays) < float64(scheduledDays) {
+		attendRatio = float64(attendInfo.WorkDays) / float64(scheduledDays)
+	}
+	var total model.Money
+	for _, segment := range segments {
+		segment.AttendRatio = math.Round(attendRatio*10000) / 10000
+		segment.Amount = segment.BaseRate.Mul(segment.PayRatio * attendRatio *
+			float64(segment.WorkDays) / float64(monthWorkDays))
+		total += segment.Amount
+	}
+	return total, segments, nil
+}
+
+// saveSalaryRecordSegments 保存薪资记录的基本工资分段明细，重新核算时覆盖原明细
+func saveSalaryRecordSegments(tx *gorm.DB, salaryRecordId string, segments []*model.SalaryV2RecordSegment) error {
+	if err := tx.Unscoped().Where("salary_record_id = ?", salaryRecordId).Delete(&model.SalaryV2RecordSegment{}).Error; err != nil {
+		return err
+	}
+	for _, segment := range segments {
+		segment.SalaryRecordId = salaryRecordId
+		if err := tx.Create(segment).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
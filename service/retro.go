@@ -0,0 +1,310 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errRetroPreview 用于回滚试算过程中产生的数据变更
+var errRetroPreview = errors.New("retro preview")
+
+// PreviewRetroAdjustmentsV2 试算追溯调整，不保存结果
+func PreviewRetroAdjustmentsV2(c *gin.Context, dto *model.SalaryV2RetroCalculateDTO) ([]*model.SalaryV2RetroAdjustment, error) {
+	var adjustments []*model.SalaryV2RetroAdjustment
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		adjustments, err = calculateRetroAdjustments(c, tx, dto, "")
+		return err
+	})
+	if err != nil {
+		log.Printf("PreviewRetroAdjustmentsV2 err = %v", err)
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+// CreateRetroAdjustmentsV2 生成追溯调整，差额计入各员工下一个未发放的薪资月份，该月薪资已核算时重新核算
+func CreateRetroAdjustmentsV2(c *gin.Context, dto *model.SalaryV2RetroCalculateDTO, operator string) ([]*model.SalaryV2RetroAdjustment, error) {
+	var adjustments []*model.SalaryV2RetroAdjustment
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		adjustments, err = createRetroAdjustments(c, tx, dto, operator)
+		return err
+	})
+	if err != nil {
+		log.Printf("CreateRetroAdjustmentsV2 err = %v", err)
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+// GetRetroAdjustmentsV2 查询追溯调整
+func GetRetroAdjustmentsV2(c *gin.Context, staffId, sourceMonth, targetMonth, status string, start int, limit int) ([]*model.SalaryV2RetroAdjustment, int64, error) {
+	var adjustments []*model.SalaryV2RetroAdjustment
+	var total int64
+	query := resource.HrmsDB(c).Model(&model.SalaryV2RetroAdjustment{})
+	if staffId != "" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+	if sourceMonth != "" {
+		query = query.Where("source_month = ?", sourceMonth)
+	}
+	if targetMonth != "" {
+		query = query.Where("target_month = ?", targetMonth)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	query.Count(&total)
+	if start >= 0 && limit > 0 {
+		query = query.Offset(start).Limit(limit)
+	}
+	if err := query.Order("created_at desc").Find(&adjustments).Error; err != nil {
+		log.Printf("GetRetroAdjustmentsV2 err = %v", err)
+		return nil, 0, err
+	}
+	return adjustments, total, nil
+}
+
+// CancelRetroAdjustmentV2 撤销尚未随薪资发放的追溯调整，并重新核算计入月份的薪资记录
+func CancelRetroAdjustmentV2(c *gin.Context, retroId string, operator string) error {
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var adjustment model.SalaryV2RetroAdjustment
+		if err := tx.Where("retro_id = ?", retroId).First(&adjustment).Error; err != nil {
+			return errors.New("不存在该追溯调整")
+		}
+		if adjustment.Status == "cancelled" {
+			return errors.New("该追溯调整已撤销")
+		}
+		var paidCount int64
+		tx.Model(&model.SalaryRecord{}).Where("staff_id = ? and salary_date = ? and is_pay = 2",
+			adjustment.StaffId, adjustment.TargetMonth).Count(&paidCount)
+		if paidCount > 0 {
+			return errors.New("该追溯调整已随薪资发放，不能撤销")
+		}
+		if err := tx.Model(&model.SalaryV2RetroAdjustment{}).Where("id = ?", adjustment.ID).
+			Updates(map[string]interface{}{"status": "cancelled", "updated_by": operator}).Error; err != nil {
+			return err
+		}
+		return recalculateUnpaidSalaryRecord(c, tx, adjustment.StaffId, adjustment.TargetMonth)
+	})
+	if err != nil {
+		log.Printf("CancelRetroAdjustmentV2 err = %v", err)
+	}
+	return err
+}
+
+// createRetroAdjustments 保存追溯调整并重新核算计入月份已生成的薪资记录
+func createRetroAdjustments(c *gin.Context, tx *gorm.DB, dto *model.SalaryV2RetroCalculateDTO, operator string) ([]*model.SalaryV2RetroAdjustment, error) {
+	adjustments, err := calculateRetroAdjustments(c, tx, dto, operator)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]bool)
+	for _, adjustment := range adjustments {
+		if err := tx.Create(adjustment).Error; err != nil {
+			return nil, err
+		}
+		key := adjustment.StaffId + "|" + adjustment.TargetMonth
+		if targets[key] {
+			continue
+		}
+		targets[key] = true
+		if err := recalculateUnpaidSalaryRecord(c, tx, adjustment.StaffId, adjustment.TargetMonth); err != nil {
+			return nil, err
+		}
+	}
+	return adjustments, nil
+}
+
+// calculateRetroAdjustments 按当前考勤及参数重新核算区间内已发放的薪资记录，与已发放金额及此前的追溯调整比较得出差额
+func calculateRetroAdjustments(c *gin.Context, tx *gorm.DB, dto *model.SalaryV2RetroCalculateDTO, operator string) ([]*model.SalaryV2RetroAdjustment, error) {
+	if _, err := time.Parse("2006-01", dto.StartMonth); err != nil {
+		return nil, errors.New("开始月份格式错误: " + dto.StartMonth)
+	}
+	if _, err := time.Parse("2006-01", dto.EndMonth); err != nil {
+		return nil, errors.New("结束月份格式错误: " + dto.EndMonth)
+	}
+	if dto.StartMonth > dto.EndMonth {
+		return nil, errors.New("开始月份不能晚于结束月份")
+	}
+
+	// 离职结算记录包含经济补偿等结算项目，不参与追溯
+	query := tx.Where("is_pay = 2 and salary_date >= ? and salary_date <= ? and record_type <> ?",
+		dto.StartMonth, dto.EndMonth, "settlement")
+	if dto.StaffId != "" {
+		query = query.Where("staff_id = ?", dto.StaffId)
+	}
+	var paidRecords []*model.SalaryRecord
+	if err := query.Order("staff_id asc, salary_date asc").Find(&paidRecords).Error; err != nil {
+		return nil, err
+	}
+
+	var adjustments []*model.SalaryV2RetroAdjustment
+	for _, paid := range paidRecords {
+		recalculated, err := previewSalaryRecord(c, tx, paid.StaffId, paid.SalaryDate)
+		if err != nil {
+			return nil, errors.New(paid.StaffId + " " + paid.SalaryDate + ": " + err.Error())
+		}
+
+		// 扣除此前已生成的追溯调整，避免重复补发或扣回
+		var prior []*model.SalaryV2RetroAdjustment
+		if err := tx.Where("staff_id = ? and source_month = ? and status <> ?", paid.StaffId, paid.SalaryDate, "cancelled").
+			Find(&prior).Error; err != nil {
+			return nil, err
+		}
+		var priorEarnings, priorDeduction, priorNet, priorTotal model.Money
+		for _, adjustment := range prior {
+			priorEarnings += adjustment.EarningsDifference
+			priorDeduction += adjustment.DeductionDifference
+			priorNet += adjustment.NetDifference
+			priorTotal += adjustment.TotalDifference
+		}
+
+		adjustment := &model.SalaryV2RetroAdjustment{
+			RetroId:               RandomID("retro"),
+			StaffId:               paid.StaffId,
+			StaffName:             paid.StaffName,
+			SourceMonth:           paid.SalaryDate,
+			SourceRecordId:        paid.SalaryRecordId,
+			PaidIncome:            salaryRecordRegularIncome(paid),
+			RecalculatedIncome:    salaryRecordRegularIncome(recalculated),
+			PaidDeduction:         salaryRecordDeduction(paid),
+			RecalculatedDeduction: salaryRecordDeduction(recalculated),
+			PaidTax:               paid.Tax,
+			RecalculatedTax:       recalculated.Tax,
+			PaidTotal:             paid.Total,
+			RecalculatedTotal:     recalculated.Total,
+			Status:                "pending",
+			Reason:                dto.Reason,
+			CreatedBy:             operator,
+			UpdatedBy:             operator,
+		}
+		adjustment.EarningsDifference = adjustment.RecalculatedIncome - adjustment.PaidIncome - priorEarnings
+		adjustment.DeductionDifference = adjustment.RecalculatedDeduction - adjustment.PaidDeduction - priorDeduction
+		adjustment.NetDifference = salaryRecordNetAdjustment(recalculated) - salaryRecordNetAdjustment(paid) - priorNet
+		adjustment.TotalDifference = recalculated.Total - paid.Total - priorTotal
+		if adjustment.EarningsDifference.IsZero() && adjustment.DeductionDifference.IsZero() &&
+			adjustment.NetDifference.IsZero() {
+			continue
+		}
+		if adjustment.TargetMonth, err = nextOpenPayrollMonth(tx, paid.StaffId); err != nil {
+			return nil, err
+		}
+		adjustments = append(adjustments, adjustment)
+	}
+	return adjustments, nil
+}
+
+// previewSalaryRecord 按当前薪资套账、考勤及参数试算指定月份薪资，试算产生的明细及关联变更全部回滚
+func previewSalaryRecord(c *gin.Context, tx *gorm.DB, staffId, month string) (*model.SalaryRecord, error) {
+	salaryInfo, err := getSalaryInfoByStaffId(tx, staffId)
+	if err != nil {
+		return nil, err
+	}
+	var attendances []*model.AttendanceRecord
+	tx.Where("staff_id = ? and date = ?", staffId, month).Find(&attendances)
+	if len(attendances) == 0 {
+		return nil, errors.New("不存在该月考勤信息")
+	}
+
+	var salaryRecord model.SalaryRecord
+	err = tx.Transaction(func(sub *gorm.DB) error {
+		record, err := CalculateSalaryV2(c, sub, salaryInfo, attendances[0])
+		if err != nil {
+			return err
+		}
+		salaryRecord = record
+		return errRetroPreview
+	})
+	if !errors.Is(err, errRetroPreview) {
+		return nil, err
+	}
+	return &salaryRecord, nil
+}
+
+// recalculateUnpaidSalaryRecord 重新核算已生成但尚未发放的薪资记录，离职结算记录需重新生成离职结算
+func recalculateUnpaidSalaryRecord(c *gin.Context, tx *gorm.DB, staffId, month string) error {
+	var records []*model.SalaryRecord
+	tx.Where("staff_id = ? and salary_date = ?", staffId, month).Find(&records)
+	if len(records) == 0 || records[0].RecordType == "settlement" {
+		return nil
+	}
+	if err := checkSalaryRecordEditable(tx, records[0]); err != nil {
+		return err
+	}
+	var attendances []*model.AttendanceRecord
+	tx.Where("staff_id = ? and date = ?", staffId, month).Find(&attendances)
+	if len(attendances) == 0 {
+		return nil
+	}
+	salaryInfo, err := getSalaryInfoByStaffId(tx, staffId)
+	if err != nil {
+		return err
+	}
+	salaryRecord, err := CalculateSalaryV2(c, tx, salaryInfo, attendances[0])
+	if err != nil {
+		return err
+	}
+	return tx.Where("id = ?", records[0].ID).
+		Select("*").Omit("id", "created_at", "deleted_at").Updates(&salaryRecord).Error
+}
+
+// nextOpenPayrollMonth 获取员工下一个未发放的薪资月份：最近一期薪资未发放时取该月，已发放或正在银行代发处理中时取其次月，
+// 该月薪资期间已锁定或已结账时顺延至下一个开放的期间
+func nextOpenPayrollMonth(tx *gorm.DB, staffId string) (string, error) {
+	var records []*model.SalaryRecord
+	if err := tx.Where("staff_id = ?", staffId).Order("salary_date desc").Limit(1).Find(&records).Error; err != nil {
+		return "", err
+	}
+	next, _ := time.Parse("2006-01", time.Now().Format("2006-01"))
+	if len(records) != 0 {
+		latest, err := time.Parse("2006-01", records[0].SalaryDate)
+		if err != nil {
+			return "", errors.New("薪资月份格式错误: " + records[0].SalaryDate)
+		}
+		next = latest
+		if records[0].IsPay == 2 || records[0].IsPay == 3 {
+			next = latest.AddDate(0, 1, 0)
+		}
+	}
+	for getPayrollPeriodStatus(tx, next.Format("2006-01")) != "open" {
+		next = next.AddDate(0, 1, 0)
+	}
+	return next.Format("2006-01"), nil
+}
+
+// applyRetroAdjustments 将计入当月的追溯调整汇总到薪资记录，须在薪资记录ID确定后、计算个人所得税前调用
+// 计税收入及专项扣除差额并入当月累计预扣，同一年度内已预扣税额的差额随累计预扣自动补扣或退还
+func applyRetroAdjustments(tx *gorm.DB, salaryRecord *model.SalaryRecord) error {
+	var adjustments []*model.SalaryV2RetroAdjustment
+	if err := tx.Where("staff_id = ? and target_month = ? and status <> ?", salaryRecord.StaffId, salaryRecord.SalaryDate, "cancelled").
+		Find(&adjustments).Error; err != nil {
+		return err
+	}
+	var earnings, deductions, net model.Money
+	for _, adjustment := range adjustments {
+		earnings += adjustment.EarningsDifference
+		deductions += adjustment.DeductionDifference
+		net += adjustment.NetDifference
+		if err := tx.Model(&model.SalaryV2RetroAdjustment{}).Where("id = ?", adjustment.ID).
+			Updates(map[string]interface{}{"status": "applied", "applied_record_id": salaryRecord.SalaryRecordId}).Error; err != nil {
+			return err
+		}
+	}
+	salaryRecord.RetroEarnings = earnings
+	salaryRecord.RetroDeductions = deductions
+	salaryRecord.RetroNetAdjustment = net
+	return nil
+}
+
+// salaryRecordNetAdjustment 获取薪资记录中直接计入实发工资的不计税收入及税后扣款
+func salaryRecordNetAdjustment(record *model.SalaryRecord) model.Money {
+	return record.TaxFreeEarnings - record.PostTaxDeductions + record.RetroNetAdjustment
+}
@@ -18,6 +18,12 @@ func CreateSalary(c *gin.Context, dto *model.SalaryCreateDTO) error {
 	}
 	var salary model.Salary
 	Transfer(&dto, &salary)
+	if salary.AnnualBonusTaxMethod == "" {
+		salary.AnnualBonusTaxMethod = "separate"
+	}
+	if err := checkAnnualBonusTaxMethod(salary.AnnualBonusTaxMethod); err != nil {
+		return err
+	}
 	salary.SalaryId = RandomID("salary")
 	if err := resource.HrmsDB(c).Create(&salary).Error; err != nil {
 		log.Printf("CreateSalary err = %v", err)
@@ -38,17 +44,33 @@ func DelSalaryBySalaryId(c *gin.Context, salaryId string) error {
 func UpdateSalaryById(c *gin.Context, dto *model.SalaryEditDTO) error {
 	var salary model.Salary
 	Transfer(&dto, &salary)
+	// 基本工资需通过调薪申请审批后变更
+	var oldSalary model.Salary
+	if err := resource.HrmsDB(c).Where("id = ?", dto.Id).First(&oldSalary).Error; err != nil {
+		return errors.New("不存在该薪资套账")
+	}
+	if salary.Base != oldSalary.Base {
+		return errors.New("基本工资变更请提交调薪申请")
+	}
+	updates := map[string]interface{}{
+		"staff_id":   salary.StaffId,
+		"staff_name": salary.StaffName,
+		"base":       salary.Base,
+		"subsidy":    salary.Subsidy,
+		"bonus":      salary.Bonus,
+		"commission": salary.Commission,
+		"other":      salary.Other,
+		"fund":       salary.Fund,
+	}
+	// 未传入计税方式时保留原有选择
+	if salary.AnnualBonusTaxMethod != "" {
+		if err := checkAnnualBonusTaxMethod(salary.AnnualBonusTaxMethod); err != nil {
+			return err
+		}
+		updates["annual_bonus_tax_method"] = salary.AnnualBonusTaxMethod
+	}
 	if err := resource.HrmsDB(c).Model(&model.Salary{}).Where("id = ?", dto.Id).
-		Updates(map[string]interface{}{
-			"staff_id":   salary.StaffId,
-			"staff_name": salary.StaffName,
-			"base":       salary.Base,
-			"subsidy":    salary.Subsidy,
-			"bonus":      salary.Bonus,
-			"commission": salary.Commission,
-			"other":      salary.Other,
-			"fund":       salary.Fund,
-		}).
+		Updates(updates).
 		Error; err != nil {
 		log.Printf("UpdateSalaryById err = %v", err)
 		return err
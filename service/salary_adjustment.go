@@ -0,0 +1,312 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func checkSalaryAdjustmentType(adjustmentType string) error {
+	switch adjustmentType {
+	case "raise", "demotion", "probation_regular", "other":
+		return nil
+	}
+	return errors.New("不支持的调薪类型: " + adjustmentType)
+}
+
+// CreateSalaryAdjustmentV2 提交调薪申请，员工无直属领导时直接进入人事审批
+func CreateSalaryAdjustmentV2(c *gin.Context, dto *model.SalaryV2AdjustmentCreateDTO, createdBy string) error {
+	if err := checkSalaryAdjustmentType(dto.AdjustmentType); err != nil {
+		return err
+	}
+	if dto.EffectiveDate != "" {
+		if _, err := time.Parse("2006-01-02", dto.EffectiveDate); err != nil {
+			return errors.New("生效日期格式错误: " + dto.EffectiveDate)
+		}
+		dto.EffectiveMonth = dto.EffectiveDate[:7]
+	}
+	if _, err := time.Parse("2006-01", dto.EffectiveMonth); err != nil {
+		return errors.New("生效月份格式错误: " + dto.EffectiveMonth)
+	}
+	if dto.EffectiveDate == "" {
+		dto.EffectiveDate = dto.EffectiveMonth + "-01"
+	}
+	if dto.NewBase <= 0 {
+		return errors.New("调整后基本工资必须大于0")
+	}
+	var staff model.Staff
+	if err := resource.HrmsDB(c).Where("staff_id = ?", dto.StaffId).First(&staff).Error; err != nil {
+		return errors.New("不存在该员工")
+	}
+	if dto.NewRankId != "" {
+		var count int64
+		resource.HrmsDB(c).Model(&model.Rank{}).Where("rank_id = ?", dto.NewRankId).Count(&count)
+		if count == 0 {
+			return errors.New("不存在该职级")
+		}
+	}
+	var openCount int64
+	resource.HrmsDB(c).Model(&model.SalaryV2Adjustment{}).
+		Where("staff_id = ? and status in ?", dto.StaffId, []string{"pending_leader", "pending_hr", "approved"}).
+		Count(&openCount)
+	if openCount > 0 {
+		return errors.New("该员工已有未生效的调薪申请")
+	}
+
+	var adjustment model.SalaryV2Adjustment
+	Transfer(&dto, &adjustment)
+	adjustment.AdjustmentId = RandomID("adjustment")
+	adjustment.StaffName = staff.StaffName
+	adjustment.OldBase = staff.BaseSalary
+	adjustment.OldRankId = staff.RankId
+	adjustment.Status = "pending_leader"
+	if staff.LeaderStaffId == "" || staff.LeaderStaffId == staff.StaffId {
+		adjustment.Status = "pending_hr"
+	}
+	adjustment.CreatedBy = createdBy
+	adjustment.UpdatedBy = createdBy
+
+	if err := resource.HrmsDB(c).Create(&adjustment).Error; err != nil {
+		log.Printf("CreateSalaryAdjustmentV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func GetSalaryAdjustmentsV2(c *gin.Context, staffId string, status string, start int, limit int) ([]*model.SalaryV2Adjustment, int64, error) {
+	var adjustments []*model.SalaryV2Adjustment
+	var err error
+	query := resource.HrmsDB(c).Model(&model.SalaryV2Adjustment{})
+	if staffId != "" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("created_at desc").Find(&adjustments).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("created_at desc").Find(&adjustments).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return adjustments, total, nil
+}
+
+// GetSalaryAdjustmentsByLeaderV2 查询待指定领导审批的下属调薪申请
+func GetSalaryAdjustmentsByLeaderV2(c *gin.Context, leaderStaffId string) ([]*model.SalaryV2Adjustment, int64, error) {
+	var adjustments []*model.SalaryV2Adjustment
+	err := resource.HrmsDB(c).Where("status = ? and staff_id in (?)", "pending_leader",
+		resource.HrmsDB(c).Model(&model.Staff{}).Select("staff_id").Where("leader_staff_id = ?", leaderStaffId)).
+		Order("created_at desc").Find(&adjustments).Error
+	if err != nil {
+		return nil, 0, err
+	}
+	return adjustments, int64(len(adjustments)), nil
+}
+
+// ApproveSalaryAdjustmentByLeaderV2 直属领导审批调薪申请，通过后进入人事审批
+func ApproveSalaryAdjustmentByLeaderV2(c *gin.Context, dto *model.SalaryV2AdjustmentApproveDTO, approverId string) error {
+	adjustment, err := getSalaryAdjustment(resource.HrmsDB(c), dto.AdjustmentId)
+	if err != nil {
+		return err
+	}
+	if adjustment.Status != "pending_leader" {
+		return errors.New("该调薪申请不在领导审批环节")
+	}
+	var staff model.Staff
+	resource.HrmsDB(c).Where("staff_id = ?", adjustment.StaffId).Find(&staff)
+	if staff.LeaderStaffId != approverId {
+		return errors.New("只能审批直属下属的调薪申请")
+	}
+
+	status := "pending_hr"
+	if !dto.Approved {
+		status = "rejected"
+	}
+	now := time.Now()
+	if err := resource.HrmsDB(c).Model(&model.SalaryV2Adjustment{}).Where("adjustment_id = ?", dto.AdjustmentId).
+		Updates(map[string]interface{}{
+			"status":             status,
+			"leader_approver_id": approverId,
+			"leader_comment":     dto.Comment,
+			"leader_approved_at": &now,
+			"updated_by":         approverId,
+		}).Error; err != nil {
+		log.Printf("ApproveSalaryAdjustmentByLeaderV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// ApproveSalaryAdjustmentByHrV2 人事审批调薪申请，生效月份已到时立即执行
+func ApproveSalaryAdjustmentByHrV2(c *gin.Context, dto *model.SalaryV2AdjustmentApproveDTO, approverId string) error {
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		adjustment, err := getSalaryAdjustment(tx, dto.AdjustmentId)
+		if err != nil {
+			return err
+		}
+		if adjustment.Status != "pending_hr" {
+			return errors.New("该调薪申请不在人事审批环节")
+		}
+		if approverId == adjustment.StaffId || approverId == adjustment.CreatedBy {
+			return errors.New("不能审批本人或本人提交的调薪申请")
+		}
+
+		status := "approved"
+		if !dto.Approved {
+			status = "rejected"
+		}
+		now := time.Now()
+		if err := tx.Model(&model.SalaryV2Adjustment{}).Where("adjustment_id = ?", dto.AdjustmentId).
+			Updates(map[string]interface{}{
+				"status":         status,
+				"hr_approver_id": approverId,
+				"hr_comment":     dto.Comment,
+				"hr_approved_at": &now,
+				"updated_by":     approverId,
+			}).Error; err != nil {
+			log.Printf("ApproveSalaryAdjustmentByHrV2 err = %v", err)
+			return err
+		}
+		if status == "approved" && adjustment.EffectiveMonth <= now.Format("2006-01") {
+			return applySalaryAdjustment(tx, adjustment, approverId)
+		}
+		return nil
+	})
+}
+
+// CancelSalaryAdjustmentV2 撤销尚未生效的调薪申请，非管理员只能撤销本人的调薪申请
+func CancelSalaryAdjustmentV2(c *gin.Context, adjustmentId string, operator string, isAdmin bool) error {
+	adjustment, err := getSalaryAdjustment(resource.HrmsDB(c), adjustmentId)
+	if err != nil {
+		return err
+	}
+	if !isAdmin && adjustment.StaffId != operator {
+		return errors.New("只能撤销本人的调薪申请")
+	}
+	result := resource.HrmsDB(c).Model(&model.SalaryV2Adjustment{}).
+		Where("adjustment_id = ? and status in ?", adjustmentId, []string{"pending_leader", "pending_hr", "approved"}).
+		Updates(map[string]interface{}{
+			"status":     "cancelled",
+			"updated_by": operator,
+		})
+	if result.Error != nil {
+		log.Printf("CancelSalaryAdjustmentV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("该调薪申请已生效或已结束，无法撤销")
+	}
+	return nil
+}
+
+// GetCompensationHistoryV2 查询员工薪酬变动历史
+func GetCompensationHistoryV2(c *gin.Context, staffId string) ([]*model.SalaryV2CompensationHistory, error) {
+	var histories []*model.SalaryV2CompensationHistory
+	if err := resource.HrmsDB(c).Where("staff_id = ?", staffId).Order("effective_month desc, effective_date desc, id desc").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+	return histories, nil
+}
+
+// ApplyDueSalaryAdjustments 执行已审批且到达生效月份的调薪申请，由定时任务每日执行
+func ApplyDueSalaryAdjustments() {
+	month := time.Now().Format("2006-01")
+	for dbName, db := range resource.DbMapper {
+		var adjustments []*model.SalaryV2Adjustment
+		if err := db.Where("status = ? and effective_month <= ?", "approved", month).Find(&adjustments).Error; err != nil {
+			log.Printf("分公司 %s 查询待生效调薪申请失败: %v", dbName, err)
+			continue
+		}
+		for _, adjustment := range adjustments {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return applySalaryAdjustment(tx, adjustment, "system")
+			})
+			if err != nil {
+				log.Printf("分公司 %s 员工 %s 调薪生效失败: %v", dbName, adjustment.StaffName, err)
+			}
+		}
+	}
+}
+
+func getSalaryAdjustment(db *gorm.DB, adjustmentId string) (*model.SalaryV2Adjustment, error) {
+	var adjustment model.SalaryV2Adjustment
+	if err := db.Where("adjustment_id = ?", adjustmentId).First(&adjustment).Error; err != nil {
+		return nil, errors.New("不存在该调薪申请")
+	}
+	return &adjustment, nil
+}
+
+// applySalaryAdjustment 执行调薪：更新员工档案基本工资及职级、薪资套账并记录薪酬变动历史
+// 员工已分配薪资模板时按模板重新生成薪资套账
+func applySalaryAdjustment(tx *gorm.DB, adjustment *model.SalaryV2Adjustment, operator string) error {
+	var staff model.Staff
+	if err := tx.Where("staff_id = ?", adjustment.StaffId).First(&staff).Error; err != nil {
+		return errors.New("不存在该员工")
+	}
+	staffUpdates := map[string]interface{}{"base_salary": adjustment.NewBase}
+	newRankId := staff.RankId
+	if adjustment.NewRankId != "" {
+		staffUpdates["rank_id"] = adjustment.NewRankId
+		newRankId = adjustment.NewRankId
+	}
+	if err := tx.Model(&model.Staff{}).Where("staff_id = ?", staff.StaffId).Updates(staffUpdates).Error; err != nil {
+		return err
+	}
+
+	var assignmentCount int64
+	tx.Model(&model.StaffSalaryTemplate{}).Where("staff_id = ? and status = ?", staff.StaffId, "active").Count(&assignmentCount)
+	if assignmentCount > 0 {
+		// 模板分配中指定的基本工资同步调整
+		if err := tx.Model(&model.StaffSalaryTemplate{}).
+			Where("staff_id = ? and status = ? and base_salary <> 0", staff.StaffId, "active").
+			Update("base_salary", adjustment.NewBase).Error; err != nil {
+			return err
+		}
+		if err := refreshStaffSalaryFromTemplate(tx, staff.StaffId); err != nil {
+			return err
+		}
+	} else if err := tx.Model(&model.Salary{}).Where("staff_id = ?", staff.StaffId).
+		Update("base", adjustment.NewBase).Error; err != nil {
+		return err
+	}
+
+	history := model.SalaryV2CompensationHistory{
+		HistoryId:      RandomID("compensation"),
+		StaffId:        staff.StaffId,
+		StaffName:      staff.StaffName,
+		AdjustmentId:   adjustment.AdjustmentId,
+		ChangeType:     adjustment.AdjustmentType,
+		OldBase:        staff.BaseSalary,
+		NewBase:        adjustment.NewBase,
+		OldRankId:      staff.RankId,
+		NewRankId:      newRankId,
+		EffectiveMonth: adjustment.EffectiveMonth,
+		EffectiveDate:  adjustment.EffectiveDate,
+		Reason:         adjustment.Reason,
+		Operator:       operator,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return tx.Model(&model.SalaryV2Adjustment{}).Where("adjustment_id = ?", adjustment.AdjustmentId).
+		Updates(map[string]interface{}{
+			"status":     "applied",
+			"applied_at": &now,
+		}).Error
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"errors"
 	"github.com/gin-gonic/gin"
 	"hrms/model"
 	"hrms/resource"
@@ -26,6 +27,15 @@ import (
 //}
 
 func DelSalaryRecordBySalaryRecordId(c *gin.Context, salaryRecordId string) error {
+	var salaryRecords []*model.SalaryRecord
+	resource.HrmsDB(c).Where("salary_record_id = ?", salaryRecordId).Find(&salaryRecords)
+	if len(salaryRecords) == 0 {
+		return errors.New("不存在该薪资记录")
+	}
+	// 已发放或所属期间已锁定的薪资记录不可删除
+	if err := checkSalaryRecordEditable(resource.HrmsDB(c), salaryRecords[0]); err != nil {
+		return err
+	}
 	if err := resource.HrmsDB(c).Where("salary_record_id = ?", salaryRecordId).Delete(&model.SalaryRecord{}).
 		Error; err != nil {
 		log.Printf("DelSalaryRecordBySalaryRecordId err = %v", err)
@@ -90,28 +100,6 @@ func GetSalaryRecordIsPayById(c *gin.Context, id int64) bool {
 	return total != 0
 }
 
-func PaySalaryRecordById(c *gin.Context, id int64) error {
-	if err := resource.HrmsDB(c).Model(&model.SalaryRecord{}).Where("id = ?", id).
-		Update("is_pay", 2).Error; err != nil {
-		log.Printf("PaySalaryRecordById err = %v", err)
-		return err
-	}
-	var salarys []*model.SalaryRecord
-	resource.HrmsDB(c).Where("id = ?", id).Find(&salarys)
-	if len(salarys) > 0 && salarys[0].IsPay == 2 {
-		// 发送短信通知员工薪资已发放
-		date := salarys[0].SalaryDate
-		sendNoticeMsg("salary", getStaffPhoneByStaffId(c, salarys[0].StaffId), []string{date})
-	}
-	// 通过税务系统上报税款并将工资发放至员工银行卡中
-	payStaffSalaryAndTax(salarys[0])
-	return nil
-}
-
-func payStaffSalaryAndTax(record *model.SalaryRecord) {
-	// 需要对接银行系统及税务系统
-}
-
 func getStaffPhoneByStaffId(c *gin.Context, staffId string) int64 {
 	var staffs []*model.Staff
 	resource.HrmsDB(c).Where("staff_id = ?", staffId).Find(&staffs)
@@ -150,3 +138,12 @@ func GetHadPaySalaryRecordByStaffId(c *gin.Context, staffId string, start int, l
 	}
 	return salaryRecords, total, nil
 }
+
+// GetSalaryRecordSegments 查询薪资记录的基本工资分段明细
+func GetSalaryRecordSegments(c *gin.Context, salaryRecordId string) ([]*model.SalaryV2RecordSegment, error) {
+	var segments []*model.SalaryV2RecordSegment
+	if err := resource.HrmsDB(c).Where("salary_record_id = ?", salaryRecordId).Order("start_date asc").Find(&segments).Error; err != nil {
+		return nil, err
+	}
+	return segments, nil
+}
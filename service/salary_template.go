@@ -6,8 +6,10 @@ import (
 	"hrms/model"
 	"hrms/resource"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 // CreateSalaryTemplate 创建薪资模板
@@ -18,6 +20,9 @@ func CreateSalaryTemplate(c *gin.Context, template *model.SalaryTemplateWithItem
 	if result.Error == nil {
 		return errors.New("模板ID已存在")
 	}
+	if err := validateTemplateItems(resource.HrmsDB(c), template.Items); err != nil {
+		return err
+	}
 
 	// 开始事务
 	tx := resource.HrmsDB(c).Begin()
@@ -45,7 +50,7 @@ func CreateSalaryTemplate(c *gin.Context, template *model.SalaryTemplateWithItem
 	return tx.Commit().Error
 }
 
-// UpdateSalaryTemplate 更新薪资模板
+// UpdateSalaryTemplate 更新薪资模板，并按新模板重新生成已分配员工的薪资套账
 func UpdateSalaryTemplate(c *gin.Context, template *model.SalaryTemplateWithItems) error {
 	// 验证模板是否存在
 	existingTemplate := &model.SalaryTemplate{}
@@ -53,6 +58,9 @@ func UpdateSalaryTemplate(c *gin.Context, template *model.SalaryTemplateWithItem
 	if result.Error != nil {
 		return errors.New("模板不存在")
 	}
+	if err := validateTemplateItems(resource.HrmsDB(c), template.Items); err != nil {
+		return err
+	}
 
 	// 开始事务
 	tx := resource.HrmsDB(c).Begin()
@@ -62,17 +70,45 @@ func UpdateSalaryTemplate(c *gin.Context, template *model.SalaryTemplateWithItem
 		}
 	}()
 
-	// 删除模板项目
-	if err := tx.Where("template_id = ?", template.TemplateID).Delete(&model.SalaryTemplateItem{}).Error; err != nil {
+	// 更新模板
+	if err := tx.Model(&model.SalaryTemplate{}).Where("template_id = ?", template.TemplateID).
+		Updates(map[string]interface{}{
+			"template_name":        template.TemplateName,
+			"template_description": template.TemplateDescription,
+			"template_type":        template.TemplateType,
+			"applicable_rank_ids":  template.ApplicableRankIDs,
+			"applicable_dep_ids":   template.ApplicableDepIDs,
+			"is_active":            template.IsActive,
+			"updated_by":           template.UpdatedBy,
+		}).Error; err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	// 删除模板
-	if err := tx.Where("template_id = ?", template.TemplateID).Delete(&model.SalaryTemplate{}).Error; err != nil {
+	// 删除原模板项目后重新保存
+	if err := tx.Unscoped().Where("template_id = ?", template.TemplateID).Delete(&model.SalaryTemplateItem{}).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i := range template.Items {
+		template.Items[i].ID = 0
+		template.Items[i].TemplateID = template.TemplateID
+		if template.Items[i].ItemID == "" {
+			template.Items[i].ItemID = RandomID("item")
+		}
+		if err := tx.Create(&template.Items[i]).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	// 批量重新生成使用该模板的员工薪资套账
+	count, err := reapplySalaryTemplate(tx, template.TemplateID)
+	if err != nil {
 		tx.Rollback()
 		return err
 	}
+	log.Printf("UpdateSalaryTemplate template_id = %v, reapplied staff count = %v", template.TemplateID, count)
 
 	return tx.Commit().Error
 }
@@ -193,28 +229,44 @@ func ApplySalaryTemplate(c *gin.Context, applyReq *model.TemplateApplyRequest) (
 		return nil, errors.New("模板未启用")
 	}
 
-	// 计算各项薪资
-	result := &model.TemplateApplyResponse{
-		Base:       applyReq.BaseSalary,
-		Subsidy:    0,
-		Bonus:      0,
-		Commission: 0,
-		Other:      0,
+	return calculateTemplateSalary(template.Items, applyReq.BaseSalary), nil
+}
+
+// calculateTemplateSalary 按模板项目计算各项薪资，金额单位为元
+// baseSalary 大于0时作为基本工资，否则使用模板中的基本工资项目
+func calculateTemplateSalary(items []model.SalaryTemplateItem, baseSalary model.Money) *model.TemplateApplyResponse {
+	result := &model.TemplateApplyResponse{}
+
+	// 先确定基本工资，百分比项目以基本工资为基准
+	if baseSalary > 0 {
+		result.Base = baseSalary
+	} else {
+		for _, item := range items {
+			if item.ItemType == "base" && item.CalculationType == "fixed" && item.Amount != nil {
+				result.Base += *item.Amount
+			}
+		}
 	}
 
-	for _, item := range template.Items {
-		var amount int64
+	for _, item := range items {
+		if item.ItemType == "base" {
+			continue
+		}
+		var amount model.Money
 		if item.CalculationType == "fixed" && item.Amount != nil {
 			amount = *item.Amount
 		} else if item.CalculationType == "percentage" && item.Percentage != nil {
 			// 百分比计算，基于基本工资
-			amount = int64(float64(applyReq.BaseSalary) * (*item.Percentage / 100.0))
+			amount = result.Base.Mul(*item.Percentage / 100.0)
 		}
 
 		// 根据项目类型累加到对应字段
 		switch item.ItemType {
-		case "base":
-			result.Base += amount
+		case "component":
+			if result.Components == nil {
+				result.Components = make(map[string]model.Money)
+			}
+			result.Components[item.ComponentCode] += amount
 		case "subsidy":
 			result.Subsidy += amount
 		case "bonus":
@@ -226,7 +278,7 @@ func ApplySalaryTemplate(c *gin.Context, applyReq *model.TemplateApplyRequest) (
 		}
 	}
 
-	return result, nil
+	return result
 }
 
 // GetApplicableTemplates 获取适用于指定员工的可应用模板
@@ -247,42 +299,8 @@ func GetApplicableTemplates(c *gin.Context, staffID string) ([]model.SalaryTempl
 	// 筛选适用于该员工的模板
 	var applicableTemplates []model.SalaryTemplateWithItems
 	for _, template := range templates {
-		// 检查职级匹配
-		if template.ApplicableRankIDs != "" {
-			var rankIDs []string
-			if err := json.Unmarshal([]byte(template.ApplicableRankIDs), &rankIDs); err != nil {
-				log.Printf("解析职级ID列表失败: %v", err)
-				continue
-			}
-			rankMatched := false
-			for _, rankID := range rankIDs {
-				if rankID == staff.RankId {
-					rankMatched = true
-					break
-				}
-			}
-			if !rankMatched {
-				continue
-			}
-		}
-
-		// 检查部门匹配
-		if template.ApplicableDepIDs != "" {
-			var depIDs []string
-			if err := json.Unmarshal([]byte(template.ApplicableDepIDs), &depIDs); err != nil {
-				log.Printf("解析部门ID列表失败: %v", err)
-				continue
-			}
-			depMatched := false
-			for _, depID := range depIDs {
-				if depID == staff.DepId {
-					depMatched = true
-					break
-				}
-			}
-			if !depMatched {
-				continue
-			}
+		if !templateApplicableToStaff(&template, staff) {
+			continue
 		}
 
 		// 获取模板项目
@@ -316,3 +334,267 @@ func ToggleTemplateStatus(c *gin.Context, templateID string, status bool) error
 
 	return nil
 }
+
+// validateTemplateItems 校验模板项目类型，薪资项目目录中的项目需引用启用中的薪资项目
+func validateTemplateItems(db *gorm.DB, items []model.SalaryTemplateItem) error {
+	for _, item := range items {
+		switch item.ItemType {
+		case "base", "subsidy", "bonus", "commission", "other":
+		case "component":
+			if _, err := getActivePayComponent(db, item.ComponentCode); err != nil {
+				return err
+			}
+		default:
+			return errors.New("不支持的模板项目类型: " + item.ItemType)
+		}
+	}
+	return nil
+}
+
+// templateApplicableToStaff 检查模板的适用职级、部门是否匹配员工
+func templateApplicableToStaff(template *model.SalaryTemplate, staff *model.Staff) bool {
+	// 检查职级匹配
+	if template.ApplicableRankIDs != "" {
+		var rankIDs []string
+		if err := json.Unmarshal([]byte(template.ApplicableRankIDs), &rankIDs); err != nil {
+			log.Printf("解析职级ID列表失败: %v", err)
+			return false
+		}
+		rankMatched := false
+		for _, rankID := range rankIDs {
+			if rankID == staff.RankId {
+				rankMatched = true
+				break
+			}
+		}
+		if !rankMatched {
+			return false
+		}
+	}
+
+	// 检查部门匹配
+	if template.ApplicableDepIDs != "" {
+		var depIDs []string
+		if err := json.Unmarshal([]byte(template.ApplicableDepIDs), &depIDs); err != nil {
+			log.Printf("解析部门ID列表失败: %v", err)
+			return false
+		}
+		depMatched := false
+		for _, depID := range depIDs {
+			if depID == staff.DepId {
+				depMatched = true
+				break
+			}
+		}
+		if !depMatched {
+			return false
+		}
+	}
+	return true
+}
+
+// AssignSalaryTemplate 为员工分配薪资模板，生效日期不晚于当天时立即生成薪资套账
+func AssignSalaryTemplate(c *gin.Context, assignReq *model.TemplateAssignRequest, operator string) (*model.StaffSalaryTemplate, error) {
+	template, err := GetSalaryTemplate(c, assignReq.TemplateID)
+	if err != nil {
+		return nil, err
+	}
+	if !template.IsActive {
+		return nil, errors.New("模板未启用")
+	}
+	staff := &model.Staff{}
+	if err := resource.HrmsDB(c).Where("staff_id = ?", assignReq.StaffID).First(staff).Error; err != nil {
+		return nil, errors.New("员工不存在")
+	}
+	if !templateApplicableToStaff(&template.SalaryTemplate, staff) {
+		return nil, errors.New("该模板不适用于员工当前职级或部门")
+	}
+
+	today := time.Now().Format("2006-01-02")
+	effectiveDate := assignReq.EffectiveDate
+	if effectiveDate == "" {
+		effectiveDate = today
+	}
+	if _, err := time.Parse("2006-01-02", effectiveDate); err != nil {
+		return nil, errors.New("生效日期格式错误: " + effectiveDate)
+	}
+
+	assignment := &model.StaffSalaryTemplate{
+		AssignmentID:  RandomID("staff_template"),
+		StaffID:       staff.StaffId,
+		StaffName:     staff.StaffName,
+		TemplateID:    template.TemplateID,
+		BaseSalary:    assignReq.BaseSalary,
+		EffectiveDate: effectiveDate,
+		Status:        "pending",
+		CreatedBy:     operator,
+	}
+	err = resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		// 同一员工只保留一条待生效的分配
+		if err := tx.Model(&model.StaffSalaryTemplate{}).Where("staff_id = ? and status = ?", staff.StaffId, "pending").
+			Update("status", "expired").Error; err != nil {
+			return err
+		}
+		if err := tx.Create(assignment).Error; err != nil {
+			return err
+		}
+		if effectiveDate <= today {
+			return activateStaffSalaryTemplate(tx, assignment)
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("AssignSalaryTemplate err = %v", err)
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// GetStaffSalaryTemplates 查询员工薪资模板分配记录
+func GetStaffSalaryTemplates(c *gin.Context, staffID string) ([]model.StaffSalaryTemplate, error) {
+	var assignments []model.StaffSalaryTemplate
+	if err := resource.HrmsDB(c).Where("staff_id = ?", staffID).Order("effective_date DESC, id DESC").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+// ReapplySalaryTemplate 按模板重新生成所有已分配员工的薪资套账
+func ReapplySalaryTemplate(c *gin.Context, templateID string) (int64, error) {
+	var count int64
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var err error
+		count, err = reapplySalaryTemplate(tx, templateID)
+		return err
+	})
+	return count, err
+}
+
+// RefreshStaffSalaryByTemplate 员工职级或部门变更后按已分配模板刷新薪资套账，需与员工信息变更在同一事务中执行
+func RefreshStaffSalaryByTemplate(tx *gorm.DB, staffID string) error {
+	return refreshStaffSalaryFromTemplate(tx, staffID)
+}
+
+// ApplyPendingSalaryTemplates 生效到期的员工薪资模板分配，由定时任务每日执行
+func ApplyPendingSalaryTemplates() {
+	today := time.Now().Format("2006-01-02")
+	for dbName, db := range resource.DbMapper {
+		var assignments []*model.StaffSalaryTemplate
+		if err := db.Where("status = ? and effective_date <= ?", "pending", today).Find(&assignments).Error; err != nil {
+			log.Printf("分公司 %s 查询待生效薪资模板失败: %v", dbName, err)
+			continue
+		}
+		for _, assignment := range assignments {
+			err := db.Transaction(func(tx *gorm.DB) error {
+				return activateStaffSalaryTemplate(tx, assignment)
+			})
+			if err != nil {
+				log.Printf("分公司 %s 员工 %s 薪资模板生效失败: %v", dbName, assignment.StaffName, err)
+			}
+		}
+	}
+}
+
+// activateStaffSalaryTemplate 生效员工薪资模板分配，原生效中的分配置为失效
+func activateStaffSalaryTemplate(tx *gorm.DB, assignment *model.StaffSalaryTemplate) error {
+	if err := tx.Model(&model.StaffSalaryTemplate{}).
+		Where("staff_id = ? and status = ? and assignment_id <> ?", assignment.StaffID, "active", assignment.AssignmentID).
+		Update("status", "expired").Error; err != nil {
+		return err
+	}
+	if err := tx.Model(&model.StaffSalaryTemplate{}).Where("assignment_id = ?", assignment.AssignmentID).
+		Update("status", "active").Error; err != nil {
+		return err
+	}
+	assignment.Status = "active"
+	return refreshStaffSalaryFromTemplate(tx, assignment.StaffID)
+}
+
+// reapplySalaryTemplate 重新生成使用该模板的员工薪资套账，返回处理的员工数
+func reapplySalaryTemplate(tx *gorm.DB, templateID string) (int64, error) {
+	var assignments []*model.StaffSalaryTemplate
+	if err := tx.Where("template_id = ? and status = ?", templateID, "active").Find(&assignments).Error; err != nil {
+		return 0, err
+	}
+	for _, assignment := range assignments {
+		if err := refreshStaffSalaryFromTemplate(tx, assignment.StaffID); err != nil {
+			return 0, err
+		}
+	}
+	return int64(len(assignments)), nil
+}
+
+// refreshStaffSalaryFromTemplate 按员工生效中的模板生成或更新薪资套账，未分配模板时不做处理；
+// 模板已不适用于员工当前职级或部门时失效该分配并保留原薪资套账，需重新分配适用的模板
+func refreshStaffSalaryFromTemplate(db *gorm.DB, staffID string) error {
+	var assignments []*model.StaffSalaryTemplate
+	if err := db.Where("staff_id = ? and status = ?", staffID, "active").Find(&assignments).Error; err != nil {
+		return err
+	}
+	if len(assignments) == 0 {
+		return nil
+	}
+	assignment := assignments[0]
+
+	var staff model.Staff
+	if err := db.Where("staff_id = ?", staffID).First(&staff).Error; err != nil {
+		return errors.New("员工不存在")
+	}
+	var template model.SalaryTemplate
+	if err := db.Where("template_id = ?", assignment.TemplateID).First(&template).Error; err != nil {
+		return errors.New("模板不存在")
+	}
+	if !templateApplicableToStaff(&template, &staff) {
+		log.Printf("员工 %s 的薪资模板 %s 不适用于当前职级或部门，分配已失效", staff.StaffName, template.TemplateID)
+		return db.Model(&model.StaffSalaryTemplate{}).Where("assignment_id = ?", assignment.AssignmentID).
+			Update("status", "expired").Error
+	}
+	var items []model.SalaryTemplateItem
+	if err := db.Where("template_id = ?", assignment.TemplateID).Order("sort_order ASC").Find(&items).Error; err != nil {
+		return err
+	}
+	baseSalary := assignment.BaseSalary
+	if baseSalary == 0 {
+		baseSalary = staff.BaseSalary
+	}
+	result := calculateTemplateSalary(items, baseSalary)
+
+	var salarys []*model.Salary
+	db.Where("staff_id = ?", staffID).Find(&salarys)
+	if len(salarys) == 0 {
+		salary := model.Salary{
+			SalaryId:             RandomID("salary"),
+			StaffId:              staff.StaffId,
+			StaffName:            staff.StaffName,
+			Base:                 result.Base,
+			Subsidy:              result.Subsidy,
+			Bonus:                result.Bonus,
+			Commission:           result.Commission,
+			Other:                result.Other,
+			Fund:                 1,
+			AnnualBonusTaxMethod: "separate",
+		}
+		if err := db.Create(&salary).Error; err != nil {
+			return err
+		}
+	} else if err := db.Model(&model.Salary{}).Where("staff_id = ?", staffID).
+		Updates(map[string]interface{}{
+			"staff_name": staff.StaffName,
+			"base":       result.Base,
+			"subsidy":    result.Subsidy,
+			"bonus":      result.Bonus,
+			"commission": result.Commission,
+			"other":      result.Other,
+		}).Error; err != nil {
+		return err
+	}
+
+	// 模板中的薪资项目自分配生效月份起计入员工薪资项目
+	if err := syncTemplatePayComponents(db, &staff, assignment.EffectiveDate[:7], result.Components, assignment.CreatedBy); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	return db.Model(&model.StaffSalaryTemplate{}).Where("assignment_id = ?", assignment.AssignmentID).
+		Update("applied_at", &now).Error
+}
@@ -0,0 +1,251 @@
+package service
+
+import (
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"math"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// 未休年休假按日工资收入的300%支付，其中100%已包含在正常工资中，离职结算另付200%
+const unusedLeavePayRatio = 2
+
+func checkSeveranceType(severanceType string) error {
+	switch severanceType {
+	case "none", "n", "n_plus_1":
+		return nil
+	}
+	return errors.New("不支持的经济补偿方式: " + severanceType)
+}
+
+// CreateFinalSettlementV2 生成离职结算：折算至离职日期的当月工资、未休年休假工资报酬、经济补偿及其个人所得税、离职扣款，
+// 结算结果作为离职当月的离职结算薪资记录发放，重复生成时覆盖原结算
+func CreateFinalSettlementV2(c *gin.Context, dto *model.SalaryV2SettlementCreateDTO, operator string) (*model.SalaryV2Settlement, error) {
+	if dto.SeveranceType == "" {
+		dto.SeveranceType = "none"
+	}
+	if err := checkSeveranceType(dto.SeveranceType); err != nil {
+		return nil, err
+	}
+	if dto.UnusedLeaveDays < 0 || dto.OutstandingDeduction < 0 {
+		return nil, errors.New("未休年休假天数及离职扣款不能为负数")
+	}
+
+	var settlement *model.SalaryV2Settlement
+	err := resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		var staff model.Staff
+		if err := tx.Where("staff_id = ?", dto.StaffId).First(&staff).Error; err != nil {
+			return errors.New("不存在该员工")
+		}
+		if staff.Status != 2 || staff.ResignationDate == nil {
+			return errors.New("请先办理员工离职")
+		}
+		resignation, ok := parseStaffDate(*staff.ResignationDate)
+		if !ok {
+			return errors.New("离职日期格式错误: " + *staff.ResignationDate)
+		}
+		month := resignation.Format("2006-01")
+
+		var existRecords []*model.SalaryRecord
+		tx.Where("staff_id = ? and salary_date = ?", staff.StaffId, month).Find(&existRecords)
+		// 离职当月薪资已发放或正在银行代发处理中时不能结算
+		if len(existRecords) != 0 {
+			if err := checkSalaryRecordEditable(tx, existRecords[0]); err != nil {
+				return err
+			}
+		} else if err := checkPayrollPeriodOpen(tx, month); err != nil {
+			return err
+		}
+
+		salaryInfo, err := getSalaryInfoByStaffId(tx, staff.StaffId)
+		if err != nil {
+			return err
+		}
+		attendInfo, err := getSettlementAttendance(tx, &staff, month, resignation)
+		if err != nil {
+			return err
+		}
+
+		// 折算至离职日期的当月工资
+		salaryRecord, err := CalculateSalaryV2(c, tx, salaryInfo, attendInfo)
+		if err != nil {
+			return err
+		}
+		proratedSalary := salaryRecordRegularIncome(&salaryRecord)
+
+		monthlyWage := getAverageMonthlyWage(tx, &staff, salaryInfo, month)
+		settlement = &model.SalaryV2Settlement{
+			SettlementId:         RandomID("settlement"),
+			StaffId:              staff.StaffId,
+			StaffName:            staff.StaffName,
+			EntryDate:            staff.EntryDate,
+			ResignationDate:      resignation.Format("2006-01-02"),
+			SalaryRecordId:       salaryRecord.SalaryRecordId,
+			SalaryDate:           month,
+			ProratedSalary:       proratedSalary,
+			MonthlyWage:          monthlyWage,
+			SeveranceType:        dto.SeveranceType,
+			UnusedLeaveDays:      dto.UnusedLeaveDays,
+			OutstandingDeduction: dto.OutstandingDeduction,
+			Remark:               dto.Remark,
+			CreatedBy:            operator,
+			UpdatedBy:            operator,
+		}
+
+		// 未休年休假工资报酬
+		monthlyWorkDays, err := requireSystemParameterFloat(tx, "monthly_work_days")
+		if err != nil {
+			return err
+		}
+		settlement.LeavePayout = monthlyWage.Mul(dto.UnusedLeaveDays * unusedLeavePayRatio / monthlyWorkDays)
+
+		// 经济补偿及单独计算的个人所得税
+		if err := calculateSeverance(c, tx, settlement, &staff, resignation); err != nil {
+			return err
+		}
+
+		// 结算项目计入离职当月薪资记录并重新计算个人所得税
+		salaryRecord.RecordType = "settlement"
+		salaryRecord.LeavePayout = settlement.LeavePayout
+		salaryRecord.PostTaxDeductions += settlement.OutstandingDeduction
+		salaryRecord.Severance = settlement.Severance
+		salaryRecord.SeveranceTax = settlement.SeveranceTax
+		if err := applyAnnualBonuses(c, tx, &salaryRecord, salaryRecordRegularIncome(&salaryRecord), salaryRecordDeduction(&salaryRecord)); err != nil {
+			return err
+		}
+		settlement.Tax = salaryRecord.Tax
+		settlement.Total = salaryRecord.Total
+
+		if len(existRecords) != 0 {
+			if err := tx.Where("staff_id = ? and salary_date = ?", staff.StaffId, month).
+				Select("*").Omit("id", "created_at", "deleted_at").Updates(&salaryRecord).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Create(&salaryRecord).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("staff_id = ?", staff.StaffId).Delete(&model.SalaryV2Settlement{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(settlement).Error
+	})
+	if err != nil {
+		log.Printf("CreateFinalSettlementV2 err = %v", err)
+		return nil, err
+	}
+	return settlement, nil
+}
+
+// GetFinalSettlementV2 查询员工离职结算单及对应薪资记录
+func GetFinalSettlementV2(c *gin.Context, staffId string) (*model.SalaryV2Settlement, *model.SalaryRecord, error) {
+	var settlement model.SalaryV2Settlement
+	if err := resource.HrmsDB(c).Where("staff_id = ?", staffId).First(&settlement).Error; err != nil {
+		return nil, nil, errors.New("该员工尚未生成离职结算")
+	}
+	var salaryRecord model.SalaryRecord
+	if err := resource.HrmsDB(c).Where("salary_record_id = ?", settlement.SalaryRecordId).First(&salaryRecord).Error; err != nil {
+		return nil, nil, errors.New("不存在该离职结算薪资记录")
+	}
+	return &settlement, &salaryRecord, nil
+}
+
+// getSettlementAttendance 获取离职当月考勤，未提交考勤时按离职日期前的应出勤工作日视为全勤
+func getSettlementAttendance(tx *gorm.DB, staff *model.Staff, month string, resignation time.Time) (*model.AttendanceRecord, error) {
+	var records []*model.AttendanceRecord
+	tx.Where("staff_id = ? and date = ?", staff.StaffId, month).Find(&records)
+	if len(records) != 0 {
+		return records[0], nil
+	}
+	monthStart, _ := time.ParseInLocation("2006-01", month, time.Local)
+	start := monthStart
+	if entry, ok := parseStaffDate(staff.EntryDate); ok && entry.After(start) {
+		start = entry
+	}
+	return &model.AttendanceRecord{
+		StaffId:   staff.StaffId,
+		StaffName: staff.StaffName,
+		Date:      month,
+		WorkDays:  loadWorkCalendar(tx).WorkingDays(start, resignation),
+	}, nil
+}
+
+// getAverageMonthlyWage 计算离职前十二个月的月平均工资，无历史薪资记录时按薪资套账月工资计算
+func getAverageMonthlyWage(tx *gorm.DB, staff *model.Staff, salaryInfo *model.Salary, month string) model.Money {
+	current, _ := time.ParseInLocation("2006-01", month, time.Local)
+	var records []*model.SalaryRecord
+	tx.Where("staff_id = ? and salary_date >= ? and salary_date < ? and record_type <> ?",
+		staff.StaffId, current.AddDate(-1, 0, 0).Format("2006-01"), month, "settlement").Find(&records)
+	if len(records) == 0 {
+		return salaryInfo.Base + salaryInfo.Subsidy + salaryInfo.Bonus + salaryInfo.Commission + salaryInfo.Other
+	}
+	var total model.Money
+	for _, record := range records {
+		total += salaryRecordGrossIncome(record)
+	}
+	return total.Div(float64(len(records)))
+}
+
+// severanceYears 按工作年限计算经济补偿月数：每满一年支付一个月，六个月以上不满一年按一年，不满六个月支付半个月
+func severanceYears(entry, resignation time.Time) float64 {
+	months := (resignation.Year()-entry.Year())*12 + int(resignation.Month()-entry.Month())
+	if resignation.Day() < entry.Day() {
+		months--
+	}
+	if months < 0 {
+		return 0
+	}
+	years := float64(months / 12)
+	switch remainder := months % 12; {
+	case remainder >= 6:
+		years++
+	case remainder > 0 || months == 0:
+		years += 0.5
+	}
+	return years
+}
+
+// calculateSeverance 计算经济补偿：月工资高于当地上年度职工月平均工资三倍的按三倍计算且年限最高十二年，
+// 代通知金按离职前月平均工资支付；经济补偿在当地上年度职工平均工资三倍以内免税，超过部分单独适用综合所得税率表
+func calculateSeverance(c *gin.Context, tx *gorm.DB, settlement *model.SalaryV2Settlement, staff *model.Staff, resignation time.Time) error {
+	if settlement.SeveranceType == "none" {
+		return nil
+	}
+	entry, ok := parseStaffDate(staff.EntryDate)
+	if !ok {
+		return errors.New("入职日期格式错误: " + staff.EntryDate)
+	}
+	localAverageWage := model.NewMoney(getSystemParameterFloat(tx, "local_average_wage", 0))
+	if localAverageWage <= 0 {
+		return errors.New("未配置当地上年度职工月平均工资(local_average_wage)")
+	}
+
+	years := severanceYears(entry, resignation)
+	wage := settlement.MonthlyWage
+	if wage > localAverageWage*3 {
+		wage = localAverageWage * 3
+		years = math.Min(years, 12)
+	}
+	severance := wage.Mul(years)
+	if settlement.SeveranceType == "n_plus_1" {
+		severance += settlement.MonthlyWage
+	}
+	settlement.SeveranceYears = years
+	settlement.Severance = severance
+
+	taxFree := localAverageWage * 12 * 3
+	settlement.SeveranceTaxFree = model.MinMoney(taxFree, settlement.Severance)
+	if settlement.Severance > taxFree {
+		brackets, err := getAnnualTaxBrackets(c)
+		if err != nil {
+			return err
+		}
+		settlement.SeveranceTax = calculateTaxByBrackets(brackets, settlement.Severance-taxFree)
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// specialDeductionLimits 专项附加扣除每月扣除标准上限(元)，子女教育及婴幼儿照护按人数计算
+var specialDeductionLimits = map[string]model.Money{
+	"children_education":   model.MoneyFromYuan(2000),
+	"continuing_education": model.MoneyFromYuan(400),
+	"housing_loan":         model.MoneyFromYuan(1000),
+	"housing_rent":         model.MoneyFromYuan(1500),
+	"elderly_support":      model.MoneyFromYuan(3000),
+	"infant_care":          model.MoneyFromYuan(2000),
+}
+
+// 继续教育中职业资格继续教育在取得证书当年一次性扣除
+var continuingEducationCertificateLimit = model.MoneyFromYuan(3600)
+
+func CreateSpecialDeductionV2(c *gin.Context, dto *model.SalaryV2SpecialDeductionCreateDTO, createdBy string) error {
+	var deduction model.SalaryV2SpecialDeduction
+	Transfer(&dto, &deduction)
+	if deduction.Quantity <= 0 {
+		deduction.Quantity = 1
+	}
+	if deduction.StaffName == "" {
+		var staff model.Staff
+		if err := resource.HrmsDB(c).Where("staff_id = ?", deduction.StaffId).First(&staff).Error; err != nil {
+			return errors.New("不存在该员工")
+		}
+		deduction.StaffName = staff.StaffName
+	}
+	if err := validateSpecialDeduction(resource.HrmsDB(c), &deduction); err != nil {
+		return err
+	}
+	deduction.DeductionId = RandomID("deduction")
+	deduction.IsActive = true
+	deduction.CreatedBy = createdBy
+	deduction.UpdatedBy = createdBy
+
+	if err := resource.HrmsDB(c).Create(&deduction).Error; err != nil {
+		log.Printf("CreateSpecialDeductionV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func GetSpecialDeductionsV2(c *gin.Context, staffId string, start int, limit int) ([]*model.SalaryV2SpecialDeduction, int64, error) {
+	var deductions []*model.SalaryV2SpecialDeduction
+	var err error
+	var query *gorm.DB
+
+	if staffId != "" {
+		query = resource.HrmsDB(c).Where("staff_id = ? and is_active = ?", staffId, true)
+	} else {
+		query = resource.HrmsDB(c).Where("is_active = ?", true)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2SpecialDeduction{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("staff_id asc, start_month desc").Find(&deductions).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("staff_id asc, start_month desc").Find(&deductions).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deductions, total, nil
+}
+
+// UpdateSpecialDeductionV2 更新专项附加扣除申报，staffId 不为空时只允许修改本人的申报
+func UpdateSpecialDeductionV2(c *gin.Context, dto *model.SalaryV2SpecialDeductionEditDTO, staffId string, updatedBy string) error {
+	var oldDeduction model.SalaryV2SpecialDeduction
+	if err := resource.HrmsDB(c).Where("deduction_id = ? and is_active = ?", dto.DeductionId, true).First(&oldDeduction).Error; err != nil {
+		return errors.New("不存在该专项附加扣除申报")
+	}
+	if staffId != "" && oldDeduction.StaffId != staffId {
+		return errors.New("只能修改本人的专项附加扣除申报")
+	}
+
+	deduction := oldDeduction
+	deduction.DeductionType = dto.DeductionType
+	deduction.MonthlyAmount = dto.MonthlyAmount
+	deduction.Quantity = dto.Quantity
+	deduction.StartMonth = dto.StartMonth
+	deduction.EndMonth = dto.EndMonth
+	deduction.Remark = dto.Remark
+	if deduction.Quantity <= 0 {
+		deduction.Quantity = 1
+	}
+	if err := validateSpecialDeduction(resource.HrmsDB(c), &deduction); err != nil {
+		return err
+	}
+
+	if err := resource.HrmsDB(c).Model(&model.SalaryV2SpecialDeduction{}).Where("deduction_id = ?", dto.DeductionId).
+		Updates(map[string]interface{}{
+			"deduction_type": deduction.DeductionType,
+			"monthly_amount": deduction.MonthlyAmount,
+			"quantity":       deduction.Quantity,
+			"start_month":    deduction.StartMonth,
+			"end_month":      deduction.EndMonth,
+			"remark":         deduction.Remark,
+			"updated_by":     updatedBy,
+		}).Error; err != nil {
+		log.Printf("UpdateSpecialDeductionV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteSpecialDeductionV2 作废专项附加扣除申报，staffId 不为空时只允许作废本人的申报
+func DeleteSpecialDeductionV2(c *gin.Context, id uint, staffId string, deletedBy string) error {
+	var deduction model.SalaryV2SpecialDeduction
+	if err := resource.HrmsDB(c).First(&deduction, id).Error; err != nil {
+		return err
+	}
+	if staffId != "" && deduction.StaffId != staffId {
+		return errors.New("只能删除本人的专项附加扣除申报")
+	}
+
+	if err := resource.HrmsDB(c).Model(&model.SalaryV2SpecialDeduction{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": deletedBy,
+		}).Error; err != nil {
+		log.Printf("DeleteSpecialDeductionV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// validateSpecialDeduction 校验扣除类型、有效月份、扣除标准，并检查同类申报及住房贷款利息与住房租金的互斥关系
+func validateSpecialDeduction(db *gorm.DB, deduction *model.SalaryV2SpecialDeduction) error {
+	limit, ok := specialDeductionLimits[deduction.DeductionType]
+	if !ok {
+		return errors.New("不支持的专项附加扣除类型: " + deduction.DeductionType)
+	}
+	if deduction.StaffId == "" {
+		return errors.New("员工工号不能为空")
+	}
+	start, err := time.Parse("2006-01", deduction.StartMonth)
+	if err != nil {
+		return errors.New("起始月份格式错误: " + deduction.StartMonth)
+	}
+	if deduction.EndMonth != "" {
+		end, err := time.Parse("2006-01", deduction.EndMonth)
+		if err != nil {
+			return errors.New("截止月份格式错误: " + deduction.EndMonth)
+		}
+		if end.Before(start) {
+			return errors.New("截止月份不能早于起始月份")
+		}
+	}
+	if deduction.MonthlyAmount <= 0 {
+		return errors.New("每月扣除金额必须大于0")
+	}
+	if deduction.DeductionType == "children_education" || deduction.DeductionType == "infant_care" {
+		limit = limit * model.Money(deduction.Quantity)
+	}
+	if deduction.DeductionType == "continuing_education" && deduction.StartMonth == deduction.EndMonth {
+		limit = continuingEducationCertificateLimit
+	}
+	if deduction.MonthlyAmount > limit {
+		return fmt.Errorf("每月扣除金额超过扣除标准%s元", limit)
+	}
+
+	// 检查有效期重叠的申报
+	conflictTypes := []string{deduction.DeductionType}
+	if deduction.DeductionType == "housing_loan" {
+		conflictTypes = append(conflictTypes, "housing_rent")
+	} else if deduction.DeductionType == "housing_rent" {
+		conflictTypes = append(conflictTypes, "housing_loan")
+	}
+	query := db.Model(&model.SalaryV2SpecialDeduction{}).
+		Where("staff_id = ? and is_active = ? and deduction_type in ?", deduction.StaffId, true, conflictTypes).
+		Where("(end_month = '' or end_month is null or end_month >= ?)", deduction.StartMonth)
+	if deduction.EndMonth != "" {
+		query = query.Where("start_month <= ?", deduction.EndMonth)
+	}
+	if deduction.DeductionId != "" {
+		query = query.Where("deduction_id <> ?", deduction.DeductionId)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		if len(conflictTypes) > 1 {
+			return errors.New("同一期间已存在同类或住房贷款利息/住房租金申报，两者不能同时享受")
+		}
+		return errors.New("同一期间已存在同类专项附加扣除申报")
+	}
+	return nil
+}
+
+// getSpecialDeductionByMonth 获取员工某月可享受的专项附加扣除合计
+func getSpecialDeductionByMonth(deductions []*model.SalaryV2SpecialDeduction, month string) model.Money {
+	var total model.Money
+	for _, deduction := range deductions {
+		if deduction.StartMonth > month || (deduction.EndMonth != "" && deduction.EndMonth < month) {
+			continue
+		}
+		total += deduction.MonthlyAmount
+	}
+	return total
+}
+
+// getCumulativeSpecialDeduction 获取员工本年度任职受雇月份内累计专项附加扣除及当月专项附加扣除
+// 年中补充申报的以前月份扣除在申报后的当月一并扣除
+func getCumulativeSpecialDeduction(tx *gorm.DB, staffId string, start, end time.Time) (model.Money, model.Money, error) {
+	var deductions []*model.SalaryV2SpecialDeduction
+	if err := tx.Where("staff_id = ? and is_active = ?", staffId, true).Find(&deductions).Error; err != nil {
+		return 0, 0, err
+	}
+	var cumulative, current model.Money
+	for month := start; !month.After(end); month = month.AddDate(0, 1, 0) {
+		current = getSpecialDeductionByMonth(deductions, month.Format("2006-01"))
+		cumulative += current
+	}
+	return cumulative, current, nil
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// taxBracketPresets 内置税率表，金额单位为元
+var taxBracketPresets = []*model.SalaryV2TaxBracketPreset{
+	{
+		PresetCode:  "prc_2019_monthly",
+		PresetName:  "中国大陆个人所得税月度税率表(2019年起)",
+		BracketType: "monthly",
+		Brackets: []*model.SalaryV2TaxBracketItem{
+			{MinIncome: 0, MaxIncome: model.MoneyFromYuan(3000), TaxRate: 3, QuickDeduction: 0, Description: "不超过3000元的部分"},
+			{MinIncome: model.MoneyFromYuan(3000), MaxIncome: model.MoneyFromYuan(12000), TaxRate: 10, QuickDeduction: model.MoneyFromYuan(210), Description: "超过3000元至12000元的部分"},
+			{MinIncome: model.MoneyFromYuan(12000), MaxIncome: model.MoneyFromYuan(25000), TaxRate: 20, QuickDeduction: model.MoneyFromYuan(1410), Description: "超过12000元至25000元的部分"},
+			{MinIncome: model.MoneyFromYuan(25000), MaxIncome: model.MoneyFromYuan(35000), TaxRate: 25, QuickDeduction: model.MoneyFromYuan(2660), Description: "超过25000元至35000元的部分"},
+			{MinIncome: model.MoneyFromYuan(35000), MaxIncome: model.MoneyFromYuan(55000), TaxRate: 30, QuickDeduction: model.MoneyFromYuan(4410), Description: "超过35000元至55000元的部分"},
+			{MinIncome: model.MoneyFromYuan(55000), MaxIncome: model.MoneyFromYuan(80000), TaxRate: 35, QuickDeduction: model.MoneyFromYuan(7160), Description: "超过55000元至80000元的部分"},
+			{MinIncome: model.MoneyFromYuan(80000), MaxIncome: 0, TaxRate: 45, QuickDeduction: model.MoneyFromYuan(15160), Description: "超过80000元的部分"},
+		},
+	},
+	{
+		PresetCode:  "prc_2019_annual",
+		PresetName:  "中国大陆个人所得税预扣率表(居民个人工资薪金累计预扣)",
+		BracketType: "annual",
+		Brackets: []*model.SalaryV2TaxBracketItem{
+			{MinIncome: 0, MaxIncome: model.MoneyFromYuan(36000), TaxRate: 3, QuickDeduction: 0, Description: "累计预扣不超过36000元的部分"},
+			{MinIncome: model.MoneyFromYuan(36000), MaxIncome: model.MoneyFromYuan(144000), TaxRate: 10, QuickDeduction: model.MoneyFromYuan(2520), Description: "累计预扣超过36000元至144000元的部分"},
+			{MinIncome: model.MoneyFromYuan(144000), MaxIncome: model.MoneyFromYuan(300000), TaxRate: 20, QuickDeduction: model.MoneyFromYuan(16920), Description: "累计预扣超过144000元至300000元的部分"},
+			{MinIncome: model.MoneyFromYuan(300000), MaxIncome: model.MoneyFromYuan(420000), TaxRate: 25, QuickDeduction: model.MoneyFromYuan(31920), Description: "累计预扣超过300000元至420000元的部分"},
+			{MinIncome: model.MoneyFromYuan(420000), MaxIncome: model.MoneyFromYuan(660000), TaxRate: 30, QuickDeduction: model.MoneyFromYuan(52920), Description: "累计预扣超过420000元至660000元的部分"},
+			{MinIncome: model.MoneyFromYuan(660000), MaxIncome: model.MoneyFromYuan(960000), TaxRate: 35, QuickDeduction: model.MoneyFromYuan(85920), Description: "累计预扣超过660000元至960000元的部分"},
+			{MinIncome: model.MoneyFromYuan(960000), MaxIncome: 0, TaxRate: 45, QuickDeduction: model.MoneyFromYuan(181920), Description: "累计预扣超过960000元的部分"},
+		},
+	},
+}
+
+// GetTaxBracketPresetsV2 获取内置税率表
+func GetTaxBracketPresetsV2() []*model.SalaryV2TaxBracketPreset {
+	return taxBracketPresets
+}
+
+// ValidateTaxBracketTableV2 校验待替换的税率表，返回全部校验错误
+func ValidateTaxBracketTableV2(dto *model.SalaryV2TaxBracketReplaceDTO) []string {
+	return validateTaxBracketTable(taxBracketsFromItems(dto.BracketType, dto.Brackets))
+}
+
+// ReplaceTaxBracketTableV2 整体替换指定类型的税率表，原税率区间全部停用
+func ReplaceTaxBracketTableV2(c *gin.Context, dto *model.SalaryV2TaxBracketReplaceDTO, operator string) error {
+	if dto.BracketType != "monthly" && dto.BracketType != "annual" {
+		return errors.New("税率表类型错误: " + dto.BracketType)
+	}
+	brackets := taxBracketsFromItems(dto.BracketType, dto.Brackets)
+	if errs := validateTaxBracketTable(brackets); len(errs) > 0 {
+		return errors.New("税率表校验不通过: " + strings.Join(errs, "; "))
+	}
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		return replaceTaxBracketTable(tx, dto.BracketType, dto.EffectiveDate, brackets, operator, "Tax bracket table replaced")
+	})
+}
+
+// LoadTaxBracketPresetV2 以内置税率表替换当前分公司的税率表
+func LoadTaxBracketPresetV2(c *gin.Context, dto *model.SalaryV2TaxBracketPresetLoadDTO, operator string) error {
+	for _, preset := range taxBracketPresets {
+		if preset.PresetCode == dto.PresetCode {
+			brackets := taxBracketsFromItems(preset.BracketType, preset.Brackets)
+			return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+				return replaceTaxBracketTable(tx, preset.BracketType, dto.EffectiveDate, brackets, operator, "Loaded tax bracket preset "+preset.PresetCode)
+			})
+		}
+	}
+	return errors.New("不存在该内置税率表: " + dto.PresetCode)
+}
+
+// replaceTaxBracketTable 停用当前税率表并创建新的税率区间，原税率区间立即停用，因此不支持以后的生效日期
+func replaceTaxBracketTable(tx *gorm.DB, bracketType string, effectiveDate string, brackets []*model.SalaryV2TaxBracket, operator string, reason string) error {
+	today := time.Now().Format("2006-01-02")
+	if effectiveDate == "" {
+		effectiveDate = today
+	}
+	if _, err := time.Parse("2006-01-02", effectiveDate); err != nil {
+		return errors.New("生效日期格式错误: " + effectiveDate)
+	}
+	if effectiveDate > today {
+		return errors.New("税率表整体替换后立即生效，生效日期不能晚于今天")
+	}
+	var current []*model.SalaryV2TaxBracket
+	if err := tx.Where("bracket_type = ? and is_active = ?", bracketType, true).Find(&current).Error; err != nil {
+		return err
+	}
+	for _, bracket := range current {
+		if err := tx.Model(&model.SalaryV2TaxBracket{}).Where("id = ?", bracket.ID).
+			Updates(map[string]interface{}{"is_active": false, "updated_by": operator}).Error; err != nil {
+			log.Printf("replaceTaxBracketTable err = %v", err)
+			return err
+		}
+		oldValue, _ := json.Marshal(bracket)
+		recordParameterHistory(tx, bracket.TaxBracketId, "tax_bracket", string(oldValue), "", reason, operator)
+	}
+	for _, bracket := range brackets {
+		bracket.TaxBracketId = RandomID("tax_bracket")
+		bracket.EffectiveDate = effectiveDate
+		bracket.CreatedBy, bracket.UpdatedBy = operator, operator
+		if err := tx.Create(bracket).Error; err != nil {
+			log.Printf("replaceTaxBracketTable err = %v", err)
+			return err
+		}
+		newValue, _ := json.Marshal(bracket)
+		recordParameterHistory(tx, bracket.TaxBracketId, "tax_bracket", "", string(newValue), reason, operator)
+	}
+	return checkTaxBracketTables(tx)
+}
+
+func taxBracketsFromItems(bracketType string, items []*model.SalaryV2TaxBracketItem) []*model.SalaryV2TaxBracket {
+	var brackets []*model.SalaryV2TaxBracket
+	for _, item := range items {
+		brackets = append(brackets, &model.SalaryV2TaxBracket{
+			BracketType:    bracketType,
+			MinIncome:      item.MinIncome,
+			MaxIncome:      item.MaxIncome,
+			TaxRate:        item.TaxRate,
+			QuickDeduction: item.QuickDeduction,
+			Description:    item.Description,
+			IsActive:       true,
+		})
+	}
+	return brackets
+}
+
+// checkTaxBracketTables 检查当前启用的各类税率表，整体替换税率表后在同一事务中调用，校验不通过时回滚
+func checkTaxBracketTables(db *gorm.DB) error {
+	for _, bracketType := range []string{"monthly", "annual"} {
+		var brackets []*model.SalaryV2TaxBracket
+		if err := db.Where("bracket_type = ? and is_active = ?", bracketType, true).Find(&brackets).Error; err != nil {
+			return err
+		}
+		// 未配置年度税率表时按月度税率表折算
+		if len(brackets) == 0 && bracketType == "annual" {
+			continue
+		}
+		if errs := validateTaxBracketTable(brackets); len(errs) > 0 {
+			return fmt.Errorf("%s税率表校验不通过: %s", bracketTypeName(bracketType), strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// validateTaxBracket 校验单条启用的税率区间；逐条修改时税率表可能暂时不完整，
+// 税率表整体在替换、恢复及计税时校验
+func validateTaxBracket(bracket *model.SalaryV2TaxBracket) error {
+	if !bracket.IsActive {
+		return nil
+	}
+	if bracket.BracketType != "monthly" && bracket.BracketType != "annual" {
+		return errors.New("税率表类型错误: " + bracket.BracketType)
+	}
+	if bracket.TaxRate <= 0 || bracket.TaxRate > 100 {
+		return errors.New("税率应在0-100之间")
+	}
+	if bracket.MinIncome < 0 {
+		return errors.New("收入下限不能为负数")
+	}
+	if bracket.MaxIncome != 0 && bracket.MaxIncome <= bracket.MinIncome {
+		return errors.New("收入上限应大于下限")
+	}
+	if bracket.QuickDeduction < 0 {
+		return errors.New("速算扣除数不能为负数")
+	}
+	return nil
+}
+
+func bracketTypeName(bracketType string) string {
+	if bracketType == "annual" {
+		return "年度累计预扣"
+	}
+	return "月度"
+}
+
+// validateTaxBracketTable 校验一张税率表：区间从0开始连续且不重叠，仅最高档不设上限，税率逐档递增，速算扣除数与区间及税率一致
+func validateTaxBracketTable(brackets []*model.SalaryV2TaxBracket) []string {
+	if len(brackets) == 0 {
+		return []string{"税率表不能为空"}
+	}
+	sorted := append([]*model.SalaryV2TaxBracket(nil), brackets...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].MinIncome < sorted[j].MinIncome
+	})
+
+	var errs []string
+	for i, bracket := range sorted {
+		level := i + 1
+		if bracket.TaxRate <= 0 || bracket.TaxRate > 100 {
+			errs = append(errs, fmt.Sprintf("第%d档税率应在0-100之间", level))
+		}
+		if i == len(sorted)-1 {
+			if bracket.MaxIncome != 0 {
+				errs = append(errs, fmt.Sprintf("最高档(第%d档)不能设置收入上限", level))
+			}
+		} else if bracket.MaxIncome == 0 {
+			errs = append(errs, fmt.Sprintf("第%d档未设置收入上限，只有最高档可以不设上限", level))
+		} else if bracket.MaxIncome <= bracket.MinIncome {
+			errs = append(errs, fmt.Sprintf("第%d档收入上限应大于下限", level))
+		}
+
+		if i == 0 {
+			if bracket.MinIncome != 0 {
+				errs = append(errs, "第1档收入下限应为0")
+			}
+			if bracket.QuickDeduction != 0 {
+				errs = append(errs, "第1档速算扣除数应为0")
+			}
+			continue
+		}
+		prev := sorted[i-1]
+		if prev.MaxIncome != 0 && bracket.MinIncome > prev.MaxIncome {
+			errs = append(errs, fmt.Sprintf("第%d档与第%d档之间存在间隔(%s-%s)", i, level, prev.MaxIncome, bracket.MinIncome))
+		} else if prev.MaxIncome != 0 && bracket.MinIncome < prev.MaxIncome {
+			errs = append(errs, fmt.Sprintf("第%d档与第%d档区间重叠(%s-%s)", i, level, bracket.MinIncome, prev.MaxIncome))
+		}
+		if bracket.TaxRate <= prev.TaxRate {
+			errs = append(errs, fmt.Sprintf("第%d档税率应高于第%d档", level, i))
+		}
+		// 速算扣除数 = 上一档速算扣除数 + 本档下限 × (本档税率 - 上一档税率)
+		expected := prev.QuickDeduction + bracket.MinIncome.Mul((bracket.TaxRate-prev.TaxRate)/100.0)
+		if (bracket.QuickDeduction - expected).Abs() > 1 {
+			errs = append(errs, fmt.Sprintf("第%d档速算扣除数应为%s", level, expected))
+		}
+	}
+	return errs
+}
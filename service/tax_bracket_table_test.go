@@ -0,0 +1,63 @@
+package service
+
+import (
+	"hrms/model"
+	"strings"
+	"testing"
+)
+
+func TestTaxBracketPresetsValid(t *testing.T) {
+	for _, preset := range taxBracketPresets {
+		if errs := validateTaxBracketTable(taxBracketsFromItems(preset.BracketType, preset.Brackets)); len(errs) != 0 {
+			t.Errorf("preset %s errs = %v", preset.PresetCode, errs)
+		}
+	}
+}
+
+func TestValidateTaxBracketTable(t *testing.T) {
+	bracket := func(min, max int64, rate float64, deduction int64) *model.SalaryV2TaxBracket {
+		return &model.SalaryV2TaxBracket{MinIncome: model.MoneyFromYuan(min), MaxIncome: model.MoneyFromYuan(max),
+			TaxRate: rate, QuickDeduction: model.MoneyFromYuan(deduction)}
+	}
+	cases := []struct {
+		name     string
+		brackets []*model.SalaryV2TaxBracket
+		want     string
+	}{
+		{"empty", nil, "税率表不能为空"},
+		{"gap", []*model.SalaryV2TaxBracket{bracket(0, 3000, 3, 0), bracket(3500, 0, 10, 245)}, "存在间隔"},
+		{"overlap", []*model.SalaryV2TaxBracket{bracket(0, 3000, 3, 0), bracket(2500, 0, 10, 175)}, "区间重叠"},
+		{"unbounded middle", []*model.SalaryV2TaxBracket{bracket(0, 0, 3, 0), bracket(3000, 0, 10, 210)}, "只有最高档可以不设上限"},
+		{"bounded top", []*model.SalaryV2TaxBracket{bracket(0, 3000, 3, 0), bracket(3000, 12000, 10, 210)}, "不能设置收入上限"},
+		{"rate order", []*model.SalaryV2TaxBracket{bracket(0, 3000, 10, 0), bracket(3000, 0, 3, -210)}, "税率应高于"},
+		{"deduction", []*model.SalaryV2TaxBracket{bracket(0, 3000, 3, 0), bracket(3000, 0, 10, 105)}, "速算扣除数应为210.00"},
+		{"first bracket", []*model.SalaryV2TaxBracket{bracket(500, 3000, 3, 0), bracket(3000, 0, 10, 210)}, "收入下限应为0"},
+	}
+	for _, tc := range cases {
+		errs := validateTaxBracketTable(tc.brackets)
+		if !strings.Contains(strings.Join(errs, "; "), tc.want) {
+			t.Errorf("%s: errs = %v, want %q", tc.name, errs, tc.want)
+		}
+	}
+}
+
+func TestValidateTaxBracket(t *testing.T) {
+	cases := []struct {
+		name    string
+		bracket model.SalaryV2TaxBracket
+		valid   bool
+	}{
+		{"valid", model.SalaryV2TaxBracket{BracketType: "monthly", MaxIncome: model.MoneyFromYuan(3000), TaxRate: 3, IsActive: true}, true},
+		{"top bracket", model.SalaryV2TaxBracket{BracketType: "annual", MinIncome: model.MoneyFromYuan(960000), TaxRate: 45, IsActive: true}, true},
+		{"bracket type", model.SalaryV2TaxBracket{BracketType: "weekly", TaxRate: 3, IsActive: true}, false},
+		{"tax rate", model.SalaryV2TaxBracket{BracketType: "monthly", TaxRate: 0, IsActive: true}, false},
+		{"income range", model.SalaryV2TaxBracket{BracketType: "monthly", MinIncome: model.MoneyFromYuan(3000), MaxIncome: model.MoneyFromYuan(3000), TaxRate: 10, IsActive: true}, false},
+		{"quick deduction", model.SalaryV2TaxBracket{BracketType: "monthly", TaxRate: 10, QuickDeduction: -1, IsActive: true}, false},
+		{"inactive", model.SalaryV2TaxBracket{BracketType: "monthly", TaxRate: 0}, true},
+	}
+	for _, tc := range cases {
+		if err := validateTaxBracket(&tc.bracket); (err == nil) != tc.valid {
+			t.Errorf("%s: err = %v, want valid %v", tc.name, err, tc.valid)
+		}
+	}
+}
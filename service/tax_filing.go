@@ -0,0 +1,339 @@
+package service
+
+import (
+	"bytes"
+	"errors"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/tealeg/xlsx"
+	"gorm.io/gorm"
+)
+
+const taxFilingIdentityType = "居民身份证"
+
+// GetTaxFilingDeclarationV2 生成月度个税扣缴申报数据，按已发放的薪资记录、全年一次性奖金及离职经济补偿汇总
+func GetTaxFilingDeclarationV2(c *gin.Context, month string) (*model.SalaryV2TaxFilingDeclaration, error) {
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return nil, errors.New("薪资月份格式错误: " + month)
+	}
+	db := resource.HrmsDB(c)
+	declaration := &model.SalaryV2TaxFilingDeclaration{
+		SalaryDate:    month,
+		Wages:         []*model.SalaryV2TaxFilingWage{},
+		AnnualBonuses: []*model.SalaryV2TaxFilingSeparate{},
+		Severances:    []*model.SalaryV2TaxFilingSeparate{},
+		Warnings:      []string{},
+	}
+	staffs := make(map[string]*model.Staff)
+	getStaff := func(staffId string) *model.Staff {
+		if staff, ok := staffs[staffId]; ok {
+			return staff
+		}
+		var staff model.Staff
+		db.Where("staff_id = ?", staffId).Find(&staff)
+		if staff.IdentityNum == "" {
+			declaration.Warnings = append(declaration.Warnings, staffId+"未登记证件号码")
+		}
+		staffs[staffId] = &staff
+		return &staff
+	}
+
+	var records []*model.SalaryRecord
+	if err := db.Where("salary_date = ? and is_pay = 2", month).Order("staff_id asc").Find(&records).Error; err != nil {
+		log.Printf("GetTaxFilingDeclarationV2 err = %v", err)
+		return nil, err
+	}
+	for _, record := range records {
+		staff := getStaff(record.StaffId)
+		additional, err := getCumulativeSpecialDeductionByType(db, staff, month)
+		if err != nil {
+			return nil, err
+		}
+		wage := &model.SalaryV2TaxFilingWage{
+			StaffId:               record.StaffId,
+			StaffName:             record.StaffName,
+			IdentityType:          taxFilingIdentityType,
+			IdentityNum:           staff.IdentityNum,
+			SalaryRecordId:        record.SalaryRecordId,
+			Income:                salaryRecordGrossIncome(record) + record.TaxFreeEarnings,
+			TaxFreeIncome:         record.TaxFreeEarnings,
+			PensionInsurance:      record.PensionInsurance,
+			MedicalInsurance:      record.MedicalInsurance,
+			UnemploymentInsurance: record.UnemploymentInsurance,
+			HousingFund:           record.HousingFund,
+			ChildrenEducation:     additional["children_education"],
+			ContinuingEducation:   additional["continuing_education"],
+			HousingLoan:           additional["housing_loan"],
+			HousingRent:           additional["housing_rent"],
+			ElderlySupport:        additional["elderly_support"],
+			InfantCare:            additional["infant_care"],
+			OtherDeduction:        record.PreTaxDeductions + record.RetroDeductions,
+			TaxWithheld:           record.Tax,
+		}
+		if record.RetroEarnings != 0 {
+			wage.Remark = "含追溯补发(扣回)" + record.RetroEarnings.String()
+		}
+		declaration.Wages = append(declaration.Wages, wage)
+		declaration.TotalIncome += wage.Income
+		declaration.TotalTax += wage.TaxWithheld
+	}
+
+	// 单独计税的全年一次性奖金随当月薪资发放
+	var bonuses []*model.SalaryV2AnnualBonus
+	if err := db.Where("pay_month = ? and tax_method = ? and is_active = ?", month, "separate", true).
+		Order("staff_id asc").Find(&bonuses).Error; err != nil {
+		return nil, err
+	}
+	for _, bonus := range bonuses {
+		if !isSalaryRecordPaid(db, bonus.SalaryRecordId) {
+			continue
+		}
+		staff := getStaff(bonus.StaffId)
+		declaration.AnnualBonuses = append(declaration.AnnualBonuses, &model.SalaryV2TaxFilingSeparate{
+			StaffId:      bonus.StaffId,
+			StaffName:    bonus.StaffName,
+			IdentityType: taxFilingIdentityType,
+			IdentityNum:  staff.IdentityNum,
+			Income:       bonus.Amount,
+			TaxWithheld:  bonus.Tax,
+			Remark:       bonus.BonusYear + "年度全年一次性奖金",
+		})
+		declaration.TotalIncome += bonus.Amount
+		declaration.TotalTax += bonus.Tax
+	}
+
+	// 解除劳动合同一次性补偿金
+	var settlements []*model.SalaryV2Settlement
+	if err := db.Where("salary_date = ? and severance > 0", month).Order("staff_id asc").Find(&settlements).Error; err != nil {
+		return nil, err
+	}
+	for _, settlement := range settlements {
+		if !isSalaryRecordPaid(db, settlement.SalaryRecordId) {
+			continue
+		}
+		staff := getStaff(settlement.StaffId)
+		declaration.Severances = append(declaration.Severances, &model.SalaryV2TaxFilingSeparate{
+			StaffId:       settlement.StaffId,
+			StaffName:     settlement.StaffName,
+			IdentityType:  taxFilingIdentityType,
+			IdentityNum:   staff.IdentityNum,
+			Income:        settlement.Severance,
+			TaxFreeIncome: settlement.SeveranceTaxFree,
+			TaxWithheld:   settlement.SeveranceTax,
+		})
+		declaration.TotalIncome += settlement.Severance
+		declaration.TotalTax += settlement.SeveranceTax
+	}
+
+	declaration.StaffCount = int64(len(staffs))
+	return declaration, nil
+}
+
+// ExportTaxFilingDeclarationV2 按扣缴客户端导入模板导出月度个税扣缴申报文件
+func ExportTaxFilingDeclarationV2(c *gin.Context, month string) ([]byte, error) {
+	declaration, err := GetTaxFilingDeclarationV2(c, month)
+	if err != nil {
+		return nil, err
+	}
+	file := xlsx.NewFile()
+
+	sheet, err := file.AddSheet("正常工资薪金所得")
+	if err != nil {
+		return nil, err
+	}
+	addXlsxRow(sheet, "工号", "*姓名", "*证件类型", "*证件号码", "本期收入", "本期免税收入", "基本养老保险费",
+		"基本医疗保险费", "失业保险费", "住房公积金", "累计子女教育", "累计继续教育", "累计住房贷款利息", "累计住房租金",
+		"累计赡养老人", "累计3岁以下婴幼儿照护", "累计个人养老金", "企业(职业)年金", "商业健康保险", "税延养老保险",
+		"其他", "准予扣除的捐赠额", "减免税额", "备注", "本期已扣缴税额")
+	for _, wage := range declaration.Wages {
+		addXlsxRow(sheet, wage.StaffId, wage.StaffName, wage.IdentityType, wage.IdentityNum, wage.Income, wage.TaxFreeIncome,
+			wage.PensionInsurance, wage.MedicalInsurance, wage.UnemploymentInsurance, wage.HousingFund,
+			wage.ChildrenEducation, wage.ContinuingEducation, wage.HousingLoan, wage.HousingRent, wage.ElderlySupport,
+			wage.InfantCare, 0.0, 0.0, 0.0, 0.0, wage.OtherDeduction, 0.0, 0.0, wage.Remark, wage.TaxWithheld)
+	}
+
+	separateSheets := []struct {
+		name        string
+		incomeTitle string
+		rows        []*model.SalaryV2TaxFilingSeparate
+	}{
+		{"全年一次性奖金收入", "*全年一次性奖金额", declaration.AnnualBonuses},
+		{"解除劳动合同一次性补偿金", "*收入", declaration.Severances},
+	}
+	for _, separate := range separateSheets {
+		if len(separate.rows) == 0 {
+			continue
+		}
+		sheet, err := file.AddSheet(separate.name)
+		if err != nil {
+			return nil, err
+		}
+		addXlsxRow(sheet, "工号", "*姓名", "*证件类型", "*证件号码", separate.incomeTitle, "免税收入", "其他",
+			"准予扣除的捐赠额", "减免税额", "备注", "本期已扣缴税额")
+		for _, row := range separate.rows {
+			addXlsxRow(sheet, row.StaffId, row.StaffName, row.IdentityType, row.IdentityNum, row.Income, row.TaxFreeIncome,
+				0.0, 0.0, 0.0, row.Remark, row.TaxWithheld)
+		}
+	}
+
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		log.Printf("ExportTaxFilingDeclarationV2 err = %v", err)
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// GetTaxAnnualSummaryV2 按员工汇总年度已发放薪资的综合所得及已预扣税额，按全年基本减除费用测算应补(退)税额
+func GetTaxAnnualSummaryV2(c *gin.Context, year string, staffId string) ([]*model.SalaryV2TaxAnnualSummary, error) {
+	if _, err := time.Parse("2006", year); err != nil {
+		return nil, errors.New("年度格式错误: " + year)
+	}
+	db := resource.HrmsDB(c)
+	query := db.Where("salary_date like ? and is_pay = 2", year+"-%")
+	if staffId != "" {
+		query = query.Where("staff_id = ?", staffId)
+	}
+	var records []*model.SalaryRecord
+	if err := query.Order("staff_id asc, salary_date asc").Find(&records).Error; err != nil {
+		log.Printf("GetTaxAnnualSummaryV2 err = %v", err)
+		return nil, err
+	}
+
+	threshold, err := requireSystemParameterMoney(resource.HrmsDB(c), "tax_threshold")
+	if err != nil {
+		return nil, err
+	}
+	brackets, err := getAnnualTaxBrackets(c)
+	if err != nil {
+		return nil, err
+	}
+
+	summaries := make(map[string]*model.SalaryV2TaxAnnualSummary)
+	var staffIds []string
+	for _, record := range records {
+		summary, ok := summaries[record.StaffId]
+		if !ok {
+			summary = &model.SalaryV2TaxAnnualSummary{
+				StaffId:        record.StaffId,
+				StaffName:      record.StaffName,
+				Year:           year,
+				BasicDeduction: threshold * 12,
+			}
+			summaries[record.StaffId] = summary
+			staffIds = append(staffIds, record.StaffId)
+		}
+		summary.Months++
+		summary.Income += salaryRecordGrossIncome(record)
+		summary.TaxFreeIncome += record.TaxFreeEarnings
+		summary.SpecialDeduction += salaryRecordDeduction(record)
+		summary.TaxWithheld += record.Tax
+		summary.SeparateBonusTax += record.AnnualBonusTax
+		summary.Severance += record.Severance
+		summary.SeveranceTax += record.SeveranceTax
+		if combined := record.GrossIncome - salaryRecordRegularIncome(record); record.GrossIncome != 0 && combined > 0 {
+			// 应发合计中超出当月工资收入的部分为并入综合所得的全年一次性奖金
+			summary.SeparateBonus += record.AnnualBonus - combined
+		} else {
+			summary.SeparateBonus += record.AnnualBonus
+		}
+	}
+
+	sort.Strings(staffIds)
+	var result []*model.SalaryV2TaxAnnualSummary
+	for _, id := range staffIds {
+		summary := summaries[id]
+		var staff model.Staff
+		db.Where("staff_id = ?", id).Find(&staff)
+		summary.IdentityNum = staff.IdentityNum
+		additional, err := getCumulativeSpecialDeductionByType(db, &staff, year+"-12")
+		if err != nil {
+			return nil, err
+		}
+		for _, amount := range additional {
+			summary.AdditionalDeduction += amount
+		}
+		summary.TaxableIncome = model.MaxMoney(0, summary.Income-summary.SpecialDeduction-
+			summary.AdditionalDeduction-summary.BasicDeduction)
+		summary.TaxPayable = calculateTaxByBrackets(brackets, summary.TaxableIncome)
+		summary.TaxDifference = summary.TaxPayable - summary.TaxWithheld
+		result = append(result, summary)
+	}
+	return result, nil
+}
+
+// ExportTaxAnnualSummaryV2 导出员工年度个税汇总
+func ExportTaxAnnualSummaryV2(c *gin.Context, year string) ([]byte, error) {
+	summaries, err := GetTaxAnnualSummaryV2(c, year, "")
+	if err != nil {
+		return nil, err
+	}
+	file := xlsx.NewFile()
+	sheet, err := file.AddSheet(year + "年度个税汇总")
+	if err != nil {
+		return nil, err
+	}
+	addXlsxRow(sheet, "工号", "姓名", "证件号码", "发放月数", "综合所得收入", "免税收入", "专项扣除及税前扣款",
+		"专项附加扣除", "基本减除费用", "应纳税所得额", "应纳税额", "已预扣预缴税额", "应补(退)税额",
+		"单独计税全年一次性奖金", "全年一次性奖金税额", "解除劳动合同补偿金", "补偿金税额")
+	for _, summary := range summaries {
+		addXlsxRow(sheet, summary.StaffId, summary.StaffName, summary.IdentityNum, summary.Months, summary.Income,
+			summary.TaxFreeIncome, summary.SpecialDeduction, summary.AdditionalDeduction, summary.BasicDeduction,
+			summary.TaxableIncome, summary.TaxPayable, summary.TaxWithheld, summary.TaxDifference,
+			summary.SeparateBonus, summary.SeparateBonusTax, summary.Severance, summary.SeveranceTax)
+	}
+	var buffer bytes.Buffer
+	if err := file.Write(&buffer); err != nil {
+		log.Printf("ExportTaxAnnualSummaryV2 err = %v", err)
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// getCumulativeSpecialDeductionByType 按类别获取员工本年度截至指定月份的累计专项附加扣除
+func getCumulativeSpecialDeductionByType(tx *gorm.DB, staff *model.Staff, month string) (map[string]model.Money, error) {
+	start, end, err := taxMonthRange(staff.EntryDate, staff.ResignationDate, month)
+	if err != nil {
+		return nil, err
+	}
+	var deductions []*model.SalaryV2SpecialDeduction
+	if err := tx.Where("staff_id = ? and is_active = ?", staff.StaffId, true).Find(&deductions).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[string]model.Money)
+	for current := start; !current.After(end); current = current.AddDate(0, 1, 0) {
+		for _, deduction := range deductions {
+			result[deduction.DeductionType] += getSpecialDeductionByMonth([]*model.SalaryV2SpecialDeduction{deduction}, current.Format("2006-01"))
+		}
+	}
+	return result, nil
+}
+
+func isSalaryRecordPaid(db *gorm.DB, salaryRecordId string) bool {
+	var count int64
+	db.Model(&model.SalaryRecord{}).Where("salary_record_id = ? and is_pay = 2", salaryRecordId).Count(&count)
+	return count != 0
+}
+
+// addXlsxRow 追加一行单元格，数值类型按数字写入
+func addXlsxRow(sheet *xlsx.Sheet, values ...interface{}) {
+	row := sheet.AddRow()
+	for _, value := range values {
+		cell := row.AddCell()
+		switch v := value.(type) {
+		case float64:
+			cell.SetFloat(v)
+		case int64:
+			cell.SetInt64(v)
+		case model.Money:
+			cell.SetFloat(v.Float64())
+		case string:
+			cell.SetString(v)
+		}
+	}
+}
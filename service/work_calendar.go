@@ -0,0 +1,402 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	calendarDayHoliday = "holiday"
+	calendarDayWorkday = "workday"
+)
+
+// maxCalendarEventDays iCalendar单个事件最多展开的天数
+const maxCalendarEventDays = 366
+
+// workCalendar 工作日历：周一至周五为工作日，法定节假日休息，调休上班日计为工作日
+type workCalendar struct {
+	holidays map[string]bool
+	workdays map[string]bool
+}
+
+// loadWorkCalendar 加载分公司工作日历，兼容系统参数 public_holidays、adjusted_workdays 中登记的日期，
+// 同一日期以工作日历表中的登记为准
+func loadWorkCalendar(db *gorm.DB) *workCalendar {
+	calendar := &workCalendar{
+		holidays: loadCalendarDates(db, "public_holidays"),
+		workdays: loadCalendarDates(db, "adjusted_workdays"),
+	}
+	var days []*model.SalaryV2WorkCalendarDay
+	if err := db.Find(&days).Error; err != nil {
+		log.Printf("loadWorkCalendar err = %v", err)
+		return calendar
+	}
+	for _, day := range days {
+		switch day.DayType {
+		case calendarDayHoliday:
+			calendar.holidays[day.CalendarDate] = true
+			delete(calendar.workdays, day.CalendarDate)
+		case calendarDayWorkday:
+			calendar.workdays[day.CalendarDate] = true
+			delete(calendar.holidays, day.CalendarDate)
+		}
+	}
+	return calendar
+}
+
+func loadCalendarDates(db *gorm.DB, parameterKey string) map[string]bool {
+	dates := make(map[string]bool)
+	var parameter model.SalaryV2SystemParameter
+	if err := db.Where("parameter_key = ? and is_active = ?", parameterKey, true).First(&parameter).Error; err != nil {
+		return dates
+	}
+	for _, date := range strings.Split(parameter.ParameterValue, ",") {
+		if date = strings.TrimSpace(date); date != "" {
+			dates[date] = true
+		}
+	}
+	return dates
+}
+
+// IsWorkingDay 判断指定日期是否为工作日
+func (w *workCalendar) IsWorkingDay(day time.Time) bool {
+	date := day.Format("2006-01-02")
+	if w.workdays[date] {
+		return true
+	}
+	if w.holidays[date] {
+		return false
+	}
+	return day.Weekday() != time.Saturday && day.Weekday() != time.Sunday
+}
+
+// IsHoliday 判断指定日期是否为法定节假日
+func (w *workCalendar) IsHoliday(day time.Time) bool {
+	date := day.Format("2006-01-02")
+	return w.holidays[date] && !w.workdays[date]
+}
+
+// OvertimeCategory 按日期返回加班类别：weekday工作日、weekend休息日、holiday法定节假日
+func (w *workCalendar) OvertimeCategory(day time.Time) string {
+	if w.IsHoliday(day) {
+		return "holiday"
+	}
+	if !w.IsWorkingDay(day) {
+		return "weekend"
+	}
+	return "weekday"
+}
+
+// WorkingDays 统计起止日期(含)之间的工作日数
+func (w *workCalendar) WorkingDays(start, end time.Time) int64 {
+	var days int64
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if w.IsWorkingDay(day) {
+			days++
+		}
+	}
+	return days
+}
+
+// monthRange 返回月份的第一天及最后一天
+func monthRange(month string) (time.Time, time.Time, error) {
+	first, err := time.ParseInLocation("2006-01", month, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("月份格式错误: " + month)
+	}
+	return first, first.AddDate(0, 1, -1), nil
+}
+
+// SaveWorkCalendarDayV2 登记法定节假日或调休上班日，同一日期已登记时覆盖
+func SaveWorkCalendarDayV2(c *gin.Context, dto *model.SalaryV2WorkCalendarDayDTO, operator string) error {
+	if err := validateWorkCalendarDay(dto); err != nil {
+		return err
+	}
+	_, err := saveWorkCalendarDay(resource.HrmsDB(c), dto, "manual", operator)
+	return err
+}
+
+func GetWorkCalendarDaysV2(c *gin.Context, year string, dayType string, start int, limit int) ([]*model.SalaryV2WorkCalendarDay, int64, error) {
+	var days []*model.SalaryV2WorkCalendarDay
+	var err error
+	query := resource.HrmsDB(c).Model(&model.SalaryV2WorkCalendarDay{})
+	if year != "" {
+		query = query.Where("calendar_date like ?", year+"-%")
+	}
+	if dayType != "" {
+		query = query.Where("day_type = ?", dayType)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("calendar_date asc").Find(&days).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("calendar_date asc").Find(&days).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return days, total, nil
+}
+
+// DeleteWorkCalendarDayV2 删除登记的日期，删除后该日期按周一至周五工作、周六周日休息计算
+func DeleteWorkCalendarDayV2(c *gin.Context, calendarDate string) error {
+	result := resource.HrmsDB(c).Unscoped().Where("calendar_date = ?", calendarDate).Delete(&model.SalaryV2WorkCalendarDay{})
+	if result.Error != nil {
+		log.Printf("DeleteWorkCalendarDayV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("工作日历中未登记该日期: " + calendarDate)
+	}
+	return nil
+}
+
+// GetWorkCalendarMonthV2 获取月度工作日历及应出勤天数
+func GetWorkCalendarMonthV2(c *gin.Context, month string) (*model.SalaryV2WorkCalendarMonth, error) {
+	first, last, err := monthRange(month)
+	if err != nil {
+		return nil, err
+	}
+	db := resource.HrmsDB(c)
+	calendar := loadWorkCalendar(db)
+	result := &model.SalaryV2WorkCalendarMonth{
+		Month:    month,
+		Holidays: []*model.SalaryV2WorkCalendarDay{},
+		Workdays: []*model.SalaryV2WorkCalendarDay{},
+	}
+	result.WorkingDays = calendar.WorkingDays(first, last)
+	result.RestDays = int64(last.Day()) - result.WorkingDays
+
+	var days []*model.SalaryV2WorkCalendarDay
+	if err := db.Where("calendar_date like ?", month+"-%").Order("calendar_date asc").Find(&days).Error; err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		if day.DayType == calendarDayHoliday {
+			result.Holidays = append(result.Holidays, day)
+		} else {
+			result.Workdays = append(result.Workdays, day)
+		}
+	}
+	return result, nil
+}
+
+// ImportWorkCalendarV2 从iCalendar或JSON文件导入法定节假日及调休上班日，replace为true时先清除文件所涉年份已登记的日期
+func ImportWorkCalendarV2(c *gin.Context, content []byte, format string, replace bool, dryRun bool, operator string) (*model.SalaryV2WorkCalendarImportResult, error) {
+	var days []*model.SalaryV2WorkCalendarDayDTO
+	var err error
+	switch strings.ToLower(format) {
+	case "ics", "ical":
+		days, err = parseICalendar(content)
+	case "json":
+		err = json.Unmarshal(content, &days)
+	default:
+		return nil, errors.New("不支持的工作日历文件格式: " + format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析工作日历文件失败: %v", err)
+	}
+	if len(days) == 0 {
+		return nil, errors.New("工作日历文件中没有节假日或调休上班日")
+	}
+	years := make(map[string]bool)
+	for i, day := range days {
+		if day == nil {
+			return nil, fmt.Errorf("工作日历文件第%d项为空", i+1)
+		}
+		if err := validateWorkCalendarDay(day); err != nil {
+			return nil, err
+		}
+		years[day.CalendarDate[:4]] = true
+	}
+
+	result := &model.SalaryV2WorkCalendarImportResult{Days: days}
+	if dryRun {
+		return result, nil
+	}
+	source := strings.ToLower(format)
+	if source == "ical" {
+		source = "ics"
+	}
+	err = resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		if replace {
+			for year := range years {
+				if err := tx.Unscoped().Where("calendar_date like ?", year+"-%").Delete(&model.SalaryV2WorkCalendarDay{}).Error; err != nil {
+					return err
+				}
+			}
+		}
+		for _, day := range days {
+			created, err := saveWorkCalendarDay(tx, day, source, operator)
+			if err != nil {
+				return err
+			}
+			if created {
+				result.Created++
+			} else {
+				result.Updated++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		log.Printf("ImportWorkCalendarV2 err = %v", err)
+		return nil, err
+	}
+	return result, nil
+}
+
+func saveWorkCalendarDay(db *gorm.DB, dto *model.SalaryV2WorkCalendarDayDTO, source string, operator string) (bool, error) {
+	var day model.SalaryV2WorkCalendarDay
+	err := db.Where("calendar_date = ?", dto.CalendarDate).First(&day).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		day = model.SalaryV2WorkCalendarDay{
+			CalendarDate: dto.CalendarDate,
+			DayType:      dto.DayType,
+			DayName:      dto.DayName,
+			Source:       source,
+			CreatedBy:    operator,
+			UpdatedBy:    operator,
+		}
+		return true, db.Create(&day).Error
+	}
+	if err != nil {
+		return false, err
+	}
+	return false, db.Model(&model.SalaryV2WorkCalendarDay{}).Where("id = ?", day.ID).
+		Updates(map[string]interface{}{
+			"day_type":   dto.DayType,
+			"day_name":   dto.DayName,
+			"source":     source,
+			"updated_by": operator,
+		}).Error
+}
+
+// validateWorkCalendarDay 校验日期格式及日期类型，调休上班日只能登记在周六或周日
+func validateWorkCalendarDay(dto *model.SalaryV2WorkCalendarDayDTO) error {
+	day, err := time.Parse("2006-01-02", dto.CalendarDate)
+	if err != nil {
+		return errors.New("日期格式错误: " + dto.CalendarDate)
+	}
+	switch dto.DayType {
+	case calendarDayHoliday:
+	case calendarDayWorkday:
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			return fmt.Errorf("%s 不是周末，无需登记为调休上班日", dto.CalendarDate)
+		}
+	default:
+		return errors.New("日期类型错误: " + dto.DayType)
+	}
+	return nil
+}
+
+// parseICalendar 解析iCalendar文件中的全天事件，摘要含“班”的事件(如“国庆节 补班”)为调休上班日，其余为法定节假日，
+// 跨多日的事件按DTEND(不含)展开为逐日记录
+func parseICalendar(content []byte) ([]*model.SalaryV2WorkCalendarDayDTO, error) {
+	// 展开折行：以空格或制表符开头的行接续上一行
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	days := make(map[string]*model.SalaryV2WorkCalendarDayDTO)
+	var inEvent bool
+	var summary, dtStart, dtEnd string
+	for _, line := range lines {
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent = true
+			summary, dtStart, dtEnd = "", "", ""
+		case line == "END:VEVENT":
+			inEvent = false
+			start, err := parseICalendarDate(dtStart)
+			if err != nil {
+				return nil, err
+			}
+			end := start.AddDate(0, 0, 1)
+			if dtEnd != "" {
+				if end, err = parseICalendarDate(dtEnd); err != nil {
+					return nil, err
+				}
+				if !end.After(start) {
+					end = start.AddDate(0, 0, 1)
+				}
+			}
+			if end.After(start.AddDate(0, 0, maxCalendarEventDays)) {
+				return nil, fmt.Errorf("iCalendar事件%s跨度超过%d天", dtStart, maxCalendarEventDays)
+			}
+			dayType := calendarDayHoliday
+			if strings.Contains(summary, "班") {
+				dayType = calendarDayWorkday
+			}
+			for day := start; day.Before(end); day = day.AddDate(0, 0, 1) {
+				date := day.Format("2006-01-02")
+				days[date] = &model.SalaryV2WorkCalendarDayDTO{CalendarDate: date, DayType: dayType, DayName: summary}
+			}
+		case inEvent:
+			name, value, ok := strings.Cut(line, ":")
+			if !ok {
+				continue
+			}
+			// 去掉属性参数，如DTSTART;VALUE=DATE
+			if i := strings.Index(name, ";"); i >= 0 {
+				name = name[:i]
+			}
+			switch strings.ToUpper(name) {
+			case "SUMMARY":
+				summary = strings.TrimSpace(strings.NewReplacer(`\,`, ",", `\;`, ";", `\n`, " ").Replace(value))
+			case "DTSTART":
+				dtStart = value
+			case "DTEND":
+				dtEnd = value
+			}
+		}
+	}
+
+	result := make([]*model.SalaryV2WorkCalendarDayDTO, 0, len(days))
+	for _, day := range days {
+		result = append(result, day)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CalendarDate < result[j].CalendarDate
+	})
+	return result, nil
+}
+
+func parseICalendarDate(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 8 {
+		return time.Time{}, errors.New("iCalendar日期格式错误: " + value)
+	}
+	day, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, errors.New("iCalendar日期格式错误: " + value)
+	}
+	return day, nil
+}
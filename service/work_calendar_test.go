@@ -0,0 +1,74 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseICalendar(t *testing.T) {
+	content := "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20251001\r\nDTEND;VALUE=DATE:20251004\r\nSUMMARY:国庆节\r\n  休假\r\nEND:VEVENT\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250928\r\nSUMMARY:国庆节 补班\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	days, err := parseICalendar([]byte(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"2025-09-28": calendarDayWorkday,
+		"2025-10-01": calendarDayHoliday,
+		"2025-10-02": calendarDayHoliday,
+		"2025-10-03": calendarDayHoliday,
+	}
+	if len(days) != len(want) {
+		t.Fatalf("days = %d, want %d", len(days), len(want))
+	}
+	for _, day := range days {
+		if want[day.CalendarDate] != day.DayType {
+			t.Errorf("%s day_type = %s, want %s", day.CalendarDate, day.DayType, want[day.CalendarDate])
+		}
+	}
+	if days[1].DayName != "国庆节 休假" {
+		t.Errorf("day_name = %q", days[1].DayName)
+	}
+}
+
+func TestWorkCalendarOvertimeCategory(t *testing.T) {
+	calendar := &workCalendar{
+		holidays: map[string]bool{"2025-10-01": true, "2025-10-02": true},
+		workdays: map[string]bool{"2025-09-28": true},
+	}
+	cases := map[string]string{
+		"2025-09-26": "weekday",
+		"2025-09-27": "weekend",
+		"2025-09-28": "weekday",
+		"2025-10-01": "holiday",
+	}
+	for date, want := range cases {
+		day, _ := time.Parse("2006-01-02", date)
+		if got := calendar.OvertimeCategory(day); got != want {
+			t.Errorf("%s category = %s, want %s", date, got, want)
+		}
+	}
+	start, _ := time.Parse("2006-01-02", "2025-09-22")
+	end, _ := time.Parse("2006-01-02", "2025-10-05")
+	// 9月22日至26日5天，9月28日调休上班，9月29日至30日2天，10月3日周五未登记为节假日
+	if days := calendar.WorkingDays(start, end); days != 9 {
+		t.Errorf("working days = %d, want 9", days)
+	}
+}
+
+func TestParseICalendarRejectsLongEvent(t *testing.T) {
+	content := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\nDTSTART;VALUE=DATE:20250101\r\nDTEND;VALUE=DATE:20990101\r\nSUMMARY:假期\r\nEND:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+	if _, err := parseICalendar([]byte(content)); err == nil {
+		t.Error("expected error for event spanning more than a year")
+	}
+}
+
+func TestImportWorkCalendarRejectsNullEntry(t *testing.T) {
+	if _, err := ImportWorkCalendarV2(nil, []byte(`[null]`), "json", false, true, "admin"); err == nil {
+		t.Error("expected error for null entry")
+	}
+}
@@ -0,0 +1,517 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"hrms/model"
+	"hrms/resource"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// restShift 轮换规律中表示休息的班次
+const restShift = "rest"
+
+func CreateWorkShiftV2(c *gin.Context, dto *model.SalaryV2WorkShiftCreateDTO, createdBy string) error {
+	var shift model.SalaryV2WorkShift
+	Transfer(&dto, &shift)
+	db := resource.HrmsDB(c)
+	// 未设置宽限分钟数时使用系统参数中的迟到、早退宽限分钟数
+	if shift.LateGraceMinutes == 0 {
+		shift.LateGraceMinutes = int64(getSystemParameterFloat(db, "late_grace_minutes", 0))
+	}
+	if shift.EarlyLeaveGraceMinutes == 0 {
+		shift.EarlyLeaveGraceMinutes = int64(getSystemParameterFloat(db, "early_leave_grace_minutes", 0))
+	}
+	if err := validateWorkShift(&shift); err != nil {
+		return err
+	}
+	shift.ShiftId = RandomID("shift")
+	shift.IsActive = true
+	shift.CreatedBy = createdBy
+	shift.UpdatedBy = createdBy
+
+	if err := db.Create(&shift).Error; err != nil {
+		log.Printf("CreateWorkShiftV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+func GetWorkShiftsV2(c *gin.Context, start int, limit int) ([]*model.SalaryV2WorkShift, int64, error) {
+	var shifts []*model.SalaryV2WorkShift
+	var err error
+	query := resource.HrmsDB(c).Where("is_active = ?", true)
+
+	var total int64
+	query.Session(&gorm.Session{}).Model(&model.SalaryV2WorkShift{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("start_time asc").Find(&shifts).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("start_time asc").Find(&shifts).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return shifts, total, nil
+}
+
+func UpdateWorkShiftV2(c *gin.Context, dto *model.SalaryV2WorkShiftEditDTO, updatedBy string) error {
+	db := resource.HrmsDB(c)
+	var shift model.SalaryV2WorkShift
+	if err := db.Where("shift_id = ? and is_active = ?", dto.ShiftId, true).First(&shift).Error; err != nil {
+		return errors.New("不存在该班次")
+	}
+	Transfer(&dto, &shift)
+	if err := validateWorkShift(&shift); err != nil {
+		return err
+	}
+
+	if err := db.Model(&model.SalaryV2WorkShift{}).Where("shift_id = ?", dto.ShiftId).
+		Updates(map[string]interface{}{
+			"shift_name":                shift.ShiftName,
+			"start_time":                shift.StartTime,
+			"end_time":                  shift.EndTime,
+			"break_minutes":             shift.BreakMinutes,
+			"cross_midnight":            shift.CrossMidnight,
+			"late_grace_minutes":        shift.LateGraceMinutes,
+			"early_leave_grace_minutes": shift.EarlyLeaveGraceMinutes,
+			"description":               shift.Description,
+			"updated_by":                updatedBy,
+		}).Error; err != nil {
+		log.Printf("UpdateWorkShiftV2 err = %v", err)
+		return err
+	}
+	return nil
+}
+
+// DeleteWorkShiftV2 停用班次，仍在有效排班中使用的班次不能停用
+func DeleteWorkShiftV2(c *gin.Context, shiftId string, deletedBy string) error {
+	db := resource.HrmsDB(c)
+	today := time.Now().Format("2006-01-02")
+	var schedules []*model.SalaryV2WorkSchedule
+	if err := db.Where("end_date = '' or end_date is null or end_date >= ?", today).Find(&schedules).Error; err != nil {
+		return err
+	}
+	for _, schedule := range schedules {
+		for _, entry := range splitRotationPattern(schedule.RotationPattern) {
+			if entry == shiftId {
+				return fmt.Errorf("班次仍在%s的排班中使用，请先调整排班", schedule.TargetName)
+			}
+		}
+	}
+
+	result := db.Model(&model.SalaryV2WorkShift{}).Where("shift_id = ? and is_active = ?", shiftId, true).
+		Updates(map[string]interface{}{
+			"is_active":  false,
+			"updated_by": deletedBy,
+		})
+	if result.Error != nil {
+		log.Printf("DeleteWorkShiftV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该班次")
+	}
+	return nil
+}
+
+// CreateWorkScheduleV2 为员工或部门排班，同一对象原有长期有效的排班截止到新排班起始日期的前一天
+func CreateWorkScheduleV2(c *gin.Context, dto *model.SalaryV2WorkScheduleCreateDTO, createdBy string) error {
+	var schedule model.SalaryV2WorkSchedule
+	Transfer(&dto, &schedule)
+	if schedule.RotationStartDate == "" {
+		schedule.RotationStartDate = schedule.StartDate
+	}
+	start, err := time.Parse("2006-01-02", schedule.StartDate)
+	if err != nil {
+		return errors.New("排班起始日期格式错误: " + schedule.StartDate)
+	}
+	if schedule.EndDate != "" {
+		if _, err := time.Parse("2006-01-02", schedule.EndDate); err != nil {
+			return errors.New("排班截止日期格式错误: " + schedule.EndDate)
+		}
+		if schedule.EndDate < schedule.StartDate {
+			return errors.New("排班截止日期不能早于起始日期")
+		}
+	}
+	if _, err := time.Parse("2006-01-02", schedule.RotationStartDate); err != nil {
+		return errors.New("轮换起始日期格式错误: " + schedule.RotationStartDate)
+	}
+	previousDay := start.AddDate(0, 0, -1).Format("2006-01-02")
+
+	return resource.HrmsDB(c).Transaction(func(tx *gorm.DB) error {
+		switch schedule.TargetType {
+		case "staff":
+			var staff model.Staff
+			if err := tx.Where("staff_id = ?", schedule.TargetId).First(&staff).Error; err != nil {
+				return errors.New("不存在该员工")
+			}
+			schedule.TargetName = staff.StaffName
+		case "department":
+			var department model.Department
+			if err := tx.Where("dep_id = ?", schedule.TargetId).First(&department).Error; err != nil {
+				return errors.New("不存在该部门")
+			}
+			schedule.TargetName = department.DepName
+		default:
+			return errors.New("排班对象类型错误: " + schedule.TargetType)
+		}
+		if err := validateRotationPattern(tx, schedule.RotationPattern); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&model.SalaryV2WorkSchedule{}).
+			Where("target_type = ? and target_id = ? and start_date < ? and (end_date = '' or end_date is null)",
+				schedule.TargetType, schedule.TargetId, schedule.StartDate).
+			Update("end_date", previousDay).Error; err != nil {
+			return err
+		}
+		var overlaps []*model.SalaryV2WorkSchedule
+		query := tx.Where("target_type = ? and target_id = ? and (end_date = '' or end_date is null or end_date >= ?)",
+			schedule.TargetType, schedule.TargetId, schedule.StartDate)
+		if schedule.EndDate != "" {
+			query = query.Where("start_date <= ?", schedule.EndDate)
+		}
+		if err := query.Find(&overlaps).Error; err != nil {
+			return err
+		}
+		if len(overlaps) > 0 {
+			return fmt.Errorf("%s自%s起已有排班，请先删除后再排班", schedule.TargetName, overlaps[0].StartDate)
+		}
+
+		schedule.ScheduleId = RandomID("schedule")
+		schedule.CreatedBy = createdBy
+		if err := tx.Create(&schedule).Error; err != nil {
+			log.Printf("CreateWorkScheduleV2 err = %v", err)
+			return err
+		}
+		return nil
+	})
+}
+
+func GetWorkSchedulesV2(c *gin.Context, targetType string, targetId string, start int, limit int) ([]*model.SalaryV2WorkSchedule, int64, error) {
+	var schedules []*model.SalaryV2WorkSchedule
+	var err error
+	query := resource.HrmsDB(c).Model(&model.SalaryV2WorkSchedule{})
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetId != "" {
+		query = query.Where("target_id = ?", targetId)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	if start == -1 && limit == -1 {
+		err = query.Order("target_type asc, target_id asc, start_date desc").Find(&schedules).Error
+	} else {
+		err = query.Offset(start).Limit(limit).Order("target_type asc, target_id asc, start_date desc").Find(&schedules).Error
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return schedules, total, nil
+}
+
+// DeleteWorkScheduleV2 删除排班，删除后相应日期按上级排班或工作日历考勤
+func DeleteWorkScheduleV2(c *gin.Context, scheduleId string) error {
+	result := resource.HrmsDB(c).Where("schedule_id = ?", scheduleId).Delete(&model.SalaryV2WorkSchedule{})
+	if result.Error != nil {
+		log.Printf("DeleteWorkScheduleV2 err = %v", result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("不存在该排班")
+	}
+	return nil
+}
+
+// GetStaffRosterV2 获取员工月度排班表
+func GetStaffRosterV2(c *gin.Context, staffId string, month string) ([]*model.SalaryV2RosterDay, error) {
+	first, last, err := monthRange(month)
+	if err != nil {
+		return nil, err
+	}
+	db := resource.HrmsDB(c)
+	roster, err := loadStaffRoster(db, loadWorkCalendar(db), staffId)
+	if err != nil {
+		return nil, err
+	}
+	var days []*model.SalaryV2RosterDay
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		rosterDay, _ := roster.Day(day)
+		days = append(days, rosterDay)
+	}
+	return days, nil
+}
+
+// validateWorkShift 校验上下班时间、跨零点标记、休息时长及宽限分钟数
+func validateWorkShift(shift *model.SalaryV2WorkShift) error {
+	start, err := parseShiftClock(shift.StartTime)
+	if err != nil {
+		return err
+	}
+	end, err := parseShiftClock(shift.EndTime)
+	if err != nil {
+		return err
+	}
+	if end <= start && !shift.CrossMidnight {
+		return errors.New("下班时间早于上班时间的班次须标记为跨零点")
+	}
+	if end > start && shift.CrossMidnight {
+		return errors.New("跨零点班次的下班时间应早于上班时间")
+	}
+	if shift.BreakMinutes < 0 || shift.LateGraceMinutes < 0 || shift.EarlyLeaveGraceMinutes < 0 {
+		return errors.New("休息及宽限分钟数不能为负数")
+	}
+	if shiftDuration(shift) <= time.Duration(shift.BreakMinutes)*time.Minute {
+		return errors.New("班内休息时长不能超过班次时长")
+	}
+	return nil
+}
+
+// parseShiftClock 解析HH:MM格式的班次时间，返回距零点的分钟数
+func parseShiftClock(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, errors.New("班次时间格式错误: " + value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// shiftDuration 班次时长(含班内休息)
+func shiftDuration(shift *model.SalaryV2WorkShift) time.Duration {
+	start, _ := parseShiftClock(shift.StartTime)
+	end, _ := parseShiftClock(shift.EndTime)
+	if shift.CrossMidnight {
+		end += 24 * 60
+	}
+	return time.Duration(end-start) * time.Minute
+}
+
+func splitRotationPattern(pattern string) []string {
+	var entries []string
+	for _, entry := range strings.Split(pattern, ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// validateRotationPattern 校验轮换规律中的班次均为启用的班次，且至少包含一个上班班次
+func validateRotationPattern(db *gorm.DB, pattern string) error {
+	entries := splitRotationPattern(pattern)
+	hasShift := false
+	for _, entry := range entries {
+		if entry == restShift {
+			continue
+		}
+		var count int64
+		db.Model(&model.SalaryV2WorkShift{}).Where("shift_id = ? and is_active = ?", entry, true).Count(&count)
+		if count == 0 {
+			return errors.New("轮换规律中的班次不存在: " + entry)
+		}
+		hasShift = true
+	}
+	if !hasShift {
+		return errors.New("轮换规律中至少需要一个上班班次")
+	}
+	return nil
+}
+
+// getStaffExpectedWorkDays 按员工排班及工作日历计算当月应出勤天数，与考勤统计的出勤天数口径一致
+func getStaffExpectedWorkDays(db *gorm.DB, staffId string, month string) (int64, error) {
+	first, last, err := monthRange(month)
+	if err != nil {
+		return 0, err
+	}
+	roster, err := loadStaffRoster(db, loadWorkCalendar(db), staffId)
+	if err != nil {
+		return 0, err
+	}
+	return roster.WorkingDays(first, last), nil
+}
+
+// staffRoster 员工排班，未排班的日期按工作日历及每日标准工时考勤
+type staffRoster struct {
+	calendar       *workCalendar
+	dailyWorkHours float64
+	staffSchedules []*model.SalaryV2WorkSchedule // 员工排班，优先于部门排班
+	depSchedules   []*model.SalaryV2WorkSchedule
+	shifts         map[string]*model.SalaryV2WorkShift
+}
+
+// loadStaffRoster 加载员工本人及所在部门的排班，停用的班次仍可用于历史日期的考勤
+func loadStaffRoster(db *gorm.DB, calendar *workCalendar, staffId string) (*staffRoster, error) {
+	var staff model.Staff
+	if err := db.Where("staff_id = ?", staffId).First(&staff).Error; err != nil {
+		return nil, errors.New("不存在该员工")
+	}
+	roster := &staffRoster{
+		calendar:       calendar,
+		dailyWorkHours: getDailyWorkHours(db),
+		shifts:         make(map[string]*model.SalaryV2WorkShift),
+	}
+	var schedules []*model.SalaryV2WorkSchedule
+	if err := db.Where("(target_type = 'staff' and target_id = ?) or (target_type = 'department' and target_id = ?)", staffId, staff.DepId).
+		Order("start_date desc").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+	for _, schedule := range schedules {
+		if schedule.TargetType == "staff" {
+			roster.staffSchedules = append(roster.staffSchedules, schedule)
+		} else {
+			roster.depSchedules = append(roster.depSchedules, schedule)
+		}
+	}
+	if len(schedules) > 0 {
+		var shifts []*model.SalaryV2WorkShift
+		if err := db.Find(&shifts).Error; err != nil {
+			return nil, err
+		}
+		for _, shift := range shifts {
+			roster.shifts[shift.ShiftId] = shift
+		}
+	}
+	return roster, nil
+}
+
+func (r *staffRoster) schedule(date string) *model.SalaryV2WorkSchedule {
+	for _, schedules := range [][]*model.SalaryV2WorkSchedule{r.staffSchedules, r.depSchedules} {
+		for _, schedule := range schedules {
+			if schedule.StartDate <= date && (schedule.EndDate == "" || schedule.EndDate >= date) {
+				return schedule
+			}
+		}
+	}
+	return nil
+}
+
+// Day 获取指定日期的排班，返回当日班次，休息或未排班时班次为nil
+func (r *staffRoster) Day(day time.Time) (*model.SalaryV2RosterDay, *model.SalaryV2WorkShift) {
+	date := day.Format("2006-01-02")
+	rosterDay := &model.SalaryV2RosterDay{Date: date}
+	schedule := r.schedule(date)
+	if schedule == nil {
+		rosterDay.IsWorkday = r.calendar.IsWorkingDay(day)
+		return rosterDay, nil
+	}
+	rosterDay.ScheduleId = schedule.ScheduleId
+	entries := splitRotationPattern(schedule.RotationPattern)
+	rotationStart, err := time.ParseInLocation("2006-01-02", schedule.RotationStartDate, day.Location())
+	if len(entries) == 0 || err != nil {
+		return rosterDay, nil
+	}
+	offset := int(math.Round(day.Sub(rotationStart).Hours() / 24))
+	index := ((offset % len(entries)) + len(entries)) % len(entries)
+	shift := r.shifts[entries[index]]
+	if entries[index] == restShift || shift == nil || (schedule.FollowCalendar && !r.calendar.IsWorkingDay(day)) {
+		return rosterDay, nil
+	}
+	rosterDay.ShiftId = shift.ShiftId
+	rosterDay.ShiftName = shift.ShiftName
+	rosterDay.StartTime = shift.StartTime
+	rosterDay.EndTime = shift.EndTime
+	rosterDay.IsWorkday = true
+	return rosterDay, shift
+}
+
+// IsWorkingDay 判断员工指定日期是否应出勤
+func (r *staffRoster) IsWorkingDay(day time.Time) bool {
+	rosterDay, _ := r.Day(day)
+	return rosterDay.IsWorkday
+}
+
+// WorkingDays 统计起止日期(含)之间员工应出勤的天数
+func (r *staffRoster) WorkingDays(start, end time.Time) int64 {
+	var days int64
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		if r.IsWorkingDay(day) {
+			days++
+		}
+	}
+	return days
+}
+
+// clockInEvaluation 按排班评估的单日打卡结果
+type clockInEvaluation struct {
+	WeekdayHours float64
+	WeekendHours float64
+	HolidayHours float64
+	Late         bool
+	EarlyLeave   bool
+}
+
+func (e *clockInEvaluation) OvertimeHours() float64 {
+	return e.WeekdayHours + e.WeekendHours + e.HolidayHours
+}
+
+// EvaluateClockIn 按当日班次评估打卡：超过宽限时间上班为迟到、提前下班为早退，班次结束后的出勤计为加班，
+// 休息日出勤全部计为加班，法定节假日出勤全部计为法定节假日加班；未排班的日期按工作日历及每日标准工时计算
+func (r *staffRoster) EvaluateClockIn(clockIn *model.ClockIn) *clockInEvaluation {
+	result := &clockInEvaluation{}
+	day, err := time.ParseInLocation("2006-01-02", clockIn.Date, time.Local)
+	if err != nil {
+		return result
+	}
+	rosterDay, shift := r.Day(day)
+	if rosterDay.ScheduleId == "" {
+		result.WeekdayHours, result.WeekendHours, result.HolidayHours = clockInOvertimeHours(clockIn, r.dailyWorkHours, r.calendar)
+		return result
+	}
+	if clockIn.CheckInTime == nil || clockIn.CheckOutTime == nil {
+		return result
+	}
+	checkIn, ok1 := parseClockTime(clockIn.Date, *clockIn.CheckInTime)
+	checkOut, ok2 := parseClockTime(clockIn.Date, *clockIn.CheckOutTime)
+	if !ok1 || !ok2 {
+		return result
+	}
+	// 跨零点班次仅记录时分的下班打卡为次日
+	if !checkOut.After(checkIn) && (shift == nil || shift.CrossMidnight) {
+		checkOut = checkOut.AddDate(0, 0, 1)
+	}
+	if !checkOut.After(checkIn) {
+		return result
+	}
+
+	if shift == nil {
+		hours := math.Floor(checkOut.Sub(checkIn).Hours()*2) / 2
+		if r.calendar.IsHoliday(day) {
+			result.HolidayHours = hours
+		} else {
+			result.WeekendHours = hours
+		}
+		return result
+	}
+
+	startMinutes, _ := parseShiftClock(shift.StartTime)
+	shiftStart := day.Add(time.Duration(startMinutes) * time.Minute)
+	shiftEnd := shiftStart.Add(shiftDuration(shift))
+	result.Late = checkIn.After(shiftStart.Add(time.Duration(shift.LateGraceMinutes) * time.Minute))
+	result.EarlyLeave = checkOut.Before(shiftEnd.Add(-time.Duration(shift.EarlyLeaveGraceMinutes) * time.Minute))
+	if r.calendar.IsHoliday(day) {
+		worked := checkOut.Sub(checkIn) - time.Duration(shift.BreakMinutes)*time.Minute
+		if worked > 0 {
+			result.HolidayHours = math.Floor(worked.Hours()*2) / 2
+		}
+		return result
+	}
+	if checkOut.After(shiftEnd) {
+		result.WeekdayHours = math.Floor(checkOut.Sub(shiftEnd).Hours()*2) / 2
+	}
+	return result
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"hrms/model"
+	"testing"
+	"time"
+)
+
+func TestValidateWorkShift(t *testing.T) {
+	cases := []struct {
+		shift model.SalaryV2WorkShift
+		valid bool
+	}{
+		{model.SalaryV2WorkShift{StartTime: "08:00", EndTime: "17:00", BreakMinutes: 60}, true},
+		{model.SalaryV2WorkShift{StartTime: "22:00", EndTime: "06:00", CrossMidnight: true}, true},
+		{model.SalaryV2WorkShift{StartTime: "22:00", EndTime: "06:00"}, false},
+		{model.SalaryV2WorkShift{StartTime: "08:00", EndTime: "17:00", CrossMidnight: true}, false},
+		{model.SalaryV2WorkShift{StartTime: "08:00", EndTime: "09:00", BreakMinutes: 60}, false},
+		{model.SalaryV2WorkShift{StartTime: "8点", EndTime: "17:00"}, false},
+	}
+	for i, tc := range cases {
+		if err := validateWorkShift(&tc.shift); (err == nil) != tc.valid {
+			t.Errorf("case %d err = %v, want valid %v", i, err, tc.valid)
+		}
+	}
+}
+
+func testStaffRoster() *staffRoster {
+	return &staffRoster{
+		calendar: &workCalendar{
+			holidays: map[string]bool{"2025-10-01": true},
+			workdays: map[string]bool{},
+		},
+		dailyWorkHours: 8,
+		staffSchedules: []*model.SalaryV2WorkSchedule{{
+			ScheduleId:        "schedule_1",
+			TargetType:        "staff",
+			RotationPattern:   "day, day, night, night, rest, rest",
+			RotationStartDate: "2025-09-01",
+			StartDate:         "2025-09-01",
+		}},
+		shifts: map[string]*model.SalaryV2WorkShift{
+			"day":   {ShiftId: "day", ShiftName: "白班", StartTime: "08:00", EndTime: "17:00", BreakMinutes: 60, LateGraceMinutes: 5, EarlyLeaveGraceMinutes: 5},
+			"night": {ShiftId: "night", ShiftName: "夜班", StartTime: "22:00", EndTime: "06:00", CrossMidnight: true, LateGraceMinutes: 5},
+		},
+	}
+}
+
+func TestStaffRosterRotation(t *testing.T) {
+	roster := testStaffRoster()
+	cases := map[string]string{
+		"2025-08-29": "", // 排班起始日期前未排班
+		"2025-09-01": "day",
+		"2025-09-03": "night",
+		"2025-09-05": restShift,
+		"2025-09-07": "day",
+		"2025-09-11": restShift,
+	}
+	for date, want := range cases {
+		day, _ := time.ParseInLocation("2006-01-02", date, time.Local)
+		rosterDay, shift := roster.Day(day)
+		got := ""
+		if shift != nil {
+			got = shift.ShiftId
+		} else if rosterDay.ScheduleId != "" {
+			got = restShift
+		}
+		if got != want {
+			t.Errorf("%s shift = %q, want %q", date, got, want)
+		}
+	}
+	start, _ := time.ParseInLocation("2006-01-02", "2025-09-01", time.Local)
+	end, _ := time.ParseInLocation("2006-01-02", "2025-09-12", time.Local)
+	// 12天中9月5、6、11、12日休息
+	if days := roster.WorkingDays(start, end); days != 8 {
+		t.Errorf("working days = %d, want 8", days)
+	}
+}
+
+func TestStaffRosterEvaluateClockIn(t *testing.T) {
+	roster := testStaffRoster()
+	clockIn := func(date, checkIn, checkOut string) *model.ClockIn {
+		return &model.ClockIn{Date: date, CheckInTime: &checkIn, CheckOutTime: &checkOut}
+	}
+
+	// 白班超过宽限时间上班为迟到，提前下班超过宽限时间为早退
+	evaluation := roster.EvaluateClockIn(clockIn("2025-09-01", "2025-09-01 08:10:00", "2025-09-01 16:50:00"))
+	if !evaluation.Late || !evaluation.EarlyLeave || evaluation.OvertimeHours() != 0 {
+		t.Errorf("day shift evaluation = %+v", evaluation)
+	}
+
+	// 夜班下班打卡为次日，06:00后的出勤计为加班
+	evaluation = roster.EvaluateClockIn(clockIn("2025-09-03", "22:03:00", "07:10:00"))
+	if evaluation.Late || evaluation.EarlyLeave || evaluation.WeekdayHours != 1 {
+		t.Errorf("night shift evaluation = %+v", evaluation)
+	}
+
+	// 轮休日出勤全部计为休息日加班
+	evaluation = roster.EvaluateClockIn(clockIn("2025-09-05", "09:00:00", "13:20:00"))
+	if evaluation.WeekendHours != 4 || evaluation.Late {
+		t.Errorf("rest day evaluation = %+v", evaluation)
+	}
+
+	// 法定节假日当班出勤扣除班内休息后计为法定节假日加班
+	evaluation = roster.EvaluateClockIn(clockIn("2025-10-01", "08:00:00", "17:00:00"))
+	if evaluation.HolidayHours != 8 || evaluation.WeekdayHours != 0 {
+		t.Errorf("holiday evaluation = %+v", evaluation)
+	}
+}
+
+func TestSplitBaseSalaryByRoster(t *testing.T) {
+	roster := testStaffRoster()
+	staff := &model.Staff{StaffId: "staff_1", EntryDate: "2024-01-01", Status: 1}
+	base := model.MoneyFromYuan(6000)
+
+	// 2025年9月按轮换规律应出勤20天，出满排班天数时不按工作日历的22天核减
+	total, segments, err := splitBaseSalary(roster, staff, base, nil, 1, &model.AttendanceRecord{Date: "2025-09", WorkDays: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != base || len(segments) != 1 || segments[0].MonthWorkDays != 20 {
+		t.Errorf("full attendance total = %s, segments = %d", total, len(segments))
+	}
+
+	total, _, err = splitBaseSalary(roster, staff, base, nil, 1, &model.AttendanceRecord{Date: "2025-09", WorkDays: 15})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != model.MoneyFromYuan(4500) {
+		t.Errorf("partial attendance total = %s, want 4500.00", total)
+	}
+
+	// 9月16日调薪：前段应出勤11天按原工资5000，后段9天按6000
+	histories := []*model.SalaryV2CompensationHistory{{EffectiveDate: "2025-09-16", OldBase: model.MoneyFromYuan(5000)}}
+	total, segments, err = splitBaseSalary(roster, staff, base, histories, 1, &model.AttendanceRecord{Date: "2025-09", WorkDays: 20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != model.MoneyFromYuan(5450) || len(segments) != 2 || segments[0].WorkDays != 11 {
+		t.Errorf("adjusted total = %s, segments = %d", total, len(segments))
+	}
+}
@@ -747,3 +747,782 @@ INSERT INTO `salary_v2_template_items` (
 ('item_010', 'template_003', '住房补贴', 'subsidy', 'fixed', 100000, NULL, NULL, 2, 0),
 ('item_011', 'template_003', '销售提成', 'commission', 'percentage', NULL, 5.00, 'base', 3, 0),
 ('item_012', 'template_003', '绩效奖金', 'bonus', 'percentage', NULL, 8.00, 'base', 4, 0);
+
+-- 个人所得税累计预扣法
+ALTER TABLE `salary_v2_tax_brackets`
+    ADD COLUMN `bracket_type` varchar(20) NOT NULL DEFAULT 'monthly' COMMENT '税率表类型：monthly月度、annual年度累计预扣' AFTER `tax_bracket_id`;
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `gross_income` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '当月应发合计' AFTER `tax`,
+    ADD COLUMN `cumulative_income` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '本年累计收入' AFTER `gross_income`,
+    ADD COLUMN `cumulative_deduction` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '本年累计减除费用及专项扣除' AFTER `cumulative_income`,
+    ADD COLUMN `cumulative_taxable_income` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '本年累计应纳税所得额' AFTER `cumulative_deduction`,
+    ADD COLUMN `cumulative_tax` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '本年累计应纳税额' AFTER `cumulative_taxable_income`;
+
+-- 插入年度累计预扣税率表
+INSERT INTO `salary_v2_tax_brackets` (
+    `tax_bracket_id`, `bracket_type`, `min_income`, `max_income`, `tax_rate`, `quick_deduction`,
+    `description`, `effective_date`, `is_active`, `created_by`
+) VALUES
+('tax_bracket_101', 'annual', 0, 3600000, 3.00, 0, '累计预扣0-36000元税率3%', '2024-01-01', 1, 'admin'),
+('tax_bracket_102', 'annual', 3600000, 14400000, 10.00, 252000, '累计预扣36000-144000元税率10%', '2024-01-01', 1, 'admin'),
+('tax_bracket_103', 'annual', 14400000, 30000000, 20.00, 1692000, '累计预扣144000-300000元税率20%', '2024-01-01', 1, 'admin'),
+('tax_bracket_104', 'annual', 30000000, 42000000, 25.00, 3192000, '累计预扣300000-420000元税率25%', '2024-01-01', 1, 'admin'),
+('tax_bracket_105', 'annual', 42000000, 66000000, 30.00, 5292000, '累计预扣420000-660000元税率30%', '2024-01-01', 1, 'admin'),
+('tax_bracket_106', 'annual', 66000000, 96000000, 35.00, 8592000, '累计预扣660000-960000元税率35%', '2024-01-01', 1, 'admin'),
+('tax_bracket_107', 'annual', 96000000, NULL, 45.00, 18192000, '累计预扣960000元以上税率45%', '2024-01-01', 1, 'admin');
+
+-- 个人所得税专项附加扣除
+CREATE TABLE IF NOT EXISTS `salary_v2_special_deductions` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `deduction_id` varchar(32) NOT NULL COMMENT '申报ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `deduction_type` varchar(30) NOT NULL COMMENT '扣除类型：children_education子女教育、continuing_education继续教育、housing_loan住房贷款利息、housing_rent住房租金、elderly_support赡养老人、infant_care婴幼儿照护',
+    `monthly_amount` decimal(10,2) NOT NULL COMMENT '每月扣除金额(元)',
+    `quantity` int DEFAULT 1 COMMENT '子女人数等计数',
+    `start_month` varchar(7) NOT NULL COMMENT '起始月份',
+    `end_month` varchar(7) DEFAULT NULL COMMENT '截止月份，NULL表示长期有效',
+    `remark` text DEFAULT NULL COMMENT '备注',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否有效，1有效，0作废',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_deduction_id` (`deduction_id`),
+    KEY `idx_staff_id` (`staff_id`),
+    KEY `idx_is_active` (`is_active`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='个人所得税专项附加扣除申报表';
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `special_deduction` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '当月专项附加扣除' AFTER `gross_income`;
+
+-- 全年一次性奖金
+ALTER TABLE `salary`
+    ADD COLUMN `annual_bonus_tax_method` varchar(20) NOT NULL DEFAULT 'separate' COMMENT '全年一次性奖金计税方式：separate单独计税、combined并入综合所得';
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `annual_bonus` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '当月发放的全年一次性奖金' AFTER `cumulative_tax`,
+    ADD COLUMN `annual_bonus_tax` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '单独计税的全年一次性奖金个税' AFTER `annual_bonus`;
+
+CREATE TABLE IF NOT EXISTS `salary_v2_annual_bonuses` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `bonus_id` varchar(32) NOT NULL COMMENT '奖金记录ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `bonus_year` varchar(4) NOT NULL COMMENT '奖金所属年度',
+    `pay_month` varchar(7) NOT NULL COMMENT '发放月份',
+    `amount` decimal(12,2) NOT NULL COMMENT '奖金金额(元)',
+    `tax_method` varchar(20) NOT NULL COMMENT '计税方式：separate单独计税、combined并入综合所得',
+    `tax` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '奖金应扣个税',
+    `net_amount` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '税后奖金',
+    `salary_record_id` varchar(32) DEFAULT NULL COMMENT '随发放的当月薪资记录ID',
+    `remark` text DEFAULT NULL COMMENT '备注',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否有效，1有效，0作废',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_bonus_id` (`bonus_id`),
+    KEY `idx_staff_pay_month` (`staff_id`, `pay_month`),
+    KEY `idx_salary_record_id` (`salary_record_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='全年一次性奖金发放表';
+
+-- 加班分类计算
+ALTER TABLE `attendance_record`
+    ADD COLUMN `weekday_overtime_hours` decimal(6,2) NOT NULL DEFAULT 0 COMMENT '工作日加班时长(小时)' AFTER `overtime_days`,
+    ADD COLUMN `weekend_overtime_hours` decimal(6,2) NOT NULL DEFAULT 0 COMMENT '休息日加班时长(小时)' AFTER `weekday_overtime_hours`,
+    ADD COLUMN `holiday_overtime_hours` decimal(6,2) NOT NULL DEFAULT 0 COMMENT '法定节假日加班时长(小时)' AFTER `weekend_overtime_hours`;
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `weekday_overtime_hours` decimal(6,2) NOT NULL DEFAULT 0 COMMENT '工作日加班时长(小时)' AFTER `overtime`,
+    ADD COLUMN `weekend_overtime_hours` decimal(6,2) NOT NULL DEFAULT 0 COMMENT '休息日加班时长(小时)' AFTER `weekday_overtime_hours`,
+    ADD COLUMN `holiday_overtime_hours` decimal(6,2) NOT NULL DEFAULT 0 COMMENT '法定节假日加班时长(小时)' AFTER `weekend_overtime_hours`,
+    ADD COLUMN `weekday_overtime_pay` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '工作日加班工资' AFTER `holiday_overtime_hours`,
+    ADD COLUMN `weekend_overtime_pay` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '休息日加班工资' AFTER `weekday_overtime_pay`,
+    ADD COLUMN `holiday_overtime_pay` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '法定节假日加班工资' AFTER `weekend_overtime_pay`;
+
+ALTER TABLE `salary_v2_calculation_rules`
+    ADD COLUMN `rule_code` varchar(50) DEFAULT NULL COMMENT '规则编码，计算时按编码取值' AFTER `rule_type`,
+    ADD KEY `idx_rule_code` (`rule_code`);
+
+UPDATE `salary_v2_calculation_rules` SET `rule_code` = 'overtime_weekday' WHERE `calculation_rule_id` = 'rule_001';
+UPDATE `salary_v2_calculation_rules` SET `rule_code` = 'overtime_weekend' WHERE `calculation_rule_id` = 'rule_002';
+UPDATE `salary_v2_calculation_rules` SET `rule_code` = 'overtime_holiday' WHERE `calculation_rule_id` = 'rule_003';
+
+-- 计算规则公式
+ALTER TABLE `salary_v2_calculation_rules`
+    ADD COLUMN `formula` varchar(1024) DEFAULT NULL COMMENT '计算公式，为空时直接使用规则数值' AFTER `rule_value`;
+
+ALTER TABLE `rank`
+    ADD COLUMN `rank_level` int NOT NULL DEFAULT 0 COMMENT '职级等级，用于薪资计算公式';
+
+INSERT INTO `salary_v2_calculation_rules` (
+    `calculation_rule_id`, `rule_type`, `rule_code`, `rule_name`, `rule_value`, `formula`, `rule_description`,
+    `effective_date`, `is_active`, `created_by`
+) VALUES
+('rule_009', 'bonus', 'bonus_leave_ratio', '绩效奖金请假系数', 1.0000, 'if(leave_days > 5, 0, (5 - leave_days) / 5)', '每请假一天绩效奖金扣1/5，超过5天全扣', '2024-01-01', 1, 'admin');
+
+-- 员工薪资模板分配
+CREATE TABLE IF NOT EXISTS `salary_v2_staff_templates` (
+    `id` bigint NOT NULL AUTO_INCREMENT,
+    `assignment_id` varchar(32) NOT NULL COMMENT '分配ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `template_id` varchar(32) NOT NULL COMMENT '模板ID',
+    `base_salary` bigint NOT NULL DEFAULT 0 COMMENT '基本工资(元)，为0时使用员工档案基本工资',
+    `effective_date` date NOT NULL COMMENT '生效日期',
+    `status` varchar(20) NOT NULL COMMENT '状态：pending待生效、active生效中、expired已失效',
+    `applied_at` datetime DEFAULT NULL COMMENT '最近一次生成薪资套账时间',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `idx_assignment_id` (`assignment_id`),
+    KEY `idx_staff_id` (`staff_id`),
+    KEY `idx_template_id` (`template_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='员工薪资模板分配表';
+
+-- 薪资项目目录
+CREATE TABLE IF NOT EXISTS `salary_v2_pay_components` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `component_id` varchar(32) NOT NULL COMMENT '薪资项目ID',
+    `component_code` varchar(50) NOT NULL COMMENT '项目编码',
+    `component_name` varchar(100) NOT NULL COMMENT '项目名称',
+    `component_type` varchar(20) NOT NULL COMMENT '项目类型：earning应发项、deduction扣款项',
+    `is_taxable` tinyint(1) NOT NULL DEFAULT 1 COMMENT '应发项是否计税，扣款项是否税前扣除',
+    `is_insurable` tinyint(1) NOT NULL DEFAULT 0 COMMENT '应发项是否计入五险一金缴费基数',
+    `sort_order` int DEFAULT 0 COMMENT '排序顺序',
+    `description` text DEFAULT NULL COMMENT '项目描述',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否启用，1启用，0停用',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_component_id` (`component_id`),
+    UNIQUE KEY `uk_component_code` (`component_code`),
+    KEY `idx_is_active` (`is_active`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='薪资项目目录表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_staff_pay_components` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `staff_component_id` varchar(32) NOT NULL COMMENT '员工薪资项目ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `component_code` varchar(50) NOT NULL COMMENT '项目编码',
+    `amount` decimal(10,2) NOT NULL COMMENT '每月金额(元)',
+    `start_month` varchar(7) NOT NULL COMMENT '起始月份',
+    `end_month` varchar(7) DEFAULT NULL COMMENT '截止月份，NULL表示长期有效',
+    `source` varchar(20) DEFAULT 'manual' COMMENT '来源：manual手工维护、template薪资模板生成',
+    `remark` text DEFAULT NULL COMMENT '备注',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否有效，1有效，0作废',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_staff_component_id` (`staff_component_id`),
+    KEY `idx_staff_id` (`staff_id`),
+    KEY `idx_component_code` (`component_code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='员工薪资项目表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_record_items` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `salary_record_id` varchar(32) NOT NULL COMMENT '薪资记录ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `salary_date` varchar(7) NOT NULL COMMENT '薪资月份',
+    `component_code` varchar(50) NOT NULL COMMENT '项目编码',
+    `component_name` varchar(100) DEFAULT NULL COMMENT '项目名称',
+    `component_type` varchar(20) NOT NULL COMMENT '项目类型：earning应发项、deduction扣款项',
+    `is_taxable` tinyint(1) NOT NULL DEFAULT 1 COMMENT '应发项是否计税，扣款项是否税前扣除',
+    `is_insurable` tinyint(1) NOT NULL DEFAULT 0 COMMENT '应发项是否计入五险一金缴费基数',
+    `amount` decimal(10,2) NOT NULL COMMENT '金额(元)',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_salary_record_id` (`salary_record_id`),
+    KEY `idx_staff_date` (`staff_id`, `salary_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='薪资记录明细表';
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `component_earnings` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '计税的其他薪资项目应发合计' AFTER `annual_bonus_tax`,
+    ADD COLUMN `tax_free_earnings` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '不计税的其他薪资项目应发合计' AFTER `component_earnings`,
+    ADD COLUMN `pre_tax_deductions` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '税前扣款合计' AFTER `tax_free_earnings`,
+    ADD COLUMN `post_tax_deductions` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '税后扣款合计' AFTER `pre_tax_deductions`;
+
+ALTER TABLE `salary_v2_template_items`
+    ADD COLUMN `component_code` varchar(50) DEFAULT NULL COMMENT '薪资项目编码，项目类型为component时有效' AFTER `item_type`;
+
+INSERT INTO `salary_v2_pay_components` (
+    `component_id`, `component_code`, `component_name`, `component_type`, `is_taxable`, `is_insurable`, `sort_order`, `description`, `created_by`
+) VALUES
+('pay_component_001', 'meal_allowance', '餐费补贴', 'earning', 1, 0, 10, '按月发放的餐费补贴', 'admin'),
+('pay_component_002', 'transport_allowance', '交通补贴', 'earning', 1, 0, 20, '按月发放的交通补贴', 'admin'),
+('pay_component_003', 'shift_allowance', '倒班津贴', 'earning', 1, 1, 30, '倒班岗位津贴，计入缴费基数', 'admin'),
+('pay_component_004', 'attendance_bonus', '全勤奖', 'earning', 1, 1, 40, '当月全勤奖励', 'admin'),
+('pay_component_005', 'loan_repayment', '借款还款', 'deduction', 0, 0, 110, '员工借款按月从税后工资中扣还', 'admin'),
+('pay_component_006', 'fine', '罚款', 'deduction', 0, 0, 120, '违规罚款，从税后工资中扣除', 'admin');
+
+-- 调薪申请及薪酬变动历史
+CREATE TABLE IF NOT EXISTS `salary_v2_adjustments` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `adjustment_id` varchar(32) NOT NULL COMMENT '调薪申请ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `adjustment_type` varchar(30) NOT NULL COMMENT '调薪类型：raise调薪、demotion降薪、probation_regular转正、other其他',
+    `old_base` bigint DEFAULT 0 COMMENT '调整前基本工资',
+    `new_base` bigint NOT NULL COMMENT '调整后基本工资',
+    `old_rank_id` varchar(32) DEFAULT NULL COMMENT '调整前职级',
+    `new_rank_id` varchar(32) DEFAULT NULL COMMENT '调整后职级，NULL表示职级不变',
+    `effective_month` varchar(7) NOT NULL COMMENT '生效月份',
+    `reason` text NOT NULL COMMENT '调薪原因',
+    `status` varchar(20) NOT NULL COMMENT '状态：pending_leader待领导审批、pending_hr待人事审批、approved已审批待生效、applied已生效、rejected已驳回、cancelled已撤销',
+    `leader_approver_id` varchar(32) DEFAULT NULL COMMENT '领导审批人',
+    `leader_comment` text DEFAULT NULL COMMENT '领导审批意见',
+    `leader_approved_at` datetime DEFAULT NULL COMMENT '领导审批时间',
+    `hr_approver_id` varchar(32) DEFAULT NULL COMMENT '人事审批人',
+    `hr_comment` text DEFAULT NULL COMMENT '人事审批意见',
+    `hr_approved_at` datetime DEFAULT NULL COMMENT '人事审批时间',
+    `applied_at` datetime DEFAULT NULL COMMENT '生效执行时间',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_adjustment_id` (`adjustment_id`),
+    KEY `idx_staff_id` (`staff_id`),
+    KEY `idx_status_month` (`status`, `effective_month`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='调薪申请表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_compensation_histories` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `history_id` varchar(32) NOT NULL COMMENT '历史记录ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `adjustment_id` varchar(32) DEFAULT NULL COMMENT '调薪申请ID',
+    `change_type` varchar(30) NOT NULL COMMENT '变动类型',
+    `old_base` bigint DEFAULT 0 COMMENT '变动前基本工资',
+    `new_base` bigint DEFAULT 0 COMMENT '变动后基本工资',
+    `old_rank_id` varchar(32) DEFAULT NULL COMMENT '变动前职级',
+    `new_rank_id` varchar(32) DEFAULT NULL COMMENT '变动后职级',
+    `effective_month` varchar(7) DEFAULT NULL COMMENT '生效月份',
+    `reason` text DEFAULT NULL COMMENT '变动原因',
+    `operator` varchar(32) DEFAULT NULL COMMENT '操作人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_history_id` (`history_id`),
+    KEY `idx_staff_id` (`staff_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='员工薪酬变动历史表';
+
+-- 基本工资按工作日历分段折算
+CREATE TABLE IF NOT EXISTS `salary_v2_record_segments` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `salary_record_id` varchar(32) NOT NULL COMMENT '薪资记录ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `salary_date` varchar(7) NOT NULL COMMENT '薪资月份',
+    `start_date` varchar(10) NOT NULL COMMENT '分段开始日期',
+    `end_date` varchar(10) NOT NULL COMMENT '分段结束日期',
+    `work_days` int DEFAULT 0 COMMENT '分段内应出勤工作日',
+    `month_work_days` int DEFAULT 0 COMMENT '当月应出勤工作日',
+    `base_rate` bigint DEFAULT 0 COMMENT '分段适用的月基本工资',
+    `pay_ratio` decimal(5,4) DEFAULT 1.0000 COMMENT '试用期工资比例，正式员工为1',
+    `attend_ratio` decimal(5,4) DEFAULT 1.0000 COMMENT '实际出勤天数占应出勤天数比例',
+    `amount` decimal(10,2) DEFAULT 0 COMMENT '分段基本工资',
+    `reason` varchar(20) DEFAULT NULL COMMENT '分段起始原因：month_start月初、entry入职、adjustment调薪、regular转正',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_salary_record_id` (`salary_record_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='薪资记录基本工资分段明细表';
+
+ALTER TABLE `salary_v2_adjustments`
+    ADD COLUMN `effective_date` varchar(10) DEFAULT NULL COMMENT '生效日期，月中调薪时按日期拆分当月薪资' AFTER `effective_month`;
+
+ALTER TABLE `salary_v2_compensation_histories`
+    ADD COLUMN `effective_date` varchar(10) DEFAULT NULL COMMENT '生效日期' AFTER `effective_month`,
+    ADD KEY `idx_staff_effective_date` (`staff_id`, `effective_date`);
+
+-- 已有调薪申请及薪酬变动历史按生效月份首日补齐生效日期
+UPDATE `salary_v2_adjustments` SET `effective_date` = CONCAT(`effective_month`, '-01')
+WHERE `effective_date` IS NULL OR `effective_date` = '';
+
+UPDATE `salary_v2_compensation_histories` SET `effective_date` = CONCAT(`effective_month`, '-01')
+WHERE `effective_date` IS NULL OR `effective_date` = '';
+
+INSERT INTO `salary_v2_parameters` (
+    `parameter_id`, `parameter_key`, `parameter_value`, `parameter_type`,
+    `parameter_category`, `parameter_description`, `is_editable`, `is_active`, `created_by`
+) VALUES
+('param_026', 'probation_pay_ratio', '1', 'decimal', 'salary', '试用期工资比例，试用期内基本工资按该比例发放，1表示不打折', 1, 1, 'admin'),
+('param_027', 'public_holidays', '', 'string', 'basic', '法定节假日，逗号分隔的日期，如2025-10-01,2025-10-02', 1, 1, 'admin'),
+('param_028', 'adjusted_workdays', '', 'string', 'basic', '调休上班日，逗号分隔的日期，如2025-09-28', 1, 1, 'admin');
+
+-- 离职结算
+CREATE TABLE IF NOT EXISTS `salary_v2_settlements` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `settlement_id` varchar(32) NOT NULL COMMENT '离职结算ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `entry_date` varchar(20) DEFAULT NULL COMMENT '入职日期',
+    `resignation_date` varchar(10) NOT NULL COMMENT '离职日期',
+    `salary_record_id` varchar(32) DEFAULT NULL COMMENT '离职结算薪资记录ID',
+    `salary_date` varchar(7) DEFAULT NULL COMMENT '结算月份',
+    `prorated_salary` decimal(10,2) DEFAULT 0 COMMENT '当月折算工资应发合计',
+    `monthly_wage` decimal(10,2) DEFAULT 0 COMMENT '离职前十二个月平均工资',
+    `severance_type` varchar(20) DEFAULT 'none' COMMENT '经济补偿方式：none无补偿、n按工作年限、n_plus_1另加一个月代通知金',
+    `severance_years` decimal(5,1) DEFAULT 0 COMMENT '经济补偿计算年限',
+    `severance` decimal(10,2) DEFAULT 0 COMMENT '经济补偿(含代通知金)',
+    `severance_tax_free` decimal(10,2) DEFAULT 0 COMMENT '经济补偿免税额',
+    `severance_tax` decimal(10,2) DEFAULT 0 COMMENT '经济补偿个人所得税',
+    `unused_leave_days` decimal(5,1) DEFAULT 0 COMMENT '未休年休假天数',
+    `leave_payout` decimal(10,2) DEFAULT 0 COMMENT '未休年休假工资报酬',
+    `outstanding_deduction` decimal(10,2) DEFAULT 0 COMMENT '离职扣款',
+    `tax` decimal(10,2) DEFAULT 0 COMMENT '当月工资薪金个人所得税',
+    `total` decimal(10,2) DEFAULT 0 COMMENT '实发合计',
+    `remark` varchar(255) DEFAULT NULL COMMENT '备注',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_settlement_id` (`settlement_id`),
+    KEY `idx_staff_id` (`staff_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='离职结算表';
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `leave_payout` decimal(10,2) DEFAULT 0 COMMENT '未休年休假工资报酬',
+    ADD COLUMN `severance` decimal(10,2) DEFAULT 0 COMMENT '解除劳动合同经济补偿',
+    ADD COLUMN `severance_tax` decimal(10,2) DEFAULT 0 COMMENT '经济补偿单独计算的个人所得税',
+    ADD COLUMN `record_type` varchar(20) NOT NULL DEFAULT 'regular' COMMENT '记录类型：regular月度薪资、settlement离职结算';
+
+INSERT INTO `salary_v2_parameters` (
+    `parameter_id`, `parameter_key`, `parameter_value`, `parameter_type`,
+    `parameter_category`, `parameter_description`, `is_editable`, `is_active`, `created_by`
+) VALUES
+('param_029', 'local_average_wage', '0', 'decimal', 'salary', '当地上年度职工月平均工资，用于经济补偿封顶及免税额计算，计算经济补偿前须配置', 1, 1, 'admin');
+
+-- 追溯调整(补发及扣回)
+CREATE TABLE IF NOT EXISTS `salary_v2_retro_adjustments` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `retro_id` varchar(32) NOT NULL COMMENT '追溯调整ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `source_month` varchar(7) NOT NULL COMMENT '被追溯的已发放月份',
+    `source_record_id` varchar(32) DEFAULT NULL COMMENT '被追溯的薪资记录ID',
+    `target_month` varchar(7) NOT NULL COMMENT '计入的薪资月份',
+    `applied_record_id` varchar(32) DEFAULT NULL COMMENT '计入的薪资记录ID',
+    `paid_income` decimal(10,2) DEFAULT 0 COMMENT '已发放的计税工资收入',
+    `recalculated_income` decimal(10,2) DEFAULT 0 COMMENT '重新核算的计税工资收入',
+    `paid_deduction` decimal(10,2) DEFAULT 0 COMMENT '已发放的专项扣除及税前扣款',
+    `recalculated_deduction` decimal(10,2) DEFAULT 0 COMMENT '重新核算的专项扣除及税前扣款',
+    `paid_tax` decimal(10,2) DEFAULT 0 COMMENT '已预扣个人所得税',
+    `recalculated_tax` decimal(10,2) DEFAULT 0 COMMENT '重新核算的个人所得税',
+    `paid_total` decimal(10,2) DEFAULT 0 COMMENT '已发放实发工资',
+    `recalculated_total` decimal(10,2) DEFAULT 0 COMMENT '重新核算的实发工资',
+    `earnings_difference` decimal(10,2) DEFAULT 0 COMMENT '计税收入差额，正数补发、负数扣回',
+    `deduction_difference` decimal(10,2) DEFAULT 0 COMMENT '专项扣除及税前扣款差额',
+    `net_difference` decimal(10,2) DEFAULT 0 COMMENT '不计税收入及税后扣款差额',
+    `total_difference` decimal(10,2) DEFAULT 0 COMMENT '实发工资差额',
+    `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending待计入、applied已计入、cancelled已撤销',
+    `reason` varchar(255) DEFAULT NULL COMMENT '追溯原因',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_retro_id` (`retro_id`),
+    KEY `idx_staff_source_month` (`staff_id`, `source_month`),
+    KEY `idx_staff_target_month` (`staff_id`, `target_month`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='薪资追溯调整表';
+
+ALTER TABLE `salary_record`
+    ADD COLUMN `retro_earnings` decimal(10,2) DEFAULT 0 COMMENT '追溯补发(扣回)的计税收入',
+    ADD COLUMN `retro_deductions` decimal(10,2) DEFAULT 0 COMMENT '追溯补扣(退还)的专项扣除及税前扣款',
+    ADD COLUMN `retro_net_adjustment` decimal(10,2) DEFAULT 0 COMMENT '追溯补发(扣回)的不计税收入及税后扣款';
+
+-- 薪资期间
+CREATE TABLE IF NOT EXISTS `salary_v2_payroll_periods` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `period_id` varchar(32) NOT NULL COMMENT '薪资期间ID',
+    `period_month` varchar(7) NOT NULL COMMENT '薪资月份',
+    `status` varchar(20) NOT NULL DEFAULT 'open' COMMENT '状态：open可核算、locked已锁定、closed已结账',
+    `locked_by` varchar(32) DEFAULT NULL COMMENT '锁定人',
+    `locked_at` datetime DEFAULT NULL COMMENT '锁定时间',
+    `closed_by` varchar(32) DEFAULT NULL COMMENT '结账人',
+    `closed_at` datetime DEFAULT NULL COMMENT '结账时间',
+    `unlocked_by` varchar(32) DEFAULT NULL COMMENT '最近一次解锁人',
+    `unlocked_at` datetime DEFAULT NULL COMMENT '最近一次解锁时间',
+    `unlock_reason` varchar(255) DEFAULT NULL COMMENT '最近一次解锁原因',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_period_id` (`period_id`),
+    UNIQUE KEY `uk_period_month` (`period_month`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='薪资期间表';
+
+-- 银行代发
+CREATE TABLE IF NOT EXISTS `salary_v2_bank_layouts` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `layout_id` varchar(32) NOT NULL COMMENT '代发文件格式ID',
+    `bank_code` varchar(20) NOT NULL COMMENT '银行编码',
+    `bank_name` varchar(100) NOT NULL COMMENT '银行名称',
+    `file_format` varchar(10) NOT NULL COMMENT '文件格式：csv分隔符文件、fixed定长文件',
+    `delimiter` varchar(4) DEFAULT ',' COMMENT 'csv分隔符',
+    `amount_unit` varchar(10) DEFAULT 'yuan' COMMENT '金额单位：yuan元(两位小数)、cents分',
+    `has_header` tinyint(1) DEFAULT 0 COMMENT 'csv是否输出列标题行',
+    `has_trailer` tinyint(1) DEFAULT 0 COMMENT '是否输出汇总行',
+    `fields` text NOT NULL COMMENT '明细字段配置，JSON数组',
+    `return_delimiter` varchar(4) DEFAULT ',' COMMENT '回盘文件分隔符',
+    `return_skip_lines` int DEFAULT 0 COMMENT '回盘文件跳过的标题行数',
+    `return_ref_field` varchar(20) DEFAULT 'line_no' COMMENT '回盘文件关联字段：line_no明细序号、line_id明细ID',
+    `return_ref_column` int DEFAULT 0 COMMENT '回盘文件关联列号，从0开始',
+    `return_amount_column` int DEFAULT -1 COMMENT '回盘文件金额列号，-1不核对金额',
+    `return_status_column` int DEFAULT 1 COMMENT '回盘文件状态列号',
+    `return_success_code` varchar(20) NOT NULL COMMENT '回盘成功状态值',
+    `return_message_column` int DEFAULT -1 COMMENT '回盘文件失败原因列号，-1无',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否启用',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_layout_id` (`layout_id`),
+    UNIQUE KEY `uk_bank_code` (`bank_code`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='银行代发文件格式表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_payment_batches` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `batch_id` varchar(32) NOT NULL COMMENT '代发批次ID',
+    `bank_code` varchar(20) NOT NULL COMMENT '银行编码',
+    `layout_id` varchar(32) NOT NULL COMMENT '代发文件格式ID',
+    `salary_date` varchar(7) DEFAULT NULL COMMENT '薪资月份',
+    `line_count` int DEFAULT 0 COMMENT '代发笔数',
+    `total_amount` decimal(12,2) DEFAULT 0 COMMENT '代发总金额',
+    `file_name` varchar(100) DEFAULT NULL COMMENT '代发文件名',
+    `file_content` longtext COMMENT '代发文件内容',
+    `checksum` varchar(64) DEFAULT NULL COMMENT '代发明细SHA-256校验码',
+    `status` varchar(20) NOT NULL DEFAULT 'generated' COMMENT '状态：generated已生成、partial部分回盘、completed已全部回盘',
+    `success_count` int DEFAULT 0 COMMENT '成功笔数',
+    `failed_count` int DEFAULT 0 COMMENT '失败笔数',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_batch_id` (`batch_id`),
+    KEY `idx_salary_date` (`salary_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='银行代发批次表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_payment_lines` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `line_id` varchar(32) NOT NULL COMMENT '代发明细ID',
+    `batch_id` varchar(32) NOT NULL COMMENT '代发批次ID',
+    `line_no` int NOT NULL COMMENT '明细序号',
+    `salary_record_id` varchar(32) NOT NULL COMMENT '薪资记录ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `card_num` varchar(32) NOT NULL COMMENT '银行卡号',
+    `amount` decimal(10,2) DEFAULT 0 COMMENT '代发金额',
+    `status` varchar(20) NOT NULL DEFAULT 'pending' COMMENT '状态：pending待回盘、success成功、failed失败',
+    `bank_message` varchar(255) DEFAULT NULL COMMENT '银行回盘说明',
+    `confirmed_at` datetime DEFAULT NULL COMMENT '回盘时间',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_line_id` (`line_id`),
+    KEY `idx_batch_id` (`batch_id`),
+    KEY `idx_salary_record_id` (`salary_record_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='银行代发明细表';
+
+ALTER TABLE `salary_record`
+    MODIFY COLUMN `is_pay` int NOT NULL COMMENT '发放状态：1未发放、2已发放、3银行代发处理中';
+
+INSERT INTO `salary_v2_bank_layouts` (
+    `layout_id`, `bank_code`, `bank_name`, `file_format`, `delimiter`, `amount_unit`, `has_header`, `has_trailer`, `fields`,
+    `return_delimiter`, `return_skip_lines`, `return_ref_field`, `return_ref_column`, `return_amount_column`,
+    `return_status_column`, `return_success_code`, `return_message_column`, `is_active`, `created_by`
+) VALUES
+('bank_layout_default', 'DEFAULT', '通用CSV代发格式', 'csv', ',', 'yuan', 1, 1,
+ '[{"field":"line_no","title":"序号"},{"field":"card_num","title":"收款账号"},{"field":"staff_name","title":"收款户名"},{"field":"amount","title":"金额"},{"field":"literal","title":"用途","value":"工资"}]',
+ ',', 1, 'line_no', 0, 3, 4, 'SUCCESS', 5, 1, 'admin');
+
+INSERT INTO `salary_v2_parameters` (
+    `parameter_id`, `parameter_key`, `parameter_value`, `parameter_type`,
+    `parameter_category`, `parameter_description`, `is_editable`, `is_active`, `created_by`
+) VALUES
+('param_030', 'default_bank_code', 'DEFAULT', 'string', 'salary', '默认代发银行编码，单笔发放薪资时使用该银行的代发文件格式', 1, 1, 'admin');
+
+-- 五险一金缴费明细
+CREATE TABLE IF NOT EXISTS `salary_v2_record_insurances` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `salary_record_id` varchar(32) NOT NULL COMMENT '薪资记录ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `salary_date` varchar(7) NOT NULL COMMENT '薪资月份',
+    `insurance_type` varchar(20) NOT NULL COMMENT '险种：pension养老、medical医疗、unemployment失业、injury工伤、maternity生育、housing住房公积金',
+    `base` decimal(10,2) DEFAULT 0 COMMENT '缴费基数，已按费率配置上下限调整',
+    `employee_rate` decimal(5,2) DEFAULT 0 COMMENT '个人缴费比例(%)',
+    `employer_rate` decimal(5,2) DEFAULT 0 COMMENT '单位缴费比例(%)',
+    `employee_amount` decimal(10,2) DEFAULT 0 COMMENT '个人缴纳金额',
+    `employer_amount` decimal(10,2) DEFAULT 0 COMMENT '单位缴纳金额',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    KEY `idx_salary_record_id` (`salary_record_id`),
+    KEY `idx_salary_date_type` (`salary_date`, `insurance_type`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='薪资记录五险一金缴费明细表';
+
+-- 工资条PDF加密
+INSERT INTO `salary_v2_parameters` (
+    `parameter_id`, `parameter_key`, `parameter_value`, `parameter_type`,
+    `parameter_category`, `parameter_description`, `is_editable`, `is_active`, `created_by`
+) VALUES
+('param_031', 'payslip_password_digits', '6', 'number', 'salary', '工资条PDF打开密码取员工身份证号后几位，为0时不加密', 1, 1, 'admin');
+
+-- 金额统一为保留两位小数的元(decimal)，舍入规则及币种按分公司配置
+ALTER TABLE `salary`
+    MODIFY COLUMN `base` decimal(12,2) NOT NULL COMMENT '基础薪资',
+    MODIFY COLUMN `subsidy` decimal(12,2) NOT NULL COMMENT '住房补贴',
+    MODIFY COLUMN `bonus` decimal(12,2) NOT NULL COMMENT '绩效奖金',
+    MODIFY COLUMN `commission` decimal(12,2) NOT NULL COMMENT '提成奖金',
+    MODIFY COLUMN `other` decimal(12,2) NOT NULL COMMENT '其他奖金';
+
+ALTER TABLE `salary_record`
+    MODIFY COLUMN `base` decimal(12,2) NOT NULL COMMENT '基础薪资',
+    MODIFY COLUMN `subsidy` decimal(12,2) NOT NULL COMMENT '住房补贴',
+    MODIFY COLUMN `bonus` decimal(12,2) NOT NULL COMMENT '绩效奖金',
+    MODIFY COLUMN `commission` decimal(12,2) NOT NULL COMMENT '提成薪资',
+    MODIFY COLUMN `other` decimal(12,2) NOT NULL COMMENT '其他薪资',
+    MODIFY COLUMN `overtime` decimal(12,2) NOT NULL COMMENT '加班薪资',
+    ADD COLUMN `rounding_adjustment` decimal(10,2) NOT NULL DEFAULT 0 COMMENT '实发工资按舍入规则取整的差额' AFTER `total`,
+    ADD COLUMN `currency` varchar(3) NOT NULL DEFAULT 'CNY' COMMENT '币种' AFTER `rounding_adjustment`;
+
+ALTER TABLE `staff`
+    MODIFY COLUMN `base_salary` decimal(12,2) NOT NULL COMMENT '基本工资';
+
+ALTER TABLE `salary_v2_staff_templates`
+    MODIFY COLUMN `base_salary` decimal(12,2) NOT NULL DEFAULT 0 COMMENT '基本工资(元)，为0时使用员工档案基本工资';
+
+ALTER TABLE `salary_v2_adjustments`
+    MODIFY COLUMN `old_base` decimal(12,2) DEFAULT 0 COMMENT '调整前基本工资',
+    MODIFY COLUMN `new_base` decimal(12,2) NOT NULL COMMENT '调整后基本工资';
+
+ALTER TABLE `salary_v2_compensation_histories`
+    MODIFY COLUMN `old_base` decimal(12,2) DEFAULT 0 COMMENT '变动前基本工资',
+    MODIFY COLUMN `new_base` decimal(12,2) DEFAULT 0 COMMENT '变动后基本工资';
+
+ALTER TABLE `salary_v2_record_segments`
+    MODIFY COLUMN `base_rate` decimal(12,2) DEFAULT 0 COMMENT '分段适用的月基本工资';
+
+-- 原以分存储的金额转换为元
+ALTER TABLE `salary_v2_tax_brackets`
+    MODIFY COLUMN `min_income` decimal(14,2) NOT NULL COMMENT '最低收入(元)',
+    MODIFY COLUMN `max_income` decimal(14,2) DEFAULT NULL COMMENT '最高收入，NULL表示无上限(元)',
+    MODIFY COLUMN `quick_deduction` decimal(14,2) NOT NULL COMMENT '速算扣除数(元)';
+UPDATE `salary_v2_tax_brackets` SET `min_income` = `min_income` / 100, `max_income` = `max_income` / 100,
+    `quick_deduction` = `quick_deduction` / 100;
+
+ALTER TABLE `salary_v2_insurance_rates`
+    MODIFY COLUMN `min_base` decimal(12,2) DEFAULT NULL COMMENT '缴费基数下限(元)',
+    MODIFY COLUMN `max_base` decimal(12,2) DEFAULT NULL COMMENT '缴费基数上限(元)';
+UPDATE `salary_v2_insurance_rates` SET `min_base` = `min_base` / 100, `max_base` = `max_base` / 100;
+
+ALTER TABLE `salary_v2_template_items`
+    MODIFY COLUMN `amount` decimal(12,2) DEFAULT NULL COMMENT '固定金额(元)';
+UPDATE `salary_v2_template_items` SET `amount` = `amount` / 100 WHERE `amount` IS NOT NULL;
+
+INSERT INTO `salary_v2_parameters` (
+    `parameter_id`, `parameter_key`, `parameter_value`, `parameter_type`,
+    `parameter_category`, `parameter_description`, `is_editable`, `is_active`, `created_by`
+) VALUES
+('param_032', 'money_rounding', 'cent', 'string', 'salary', '实发工资舍入规则：cent保留到分、yuan四舍五入到元，差额计入舍入调整', 1, 1, 'admin'),
+('param_033', 'currency', 'CNY', 'string', 'salary', '薪资发放币种(ISO 4217代码)，境外分公司可按当地币种配置', 1, 1, 'admin');
+
+-- 参数变更申请
+CREATE TABLE IF NOT EXISTS `salary_v2_parameter_changes` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `change_id` varchar(40) NOT NULL COMMENT '变更申请ID',
+    `parameter_type` varchar(30) NOT NULL COMMENT '参数类型：tax_bracket税率区间、insurance_rate社保费率、calculation_rule计算规则、system_parameter系统参数',
+    `target_id` bigint NOT NULL COMMENT '被修改参数记录的主键ID',
+    `parameter_id` varchar(32) DEFAULT NULL COMMENT '被修改参数的业务ID',
+    `parameter_name` varchar(100) DEFAULT NULL COMMENT '参数名称',
+    `old_value` text DEFAULT NULL COMMENT '提交时的参数快照(JSON)',
+    `new_value` text NOT NULL COMMENT '变更后的参数内容(JSON)',
+    `effective_date` varchar(10) DEFAULT '' COMMENT '生效日期，为空时审批通过后立即生效',
+    `reason` text DEFAULT NULL COMMENT '变更原因',
+    `status` varchar(20) NOT NULL COMMENT '状态：pending待审批、approved已审批待生效、applied已生效、rejected已驳回、cancelled已撤销、failed生效失败',
+    `approver_id` varchar(32) DEFAULT NULL COMMENT '审批人',
+    `approve_comment` text DEFAULT NULL COMMENT '审批意见',
+    `approved_at` datetime DEFAULT NULL COMMENT '审批时间',
+    `applied_at` datetime DEFAULT NULL COMMENT '生效执行时间',
+    `apply_error` text DEFAULT NULL COMMENT '生效失败原因',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '提交人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_change_id` (`change_id`),
+    KEY `idx_status_date` (`status`, `effective_date`),
+    KEY `idx_parameter` (`parameter_type`, `target_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='参数变更申请表';
+
+-- 参数快照
+CREATE TABLE IF NOT EXISTS `salary_v2_parameter_snapshots` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `snapshot_id` varchar(40) NOT NULL COMMENT '快照ID',
+    `snapshot_name` varchar(100) NOT NULL COMMENT '快照名称',
+    `description` varchar(255) DEFAULT NULL COMMENT '快照说明',
+    `content` longtext NOT NULL COMMENT '全部V2薪资参数(JSON)：税率区间、社保费率、计算规则、系统参数',
+    `tax_bracket_count` int DEFAULT 0 COMMENT '税率区间数量',
+    `insurance_rate_count` int DEFAULT 0 COMMENT '社保费率数量',
+    `calculation_rule_count` int DEFAULT 0 COMMENT '计算规则数量',
+    `system_parameter_count` int DEFAULT 0 COMMENT '系统参数数量',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_snapshot_id` (`snapshot_id`),
+    UNIQUE KEY `uk_snapshot_name` (`snapshot_name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='参数快照表';
+
+-- 月度税率表按现行税率表更正区间，原区间与速算扣除数不一致，无法通过税率表校验
+UPDATE `salary_v2_tax_brackets` SET `min_income` = 0, `max_income` = 3000, `description` = '0-3000元税率3%' WHERE `tax_bracket_id` = 'tax_bracket_001';
+UPDATE `salary_v2_tax_brackets` SET `min_income` = 3000, `max_income` = 12000, `description` = '3000-12000元税率10%' WHERE `tax_bracket_id` = 'tax_bracket_002';
+UPDATE `salary_v2_tax_brackets` SET `min_income` = 12000, `max_income` = 25000, `description` = '12000-25000元税率20%' WHERE `tax_bracket_id` = 'tax_bracket_003';
+
+-- 参保方案：异地参保员工按方案所在城市的费率及缴费基数上下限缴纳
+CREATE TABLE IF NOT EXISTS `salary_v2_insurance_policies` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `policy_id` varchar(32) NOT NULL COMMENT '参保方案ID',
+    `policy_name` varchar(100) NOT NULL COMMENT '方案名称',
+    `city` varchar(50) NOT NULL COMMENT '参保城市',
+    `description` text DEFAULT NULL COMMENT '描述',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否启用',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_policy_id` (`policy_id`),
+    KEY `idx_city` (`city`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='参保方案表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_staff_insurance_policies` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `assignment_id` varchar(32) NOT NULL COMMENT '员工参保方案ID',
+    `staff_id` varchar(32) NOT NULL COMMENT '员工工号',
+    `staff_name` varchar(50) DEFAULT NULL COMMENT '员工姓名',
+    `policy_id` varchar(32) NOT NULL COMMENT '参保方案ID',
+    `start_month` varchar(7) NOT NULL COMMENT '起始月份',
+    `end_month` varchar(7) DEFAULT NULL COMMENT '截止月份，NULL表示长期有效',
+    `remark` text DEFAULT NULL COMMENT '备注',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_assignment_id` (`assignment_id`),
+    KEY `idx_staff_id` (`staff_id`),
+    KEY `idx_policy_id` (`policy_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='员工参保方案表';
+
+ALTER TABLE `salary_v2_insurance_rates`
+    ADD COLUMN `policy_id` varchar(32) NOT NULL DEFAULT '' COMMENT '所属参保方案ID，空表示分公司默认费率' AFTER `insurance_rate_id`,
+    ADD KEY `idx_policy_id` (`policy_id`);
+
+ALTER TABLE `salary_v2_record_insurances`
+    ADD COLUMN `policy_id` varchar(32) NOT NULL DEFAULT '' COMMENT '计算时使用的参保方案ID，空表示分公司默认费率' AFTER `salary_date`;
+
+-- 工作日历：登记法定节假日及调休上班日，未登记的日期周一至周五为工作日
+CREATE TABLE IF NOT EXISTS `salary_v2_work_calendar_days` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `calendar_date` varchar(10) NOT NULL COMMENT '日期',
+    `day_type` varchar(20) NOT NULL COMMENT '日期类型：holiday法定节假日、workday调休上班日',
+    `day_name` varchar(100) DEFAULT NULL COMMENT '节日名称',
+    `source` varchar(20) DEFAULT NULL COMMENT '来源：manual手工登记、ics、json',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_calendar_date` (`calendar_date`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='工作日历表';
+
+ALTER TABLE `attendance_record`
+    ADD COLUMN `expected_work_days` int NOT NULL DEFAULT 0 COMMENT '按工作日历计算的当月应出勤天数' AFTER `holiday_overtime_hours`;
+
+-- 班次及排班：按员工或部门指定班次轮换规律，员工排班优先于部门排班
+CREATE TABLE IF NOT EXISTS `salary_v2_work_shifts` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `shift_id` varchar(32) NOT NULL COMMENT '班次ID',
+    `shift_name` varchar(100) NOT NULL COMMENT '班次名称',
+    `start_time` varchar(5) NOT NULL COMMENT '上班时间，如08:00',
+    `end_time` varchar(5) NOT NULL COMMENT '下班时间，如17:00',
+    `break_minutes` int NOT NULL DEFAULT 0 COMMENT '班内休息分钟数',
+    `cross_midnight` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否跨零点',
+    `late_grace_minutes` int NOT NULL DEFAULT 0 COMMENT '迟到宽限分钟数',
+    `early_leave_grace_minutes` int NOT NULL DEFAULT 0 COMMENT '早退宽限分钟数',
+    `description` text DEFAULT NULL COMMENT '描述',
+    `is_active` tinyint(1) DEFAULT 1 COMMENT '是否启用',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `updated_by` varchar(32) DEFAULT NULL COMMENT '更新人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_shift_id` (`shift_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='班次表';
+
+CREATE TABLE IF NOT EXISTS `salary_v2_work_schedules` (
+    `id` bigint NOT NULL AUTO_INCREMENT COMMENT '主键ID',
+    `schedule_id` varchar(32) NOT NULL COMMENT '排班ID',
+    `target_type` varchar(20) NOT NULL COMMENT '排班对象类型：staff员工、department部门',
+    `target_id` varchar(32) NOT NULL COMMENT '员工工号或部门ID',
+    `target_name` varchar(100) DEFAULT NULL COMMENT '员工姓名或部门名称',
+    `rotation_pattern` varchar(500) NOT NULL COMMENT '轮换规律，逗号分隔的班次ID，rest表示休息',
+    `rotation_start_date` varchar(10) NOT NULL COMMENT '轮换起始日期',
+    `follow_calendar` tinyint(1) NOT NULL DEFAULT 0 COMMENT '是否按工作日历休息',
+    `start_date` varchar(10) NOT NULL COMMENT '排班起始日期',
+    `end_date` varchar(10) DEFAULT NULL COMMENT '排班截止日期，NULL表示长期有效',
+    `remark` text DEFAULT NULL COMMENT '备注',
+    `created_by` varchar(32) DEFAULT NULL COMMENT '创建人',
+    `created_at` datetime DEFAULT CURRENT_TIMESTAMP COMMENT '创建时间',
+    `updated_at` datetime DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP COMMENT '更新时间',
+    `deleted_at` datetime DEFAULT NULL COMMENT '软删除时间',
+    PRIMARY KEY (`id`),
+    UNIQUE KEY `uk_schedule_id` (`schedule_id`),
+    KEY `idx_target` (`target_type`, `target_id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_0900_ai_ci COMMENT='排班表';
+
+ALTER TABLE `attendance_record`
+    ADD COLUMN `late_count` int NOT NULL DEFAULT 0 COMMENT '按排班班次统计的迟到次数' AFTER `expected_work_days`,
+    ADD COLUMN `early_leave_count` int NOT NULL DEFAULT 0 COMMENT '按排班班次统计的早退次数' AFTER `late_count`;
+
+ALTER TABLE `salary_v2_parameter_changes`
+    ADD COLUMN `operation` varchar(10) NOT NULL DEFAULT 'update' COMMENT '变更操作：update修改、create新增、delete停用' AFTER `parameter_type`,
+    ADD KEY `idx_parameter_id` (`parameter_type`, `parameter_id`);